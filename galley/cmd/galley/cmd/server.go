@@ -135,6 +135,14 @@ func serverCmd() *cobra.Command {
 		"The access list yaml file that contains the allowd mTLS peer ids.")
 	svr.PersistentFlags().StringVar(&serverArgs.ConfigPath, "configPath", serverArgs.ConfigPath,
 		"Istio config file path")
+	svr.PersistentFlags().StringVar(&serverArgs.ConfigGitRepository, "configGitRepository", serverArgs.ConfigGitRepository,
+		"URL of a Git repository to read the Istio config files from, instead of the API Server")
+	svr.PersistentFlags().StringVar(&serverArgs.ConfigGitRef, "configGitRef", serverArgs.ConfigGitRef,
+		"Branch, tag or commit of the Git repository to read the Istio config files from")
+	svr.PersistentFlags().StringVar(&serverArgs.ConfigGitPath, "configGitPath", serverArgs.ConfigGitPath,
+		"Directory of the Git repository holding the Istio config files")
+	svr.PersistentFlags().DurationVar(&serverArgs.ConfigGitPollInterval, "configGitPollInterval", serverArgs.ConfigGitPollInterval,
+		"Interval at which the Git repository is polled for new commits")
 	svr.PersistentFlags().StringVar(&serverArgs.MeshConfigFile, "meshConfigFile", serverArgs.MeshConfigFile,
 		"Path to the mesh config file")
 	svr.PersistentFlags().StringVar(&serverArgs.DomainSuffix, "domain", serverArgs.DomainSuffix,
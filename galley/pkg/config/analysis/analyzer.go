@@ -52,24 +52,31 @@ func (c *CombinedAnalyzer) Metadata() Metadata {
 
 // Analyze implements Analyzer
 func (c *CombinedAnalyzer) Analyze(ctx Context) {
+	for _, a := range c.Analyzers() {
+		scope.Analysis.Debugf("Started analyzer %q...", a.Metadata().Name)
+		if ctx.Canceled() {
+			scope.Analysis.Debugf("Analyzer %q has been cancelled...", c.Metadata().Name)
+			return
+		}
+		a.Analyze(ctx)
+		scope.Analysis.Debugf("Completed analyzer %q...", a.Metadata().Name)
+	}
+}
+
+// Analyzers returns the component analyzers that will be run, skipping over any analyzers that require disabled input.
+func (c *CombinedAnalyzer) Analyzers() []Analyzer {
+	var result []Analyzer
 mainloop:
 	for _, a := range c.analyzers {
-		// Skip over any analyzers that require disabled input
 		for _, in := range a.Metadata().Inputs {
 			if _, ok := c.disabled[in]; ok {
 				scope.Analysis.Debugf("Skipping analyzer %q because collection %s is disabled.", a.Metadata().Name, in)
 				continue mainloop
 			}
 		}
-
-		scope.Analysis.Debugf("Started analyzer %q...", a.Metadata().Name)
-		if ctx.Canceled() {
-			scope.Analysis.Debugf("Analyzer %q has been cancelled...", c.Metadata().Name)
-			return
-		}
-		a.Analyze(ctx)
-		scope.Analysis.Debugf("Completed analyzer %q...", a.Metadata().Name)
+		result = append(result, a)
 	}
+	return result
 }
 
 func combineInputs(analyzers []Analyzer) collection.Names {
@@ -53,6 +53,7 @@ func (ctx *context) Find(c collection.Name, name resource.Name) *resource.Entry
 func (ctx *context) Exists(c collection.Name, name resource.Name) bool          { return false }
 func (ctx *context) ForEach(c collection.Name, fn IteratorFn)                   {}
 func (ctx *context) Canceled() bool                                             { return false }
+func (ctx *context) DomainSuffix() string                                       { return "cluster.local" }
 
 func TestCombinedAnalyzer(t *testing.T) {
 	g := NewGomegaWithT(t)
@@ -72,6 +73,7 @@ func TestCombinedAnalyzer(t *testing.T) {
 	g.Expect(a1.ran).To(BeTrue())
 	g.Expect(a2.ran).To(BeTrue())
 	g.Expect(a3.ran).To(BeFalse())
+	g.Expect(a.Analyzers()).To(Equal([]Analyzer{a1, a2}))
 }
 
 func TestGetDisabledOutputs(t *testing.T) {
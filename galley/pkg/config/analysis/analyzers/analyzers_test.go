@@ -41,10 +41,11 @@ type message struct {
 }
 
 type testCase struct {
-	name       string
-	inputFiles []string
-	analyzer   analysis.Analyzer
-	expected   []message
+	name           string
+	inputFiles     []string
+	meshConfigFile string // Optional
+	analyzer       analysis.Analyzer
+	expected       []message
 }
 
 // Some notes on setting up tests for Analyzers:
@@ -53,6 +54,30 @@ type testCase struct {
 // * Expected messages are in the format {msg.ValidationMessageType, "<ResourceKind>/<Namespace>/<ResourceName>"}.
 //     * Note that if Namespace is omitted in the input YAML, it will be skipped here.
 var testGrid = []testCase{
+	{
+		name:       "mtls",
+		inputFiles: []string{"testdata/mtls.yaml"},
+		analyzer:   &auth.MTLSAnalyzer{},
+		expected: []message{
+			{msg.MTLSPolicyConflict, "MeshPolicy/default"},
+			{msg.MTLSPolicyConflict, "MeshPolicy/default"},
+			{msg.MTLSPolicyConflict, "DestinationRule/default/details"},
+			{msg.MTLSPolicyConflict, "DestinationRule/legacy/all"},
+			{msg.MTLSPolicyConflict, "Policy/legacy/default"},
+		},
+	},
+	{
+		name:           "mtlsWithAutoMTLS",
+		inputFiles:     []string{"testdata/mtls.yaml"},
+		meshConfigFile: "testdata/mtls-automtls-meshconfig.yaml",
+		analyzer:       &auth.MTLSAnalyzer{},
+		expected: []message{
+			{msg.MTLSPolicyConflict, "MeshPolicy/default"},
+			{msg.MTLSPolicyConflict, "DestinationRule/default/details"},
+			{msg.MTLSPolicyConflict, "DestinationRule/legacy/all"},
+			{msg.MTLSPolicyConflict, "Policy/legacy/default"},
+		},
+	},
 	{
 		name:       "serviceRoleBindings",
 		inputFiles: []string{"testdata/servicerolebindings.yaml"},
@@ -139,6 +164,20 @@ var testGrid = []testCase{
 			{msg.IstioProxyVersionMismatch, "Pod/enabled-namespace/details-v1-pod-old"},
 		},
 	},
+	{
+		name:       "virtualServiceConflicts",
+		inputFiles: []string{"testdata/virtualservice_conflicts.yaml"},
+		analyzer:   &virtualservice.ConflictAnalyzer{},
+		expected: []message{
+			{msg.ConflictingVirtualServiceHosts, "VirtualService/default/reviews"},
+			{msg.ConflictingVirtualServiceHosts, "VirtualService/other/reviews-duplicate"},
+			{msg.UnreachableRoute, "VirtualService/default/reviews"},
+			{msg.UnreachableRoute, "VirtualService/default/ratings"},
+			{msg.UnreachableRoute, "VirtualService/default/ratings"},
+			{msg.ConflictingDestinationRuleSubsets, "DestinationRule/default/reviews"},
+			{msg.ConflictingDestinationRuleSubsets, "DestinationRule/default/reviews-subsets"},
+		},
+	},
 	{
 		name:       "virtualServiceDestinationHosts",
 		inputFiles: []string{"testdata/virtualservice_destinationhosts.yaml"},
@@ -211,6 +250,11 @@ func TestAnalyzers(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Error setting up file kube source on testcase %s: %v", testCase.name, err)
 			}
+			if testCase.meshConfigFile != "" {
+				if err = sa.AddFileKubeMeshConfig(testCase.meshConfigFile); err != nil {
+					t.Fatalf("Error setting up mesh config on testcase %s: %v", testCase.name, err)
+				}
+			}
 			cancel := make(chan struct{})
 
 			msgs, err := sa.Analyze(cancel)
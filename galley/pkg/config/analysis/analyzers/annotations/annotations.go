@@ -76,6 +76,7 @@ var (
 		&annotation.SidecarTrafficIncludeInboundPorts,
 		&annotation.SidecarTrafficIncludeOutboundIPRanges,
 		&annotation.SidecarTrafficKubevirtInterfaces,
+		&analysis.SuppressAnnotation,
 	}
 
 	// Currently we don't have an Istio API that enumerates Istio annotations ResourceTypes
@@ -152,8 +153,9 @@ func (*K8sAnalyzer) allowAnnotations(r *resource.Entry, ctx analysis.Context, ki
 				continue
 			}
 
+			// Annotations that don't list any resource types can be applied to any resource
 			attachesTo := resourceTypesAsStrings(annotationDef.Resources)
-			if !contains(attachesTo, kind) {
+			if len(attachesTo) > 0 && !contains(attachesTo, kind) {
 				ctx.Report(collectionType,
 					msg.NewMisplacedAnnotation(r, ann, strings.Join(attachesTo, ", ")))
 				continue
@@ -0,0 +1,452 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+
+	authn "istio.io/api/authentication/v1alpha1"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// MTLSAnalyzer checks that the mTLS mode clients use to reach a service, as configured by destination
+// rules, is accepted by the service's authentication policy. Services whose pods have no sidecar are
+// outside the mesh, and are skipped.
+type MTLSAnalyzer struct{}
+
+var _ analysis.Analyzer = &MTLSAnalyzer{}
+
+const (
+	// Both namespace-wide policies and the mesh policy only take effect when named "default"
+	defaultPolicyName = "default"
+
+	istioProxyName = "istio-proxy"
+)
+
+type serverMode int
+
+const (
+	serverPlaintext serverMode = iota
+	serverPermissive
+	serverStrict
+)
+
+func (m serverMode) String() string {
+	switch m {
+	case serverStrict:
+		return "STRICT mTLS only"
+	case serverPermissive:
+		return "PERMISSIVE mTLS"
+	default:
+		return "plaintext only"
+	}
+}
+
+type clientMode int
+
+const (
+	clientPlaintext clientMode = iota
+	clientMTLS
+	// clientOther covers TLS that is not managed by Istio (SIMPLE, MUTUAL), which we can't reason about
+	clientOther
+	// clientAuto is used when auto mTLS is enabled and no TLS settings apply, so clients use mTLS
+	// exactly when the server has a sidecar accepting it
+	clientAuto
+)
+
+func (m clientMode) String() string {
+	switch m {
+	case clientMTLS:
+		return "mTLS"
+	case clientOther:
+		return "TLS not managed by Istio"
+	case clientAuto:
+		return "auto mTLS"
+	default:
+		return "plaintext"
+	}
+}
+
+// settingSource records which resource, if any, a setting came from
+type settingSource struct {
+	entry        *resource.Entry
+	collection   collection.Name
+	description  string
+	portSpecific bool
+}
+
+// Metadata implements Analyzer
+func (a *MTLSAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "auth.MTLSAnalyzer",
+		Inputs: collection.Names{
+			metadata.IstioAuthenticationV1Alpha1Meshpolicies,
+			metadata.IstioAuthenticationV1Alpha1Policies,
+			metadata.IstioMeshV1Alpha1MeshConfig,
+			metadata.IstioNetworkingV1Alpha3Destinationrules,
+			metadata.K8SCoreV1Pods,
+			metadata.K8SCoreV1Services,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *MTLSAnalyzer) Analyze(ctx analysis.Context) {
+	domainSuffix := ctx.DomainSuffix()
+	hosts := make(map[string]map[uint32]bool)
+	portNames := make(map[string]map[uint32]string)
+	addHost := func(host string, port uint32) {
+		if _, ok := hosts[host]; !ok {
+			hosts[host] = make(map[uint32]bool)
+		}
+		if port != 0 {
+			hosts[host][port] = true
+		}
+	}
+
+	autoMTLS := false
+	ctx.ForEach(metadata.IstioMeshV1Alpha1MeshConfig, func(r *resource.Entry) bool {
+		autoMTLS = r.Item.(*meshconfig.MeshConfig).GetEnableAutoMtls().GetValue()
+		return true
+	})
+
+	// Which pods, by namespace, have a sidecar
+	podSidecars := make(map[string][]podSidecar)
+	ctx.ForEach(metadata.K8SCoreV1Pods, func(r *resource.Entry) bool {
+		pod := r.Item.(*v1.Pod)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		podSidecars[ns] = append(podSidecars[ns], podSidecar{
+			labels:  k8s_labels.Set(pod.ObjectMeta.Labels),
+			sidecar: hasSidecar(pod),
+		})
+		return true
+	})
+
+	outOfMesh := make(map[string]bool)
+	ctx.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		svc := r.Item.(*v1.ServiceSpec)
+		ns, name := r.Metadata.Name.InterpretAsNamespaceAndName()
+		host := convertHostToFQDN(ns, name, domainSuffix)
+
+		if !inMesh(svc, podSidecars[ns]) {
+			outOfMesh[host] = true
+			return true
+		}
+
+		addHost(host, 0)
+		portNames[host] = make(map[uint32]string)
+		for _, p := range svc.Ports {
+			addHost(host, uint32(p.Port))
+			portNames[host][uint32(p.Port)] = p.Name
+		}
+		return true
+	})
+
+	var meshPolicy *resource.Entry
+	ctx.ForEach(metadata.IstioAuthenticationV1Alpha1Meshpolicies, func(r *resource.Entry) bool {
+		if r.Metadata.Name.String() == defaultPolicyName {
+			meshPolicy = r
+		}
+		return true
+	})
+
+	policies := make(map[string][]*resource.Entry)
+	ctx.ForEach(metadata.IstioAuthenticationV1Alpha1Policies, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		policies[ns] = append(policies[ns], r)
+
+		for _, t := range r.Item.(*authn.Policy).GetTargets() {
+			host := convertHostToFQDN(ns, t.GetName(), domainSuffix)
+			addHost(host, 0)
+			for _, p := range t.GetPorts() {
+				addHost(host, p.GetNumber())
+			}
+		}
+		return true
+	})
+
+	var rules []*resource.Entry
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		rules = append(rules, r)
+
+		dr := r.Item.(*v1alpha3.DestinationRule)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		host := convertHostToFQDN(ns, dr.GetHost(), domainSuffix)
+		// Only in-mesh services have authentication policies
+		if strings.HasPrefix(host, "*") || !strings.HasSuffix(host, ".svc."+domainSuffix) {
+			return true
+		}
+		addHost(host, 0)
+		for _, pls := range dr.GetTrafficPolicy().GetPortLevelSettings() {
+			addHost(host, pls.GetPort().GetNumber())
+		}
+		return true
+	})
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Metadata.Name.String() < rules[j].Metadata.Name.String()
+	})
+
+	for _, host := range sortedKeys(hosts) {
+		if outOfMesh[host] {
+			continue
+		}
+		ports := sortedPorts(hosts[host])
+		if len(ports) == 0 {
+			ports = []uint32{0}
+		}
+
+		reported := make(map[string]bool)
+		for _, port := range ports {
+			sMode, server := serverSettings(host, domainSuffix, port, portNames[host][port], policies, meshPolicy)
+			cMode, client := clientSettings(host, domainSuffix, port, rules, autoMTLS)
+
+			if !(sMode == serverStrict && cMode == clientPlaintext) && !(sMode == serverPlaintext && cMode == clientMTLS) {
+				continue
+			}
+
+			label := host
+			if server.portSpecific || client.portSpecific {
+				label = fmt.Sprintf("%s:%d", host, port)
+			}
+			if reported[label] {
+				continue
+			}
+			reported[label] = true
+
+			for _, src := range []settingSource{client, server} {
+				if src.entry != nil {
+					ctx.Report(src.collection, msg.NewMTLSPolicyConflict(src.entry, label,
+						cMode.String(), client.description, sMode.String(), server.description))
+				}
+			}
+		}
+	}
+}
+
+// convertHostToFQDN returns the FQDN of a host, which is the service of that name in the namespace for short names.
+func convertHostToFQDN(namespace, host, domainSuffix string) string {
+	if strings.HasPrefix(host, "*") || strings.Contains(host, ".") {
+		return host
+	}
+	return fmt.Sprintf("%s.%s.svc.%s", host, namespace, domainSuffix)
+}
+
+type podSidecar struct {
+	labels  k8s_labels.Set
+	sidecar bool
+}
+
+// inMesh returns false if the pods selected by the service are known, and none of them has a sidecar.
+func inMesh(svc *v1.ServiceSpec, pods []podSidecar) bool {
+	if len(svc.Selector) == 0 {
+		return true
+	}
+	selector := k8s_labels.SelectorFromSet(svc.Selector)
+	selected := false
+	for _, p := range pods {
+		if !selector.Matches(p.labels) {
+			continue
+		}
+		if p.sidecar {
+			return true
+		}
+		selected = true
+	}
+	return !selected
+}
+
+func hasSidecar(pod *v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == istioProxyName {
+			return true
+		}
+	}
+	return false
+}
+
+// serverSettings finds the authentication policy in effect for a port of a host. A policy targeting the
+// port takes precedence over one targeting the whole service, which takes precedence over the namespace
+// policy and then the mesh policy.
+func serverSettings(host, domainSuffix string, port uint32, portName string, policies map[string][]*resource.Entry,
+	meshPolicy *resource.Entry) (serverMode, settingSource) {
+
+	// The host is the FQDN of a service, <name>.<namespace>.svc.<domain suffix>
+	var ns, name string
+	if nameAndNs := strings.TrimSuffix(host, ".svc."+domainSuffix); nameAndNs != host {
+		if i := strings.LastIndex(nameAndNs, "."); i >= 0 {
+			name, ns = nameAndNs[:i], nameAndNs[i+1:]
+		}
+	}
+
+	var servicePolicy, namespacePolicy *resource.Entry
+	for _, r := range policies[ns] {
+		p := r.Item.(*authn.Policy)
+		if len(p.GetTargets()) == 0 {
+			if _, policyName := r.Metadata.Name.InterpretAsNamespaceAndName(); policyName == defaultPolicyName {
+				namespacePolicy = r
+			}
+			continue
+		}
+
+		for _, t := range p.GetTargets() {
+			if t.GetName() != name {
+				continue
+			}
+			if len(t.GetPorts()) == 0 {
+				if servicePolicy == nil {
+					servicePolicy = r
+				}
+				continue
+			}
+			for _, ps := range t.GetPorts() {
+				if port != 0 && (ps.GetNumber() == port || (portName != "" && ps.GetName() == portName)) {
+					return policyMode(p), settingSource{
+						entry:        r,
+						collection:   metadata.IstioAuthenticationV1Alpha1Policies,
+						description:  fmt.Sprintf("Policy %s", r.Metadata.Name),
+						portSpecific: true,
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case servicePolicy != nil:
+		return policyMode(servicePolicy.Item.(*authn.Policy)), settingSource{
+			entry:       servicePolicy,
+			collection:  metadata.IstioAuthenticationV1Alpha1Policies,
+			description: fmt.Sprintf("Policy %s", servicePolicy.Metadata.Name),
+		}
+	case namespacePolicy != nil:
+		return policyMode(namespacePolicy.Item.(*authn.Policy)), settingSource{
+			entry:       namespacePolicy,
+			collection:  metadata.IstioAuthenticationV1Alpha1Policies,
+			description: fmt.Sprintf("Policy %s", namespacePolicy.Metadata.Name),
+		}
+	case meshPolicy != nil:
+		return policyMode(meshPolicy.Item.(*authn.Policy)), settingSource{
+			entry:       meshPolicy,
+			collection:  metadata.IstioAuthenticationV1Alpha1Meshpolicies,
+			description: fmt.Sprintf("MeshPolicy %s", meshPolicy.Metadata.Name),
+		}
+	}
+	return serverPlaintext, settingSource{description: "no authentication policy"}
+}
+
+func policyMode(p *authn.Policy) serverMode {
+	for _, peer := range p.GetPeers() {
+		m, ok := peer.GetParams().(*authn.PeerAuthenticationMethod_Mtls)
+		if !ok {
+			continue
+		}
+		if p.GetPeerIsOptional() || m.Mtls.GetAllowTls() || m.Mtls.GetMode() == authn.MutualTls_PERMISSIVE {
+			return serverPermissive
+		}
+		return serverStrict
+	}
+	return serverPlaintext
+}
+
+// clientSettings finds the TLS mode clients use for a port of a host. An exact host match takes precedence
+// over wildcards, and more specific wildcards take precedence over less specific ones. With auto mTLS,
+// clients without TLS settings use mTLS exactly when the server accepts it.
+func clientSettings(host, domainSuffix string, port uint32, rules []*resource.Entry,
+	autoMTLS bool) (clientMode, settingSource) {
+	var exact, wildcard *resource.Entry
+	var wildcardHost string
+	for _, r := range rules {
+		dr := r.Item.(*v1alpha3.DestinationRule)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		drHost := convertHostToFQDN(ns, dr.GetHost(), domainSuffix)
+
+		switch {
+		case drHost == host:
+			if exact == nil {
+				exact = r
+			}
+		case strings.HasPrefix(drHost, "*") && strings.HasSuffix(host, drHost[1:]):
+			if wildcard == nil || len(drHost) > len(wildcardHost) {
+				wildcard, wildcardHost = r, drHost
+			}
+		}
+	}
+
+	best := exact
+	if best == nil {
+		best = wildcard
+	}
+	if best == nil {
+		if autoMTLS {
+			return clientAuto, settingSource{description: "auto mTLS"}
+		}
+		return clientPlaintext, settingSource{description: "no DestinationRule"}
+	}
+
+	src := settingSource{
+		entry:       best,
+		collection:  metadata.IstioNetworkingV1Alpha3Destinationrules,
+		description: fmt.Sprintf("DestinationRule %s", best.Metadata.Name),
+	}
+
+	policy := best.Item.(*v1alpha3.DestinationRule).GetTrafficPolicy()
+	tls := policy.GetTls()
+	for _, pls := range policy.GetPortLevelSettings() {
+		if port != 0 && pls.GetPort().GetNumber() == port && pls.GetTls() != nil {
+			tls = pls.GetTls()
+			src.portSpecific = true
+		}
+	}
+
+	switch {
+	case tls == nil && autoMTLS:
+		return clientAuto, src
+	case tls == nil || tls.GetMode() == v1alpha3.TLSSettings_DISABLE:
+		return clientPlaintext, src
+	case tls.GetMode() == v1alpha3.TLSSettings_ISTIO_MUTUAL:
+		return clientMTLS, src
+	}
+	return clientOther, src
+}
+
+func sortedKeys(m map[string]map[uint32]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPorts(m map[uint32]bool) []uint32 {
+	ports := make([]uint32, 0, len(m))
+	for p := range m {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
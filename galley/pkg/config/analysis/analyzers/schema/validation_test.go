@@ -59,6 +59,9 @@ func (ctx *testContext) ForEach(c collection.Name, fn analysis.IteratorFn) {
 // Canceled implements analysis.Context
 func (ctx *testContext) Canceled() bool { return false }
 
+// DomainSuffix implements analysis.Context
+func (ctx *testContext) DomainSuffix() string { return "cluster.local" }
+
 func TestSchemaValidationWrapper(t *testing.T) {
 	g := NewGomegaWithT(t)
 
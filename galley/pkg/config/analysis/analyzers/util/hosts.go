@@ -15,7 +15,9 @@
 package util
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	"istio.io/istio/galley/pkg/config/resource"
 )
@@ -40,6 +42,15 @@ func GetResourceNameFromHost(defaultNamespace, host string) resource.Name {
 	return resource.NewName(namespace, name)
 }
 
+// ConvertHostToFQDN expands a short host name into its FQDN in the given namespace. Hosts that are already
+// qualified (or are wildcards) are returned as-is.
+func ConvertHostToFQDN(namespace, host string) string {
+	if strings.HasPrefix(host, "*") || strings.Contains(host, ".") {
+		return host
+	}
+	return fmt.Sprintf("%s.%s.svc.cluster.local", host, namespace)
+}
+
 func getNamespaceAndNameFromFQDN(fqdn string) (string, string) {
 	result := fqdnPattern.FindAllStringSubmatch(fqdn, -1)
 	if len(result) == 0 {
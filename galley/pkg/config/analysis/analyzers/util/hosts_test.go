@@ -34,3 +34,17 @@ func TestGetResourceNameFromHost(t *testing.T) {
 	// bogus FQDN (gets treated like a short name)
 	g.Expect(GetResourceNameFromHost("default", "foo.svc.cluster.local")).To(Equal(resource.NewName("default", "foo.svc.cluster.local")))
 }
+
+func TestConvertHostToFQDN(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// short name
+	g.Expect(ConvertHostToFQDN("default", "foo")).To(Equal("foo.default.svc.cluster.local"))
+	// FQDN
+	g.Expect(ConvertHostToFQDN("default", "foo.other.svc.cluster.local")).To(Equal("foo.other.svc.cluster.local"))
+	// external host
+	g.Expect(ConvertHostToFQDN("default", "www.example.com")).To(Equal("www.example.com"))
+	// wildcards
+	g.Expect(ConvertHostToFQDN("default", "*")).To(Equal("*"))
+	g.Expect(ConvertHostToFQDN("default", "*.local")).To(Equal("*.local"))
+}
@@ -0,0 +1,365 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// ConflictAnalyzer checks for virtual services that bind the same host to the mesh, for http routes
+// that can never be selected because earlier routes cover them, and for destination rule subsets that are
+// defined more than once with different labels.
+type ConflictAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictAnalyzer{}
+
+// subsetKey identifies a subset of a fully qualified host
+type subsetKey struct {
+	host   string
+	subset string
+}
+
+// Metadata implements Analyzer
+func (c *ConflictAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "virtualservice.ConflictAnalyzer",
+		Inputs: collection.Names{
+			metadata.IstioNetworkingV1Alpha3Virtualservices,
+			metadata.IstioNetworkingV1Alpha3Destinationrules,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (c *ConflictAnalyzer) Analyze(ctx analysis.Context) {
+	// The virtual services bound to the mesh, by host. Only one virtual service is applied to the sidecars for a
+	// host, whereas the virtual services bound to a gateway for the same host are merged, so they do not conflict.
+	bindings := make(map[string][]*resource.Entry)
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Virtualservices, func(r *resource.Entry) bool {
+		vs := r.Item.(*v1alpha3.VirtualService)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+		if containsString(vsGateways(ns, vs), meshGateway) {
+			// Avoid counting a virtual service twice if it lists the same host more than once
+			seen := make(map[string]bool)
+			for _, h := range vs.GetHosts() {
+				host := util.ConvertHostToFQDN(ns, h)
+				if !seen[host] {
+					seen[host] = true
+					bindings[host] = append(bindings[host], r)
+				}
+			}
+		}
+
+		c.analyzeRoutes(r, ctx, vs)
+		return true
+	})
+
+	c.reportHostConflicts(ctx, bindings)
+	c.analyzeSubsets(ctx)
+}
+
+func (c *ConflictAnalyzer) reportHostConflicts(ctx analysis.Context, bindings map[string][]*resource.Entry) {
+	hosts := make([]string, 0, len(bindings))
+	for host, entries := range bindings {
+		if len(entries) > 1 {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		entries := bindings[host]
+		names := entryNames(entries)
+		for _, r := range entries {
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Virtualservices,
+				msg.NewConflictingVirtualServiceHosts(r, names, host))
+		}
+	}
+}
+
+func (c *ConflictAnalyzer) analyzeRoutes(r *resource.Entry, ctx analysis.Context, vs *v1alpha3.VirtualService) {
+	routes := vs.GetHttp()
+	for i, route := range routes {
+		// A route without any match conditions matches every request
+		matches := route.GetMatch()
+		if len(matches) == 0 {
+			matches = []*v1alpha3.HTTPMatchRequest{{}}
+		}
+
+		var covering []string
+		coveredByIndex := make(map[int]bool)
+		unreachable := true
+		for _, m := range matches {
+			j := coveringRoute(routes[:i], m)
+			if j < 0 {
+				unreachable = false
+				break
+			}
+			if !coveredByIndex[j] {
+				coveredByIndex[j] = true
+				covering = append(covering, routeName(routes[j], j))
+			}
+		}
+
+		if unreachable {
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Virtualservices,
+				msg.NewUnreachableRoute(r, routeName(route, i), strings.Join(covering, ", ")))
+		}
+	}
+}
+
+func (c *ConflictAnalyzer) analyzeSubsets(ctx analysis.Context) {
+	type subsetDefinition struct {
+		labels map[string]string
+		entry  *resource.Entry
+	}
+	definitions := make(map[subsetKey][]subsetDefinition)
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		dr := r.Item.(*v1alpha3.DestinationRule)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+		for _, ss := range dr.GetSubsets() {
+			k := subsetKey{host: util.ConvertHostToFQDN(ns, dr.GetHost()), subset: ss.GetName()}
+			definitions[k] = append(definitions[k], subsetDefinition{labels: ss.GetLabels(), entry: r})
+		}
+		return true
+	})
+
+	keys := make([]subsetKey, 0, len(definitions))
+	for k := range definitions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		return keys[i].subset < keys[j].subset
+	})
+
+	for _, k := range keys {
+		defs := definitions[k]
+
+		conflicting := false
+		for _, d := range defs[1:] {
+			if !labelsEqual(defs[0].labels, d.labels) {
+				conflicting = true
+				break
+			}
+		}
+		if !conflicting {
+			continue
+		}
+
+		var entries []*resource.Entry
+		reported := make(map[*resource.Entry]bool)
+		for _, d := range defs {
+			if !reported[d.entry] {
+				reported[d.entry] = true
+				entries = append(entries, d.entry)
+			}
+		}
+
+		names := entryNames(entries)
+		for _, r := range entries {
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Destinationrules,
+				msg.NewConflictingDestinationRuleSubsets(r, k.subset, k.host, names))
+		}
+	}
+}
+
+// vsGateways returns the namespace qualified gateways a virtual service is bound to. A virtual service
+// without any gateways applies to the mesh.
+func vsGateways(ns string, vs *v1alpha3.VirtualService) []string {
+	if len(vs.GetGateways()) == 0 {
+		return []string{meshGateway}
+	}
+
+	gateways := make([]string, 0, len(vs.GetGateways()))
+	for _, gw := range vs.GetGateways() {
+		if gw != meshGateway && !strings.Contains(gw, "/") {
+			gw = ns + "/" + gw
+		}
+		gateways = append(gateways, gw)
+	}
+	return gateways
+}
+
+// coveringRoute returns the index of the first route whose matches cover m, or -1 if there is none.
+func coveringRoute(routes []*v1alpha3.HTTPRoute, m *v1alpha3.HTTPMatchRequest) int {
+	for i, route := range routes {
+		if len(route.GetMatch()) == 0 {
+			return i
+		}
+		for _, earlier := range route.GetMatch() {
+			if matchCovers(earlier, m) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// matchCovers returns true if every request matched by later is also matched by earlier.
+func matchCovers(earlier, later *v1alpha3.HTTPMatchRequest) bool {
+	if !stringMatchCovers(earlier.GetUri(), later.GetUri(), earlier.GetIgnoreUriCase(), later.GetIgnoreUriCase(), true) ||
+		!stringMatchCovers(earlier.GetScheme(), later.GetScheme(), false, false, false) ||
+		!stringMatchCovers(earlier.GetMethod(), later.GetMethod(), false, false, false) ||
+		!stringMatchCovers(earlier.GetAuthority(), later.GetAuthority(), false, false, false) ||
+		!stringMatchesCover(earlier.GetHeaders(), later.GetHeaders()) ||
+		!stringMatchesCover(earlier.GetQueryParams(), later.GetQueryParams()) {
+		return false
+	}
+
+	if earlier.GetPort() != 0 && earlier.GetPort() != later.GetPort() {
+		return false
+	}
+
+	for k, v := range earlier.GetSourceLabels() {
+		if lv, ok := later.GetSourceLabels()[k]; !ok || lv != v {
+			return false
+		}
+	}
+
+	if len(earlier.GetGateways()) > 0 {
+		if len(later.GetGateways()) == 0 {
+			return false
+		}
+		for _, gw := range later.GetGateways() {
+			if !containsString(earlier.GetGateways(), gw) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func stringMatchesCover(earlier, later map[string]*v1alpha3.StringMatch) bool {
+	for k, e := range earlier {
+		l, ok := later[k]
+		if !ok || !stringMatchCovers(e, l, false, false, false) {
+			return false
+		}
+	}
+	return true
+}
+
+// stringMatchCovers returns true if every value matched by later is also matched by earlier. A nil
+// StringMatch matches every value.
+func stringMatchCovers(earlier, later *v1alpha3.StringMatch, earlierIgnoreCase, laterIgnoreCase, uri bool) bool {
+	if earlier == nil || matchesEverything(earlier, uri) {
+		return true
+	}
+	if later == nil {
+		return false
+	}
+	// A case insensitive match accepts values a case sensitive one would reject
+	if laterIgnoreCase && !earlierIgnoreCase {
+		return false
+	}
+
+	fold := func(s string) string {
+		if earlierIgnoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+
+	switch e := earlier.MatchType.(type) {
+	case *v1alpha3.StringMatch_Exact:
+		if l, ok := later.MatchType.(*v1alpha3.StringMatch_Exact); ok {
+			return fold(l.Exact) == fold(e.Exact)
+		}
+	case *v1alpha3.StringMatch_Prefix:
+		switch l := later.MatchType.(type) {
+		case *v1alpha3.StringMatch_Exact:
+			return strings.HasPrefix(fold(l.Exact), fold(e.Prefix))
+		case *v1alpha3.StringMatch_Prefix:
+			return strings.HasPrefix(fold(l.Prefix), fold(e.Prefix))
+		}
+	case *v1alpha3.StringMatch_Regex:
+		switch l := later.MatchType.(type) {
+		case *v1alpha3.StringMatch_Exact:
+			// Envoy regexes must match the entire value
+			re, err := regexp.Compile("^(?:" + e.Regex + ")$")
+			if err != nil {
+				return false
+			}
+			return re.MatchString(l.Exact)
+		case *v1alpha3.StringMatch_Regex:
+			return l.Regex == e.Regex
+		}
+	}
+	return false
+}
+
+func matchesEverything(m *v1alpha3.StringMatch, uri bool) bool {
+	switch t := m.MatchType.(type) {
+	case *v1alpha3.StringMatch_Prefix:
+		// Every path starts with a slash
+		return t.Prefix == "" || (uri && t.Prefix == "/")
+	case *v1alpha3.StringMatch_Regex:
+		return t.Regex == ".*"
+	}
+	return false
+}
+
+func routeName(route *v1alpha3.HTTPRoute, index int) string {
+	if route.GetName() != "" {
+		return route.GetName()
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+func entryNames(entries []*resource.Entry) string {
+	names := make([]string, 0, len(entries))
+	for _, r := range entries {
+		names = append(names, r.Metadata.Name.String())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
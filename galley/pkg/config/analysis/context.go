@@ -39,4 +39,7 @@ type Context interface {
 
 	// Canceled indicates that the context has been canceled. The analyzer should stop executing as soon as possible.
 	Canceled() bool
+
+	// DomainSuffix returns the DNS domain suffix of the cluster, e.g. "cluster.local".
+	DomainSuffix() string
 }
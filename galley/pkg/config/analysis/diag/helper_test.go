@@ -14,6 +14,10 @@
 
 package diag
 
+import (
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
 type testOrigin string
 
 func (o testOrigin) FriendlyName() string {
@@ -23,3 +27,25 @@ func (o testOrigin) FriendlyName() string {
 func (o testOrigin) Namespace() string {
 	return ""
 }
+
+func (o testOrigin) Reference() resource.Reference {
+	return nil
+}
+
+// testFileOrigin is a test origin that was read from a file.
+type testFileOrigin struct {
+	name string
+	pos  *resource.Position
+}
+
+func (o testFileOrigin) FriendlyName() string {
+	return o.name
+}
+
+func (o testFileOrigin) Namespace() string {
+	return ""
+}
+
+func (o testFileOrigin) Reference() resource.Reference {
+	return o.pos
+}
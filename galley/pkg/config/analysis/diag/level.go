@@ -14,6 +14,8 @@
 
 package diag
 
+import "strings"
+
 // Level is the severity level of a message.
 type Level struct {
 	sortOrder int
@@ -34,3 +36,37 @@ var (
 	// Error level is for error messages
 	Error = Level{0, "Error"}
 )
+
+// IsWorseThanOrEqualTo returns true if the level is at least as severe as the target level.
+func (l Level) IsWorseThanOrEqualTo(target Level) bool {
+	return l.sortOrder <= target.sortOrder
+}
+
+// GetAllLevels returns all known levels, ordered from most to least severe.
+func GetAllLevels() []Level {
+	return []Level{Error, Warning, Info}
+}
+
+// GetAllLevelStrings returns the names of all known levels, ordered from most to least severe.
+func GetAllLevelStrings() []string {
+	levels := GetAllLevels()
+	var s []string
+	for _, l := range levels {
+		s = append(s, l.name)
+	}
+	return s
+}
+
+// ParseLevel returns the level with the given name, ignoring case. The accepted names are "error", "warn" or
+// "warning", and "info".
+func ParseLevel(s string) (Level, bool) {
+	if strings.EqualFold(s, "warning") {
+		return Warning, true
+	}
+	for _, l := range GetAllLevels() {
+		if strings.EqualFold(s, l.name) {
+			return l, true
+		}
+	}
+	return Level{}, false
+}
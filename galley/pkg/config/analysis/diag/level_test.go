@@ -0,0 +1,60 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLevel_IsWorseThanOrEqualTo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(Error.IsWorseThanOrEqualTo(Warning)).To(BeTrue())
+	g.Expect(Warning.IsWorseThanOrEqualTo(Warning)).To(BeTrue())
+	g.Expect(Info.IsWorseThanOrEqualTo(Warning)).To(BeFalse())
+	g.Expect(Info.IsWorseThanOrEqualTo(Info)).To(BeTrue())
+	g.Expect(Warning.IsWorseThanOrEqualTo(Error)).To(BeFalse())
+}
+
+func TestGetAllLevelStrings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(GetAllLevelStrings()).To(Equal([]string{"Error", "Warn", "Info"}))
+}
+
+func TestParseLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, s := range []string{"error", "Error", "ERROR"} {
+		l, ok := ParseLevel(s)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(l).To(Equal(Error))
+	}
+	for _, s := range []string{"warn", "Warn", "WARN", "warning", "Warning", "WARNING"} {
+		l, ok := ParseLevel(s)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(l).To(Equal(Warning))
+	}
+	for _, s := range []string{"info", "Info", "INFO"} {
+		l, ok := ParseLevel(s)
+		g.Expect(ok).To(BeTrue())
+		g.Expect(l).To(Equal(Info))
+	}
+
+	_, ok := ParseLevel("fatal")
+	g.Expect(ok).To(BeFalse())
+}
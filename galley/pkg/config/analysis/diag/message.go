@@ -60,6 +60,22 @@ func (m *Message) Unstructured(includeOrigin bool) map[string]interface{} {
 	result["level"] = m.Type.Level().String()
 	if includeOrigin && m.Origin != nil {
 		result["origin"] = m.Origin.FriendlyName()
+		if ref := m.Origin.Reference(); ref != nil {
+			result["reference"] = ref.String()
+			if p, ok := ref.(*resource.Position); ok {
+				position := map[string]interface{}{
+					"file":      p.Filename,
+					"line":      p.Line,
+					"column":    p.Column,
+					"endLine":   p.EndLine,
+					"endColumn": p.EndColumn,
+				}
+				if p.Revision != "" {
+					position["revision"] = p.Revision
+				}
+				result["position"] = position
+			}
+		}
 	}
 	result["message"] = fmt.Sprintf(m.Type.Template(), m.Parameters...)
 
@@ -70,7 +86,11 @@ func (m *Message) Unstructured(includeOrigin bool) map[string]interface{} {
 func (m *Message) String() string {
 	origin := ""
 	if m.Origin != nil {
-		origin = "(" + m.Origin.FriendlyName() + ")"
+		origin = m.Origin.FriendlyName()
+		if ref := m.Origin.Reference(); ref != nil {
+			origin += " " + ref.String()
+		}
+		origin = "(" + origin + ")"
 	}
 	return fmt.Sprintf(
 		"%v [%v]%s %s", m.Type.Level(), m.Type.Code(), origin, fmt.Sprintf(m.Type.Template(), m.Parameters...))
@@ -18,6 +18,8 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/resource"
 )
 
 func TestMessage_String(t *testing.T) {
@@ -37,6 +39,18 @@ func TestMessageWithOrigin_String(t *testing.T) {
 	g.Expect(m.String()).To(Equal(`Error [IST-0042](toppings/cheese) Cheese type not found: "Feta"`))
 }
 
+func TestMessageWithPosition_String(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := testFileOrigin{
+		name: "toppings/cheese",
+		pos:  &resource.Position{Filename: "pizza.yaml", Line: 12, Column: 1, EndLine: 20, EndColumn: 9},
+	}
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, o, "Feta")
+
+	g.Expect(m.String()).To(Equal(`Error [IST-0042](toppings/cheese pizza.yaml:12:1) Cheese type not found: "Feta"`))
+}
+
 func TestMessage_Unstructured(t *testing.T) {
 	g := NewGomegaWithT(t)
 	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
@@ -50,3 +64,27 @@ func TestMessage_Unstructured(t *testing.T) {
 	g.Expect(m.Unstructured(true)).To((HaveKey("origin")))
 	g.Expect(m.Unstructured(false)).To(Not(HaveKey("origin")))
 }
+
+func TestMessageWithPosition_Unstructured(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := testFileOrigin{
+		name: "toppings/cheese",
+		pos:  &resource.Position{Filename: "pizza.yaml", Line: 12, Column: 1, EndLine: 20, EndColumn: 9},
+	}
+	mt := NewMessageType(Error, "IST-0042", "Cheese type not found: %q")
+	m := NewMessage(mt, o, "Feta")
+
+	u := m.Unstructured(true)
+	g.Expect(u).To(HaveKeyWithValue("reference", "pizza.yaml:12:1"))
+	g.Expect(u).To(HaveKeyWithValue("position", map[string]interface{}{
+		"file":      "pizza.yaml",
+		"line":      12,
+		"column":    1,
+		"endLine":   20,
+		"endColumn": 9,
+	}))
+
+	u = m.Unstructured(false)
+	g.Expect(u).NotTo(HaveKey("reference"))
+	g.Expect(u).NotTo(HaveKey("position"))
+}
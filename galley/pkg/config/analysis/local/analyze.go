@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io/ioutil"
 
+	"istio.io/api/mesh/v1alpha1"
+
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/event"
@@ -35,6 +37,7 @@ import (
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
 	"istio.io/istio/galley/pkg/config/source/kube/inmemory"
 	"istio.io/istio/galley/pkg/config/util/kuberesource"
+	"istio.io/istio/pkg/config/mesh"
 )
 
 const domainSuffix = "svc.local"
@@ -52,12 +55,18 @@ type SourceAnalyzer struct {
 	transformerProviders transformer.Providers
 	namespace            string
 
+	// The mesh config the analysis runs with
+	meshCfg *v1alpha1.MeshConfig
+
 	// Which kube resources are used by this analyzer
 	// Derived from metadata and the specified analyzer and transformer providers
 	kubeResources schema.KubeResources
 
 	// Hook function called when a collection is used in analysis
 	collectionReporter snapshotter.CollectionReporterFn
+
+	// Which messages to suppress, in addition to the ones suppressed through resource annotations
+	suppressions []snapshotter.AnalysisSuppression
 }
 
 // NewSourceAnalyzer creates a new SourceAnalyzer with no sources. Use the Add*Source methods to add sources in ascending precedence order,
@@ -81,6 +90,7 @@ func NewSourceAnalyzer(m *schema.Metadata, analyzer *analysis.CombinedAnalyzer,
 		analyzer:             analyzer,
 		transformerProviders: transformerProviders,
 		namespace:            namespace,
+		meshCfg:              meshcfg.Default(),
 		kubeResources:        disableUnusedKubeResources(m, inputCollections, serviceDiscovery),
 		collectionReporter:   cr,
 	}
@@ -89,7 +99,7 @@ func NewSourceAnalyzer(m *schema.Metadata, analyzer *analysis.CombinedAnalyzer,
 // Analyze loads the sources and executes the analysis
 func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (diag.Messages, error) {
 	meshsrc := meshcfg.NewInmemory()
-	meshsrc.Set(meshcfg.Default())
+	meshsrc.Set(sa.meshCfg)
 
 	if len(sa.sources) == 0 {
 		return nil, fmt.Errorf("at least one file and/or kubernetes source must be provided")
@@ -110,6 +120,7 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (diag.Messages, error) {
 		TriggerSnapshot:    metadata.LocalAnalysis,
 		CollectionReporter: sa.collectionReporter,
 		AnalysisNamespaces: namespaces,
+		Suppressions:       sa.suppressions,
 	}
 	distributor := snapshotter.NewAnalyzingDistributor(distributorSettings)
 
@@ -136,6 +147,13 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (diag.Messages, error) {
 	return nil, errors.New("cancelled")
 }
 
+// SetSuppressions will set the list of suppressions for the analyzer. Any
+// resource that matches the provided suppression will not be included in the
+// final message output.
+func (sa *SourceAnalyzer) SetSuppressions(suppressions []snapshotter.AnalysisSuppression) {
+	sa.suppressions = suppressions
+}
+
 // AddFileKubeSource adds a source based on the specified k8s yaml files to the current SourceAnalyzer
 func (sa *SourceAnalyzer) AddFileKubeSource(files []string) error {
 	src := inmemory.NewKubeSource(sa.kubeResources)
@@ -155,6 +173,21 @@ func (sa *SourceAnalyzer) AddFileKubeSource(files []string) error {
 	return nil
 }
 
+// AddFileKubeMeshConfig sets the mesh config the analysis runs with from the specified yaml file, on top of the
+// default mesh config
+func (sa *SourceAnalyzer) AddFileKubeMeshConfig(file string) error {
+	by, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	cfg, err := mesh.ApplyMeshConfig(string(by), *meshcfg.Default())
+	if err != nil {
+		return err
+	}
+	sa.meshCfg = cfg
+	return nil
+}
+
 // AddRunningKubeSource adds a source based on a running k8s cluster to the current SourceAnalyzer
 func (sa *SourceAnalyzer) AddRunningKubeSource(k kube.Interfaces) {
 	o := apiserver.Options{
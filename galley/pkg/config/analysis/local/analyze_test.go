@@ -25,8 +25,10 @@ import (
 	"istio.io/istio/galley/pkg/config/analysis/msg"
 	"istio.io/istio/galley/pkg/config/meta/metadata"
 	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
 	"istio.io/istio/galley/pkg/config/resource"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/data"
 	"istio.io/istio/galley/pkg/config/testing/k8smeta"
 	"istio.io/istio/galley/pkg/testing/mock"
@@ -121,6 +123,35 @@ func TestFilterOutputByNamespace(t *testing.T) {
 	g.Expect(msgs).To(ConsistOf(msg1))
 }
 
+func TestSuppressions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cancel := make(chan struct{})
+
+	r1 := createTestResource("ns1", "resource", "v1")
+	r2 := createTestResource("ns2", "resource", "v1")
+	r2.Origin.(*rt.Origin).Kind = "Kind1"
+	msg1 := msg.NewInternalError(r1, "msg")
+	msg2 := msg.NewInternalError(r2, "msg")
+	a := &testAnalyzer{
+		fn: func(ctx analysis.Context) {
+			ctx.Report(data.Collection1, msg1)
+			ctx.Report(data.Collection1, msg2)
+		},
+	}
+
+	sa := NewSourceAnalyzer(metadata.MustGet(), analysis.Combine("a", a), "", nil, false)
+	sa.SetSuppressions([]snapshotter.AnalysisSuppression{
+		{Code: msg.InternalError.Code(), ResourceName: "Kind1/ns2/resource"},
+	})
+	err := sa.AddFileKubeSource([]string{})
+	g.Expect(err).To(BeNil())
+
+	msgs, err := sa.Analyze(cancel)
+	g.Expect(err).To(BeNil())
+	g.Expect(msgs).To(ConsistOf(msg1))
+}
+
 func TestAddRunningKubeSource(t *testing.T) {
 	g := NewGomegaWithT(t)
 
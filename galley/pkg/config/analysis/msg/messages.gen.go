@@ -56,6 +56,22 @@ var (
 	// UnknownAnnotation defines a diag.MessageType for message "UnknownAnnotation".
 	// Description: An Istio annotation is not recognized for any kind of resource
 	UnknownAnnotation = diag.NewMessageType(diag.Warning, "IST0108", "Unknown annotation: %s")
+
+	// ConflictingVirtualServiceHosts defines a diag.MessageType for message "ConflictingVirtualServiceHosts".
+	// Description: Two or more VirtualServices bind the same host to the mesh.
+	ConflictingVirtualServiceHosts = diag.NewMessageType(diag.Error, "IST0109", "The VirtualServices %s bind host %s to the mesh. Only one of them is applied to the sidecars; merge them into a single VirtualService.")
+
+	// UnreachableRoute defines a diag.MessageType for message "UnreachableRoute".
+	// Description: An HTTP route can never be selected because earlier routes match all of its requests.
+	UnreachableRoute = diag.NewMessageType(diag.Warning, "IST0110", "HTTP route %s is unreachable: every request it matches is already matched by route(s) %s")
+
+	// ConflictingDestinationRuleSubsets defines a diag.MessageType for message "ConflictingDestinationRuleSubsets".
+	// Description: A subset of a host is defined more than once with different labels.
+	ConflictingDestinationRuleSubsets = diag.NewMessageType(diag.Error, "IST0111", "Subset %s of host %s is defined with different labels by the DestinationRules %s")
+
+	// MTLSPolicyConflict defines a diag.MessageType for message "MTLSPolicyConflict".
+	// Description: A DestinationRule and an authentication Policy disagree on whether traffic to a host uses mTLS.
+	MTLSPolicyConflict = diag.NewMessageType(diag.Error, "IST0112", "mTLS settings for host %s conflict: clients send %s traffic (%s) but the server accepts %s (%s). Requests to this host will fail.")
 )
 
 // NewInternalError returns a new diag.Message based on InternalError.
@@ -172,6 +188,50 @@ func NewUnknownAnnotation(entry *resource.Entry, annotation string) diag.Message
 	)
 }
 
+// NewConflictingVirtualServiceHosts returns a new diag.Message based on ConflictingVirtualServiceHosts.
+func NewConflictingVirtualServiceHosts(entry *resource.Entry, virtualServices string, host string) diag.Message {
+	return diag.NewMessage(
+		ConflictingVirtualServiceHosts,
+		originOrNil(entry),
+		virtualServices,
+		host,
+	)
+}
+
+// NewUnreachableRoute returns a new diag.Message based on UnreachableRoute.
+func NewUnreachableRoute(entry *resource.Entry, route string, coveringRoutes string) diag.Message {
+	return diag.NewMessage(
+		UnreachableRoute,
+		originOrNil(entry),
+		route,
+		coveringRoutes,
+	)
+}
+
+// NewConflictingDestinationRuleSubsets returns a new diag.Message based on ConflictingDestinationRuleSubsets.
+func NewConflictingDestinationRuleSubsets(entry *resource.Entry, subset string, host string, destinationRules string) diag.Message {
+	return diag.NewMessage(
+		ConflictingDestinationRuleSubsets,
+		originOrNil(entry),
+		subset,
+		host,
+		destinationRules,
+	)
+}
+
+// NewMTLSPolicyConflict returns a new diag.Message based on MTLSPolicyConflict.
+func NewMTLSPolicyConflict(entry *resource.Entry, host string, clientMode string, clientSource string, serverMode string, serverSource string) diag.Message {
+	return diag.NewMessage(
+		MTLSPolicyConflict,
+		originOrNil(entry),
+		host,
+		clientMode,
+		clientSource,
+		serverMode,
+		serverSource,
+	)
+}
+
 func originOrNil(e *resource.Entry) resource.Origin {
 	var o resource.Origin
 	if e != nil {
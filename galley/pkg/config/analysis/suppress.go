@@ -0,0 +1,49 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"strings"
+
+	"istio.io/api/annotation"
+)
+
+// SuppressAnnotation is used to silence analysis messages for the annotated resource. Its value is a comma-separated
+// list of message codes. It can be applied to any kind of resource.
+var SuppressAnnotation = annotation.Instance{
+	Name: "galley.istio.io/analyze-suppress",
+	Description: "A comma-separated list of analysis message codes (e.g. IST0102) that should not be reported " +
+		"for this resource.",
+	Hidden:     false,
+	Deprecated: false,
+	Resources:  nil,
+}
+
+// SuppressedCodes returns the set of message codes suppressed by the given annotations, if any.
+func SuppressedCodes(annotations map[string]string) map[string]struct{} {
+	value, ok := annotations[SuppressAnnotation.Name]
+	if !ok {
+		return nil
+	}
+
+	codes := make(map[string]struct{})
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code != "" {
+			codes[code] = struct{}{}
+		}
+	}
+	return codes
+}
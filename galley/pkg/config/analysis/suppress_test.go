@@ -0,0 +1,34 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSuppressedCodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(SuppressedCodes(nil)).To(BeNil())
+	g.Expect(SuppressedCodes(map[string]string{"foo": "IST0102"})).To(BeNil())
+	g.Expect(SuppressedCodes(map[string]string{
+		SuppressAnnotation.Name: "IST0102, IST0101,,",
+	})).To(Equal(map[string]struct{}{
+		"IST0101": {},
+		"IST0102": {},
+	}))
+}
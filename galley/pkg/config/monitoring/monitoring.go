@@ -33,6 +33,7 @@ const (
 	namespace  = "namespace"
 	name       = "name"
 	version    = "version"
+	analyzer   = "analyzer"
 )
 
 var (
@@ -44,6 +45,8 @@ var (
 	NameTag tag.Key
 	// VersionTag holds version of the resource for the context.
 	VersionTag tag.Key
+	// AnalyzerTag holds the name of the analyzer for the context.
+	AnalyzerTag tag.Key
 	// StateTypeConfigKeys holds key tags for runtime state metrics.
 	StateTypeConfigKeys []tag.Key
 )
@@ -85,6 +88,14 @@ var (
 		"galley/runtime/processor/snapshot_lifetime_duration_milliseconds",
 		"The duration of each snapshot",
 		stats.UnitMilliseconds)
+	analyzerDurationMs = stats.Int64(
+		"galley/analysis/analyzer_duration_milliseconds",
+		"The duration of each run of an analyzer",
+		stats.UnitMilliseconds)
+	analyzerSkippedTotal = stats.Int64(
+		"galley/analysis/analyzer_skipped_total",
+		"The number of times an analyzer was not re-run, because none of its inputs changed",
+		stats.UnitDimensionless)
 	stateTypeInstancesTotal = stats.Int64(
 		"galley/runtime/state/type_instances_total",
 		"The number of type instances per type URL",
@@ -128,6 +139,26 @@ func RecordProcessorSnapshotPublished(events int64, snapshotSpan time.Duration)
 		processorSnapshotLifetimesMs.M(snapshotSpan.Nanoseconds()/1e6))
 }
 
+// RecordAnalyzerRun event
+func RecordAnalyzerRun(analyzerName string, duration time.Duration) {
+	ctx, err := tag.New(context.Background(), tag.Insert(AnalyzerTag, analyzerName))
+	if err != nil {
+		scope.Analysis.Errorf("Error creating monitoring context for analyzer run: %v", err)
+		return
+	}
+	stats.Record(ctx, analyzerDurationMs.M(duration.Nanoseconds()/1e6))
+}
+
+// RecordAnalyzerSkipped event
+func RecordAnalyzerSkipped(analyzerName string) {
+	ctx, err := tag.New(context.Background(), tag.Insert(AnalyzerTag, analyzerName))
+	if err != nil {
+		scope.Analysis.Errorf("Error creating monitoring context for skipped analyzer: %v", err)
+		return
+	}
+	stats.Record(ctx, analyzerSkippedTotal.M(1))
+}
+
 // RecordStateTypeCount event
 func RecordStateTypeCount(collection string, count int) {
 	ctx, err := tag.New(context.Background(), tag.Insert(CollectionTag, collection))
@@ -224,9 +255,13 @@ func init() {
 	if CollectionTag, err = tag.NewKey(collection); err != nil {
 		panic(err)
 	}
+	if AnalyzerTag, err = tag.NewKey(analyzer); err != nil {
+		panic(err)
+	}
 
 	var noKeys []tag.Key
 	collectionKeys := []tag.Key{CollectionTag}
+	analyzerKeys := []tag.Key{AnalyzerTag}
 
 	err = view.Register(
 		newView(strategyOnTimerResetTotal, noKeys, view.Count()),
@@ -239,6 +274,8 @@ func init() {
 		newView(processorEventsPerSnapshot, noKeys, view.Distribution(0, 1, 2, 4, 8, 16, 32, 64, 128, 256)),
 		newView(processorSnapshotLifetimesMs, noKeys, durationDistributionMs),
 		newView(stateTypeInstancesTotal, collectionKeys, view.LastValue()),
+		newView(analyzerDurationMs, analyzerKeys, durationDistributionMs),
+		newView(analyzerSkippedTotal, analyzerKeys, view.Count()),
 	)
 
 	if err != nil {
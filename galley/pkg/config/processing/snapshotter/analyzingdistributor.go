@@ -16,11 +16,13 @@ package snapshotter
 
 import (
 	"sync"
+	"time"
 
 	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/diag"
 	coll "istio.io/istio/galley/pkg/config/collection"
 	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/monitoring"
 	"istio.io/istio/galley/pkg/config/resource"
 	"istio.io/istio/galley/pkg/config/scope"
 )
@@ -38,10 +40,21 @@ type AnalyzingDistributor struct {
 
 	snapshotsMu   sync.RWMutex
 	lastSnapshots map[string]*Snapshot
+
+	// The results of the last completed analysis. Analyzers whose inputs haven't changed since then are not re-run,
+	// and their previous messages are reused instead. resultsMu only guards reading and replacing the results, the
+	// analysis itself runs without it.
+	resultsMu           sync.Mutex
+	lastState           *snapshotState
+	lastMessages        []diag.Messages
+	lastReported        diag.Messages
+	reportedAtLeastOnce bool
 }
 
 var _ Distributor = &AnalyzingDistributor{}
 
+const defaultDomainSuffix = "cluster.local"
+
 // AnalyzingDistributorSettings are settings for an AnalyzingDistributor
 type AnalyzingDistributorSettings struct {
 	// The status updater to route diagnostic messages to
@@ -66,6 +79,12 @@ type AnalyzingDistributorSettings struct {
 
 	// Namespaces that should be analyzed
 	AnalysisNamespaces []string
+
+	// Suppressions that should be applied to the analysis messages
+	Suppressions []AnalysisSuppression
+
+	// The DNS domain suffix of the cluster. Defaults to "cluster.local".
+	DomainSuffix string
 }
 
 // NewAnalyzingDistributor returns a new instance of AnalyzingDistributor.
@@ -74,6 +93,9 @@ func NewAnalyzingDistributor(s AnalyzingDistributorSettings) *AnalyzingDistribut
 	if s.CollectionReporter == nil {
 		s.CollectionReporter = func(collection.Name) {}
 	}
+	if s.DomainSuffix == "" {
+		s.DomainSuffix = defaultDomainSuffix
+	}
 
 	return &AnalyzingDistributor{
 		s:             s,
@@ -134,37 +156,129 @@ func (d *AnalyzingDistributor) analyzeAndDistribute(cancelCh chan struct{}, name
 		sn:                 d.getCombinedSnapshot(),
 		cancelCh:           cancelCh,
 		collectionReporter: d.s.CollectionReporter,
+		domainSuffix:       d.s.DomainSuffix,
+	}
+
+	d.resultsMu.Lock()
+	lastState, lastMessages := d.lastState, d.lastMessages
+	d.resultsMu.Unlock()
+
+	state := captureState(ctx.sn, lastState)
+	analyzers := d.s.Analyzer.Analyzers()
+
+	// If there was no previous analysis, everything needs to be analyzed.
+	var changes map[collection.Name][]resource.Name
+	incremental := lastState != nil && len(lastMessages) == len(analyzers)
+	if incremental {
+		changes = state.changesSince(lastState)
+		scope.Analysis.Debugf("Collections changed since the last analysis: %v", changes)
 	}
 
 	scope.Analysis.Debugf("Beginning analyzing the current snapshot")
-	d.s.Analyzer.Analyze(ctx)
-	scope.Analysis.Debugf("Finished analyzing the current snapshot, found messages: %v", ctx.messages)
+	messages := make([]diag.Messages, len(analyzers))
+	for i, a := range analyzers {
+		if incremental && !inputsChanged(a, changes) {
+			scope.Analysis.Debugf("Skipping analyzer %q, since none of its inputs have changed", a.Metadata().Name)
+			monitoring.RecordAnalyzerSkipped(a.Metadata().Name)
+			messages[i] = lastMessages[i]
+			continue
+		}
 
-	// Only keep messages for resources in namespaces we want to analyze
-	// If the message doesn't have an origin (meaning we can't determine the namespace) fail open and keep it
-	// If no such limit is specified, keep them all.
-	var msgs diag.Messages
-	if len(namespaces) == 0 {
-		msgs = ctx.messages
-	} else {
-		for _, m := range ctx.messages {
-			if m.Origin != nil {
-				if _, ok := namespaces[m.Origin.Namespace()]; !ok {
-					continue
-				}
-			}
-			msgs = append(msgs, m)
+		if ctx.Canceled() {
+			scope.Analysis.Debugf("Analysis of the current snapshot has been cancelled...")
+			break
 		}
+
+		scope.Analysis.Debugf("Started analyzer %q...", a.Metadata().Name)
+		ctx.messages = nil
+		start := time.Now()
+		a.Analyze(ctx)
+		monitoring.RecordAnalyzerRun(a.Metadata().Name, time.Since(start))
+		messages[i] = ctx.messages
+		scope.Analysis.Debugf("Completed analyzer %q...", a.Metadata().Name)
 	}
 
 	if !ctx.Canceled() {
-		d.s.StatusUpdater.Update(msgs.SortedCopy())
+		var all diag.Messages
+		for _, ms := range messages {
+			all = append(all, ms...)
+		}
+		scope.Analysis.Debugf("Finished analyzing the current snapshot, found messages: %v", all)
+
+		msgs := filterMessages(all, namespaces, d.s.Suppressions)
+		msgs.Sort()
+		d.report(ctx, state, messages, msgs)
 	}
 
 	// Execution only reaches this point for trigger snapshot group
 	d.s.Distributor.Distribute(name, s)
 }
 
+// report updates the status with the messages of an analysis, and replaces the results of the last analysis with
+// them, unless the analysis got canceled in the meantime. A canceled analysis session might still be running while the
+// next one starts, so the check is done under the lock of the results.
+func (d *AnalyzingDistributor) report(ctx *context, state *snapshotState, messages []diag.Messages, msgs diag.Messages) {
+	d.resultsMu.Lock()
+	defer d.resultsMu.Unlock()
+
+	if ctx.Canceled() {
+		scope.Analysis.Debugf("Analysis of the current snapshot has been cancelled...")
+		return
+	}
+
+	diff := diffMessages(d.lastReported, msgs)
+
+	// There is no need to update the status if nothing has changed since the last report.
+	if !d.reportedAtLeastOnce || !diff.IsEmpty() {
+		if u, ok := d.s.StatusUpdater.(IncrementalStatusUpdater); ok {
+			u.UpdateDiff(diff)
+		} else {
+			d.s.StatusUpdater.Update(msgs)
+		}
+	}
+
+	d.lastState = state
+	d.lastMessages = messages
+	d.lastReported = msgs
+	d.reportedAtLeastOnce = true
+}
+
+// inputsChanged returns true if any of the analyzer's inputs are among the changed collections.
+func inputsChanged(a analysis.Analyzer, changes map[collection.Name][]resource.Name) bool {
+	for _, in := range a.Metadata().Inputs {
+		if _, ok := changes[in]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterMessages drops messages that are not in the namespaces we want to analyze, or that are suppressed.
+func filterMessages(messages diag.Messages, namespaces map[string]struct{}, suppressions []AnalysisSuppression) diag.Messages {
+	var msgs diag.Messages
+FilterMessages:
+	for _, m := range messages {
+		// Only keep messages for resources in namespaces we want to analyze
+		// If the message doesn't have an origin (meaning we can't determine the namespace) fail open and keep it
+		// If no such limit is specified, keep them all.
+		if len(namespaces) > 0 && m.Origin != nil {
+			if _, ok := namespaces[m.Origin.Namespace()]; !ok {
+				continue
+			}
+		}
+
+		for _, s := range suppressions {
+			if s.Matches(m) {
+				scope.Analysis.Debugf("Suppressing message %q due to suppression %v", m.String(), s)
+				continue FilterMessages
+			}
+		}
+
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
 // getCombinedSnapshot creates a new snapshot from the last snapshots of each snapshot group
 // Important assumption: the collections in each snapshot don't overlap.
 func (d *AnalyzingDistributor) getCombinedSnapshot() *Snapshot {
@@ -189,15 +303,46 @@ type context struct {
 	cancelCh           chan struct{}
 	messages           diag.Messages
 	collectionReporter CollectionReporterFn
+	domainSuffix       string
+
+	// Message codes suppressed through annotations, indexed by the origin of the annotated resource.
+	// Lazily initialized on the first report.
+	suppressedCodes map[resource.Origin]map[string]struct{}
 }
 
 var _ analysis.Context = &context{}
 
 // Report implements analysis.Context
 func (c *context) Report(col collection.Name, m diag.Message) {
+	if c.isSuppressed(m) {
+		scope.Analysis.Debugf("Suppressing message %q due to the %s annotation", m.String(), analysis.SuppressAnnotation.Name)
+		return
+	}
 	c.messages.Add(m)
 }
 
+// isSuppressed returns true if the resource the message originates from has an annotation suppressing its code.
+func (c *context) isSuppressed(m diag.Message) bool {
+	if m.Origin == nil {
+		return false
+	}
+
+	if c.suppressedCodes == nil {
+		c.suppressedCodes = make(map[resource.Origin]map[string]struct{})
+		for _, n := range c.sn.set.Names() {
+			c.sn.ForEach(n, func(e *resource.Entry) bool {
+				if codes := analysis.SuppressedCodes(e.Metadata.Annotations); codes != nil && e.Origin != nil {
+					c.suppressedCodes[e.Origin] = codes
+				}
+				return true
+			})
+		}
+	}
+
+	_, found := c.suppressedCodes[m.Origin][m.Type.Code()]
+	return found
+}
+
 // Find implements analysis.Context
 func (c *context) Find(col collection.Name, name resource.Name) *resource.Entry {
 	c.collectionReporter(col)
@@ -225,3 +370,8 @@ func (c *context) Canceled() bool {
 		return false
 	}
 }
+
+// DomainSuffix implements analysis.Context
+func (c *context) DomainSuffix() string {
+	return c.domainSuffix
+}
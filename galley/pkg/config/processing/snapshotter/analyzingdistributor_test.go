@@ -15,6 +15,7 @@
 package snapshotter
 
 import (
+	"sync"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -205,6 +206,210 @@ func TestAnalyzeSortsMessages(t *testing.T) {
 	g.Expect(u.messages[1].Origin).To(Equal(o1))
 }
 
+func TestAnalyzeSuppressesMessagesByAnnotation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{}
+	suppressed := &resource.Entry{
+		Metadata: resource.Metadata{
+			Name:        resource.NewName("includedNamespace", "r1"),
+			Annotations: map[string]string{analysis.SuppressAnnotation.Name: msg.InternalError.Code()},
+		},
+		Origin: &rt.Origin{
+			Collection: data.Collection1,
+			Kind:       "Kind1",
+			Name:       resource.NewName("includedNamespace", "r1"),
+		},
+	}
+	notSuppressed := &resource.Entry{
+		Metadata: resource.Metadata{
+			Name:        resource.NewName("includedNamespace", "r2"),
+			Annotations: map[string]string{analysis.SuppressAnnotation.Name: msg.Deprecated.Code()},
+		},
+		Origin: &rt.Origin{
+			Collection: data.Collection1,
+			Kind:       "Kind1",
+			Name:       resource.NewName("includedNamespace", "r2"),
+		},
+	}
+	a := &analyzerMock{
+		collectionToAccess: data.Collection1,
+		entriesToReport:    []*resource.Entry{suppressed, notSuppressed},
+	}
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       NewInMemoryDistributor(),
+		AnalysisSnapshots: []string{metadata.Default},
+		TriggerSnapshot:   metadata.Default,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	c := coll.New(data.Collection1)
+	c.Set(suppressed)
+	c.Set(notSuppressed)
+	sDefault := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c})}
+
+	ad.Distribute(metadata.Default, sDefault)
+
+	g.Eventually(func() diag.Messages { return u.messages }).Should(HaveLen(1))
+	g.Expect(u.messages[0].Origin).To(Equal(notSuppressed.Origin))
+}
+
+func TestAnalyzeSuppressesMessagesBySettings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &updaterMock{}
+	o1 := &rt.Origin{
+		Collection: data.Collection1,
+		Kind:       "Kind1",
+		Name:       resource.NewName("includedNamespace", "r1"),
+	}
+	o2 := &rt.Origin{
+		Collection: data.Collection1,
+		Kind:       "Kind1",
+		Name:       resource.NewName("includedNamespace", "r2"),
+	}
+	a := &analyzerMock{
+		collectionToAccess: data.Collection1,
+		entriesToReport: []*resource.Entry{
+			{Origin: o1},
+			{Origin: o2},
+		},
+	}
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a),
+		Distributor:       NewInMemoryDistributor(),
+		AnalysisSnapshots: []string{metadata.Default},
+		TriggerSnapshot:   metadata.Default,
+		Suppressions: []AnalysisSuppression{
+			{Code: msg.InternalError.Code(), ResourceName: "Kind1/includedNamespace/r1"},
+		},
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	ad.Distribute(metadata.Default, getTestSnapshot())
+
+	g.Eventually(func() diag.Messages { return u.messages }).Should(HaveLen(1))
+	g.Expect(u.messages[0].Origin).To(Equal(o2))
+}
+
+type inputAnalyzerMock struct {
+	name   string
+	input  collection.Name
+	mu     sync.Mutex
+	runs   int
+	report bool
+}
+
+// Analyze implements Analyzer
+func (a *inputAnalyzerMock) Analyze(c analysis.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runs++
+
+	if a.report {
+		c.ForEach(a.input, func(e *resource.Entry) bool {
+			c.Report(a.input, msg.NewInternalError(e, a.name+":"+e.Metadata.Name.String()))
+			return true
+		})
+	}
+}
+
+// Metadata implements Analyzer
+func (a *inputAnalyzerMock) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:   a.name,
+		Inputs: collection.Names{a.input},
+	}
+}
+
+func (a *inputAnalyzerMock) getRuns() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.runs
+}
+
+type incrementalUpdaterMock struct {
+	mu    sync.Mutex
+	diffs []MessagesDiff
+}
+
+// Update implements StatusUpdater
+func (u *incrementalUpdaterMock) Update(_ diag.Messages) {
+	panic("Update should not be called on an IncrementalStatusUpdater")
+}
+
+// UpdateDiff implements IncrementalStatusUpdater
+func (u *incrementalUpdaterMock) UpdateDiff(d MessagesDiff) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.diffs = append(u.diffs, d)
+}
+
+func (u *incrementalUpdaterMock) getDiffs() []MessagesDiff {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]MessagesDiff{}, u.diffs...)
+}
+
+func TestAnalyzeIncrementally(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u := &incrementalUpdaterMock{}
+	a1 := &inputAnalyzerMock{name: "a1", input: data.Collection1, report: true}
+	a2 := &inputAnalyzerMock{name: "a2", input: data.Collection2, report: true}
+	d := NewInMemoryDistributor()
+
+	settings := AnalyzingDistributorSettings{
+		StatusUpdater:     u,
+		Analyzer:          analysis.Combine("testCombined", a1, a2),
+		Distributor:       d,
+		AnalysisSnapshots: []string{metadata.Default},
+		TriggerSnapshot:   metadata.Default,
+	}
+	ad := NewAnalyzingDistributor(settings)
+
+	c1 := coll.New(data.Collection1)
+	c1.Set(data.EntryN1I1V1)
+	c2 := coll.New(data.Collection2)
+	c2.Set(data.EntryN2I2V1)
+	s1 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1.Clone(), c2.Clone()})}
+
+	// The first analysis runs every analyzer.
+	ad.Distribute(metadata.Default, s1)
+	g.Eventually(func() snapshot.Snapshot { return d.GetSnapshot(metadata.Default) }).Should(Equal(s1))
+	g.Expect(a1.getRuns()).To(Equal(1))
+	g.Expect(a2.getRuns()).To(Equal(1))
+	g.Expect(u.getDiffs()).To(HaveLen(1))
+	g.Expect(u.getDiffs()[0].Added).To(HaveLen(2))
+
+	// Only the analyzer for the changed collection is re-run, and the messages of the other one are kept.
+	c2.Set(data.EntryN3I3V1)
+	s2 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1.Clone(), c2.Clone()})}
+	ad.Distribute(metadata.Default, s2)
+	g.Eventually(func() snapshot.Snapshot { return d.GetSnapshot(metadata.Default) }).Should(Equal(s2))
+	g.Expect(a1.getRuns()).To(Equal(1))
+	g.Expect(a2.getRuns()).To(Equal(2))
+	g.Expect(u.getDiffs()).To(HaveLen(2))
+	diff := u.getDiffs()[1]
+	g.Expect(diff.Added).To(HaveLen(1))
+	g.Expect(diff.Added[0].Parameters).To(Equal([]interface{}{"a2:n3/i3"}))
+	g.Expect(diff.Removed).To(BeEmpty())
+	g.Expect(diff.Current).To(HaveLen(3))
+
+	// Without any changes, no analyzer is run and the status is not updated.
+	s3 := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1.Clone(), c2.Clone()})}
+	ad.Distribute(metadata.Default, s3)
+	g.Eventually(func() snapshot.Snapshot { return d.GetSnapshot(metadata.Default) }).Should(Equal(s3))
+	g.Expect(a1.getRuns()).To(Equal(1))
+	g.Expect(a2.getRuns()).To(Equal(2))
+	g.Expect(u.getDiffs()).To(HaveLen(2))
+}
+
 func getTestSnapshot(names ...string) *Snapshot {
 	c := make([]*coll.Instance, 0)
 	for _, name := range names {
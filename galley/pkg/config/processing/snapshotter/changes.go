@@ -0,0 +1,95 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// snapshotState captures the resource versions in a snapshot, so that the changes between successive snapshots can
+// be determined.
+type snapshotState struct {
+	collections map[collection.Name]collectionState
+}
+
+type collectionState struct {
+	generation int64
+	versions   map[resource.Name]resource.Version
+}
+
+// captureState captures the state of the given snapshot. If the previous state is given, the versions of collections
+// whose generation hasn't changed are reused rather than recalculated.
+func captureState(sn *Snapshot, previous *snapshotState) *snapshotState {
+	s := &snapshotState{
+		collections: make(map[collection.Name]collectionState),
+	}
+
+	for _, n := range sn.set.Names() {
+		c := sn.set.Collection(n)
+		gen := c.Generation()
+
+		if previous != nil {
+			if prev, ok := previous.collections[n]; ok && prev.generation == gen && len(prev.versions) == c.Size() {
+				s.collections[n] = prev
+				continue
+			}
+		}
+
+		cs := collectionState{
+			generation: gen,
+			versions:   make(map[resource.Name]resource.Version, c.Size()),
+		}
+		c.ForEach(func(e *resource.Entry) bool {
+			cs.versions[e.Metadata.Name] = e.Metadata.Version
+			return true
+		})
+		s.collections[n] = cs
+	}
+
+	return s
+}
+
+// changesSince returns the names of the resources that were added, updated or removed since the previous state,
+// indexed by collection. Only collections with at least one change are included.
+func (s *snapshotState) changesSince(previous *snapshotState) map[collection.Name][]resource.Name {
+	changes := make(map[collection.Name][]resource.Name)
+
+	for n, cs := range s.collections {
+		prev := previous.collections[n]
+		for name, v := range cs.versions {
+			if pv, ok := prev.versions[name]; !ok || pv != v {
+				changes[n] = append(changes[n], name)
+			}
+		}
+		for name := range prev.versions {
+			if _, ok := cs.versions[name]; !ok {
+				changes[n] = append(changes[n], name)
+			}
+		}
+	}
+
+	// Collections that are no longer present in the snapshot.
+	for n, prev := range previous.collections {
+		if _, ok := s.collections[n]; ok {
+			continue
+		}
+		for name := range prev.versions {
+			changes[n] = append(changes[n], name)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	coll "istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/testing/data"
+)
+
+func TestSnapshotState_NoChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c1 := coll.New(data.Collection1)
+	c1.Set(data.EntryN1I1V1)
+	sn := &Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1})}
+
+	s1 := captureState(sn, nil)
+	s2 := captureState(sn, s1)
+	g.Expect(s2.changesSince(s1)).To(BeEmpty())
+}
+
+func TestSnapshotState_Changes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c1 := coll.New(data.Collection1)
+	c1.Set(data.EntryN1I1V1)
+	c1.Set(data.EntryN2I2V1)
+	c2 := coll.New(data.Collection2)
+	c2.Set(data.EntryN1I1V1)
+	c3 := coll.New(data.Collection3)
+	c3.Set(data.EntryN3I3V1)
+	s1 := captureState(&Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1, c2, c3})}, nil)
+
+	// Update a resource in collection1, leave collection2 alone, and drop collection3.
+	c1 = c1.Clone()
+	c1.Set(data.EntryN1I1V2)
+	s2 := captureState(&Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1, c2})}, s1)
+
+	g.Expect(s2.changesSince(s1)).To(Equal(map[collection.Name][]resource.Name{
+		data.Collection1: {data.EntryN1I1V1.Metadata.Name},
+		data.Collection3: {data.EntryN3I3V1.Metadata.Name},
+	}))
+
+	// Remove a resource from collection1, and add a new resource to collection2.
+	c1 = c1.Clone()
+	c1.Remove(data.EntryN2I2V1.Metadata.Name)
+	c2 = c2.Clone()
+	c2.Set(data.EntryN3I3V1)
+	s3 := captureState(&Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1, c2})}, s2)
+
+	g.Expect(s3.changesSince(s2)).To(Equal(map[collection.Name][]resource.Name{
+		data.Collection1: {data.EntryN2I2V1.Metadata.Name},
+		data.Collection2: {data.EntryN3I3V1.Metadata.Name},
+	}))
+}
+
+func TestSnapshotState_SameVersionsAreNotChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c1 := coll.New(data.Collection1)
+	c1.Set(data.EntryN1I1V1)
+	s1 := captureState(&Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1})}, nil)
+
+	// Setting the same version bumps the generation of the collection, but is not a change.
+	c1 = c1.Clone()
+	c1.Set(data.EntryN1I1V1)
+	s2 := captureState(&Snapshot{set: coll.NewSetFromCollections([]*coll.Instance{c1})}, s1)
+
+	g.Expect(s2.changesSince(s1)).To(BeEmpty())
+}
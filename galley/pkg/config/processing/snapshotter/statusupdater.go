@@ -79,3 +79,61 @@ func (u *InMemoryStatusUpdater) WaitForReport(cancelCh chan struct{}) bool {
 		return true
 	}
 }
+
+// MessagesDiff captures the changes to the set of diagnostic messages between two successive analysis runs.
+type MessagesDiff struct {
+	// Current is the full set of messages after the analysis run.
+	Current diag.Messages
+
+	// Added are the messages that were not present in the previous run.
+	Added diag.Messages
+
+	// Removed are the messages of the previous run that are no longer present.
+	Removed diag.Messages
+}
+
+// IsEmpty returns true if there are no added or removed messages.
+func (d MessagesDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// IncrementalStatusUpdater is an optional interface for StatusUpdaters that can make use of the changes between
+// successive analysis runs. If a StatusUpdater implements it, UpdateDiff is called instead of Update.
+type IncrementalStatusUpdater interface {
+	StatusUpdater
+
+	UpdateDiff(diff MessagesDiff)
+}
+
+// diffMessages calculates the changes between the previous and current set of messages.
+func diffMessages(previous, current diag.Messages) MessagesDiff {
+	counts := make(map[string]int)
+	for _, m := range previous {
+		counts[messageKey(m)]++
+	}
+
+	d := MessagesDiff{Current: current}
+	for _, m := range current {
+		k := messageKey(m)
+		if counts[k] > 0 {
+			counts[k]--
+			continue
+		}
+		d.Added = append(d.Added, m)
+	}
+
+	for _, m := range previous {
+		k := messageKey(m)
+		if counts[k] > 0 {
+			counts[k]--
+			d.Removed = append(d.Removed, m)
+		}
+	}
+
+	return d
+}
+
+// messageKey identifies a message by its level, code, origin and text.
+func messageKey(m diag.Message) string {
+	return m.String()
+}
@@ -70,3 +70,30 @@ func TestInMemoryStatusUpdaterWaitThenWrite(t *testing.T) {
 	su.Update(msgs)
 	g.Expect(su.Get()).To(Equal(msgs))
 }
+
+func TestDiffMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mt := diag.NewMessageType(diag.Error, "test", "test %s")
+	m1 := diag.NewMessage(mt, nil, "1")
+	m2 := diag.NewMessage(mt, nil, "2")
+	m3 := diag.NewMessage(mt, nil, "3")
+
+	d := diffMessages(nil, diag.Messages{m1, m2})
+	g.Expect(d.Added).To(Equal(diag.Messages{m1, m2}))
+	g.Expect(d.Removed).To(BeEmpty())
+	g.Expect(d.Current).To(Equal(diag.Messages{m1, m2}))
+
+	d = diffMessages(diag.Messages{m1, m2}, diag.Messages{m2, m3})
+	g.Expect(d.Added).To(Equal(diag.Messages{m3}))
+	g.Expect(d.Removed).To(Equal(diag.Messages{m1}))
+	g.Expect(d.IsEmpty()).To(BeFalse())
+
+	d = diffMessages(diag.Messages{m1, m2}, diag.Messages{m1, m2})
+	g.Expect(d.IsEmpty()).To(BeTrue())
+
+	// Duplicate messages are counted individually
+	d = diffMessages(diag.Messages{m1, m1}, diag.Messages{m1})
+	g.Expect(d.Added).To(BeEmpty())
+	g.Expect(d.Removed).To(Equal(diag.Messages{m1}))
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"regexp"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// AnalysisSuppression describes a resource and analysis code to be suppressed
+// (e.g. ignored) during analysis. Used when a particular message code is to be
+// ignored for a specific resource.
+type AnalysisSuppression struct {
+	// Code is the analysis code to suppress (e.g. "IST0104").
+	Code string
+
+	// ResourceName is the name of the resource to suppress the message for, in the same form as the friendly name of
+	// the message's origin (e.g. "Namespace/default" or "VirtualService/default/reviews"). A "*" matches any sequence
+	// of characters, so "VirtualService/default/*" matches every VirtualService in the default namespace.
+	ResourceName string
+
+	// resourceNamePattern is the compiled pattern of ResourceName, if it has wildcards.
+	resourceNamePattern *regexp.Regexp
+}
+
+// NewAnalysisSuppression returns a suppression of the given code for the given resource name, compiling the pattern
+// of the resource name once, since the suppressions are matched against every message of every analysis.
+func NewAnalysisSuppression(code, resourceName string) AnalysisSuppression {
+	s := AnalysisSuppression{
+		Code:         code,
+		ResourceName: resourceName,
+	}
+	if strings.Contains(resourceName, "*") {
+		s.resourceNamePattern = compileResourceNamePattern(resourceName)
+	}
+	return s
+}
+
+// Matches returns true if the given message is suppressed by this suppression.
+func (s AnalysisSuppression) Matches(m diag.Message) bool {
+	if m.Type.Code() != s.Code || m.Origin == nil {
+		return false
+	}
+
+	if !strings.Contains(s.ResourceName, "*") {
+		return m.Origin.FriendlyName() == s.ResourceName
+	}
+
+	r := s.resourceNamePattern
+	if r == nil {
+		// The suppression was not created with NewAnalysisSuppression.
+		r = compileResourceNamePattern(s.ResourceName)
+	}
+	return r.MatchString(m.Origin.FriendlyName())
+}
+
+// compileResourceNamePattern compiles the pattern of a resource name with wildcards.
+func compileResourceNamePattern(resourceName string) *regexp.Regexp {
+	parts := strings.Split(resourceName, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
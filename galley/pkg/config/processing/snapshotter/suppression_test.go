@@ -0,0 +1,64 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+)
+
+func TestAnalysisSuppression_Matches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ns := &resource.Entry{
+		Origin: &rt.Origin{
+			Collection: metadata.K8SCoreV1Namespaces,
+			Kind:       "Namespace",
+			Name:       resource.NewName("", "default"),
+		},
+	}
+	vs := &resource.Entry{
+		Origin: &rt.Origin{
+			Collection: metadata.IstioNetworkingV1Alpha3Virtualservices,
+			Kind:       "VirtualService",
+			Name:       resource.NewName("default", "reviews"),
+		},
+	}
+
+	nsMsg := msg.NewNamespaceNotInjected(ns, "default", "default")
+	vsMsg := msg.NewReferencedResourceNotFound(vs, "host", "reviews")
+	noOriginMsg := msg.NewNamespaceNotInjected(nil, "default", "default")
+
+	s := NewAnalysisSuppression("IST0102", "Namespace/default")
+	g.Expect(s.Matches(nsMsg)).To(BeTrue())
+	g.Expect(s.Matches(vsMsg)).To(BeFalse())
+	g.Expect(s.Matches(noOriginMsg)).To(BeFalse())
+
+	s = NewAnalysisSuppression("IST0101", "VirtualService/default/*")
+	g.Expect(s.Matches(vsMsg)).To(BeTrue())
+	g.Expect(s.Matches(nsMsg)).To(BeFalse())
+
+	s = NewAnalysisSuppression("IST0101", "VirtualService/other/*")
+	g.Expect(s.Matches(vsMsg)).To(BeFalse())
+
+	s = AnalysisSuppression{Code: "IST0101", ResourceName: "VirtualService/*/reviews"}
+	g.Expect(s.Matches(vsMsg)).To(BeTrue())
+}
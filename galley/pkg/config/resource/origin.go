@@ -14,9 +14,46 @@
 
 package resource
 
+import "fmt"
+
 // Origin of a resource. This is source-implementation dependent.
 type Origin interface {
 	FriendlyName() string
 
 	Namespace() string
+
+	// Reference to the location the resource was read from, or nil if it is not known.
+	Reference() Reference
+}
+
+// Reference provides more information about where an Origin came from. This is source-implementation dependent.
+type Reference interface {
+	String() string
+}
+
+// Position is a Reference to a range of text within a file. Lines and columns are 1-based, and the end position
+// refers to the last character of the range.
+type Position struct {
+	Filename  string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+
+	// Revision of the file the text was read from, e.g. a commit SHA, if known.
+	Revision string
+}
+
+var _ Reference = &Position{}
+
+// String implements Reference
+func (p *Position) String() string {
+	name := p.Filename
+	if p.Revision != "" {
+		name = fmt.Sprintf("%s@%s", p.Filename, p.Revision)
+	}
+	if p.Line <= 0 {
+		return name
+	}
+	return fmt.Sprintf("%s:%d:%d", name, p.Line, p.Column)
 }
@@ -0,0 +1,40 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"testing"
+)
+
+func TestPosition_String(t *testing.T) {
+	p := &Position{Filename: "a.yaml", Line: 3, Column: 1, EndLine: 10, EndColumn: 12}
+	if p.String() != "a.yaml:3:1" {
+		t.Fatalf("unexpected string: %v", p.String())
+	}
+}
+
+func TestPosition_StringNoLine(t *testing.T) {
+	p := &Position{Filename: "a.yaml"}
+	if p.String() != "a.yaml" {
+		t.Fatalf("unexpected string: %v", p.String())
+	}
+}
+
+func TestPosition_StringRevision(t *testing.T) {
+	p := &Position{Filename: "a.yaml", Line: 3, Column: 1, Revision: "0123abcd"}
+	if p.String() != "a.yaml@0123abcd:3:1" {
+		t.Fatalf("unexpected string: %v", p.String())
+	}
+}
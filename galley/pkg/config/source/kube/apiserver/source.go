@@ -78,7 +78,7 @@ type Source struct { // nolint:maligned
 }
 
 var _ event.Source = &Source{}
-var _ snapshotter.StatusUpdater = &Source{}
+var _ snapshotter.IncrementalStatusUpdater = &Source{}
 
 // New returns a new kube.Source.
 func New(o Options) *Source {
@@ -246,6 +246,18 @@ func (s *Source) Update(messages diag.Messages) {
 	s.statusCtl.Report(messages)
 }
 
+// UpdateDiff implements processing.IncrementalStatusUpdater
+func (s *Source) UpdateDiff(diff snapshotter.MessagesDiff) {
+	if s.statusCtl == nil {
+		panic("received diagnostic messages while the source is not configured with a status controller")
+	}
+
+	changed := make(diag.Messages, 0, len(diff.Added)+len(diff.Removed))
+	changed = append(changed, diff.Added...)
+	changed = append(changed, diff.Removed...)
+	s.statusCtl.ReportDiff(diff.Current, changed)
+}
+
 func (s *Source) stop() {
 	// must be called under lock
 
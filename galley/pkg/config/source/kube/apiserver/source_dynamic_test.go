@@ -26,6 +26,7 @@ import (
 	"istio.io/istio/galley/pkg/config/event"
 	"istio.io/istio/galley/pkg/config/meta/schema"
 	"istio.io/istio/galley/pkg/config/meta/schema/collection"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
 	"istio.io/istio/galley/pkg/config/resource"
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
@@ -131,6 +132,40 @@ func TestReport(t *testing.T) {
 	g.Expect(sc.latestReport()).To(Equal(diag.Messages{m}))
 }
 
+func TestReportDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Create the source
+	w, _, cl := createMocks()
+	defer w.Stop()
+
+	sc := &statusCtl{}
+	r := basicmeta.MustGet().KubeSource().Resources()
+	s := newOrFail(t, cl, r, sc)
+
+	s.Start()
+	defer s.Stop()
+
+	e := resource.Entry{
+		Origin: &rt.Origin{
+			Collection: basicmeta.Collection1,
+			Name:       resource.NewName("foo", "bar"),
+			Version:    resource.Version("v1"),
+		},
+	}
+	m1 := msg.NewInternalError(&e, "foo")
+	m2 := msg.NewInternalError(&e, "bar")
+	m3 := msg.NewInternalError(&e, "baz")
+
+	s.UpdateDiff(snapshotter.MessagesDiff{
+		Current: diag.Messages{m1, m2},
+		Added:   diag.Messages{m2},
+		Removed: diag.Messages{m3},
+	})
+	g.Expect(sc.latestReport()).To(Equal(diag.Messages{m1, m2}))
+	g.Expect(sc.latestChanged()).To(Equal(diag.Messages{m2, m3}))
+}
+
 func TestEvents(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -541,6 +576,7 @@ type statusCtl struct {
 
 	lastStatusInput atomic.Value
 	lastReport      atomic.Value
+	lastChanged     atomic.Value
 }
 
 type statusInput struct {
@@ -575,6 +611,11 @@ func (s *statusCtl) Report(messages diag.Messages) {
 	s.lastReport.Store(messages)
 }
 
+func (s *statusCtl) ReportDiff(messages diag.Messages, changed diag.Messages) {
+	s.lastReport.Store(messages)
+	s.lastChanged.Store(changed)
+}
+
 func (s *statusCtl) hasStarted() bool {
 	return atomic.LoadInt32(&s.started) != 0
 }
@@ -591,6 +632,14 @@ func (s *statusCtl) latestStatusCall() *statusInput {
 	return i.(*statusInput)
 }
 
+func (s *statusCtl) latestChanged() diag.Messages {
+	i := s.lastChanged.Load()
+	if i == nil {
+		return nil
+	}
+	return i.(diag.Messages)
+}
+
 func (s *statusCtl) latestReport() diag.Messages {
 	i := s.lastReport.Load()
 	if i == nil {
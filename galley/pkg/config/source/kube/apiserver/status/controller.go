@@ -35,6 +35,7 @@ type Controller interface {
 	Stop()
 	UpdateResourceStatus(col collection.Name, name resource.Name, version resource.Version, status interface{})
 	Report(messages diag.Messages)
+	ReportDiff(messages diag.Messages, changed diag.Messages)
 }
 
 // ControllerImpl keeps track of status information for a given K8s style collection and continuously reconciles.
@@ -118,24 +119,56 @@ func (c *ControllerImpl) Report(messages diag.Messages) {
 	msgs := NewMessageSet()
 
 	for _, m := range messages {
+		if origin := messageOrigin(m); origin != nil {
+			msgs.Add(origin, m)
+		}
+	}
 
-		if m.Origin == nil {
-			// This should not happen. All messages should be reported against at least one origin.
-			scope.Source.Errorf("Encountered a diagnostic message without an origin: %v", m)
-			continue
+	c.state.applyMessages(msgs)
+}
+
+// ReportDiff reports the given set of messages towards particular resources, like Report, but only updates the
+// status of the resources that are the origins of the changed (i.e. added or removed) messages, or whose messages were
+// generated for a new version of the resource. Until the first report, the full set of messages is applied, to
+// reconcile the status of all resources.
+func (c *ControllerImpl) ReportDiff(messages diag.Messages, changed diag.Messages) {
+	if !c.state.isReconciling() {
+		c.Report(messages)
+		return
+	}
+
+	keys := make(map[key]struct{})
+	for _, m := range changed {
+		if origin := messageOrigin(m); origin != nil {
+			keys[key{col: origin.Collection, res: origin.Name}] = struct{}{}
 		}
+	}
 
-		origin, ok := m.Origin.(*rt.Origin)
-		if !ok {
-			// This should not happen. All messages should be routed back to the appropriate source.
-			scope.Source.Errorf("Encountered a diagnostic message with unrecognized origin: %v", m)
-			continue
+	msgs := NewMessageSet()
+	for _, m := range messages {
+		if origin := messageOrigin(m); origin != nil {
+			msgs.Add(origin, m)
 		}
+	}
 
-		msgs.Add(origin, m)
+	c.state.applyMessagesTo(msgs, keys)
+}
+
+// messageOrigin returns the origin of the message, or nil if the message can not be reported against a resource.
+func messageOrigin(m diag.Message) *rt.Origin {
+	if m.Origin == nil {
+		// This should not happen. All messages should be reported against at least one origin.
+		scope.Source.Errorf("Encountered a diagnostic message without an origin: %v", m)
+		return nil
 	}
 
-	c.state.applyMessages(msgs)
+	origin, ok := m.Origin.(*rt.Origin)
+	if !ok {
+		// This should not happen. All messages should be routed back to the appropriate source.
+		scope.Source.Errorf("Encountered a diagnostic message with unrecognized origin: %v", m)
+		return nil
+	}
+	return origin
 }
 
 func run(state *state, subfield string, ifaces map[collection.Name]dynamic.NamespaceableResourceInterface, wg *sync.WaitGroup) {
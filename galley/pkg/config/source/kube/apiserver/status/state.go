@@ -161,6 +161,52 @@ func (s *state) applyMessages(messages Messages) {
 	s.mu.Unlock()
 }
 
+// Apply the given set of messages to the resources of the given keys, and to the resources whose messages are unchanged
+// but were generated for a new version of the resource. The status of the other resources is left unchanged.
+func (s *state) applyMessagesTo(messages Messages, keys map[key]struct{}) {
+	s.mu.Lock()
+	s.reconcile = true
+
+	for k := range keys {
+		e := messages.entries[k]
+		st := s.states[k]
+
+		if len(e.messages) > 0 {
+			if st == nil {
+				st = getStatusFromPool(k)
+				s.states[k] = st
+			}
+			_ = st.setDesired(e.origin.Version, toStatusValue(e.messages))
+			s.enqueueWork(st)
+		} else if st != nil {
+			// The desired state for the resource is empty.
+			_ = st.setDesired("", nil)
+			s.enqueueWork(st)
+		}
+	}
+
+	// The status is only written for the version it was desired for, so the messages of a resource whose version
+	// changed need to be applied again.
+	for k, e := range messages.entries {
+		if _, ok := keys[k]; ok || len(e.messages) == 0 {
+			continue
+		}
+		if st := s.states[k]; st != nil && st.desiredStatusVersion != e.origin.Version {
+			_ = st.setDesired(e.origin.Version, toStatusValue(e.messages))
+			s.enqueueWork(st)
+		}
+	}
+
+	s.mu.Unlock()
+}
+
+// isReconciling returns true once the first set of messages has been applied.
+func (s *state) isReconciling() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reconcile
+}
+
 func (s *state) enqueueWork(st *status) {
 	// must be called under lock
 
@@ -288,3 +288,80 @@ func TestState_ClearMessages_AgainstAppliedEmptyState(t *testing.T) {
 
 	g.Expect(s.hasWork()).To(BeFalse())
 }
+
+func TestState_ApplyMessagesTo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newState()
+	g.Expect(s.isReconciling()).To(BeFalse())
+	s.applyMessages(NewMessageSet()) // start reconciliation
+	g.Expect(s.isReconciling()).To(BeTrue())
+
+	// An existing status that is not part of the changed keys is left untouched.
+	s.setObserved(data.Collection1, data.EntryN1I1V1.Metadata.Name, data.EntryN1I1V1.Metadata.Version, "foo")
+	_, ok := s.dequeueWork()
+	g.Expect(ok).To(BeTrue())
+
+	res := *data.EntryN2I2V1
+	res.Origin = &rt.Origin{
+		Collection: data.Collection1,
+		Kind:       "k1",
+		Name:       res.Metadata.Name,
+		Version:    res.Metadata.Version,
+	}
+	ms := msg.NewInternalError(&res, "t")
+	msgs := NewMessageSet()
+	msgs.Add(res.Origin.(*rt.Origin), ms)
+	s.applyMessagesTo(msgs, map[key]struct{}{{col: data.Collection1, res: res.Metadata.Name}: {}})
+
+	st, ok := s.dequeueWork()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(st.key.res).To(Equal(res.Metadata.Name))
+	g.Expect(st.desiredStatus).To(Equal(toStatusValue(diag.Messages{ms})))
+	g.Expect(s.hasWork()).To(BeFalse())
+
+	// Clear the messages of the changed resource, once its status is observed.
+	s.setObserved(data.Collection1, res.Metadata.Name, res.Metadata.Version, toStatusValue(diag.Messages{ms}))
+	g.Expect(s.hasWork()).To(BeFalse())
+	s.applyMessagesTo(NewMessageSet(), map[key]struct{}{{col: data.Collection1, res: res.Metadata.Name}: {}})
+	st, ok = s.dequeueWork()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(st.key.res).To(Equal(res.Metadata.Name))
+	g.Expect(st.desiredStatus).To(BeNil())
+	g.Expect(s.hasWork()).To(BeFalse())
+}
+
+func TestState_ApplyMessagesTo_NewVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := newState()
+	s.applyMessages(NewMessageSet()) // start reconciliation
+
+	res := *data.EntryN1I1V1
+	origin := &rt.Origin{
+		Collection: data.Collection1,
+		Kind:       "k1",
+		Name:       res.Metadata.Name,
+		Version:    data.EntryN1I1V1.Metadata.Version,
+	}
+	res.Origin = origin
+	ms := msg.NewInternalError(&res, "t")
+	msgs := NewMessageSet()
+	msgs.Add(origin, ms)
+	s.applyMessagesTo(msgs, map[key]struct{}{{col: data.Collection1, res: res.Metadata.Name}: {}})
+	_, ok := s.dequeueWork()
+	g.Expect(ok).To(BeTrue())
+	s.setObserved(data.Collection1, res.Metadata.Name, origin.Version, toStatusValue(diag.Messages{ms}))
+
+	// The messages are unchanged, but were generated for a new version of the resource. The status is already as
+	// desired, so it is not updated, but it will be updated for the new version.
+	newOrigin := *origin
+	newOrigin.Version = data.EntryN1I1V2.Metadata.Version
+	msgs = NewMessageSet()
+	msgs.Add(&newOrigin, ms)
+	s.applyMessagesTo(msgs, map[key]struct{}{})
+
+	st := s.states[key{col: data.Collection1, res: res.Metadata.Name}]
+	g.Expect(st.desiredStatusVersion).To(Equal(data.EntryN1I1V2.Metadata.Version))
+	g.Expect(st.needsChange()).To(BeFalse())
+}
@@ -0,0 +1,323 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/meta/schema"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/galley/pkg/config/source/kube/inmemory"
+)
+
+const (
+	// DefaultRef is the ref the configuration is read from, if none is specified.
+	DefaultRef = "HEAD"
+
+	// DefaultPollInterval is the interval at which the ref is fetched, if none is specified.
+	DefaultPollInterval = time.Minute
+)
+
+var supportedExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+}
+
+var nameDiscriminator int64
+
+// Options for a Git source.
+type Options struct {
+	// Repository is the URL of the repository to read the configuration from.
+	Repository string
+
+	// Ref is the branch, tag or commit to read the configuration from. Defaults to DefaultRef.
+	Ref string
+
+	// Path is the directory of the repository holding the configuration, relative to its root. The whole
+	// repository is read if empty.
+	Path string
+
+	// Dir is the local directory the repository is cloned into. A temporary directory is used if empty.
+	Dir string
+
+	// PollInterval is the interval at which the ref is fetched for new commits, and failed syncs are retried.
+	// Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	// Resources are the resources read from the repository.
+	Resources schema.KubeResources
+}
+
+// Source is an event.Source reading Kubernetes resources from the YAML files of a Git repository. It emits
+// events for the resources changed by new commits of the ref. The origins of the resources carry the SHA of
+// the commit they were last changed at.
+type Source struct {
+	mu      sync.Mutex
+	name    string
+	options Options
+	s       *inmemory.KubeSource
+
+	// dir is the local clone of the repository, and tempDir is set if it was created by the source.
+	dir     string
+	tempDir bool
+
+	// revision is the SHA of the commit the configuration was last read at.
+	revision string
+
+	// done is closed to stop the current run, and stopped is closed once it is stopped.
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+var _ event.Source = &Source{}
+
+// New returns a new Git based event.Source.
+func New(o Options) (*Source, error) {
+	if o.Repository == "" {
+		return nil, fmt.Errorf("no repository specified")
+	}
+	if o.Ref == "" {
+		o.Ref = DefaultRef
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPollInterval
+	}
+	if filepath.IsAbs(o.Path) || strings.HasPrefix(filepath.Clean(o.Path), "..") {
+		return nil, fmt.Errorf("path %q is not within the repository", o.Path)
+	}
+
+	s := &Source{
+		name:    fmt.Sprintf("git-%d", nameDiscriminator),
+		options: o,
+		s:       inmemory.NewKubeSource(o.Resources),
+		dir:     o.Dir,
+	}
+	nameDiscriminator++
+
+	if s.dir == "" {
+		dir, err := ioutil.TempDir("", "galley-git")
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+		s.tempDir = true
+	}
+	return s, nil
+}
+
+// Start implements event.Source
+func (s *Source) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done != nil {
+		return
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go s.run(done, s.stopped, stopped)
+	s.done = done
+	s.stopped = stopped
+}
+
+// run syncs the repository until done is closed. It first waits for the previous run, if any, to be stopped, so
+// that the local clone is only used by a single run.
+func (s *Source) run(done <-chan struct{}, previous <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	if previous != nil {
+		select {
+		case <-previous:
+		case <-done:
+			return
+		}
+	}
+	defer s.removeTempDir()
+
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	started := false
+	for {
+		if err := s.sync(done); err != nil {
+			scope.Source.Errorf("[%s] Error reading %s@%s: %v", s.name, s.options.Repository, s.options.Ref, err)
+		} else if !started {
+			// The inmemory source only starts once the configuration was read, so that the first full sync does
+			// not remove all the resources if the repository is not reachable.
+			s.mu.Lock()
+			if s.done == done {
+				s.s.Start()
+			}
+			s.mu.Unlock()
+			started = true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Stop implements event.Source
+func (s *Source) Stop() {
+	scope.Source.Debugf("git.Source.Stop >>>")
+	defer scope.Source.Debugf("git.Source.Stop <<<")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done == nil {
+		return
+	}
+	close(s.done)
+	s.s.Stop()
+	s.s.Clear()
+	s.done = nil
+	s.revision = ""
+}
+
+// Dispatch implements event.Source
+func (s *Source) Dispatch(h event.Handler) {
+	s.s.Dispatch(h)
+}
+
+// Revision returns the SHA of the commit the configuration was last read at, or an empty string if it was not
+// read yet.
+func (s *Source) Revision() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revision
+}
+
+// sync fetches the ref and applies the configuration of its commit, if it changed and the run of done was not
+// stopped. The git commands are run without holding the lock, as fetching may take long.
+func (s *Source) sync(done <-chan struct{}) error {
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, ".git")); os.IsNotExist(err) {
+		if _, err := s.git("init", "--quiet"); err != nil {
+			return err
+		}
+		if _, err := s.git("remote", "add", "origin", s.options.Repository); err != nil {
+			return err
+		}
+	}
+	if _, err := s.git("fetch", "--quiet", "--depth", "1", "origin", "--", s.options.Ref); err != nil {
+		return err
+	}
+	revision, err := s.git("rev-parse", "--verify", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return err
+	}
+	if revision == s.Revision() {
+		scope.Source.Debugf("[%s] Commit %s already read", s.name, revision)
+		return nil
+	}
+	if _, err := s.git("checkout", "--quiet", "--force", revision); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != done {
+		return nil
+	}
+	scope.Source.Infof("[%s] Reading configuration at commit %s", s.name, revision)
+	if err := s.apply(revision); err != nil {
+		return err
+	}
+	s.revision = revision
+	return nil
+}
+
+// removeTempDir removes the local clone, if it was created by the source.
+func (s *Source) removeTempDir() {
+	if !s.tempDir {
+		return
+	}
+	if err := os.RemoveAll(s.dir); err != nil {
+		scope.Source.Warnf("[%s] Error removing %s: %v", s.name, s.dir, err)
+	}
+}
+
+// apply applies the content of the YAML files of the checked out commit, and removes the content of the
+// files that do not exist anymore. Files are named by their path within the repository.
+func (s *Source) apply(revision string) error {
+	names := s.s.ContentNames()
+
+	root := filepath.Join(s.dir, s.options.Path)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !supportedExtensions[filepath.Ext(path)] || !info.Mode().IsRegular() {
+			// Symbolic links are not followed, as they may point outside of the repository
+			if info.Mode()&os.ModeSymlink != 0 {
+				scope.Source.Warnf("[%s] Ignoring the symbolic link %q", s.name, path)
+			}
+			return nil
+		}
+
+		name, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := s.s.ApplyContentAtRevision(name, revision, string(data)); err != nil {
+			scope.Source.Errorf("[%s] Error applying file contents(%q): %v", s.name, name, err)
+		}
+		delete(names, name)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for n := range names {
+		scope.Source.Infof("[%s] Removing the contents of the file %q", s.name, n)
+		s.s.RemoveContent(n)
+	}
+	return nil
+}
+
+// git runs a git command in the local clone, and returns its trimmed output.
+func (s *Source) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/event"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/source/kube/git"
+	"istio.io/istio/galley/pkg/config/testing/basicmeta"
+	"istio.io/istio/galley/pkg/config/testing/data"
+	"istio.io/istio/galley/pkg/config/testing/fixtures"
+)
+
+func TestNew(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := git.New(git.Options{})
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = git.New(git.Options{Repository: "file:///repo", Path: "../foo"})
+	g.Expect(err).NotTo(BeNil())
+
+	s, err := git.New(git.Options{Repository: "file:///repo", Path: "foo"})
+	g.Expect(err).To(BeNil())
+	g.Expect(s.Revision()).To(Equal(""))
+}
+
+func TestInvalidRepositoryShouldNotSync(t *testing.T) {
+	s := newOrFail(t, "file:///somebadrepo", "")
+
+	acc, _ := startOrFail(t, s)
+	defer s.Stop()
+
+	fixtures.ExpectFilter(t, acc, fixtures.NoFullSync)
+}
+
+func TestAddUpdateDelete(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	repo := createRepo(t)
+	defer deleteDir(t, repo)
+
+	writeFile(t, repo, "config/foo.yaml", data.YamlN1I1V1)
+	writeFile(t, repo, "other/bar.yaml", data.YamlN2I2V1)
+	rev1 := commit(t, repo)
+
+	s := newOrFail(t, "file://"+repo, "config")
+	acc, pos := startOrFail(t, s)
+	defer s.Stop()
+
+	g.Eventually(acc.EventsWithoutOrigins).Should(ConsistOf(
+		event.FullSyncFor(basicmeta.Collection1),
+		event.AddFor(data.Collection1, data.EntryN1I1V1)))
+	g.Expect(s.Revision()).To(Equal(rev1))
+	g.Expect(pos.get("n1/i1")).To(HavePrefix("config/foo.yaml@" + rev1 + ":"))
+
+	acc.Clear()
+	writeFile(t, repo, "config/foo.yaml", data.YamlN1I1V2)
+	writeFile(t, repo, "config/baz.yml", data.YamlN2I2V1)
+	rev2 := commit(t, repo)
+
+	g.Eventually(acc.EventsWithoutOrigins).Should(ConsistOf(
+		event.UpdateFor(data.Collection1, withVersion(data.EntryN1I1V2, "v3")),
+		event.AddFor(data.Collection1, withVersion(data.EntryN2I2V1, "v2"))))
+	g.Expect(s.Revision()).To(Equal(rev2))
+	g.Expect(pos.get("n1/i1")).To(HavePrefix("config/foo.yaml@" + rev2 + ":"))
+	g.Expect(pos.get("n2/i2")).To(HavePrefix("config/baz.yml@" + rev2 + ":"))
+
+	acc.Clear()
+	writeFile(t, repo, "config/foo.yaml", "")
+	rev3 := commit(t, repo)
+
+	g.Eventually(acc.EventsWithoutOrigins).Should(ConsistOf(
+		event.DeleteForResource(data.Collection1, withVersion(data.EntryN1I1V2, "v3"))))
+	g.Eventually(s.Revision).Should(Equal(rev3))
+}
+
+func TestStartStopStart(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	repo := createRepo(t)
+	defer deleteDir(t, repo)
+
+	writeFile(t, repo, "foo.yaml", data.YamlN1I1V1)
+	commit(t, repo)
+
+	s := newOrFail(t, "file://"+repo, "")
+	acc, _ := startOrFail(t, s)
+
+	g.Eventually(acc.EventsWithoutOrigins).Should(HaveLen(2))
+
+	acc.Clear()
+	s.Stop()
+	g.Eventually(acc.EventsWithoutOrigins).Should(BeEmpty())
+
+	s.Start()
+	defer s.Stop()
+	g.Eventually(acc.EventsWithoutOrigins).Should(ConsistOf(
+		event.FullSyncFor(basicmeta.Collection1),
+		event.AddFor(data.Collection1, data.EntryN1I1V1)))
+}
+
+func TestSymlinksAreIgnored(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	outside, err := ioutil.TempDir("", "outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer deleteDir(t, outside)
+	writeFile(t, outside, "secret.yaml", data.YamlN2I2V1)
+
+	repo := createRepo(t)
+	defer deleteDir(t, repo)
+
+	writeFile(t, repo, "foo.yaml", data.YamlN1I1V1)
+	if err := os.Symlink(filepath.Join(outside, "secret.yaml"), filepath.Join(repo, "bar.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	rev := commit(t, repo)
+
+	s := newOrFail(t, "file://"+repo, "")
+	acc, _ := startOrFail(t, s)
+	defer s.Stop()
+
+	g.Eventually(s.Revision).Should(Equal(rev))
+	g.Eventually(acc.EventsWithoutOrigins).Should(ConsistOf(
+		event.FullSyncFor(basicmeta.Collection1),
+		event.AddFor(data.Collection1, data.EntryN1I1V1)))
+}
+
+func createRepo(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "gitRepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "init", "--quiet")
+	return dir
+}
+
+func deleteDir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeFile writes the content of a file of the repository, or removes it if the content is empty.
+func writeFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if content == "" {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commit commits all the changes of the repository, and returns the SHA of the commit.
+func commit(t *testing.T, dir string) string {
+	t.Helper()
+	runGit(t, dir, "add", "--all")
+	runGit(t, dir, "-c", "user.name=test", "-c", "user.email=test@istio.io", "commit", "--quiet", "-m", "test")
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func newOrFail(t *testing.T, repository string, path string) *git.Source {
+	t.Helper()
+	s, err := git.New(git.Options{
+		Repository:   repository,
+		Path:         path,
+		PollInterval: 10 * time.Millisecond,
+		Resources:    basicmeta.MustGet().KubeSource().Resources(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error found: %v", err)
+	}
+	return s
+}
+
+func startOrFail(t *testing.T, s event.Source) (*fixtures.Accumulator, *positions) {
+	t.Helper()
+
+	acc := &fixtures.Accumulator{}
+	pos := &positions{byName: make(map[string]string)}
+	s.Dispatch(event.CombineHandlers(pos, acc))
+	s.Start()
+
+	return acc, pos
+}
+
+// positions records the position each resource was last added or updated from. The accumulator strips the
+// origins of the events it returns, so they are recorded separately.
+type positions struct {
+	mu     sync.Mutex
+	byName map[string]string
+}
+
+// Handle implements event.Handler
+func (p *positions) Handle(e event.Event) {
+	if e.Kind != event.Added && e.Kind != event.Updated {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byName[e.Entry.Metadata.Name.String()] = e.Entry.Origin.Reference().String()
+}
+
+func (p *positions) get(name string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.byName[name]
+}
+
+func withVersion(r *resource.Entry, v string) *resource.Entry {
+	r = r.Clone()
+	r.Metadata.Version = resource.Version(v)
+	return r
+}
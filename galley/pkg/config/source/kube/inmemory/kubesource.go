@@ -19,6 +19,7 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"sync"
+	"unicode"
 
 	"github.com/ghodss/yaml"
 	kubeJson "k8s.io/apimachinery/pkg/runtime/serializer/json"
@@ -47,6 +48,8 @@ type KubeSource struct {
 	versionCtr int64
 	shas       map[kubeResourceKey]resourceSha
 	byFile     map[string]map[kubeResourceKey]collection.Name
+	// positions are the references of the origins of the entries in the collections
+	positions map[kubeResourceKey]*resource.Position
 }
 
 type resourceSha [sha1.Size]byte
@@ -55,6 +58,8 @@ type kubeResource struct {
 	entry *resource.Entry
 	spec  schema.KubeResource
 	sha   resourceSha
+	// pos is the reference of the origin of the entry
+	pos *resource.Position
 }
 
 func (r *kubeResource) newKey() kubeResourceKey {
@@ -84,6 +89,7 @@ func NewKubeSource(resources schema.KubeResources) *KubeSource {
 		source:    s,
 		shas:      make(map[kubeResourceKey]resourceSha),
 		byFile:    make(map[string]map[kubeResourceKey]collection.Name),
+		positions: make(map[kubeResourceKey]*resource.Position),
 	}
 }
 
@@ -107,6 +113,7 @@ func (s *KubeSource) Clear() {
 	s.versionCtr = 0
 	s.shas = make(map[kubeResourceKey]resourceSha)
 	s.byFile = make(map[string]map[kubeResourceKey]collection.Name)
+	s.positions = make(map[kubeResourceKey]*resource.Position)
 	s.source.Clear()
 }
 
@@ -137,6 +144,14 @@ func (s *KubeSource) ContentNames() map[string]struct{} {
 // gets called multiple times with the same name, the contents applied by the previous incarnation will be overwritten
 // or removed, depending on the new content.
 func (s *KubeSource) ApplyContent(name, yamlText string) error {
+	return s.ApplyContentAtRevision(name, "", yamlText)
+}
+
+// ApplyContentAtRevision applies the given yamltext like ApplyContent, and records the revision it was read at, e.g. a
+// commit SHA, in the origins of the resources. The revision of a resource is only updated along with its content, so
+// that it is the revision the resource was last changed at. A resource moved within its file without being changed
+// keeps its version, but the position of its origin is updated.
+func (s *KubeSource) ApplyContentAtRevision(name, revision, yamlText string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -151,10 +166,15 @@ func (s *KubeSource) ApplyContent(name, yamlText string) error {
 		oldSha, found := s.shas[key]
 		if !found || oldSha != r.sha {
 			s.versionCtr++
+			r.pos.Revision = revision
 			r.entry.Metadata.Version = resource.Version(fmt.Sprintf("v%d", s.versionCtr))
 			scope.Source.Debuga("KubeSource.ApplyContent: Set: ", r.spec.Collection.Name, r.entry.Metadata.Name)
 			s.source.Get(r.spec.Collection.Name).Set(r.entry)
 			s.shas[key] = r.sha
+			s.positions[key] = r.pos
+		} else if pos := s.positions[key]; pos != nil {
+			r.pos.Revision = pos.Revision
+			*pos = *r.pos
 		}
 		newKeys[key] = r.spec.Collection.Name
 		if oldKeys != nil {
@@ -181,6 +201,7 @@ func (s *KubeSource) RemoveContent(name string) {
 		for key, col := range keys {
 			s.source.Get(col).Remove(key.fullName)
 			delete(s.shas, key)
+			delete(s.positions, key)
 		}
 
 		delete(s.byFile, name)
@@ -189,10 +210,10 @@ func (s *KubeSource) RemoveContent(name string) {
 
 func (s *KubeSource) parseContent(r schema.KubeResources, name, yamlText string) []kubeResource {
 	var resources []kubeResource
-	for i, chunk := range kubeyaml.Split([]byte(yamlText)) {
-		chunk = bytes.TrimSpace(chunk)
+	for i, part := range kubeyaml.SplitParts([]byte(yamlText)) {
+		chunk, pos := trimAndLocate(name, part)
 
-		r, err := s.parseChunk(r, chunk)
+		r, err := s.parseChunk(r, chunk, pos)
 		if err != nil {
 			scope.Source.Warnf("Error processing %s[%d]: %v", name, i, err)
 			scope.Source.Debugf("Offending Yaml chunk: %v", string(chunk))
@@ -204,7 +225,30 @@ func (s *KubeSource) parseContent(r schema.KubeResources, name, yamlText string)
 	return resources
 }
 
-func (s *KubeSource) parseChunk(r schema.KubeResources, yamlChunk []byte) (kubeResource, error) {
+// trimAndLocate trims the surrounding whitespace of the given yaml part and calculates the position of the remaining
+// text within the named content.
+func trimAndLocate(name string, part kubeyaml.Part) ([]byte, *resource.Position) {
+	trimmed := bytes.TrimLeftFunc(part.Text, unicode.IsSpace)
+	leading := part.Text[:len(part.Text)-len(trimmed)]
+	chunk := bytes.TrimRightFunc(trimmed, unicode.IsSpace)
+
+	pos := &resource.Position{
+		Filename: name,
+		Line:     part.Line + bytes.Count(leading, []byte("\n")),
+		Column:   len(leading) - bytes.LastIndexByte(leading, '\n'),
+	}
+
+	pos.EndLine = pos.Line + bytes.Count(chunk, []byte("\n"))
+	if lastNewline := bytes.LastIndexByte(chunk, '\n'); lastNewline >= 0 {
+		pos.EndColumn = len(chunk) - lastNewline - 1
+	} else {
+		pos.EndColumn = pos.Column + len(chunk) - 1
+	}
+
+	return chunk, pos
+}
+
+func (s *KubeSource) parseChunk(r schema.KubeResources, yamlChunk []byte, pos *resource.Position) (kubeResource, error) {
 	// Convert to JSON
 	jsonChunk, err := yaml.YAMLToJSON(yamlChunk)
 	if err != nil {
@@ -247,9 +291,13 @@ func (s *KubeSource) parseChunk(r schema.KubeResources, yamlChunk []byte) (kubeR
 		return kubeResource{}, err
 	}
 
+	entry := rt.ToResourceEntry(objMeta, &resourceSpec, item)
+	entry.Origin.(*rt.Origin).Ref = pos
+
 	return kubeResource{
 		spec:  resourceSpec,
 		sha:   sha1.Sum(yamlChunk),
-		entry: rt.ToResourceEntry(objMeta, &resourceSpec, item),
+		entry: entry,
+		pos:   pos,
 	}, nil
 }
@@ -114,6 +114,68 @@ func TestKubeSource_ApplyContent_Unchanged0Add1(t *testing.T) {
 	g.Expect(events[5].Entry.Metadata.Name).To(Equal(data.EntryN1I1V1.Metadata.Name))
 }
 
+func TestKubeSource_Positions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := setupKubeSource()
+	s.Start()
+	defer s.Stop()
+
+	err := s.ApplyContent("foo.yaml", kubeyaml.JoinString(data.YamlN1I1V1, data.YamlN2I2V1))
+	g.Expect(err).To(BeNil())
+
+	actual := s.Get(data.Collection1).AllSorted()
+	g.Expect(actual).To(HaveLen(2))
+	g.Expect(actual[0].Origin.Reference()).To(Equal(&resource.Position{
+		Filename: "foo.yaml", Line: 2, Column: 1, EndLine: 8, EndColumn: 11}))
+	g.Expect(actual[1].Origin.Reference()).To(Equal(&resource.Position{
+		Filename: "foo.yaml", Line: 11, Column: 1, EndLine: 17, EndColumn: 11}))
+}
+
+func TestKubeSource_MovedResourceKeepsVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, acc := setupKubeSource()
+	s.Start()
+	defer s.Stop()
+
+	err := s.ApplyContent("foo.yaml", data.YamlN2I2V1)
+	g.Expect(err).To(BeNil())
+	err = s.ApplyContent("foo.yaml", kubeyaml.JoinString(data.YamlN1I1V1, data.YamlN2I2V1))
+	g.Expect(err).To(BeNil())
+
+	// The moved resource is not updated, but the position of its origin is
+	events := acc.Events()
+	g.Expect(events).To(HaveLen(3))
+	g.Expect(events[2].Kind).To(Equal(event.Added))
+	g.Expect(events[2].Entry.Metadata.Name).To(Equal(data.EntryN1I1V1.Metadata.Name))
+
+	actual := s.Get(data.Collection1).AllSorted()
+	g.Expect(actual).To(HaveLen(2))
+	g.Expect(actual[1].Metadata.Name).To(Equal(data.EntryN2I2V1.Metadata.Name))
+	g.Expect(actual[1].Metadata.Version).To(Equal(resource.Version("v1")))
+	g.Expect(actual[1].Origin.Reference().String()).To(Equal("foo.yaml:11:1"))
+}
+
+func TestKubeSource_ApplyContentAtRevision(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := setupKubeSource()
+	s.Start()
+	defer s.Stop()
+
+	err := s.ApplyContentAtRevision("foo.yaml", "rev1", data.YamlN1I1V1)
+	g.Expect(err).To(BeNil())
+	err = s.ApplyContentAtRevision("foo.yaml", "rev2", kubeyaml.JoinString(data.YamlN1I1V1, data.YamlN2I2V1))
+	g.Expect(err).To(BeNil())
+
+	// Unchanged resources keep the revision they were last changed at
+	actual := s.Get(data.Collection1).AllSorted()
+	g.Expect(actual).To(HaveLen(2))
+	g.Expect(actual[0].Origin.Reference().String()).To(Equal("foo.yaml@rev1:2:1"))
+	g.Expect(actual[1].Origin.Reference().String()).To(Equal("foo.yaml@rev2:11:1"))
+}
+
 func TestKubeSource_RemoveContent(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -28,6 +28,9 @@ type Origin struct {
 	Kind       string
 	Name       resource.Name
 	Version    resource.Version
+
+	// Ref is the location the resource was read from, if known
+	Ref resource.Reference
 }
 
 var _ resource.Origin = &Origin{}
@@ -47,3 +50,8 @@ func (o *Origin) Namespace() string {
 	ns, _ := o.Name.InterpretAsNamespaceAndName()
 	return ns
 }
+
+// Reference implements resource.Origin
+func (o *Origin) Reference() resource.Reference {
+	return o.Ref
+}
@@ -35,6 +35,29 @@ func Split(yamlText []byte) [][]byte {
 	return result
 }
 
+// Part is a single document of a multipart yaml document.
+type Part struct {
+	// Text of the document
+	Text []byte
+
+	// Line (1-based) of the original multipart document that the part starts at
+	Line int
+}
+
+// SplitParts splits the given yaml doc if it's multipart document, keeping track of where each part starts.
+func SplitParts(yamlText []byte) []Part {
+	var result []Part
+	line := 1
+	for _, p := range bytes.Split(yamlText, []byte(yamlSeparator)) {
+		if len(p) != 0 {
+			result = append(result, Part{Text: p, Line: line})
+		}
+		// Account for the lines of this part, plus the one consumed by the separator.
+		line += bytes.Count(p, []byte("\n")) + 1
+	}
+	return result
+}
+
 // SplitString splits the given yaml doc if it's multipart document.
 func SplitString(yamlText string) []string {
 	parts := strings.Split(yamlText, yamlSeparator)
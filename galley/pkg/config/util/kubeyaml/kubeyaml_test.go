@@ -82,6 +82,26 @@ func TestSplit(t *testing.T) {
 	}
 }
 
+func TestSplitParts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	merged := `
+yaml: foo
+---
+---
+bar: boo
+baz: bah
+---
+qux: quux
+`
+	actual := SplitParts([]byte(merged))
+	g.Expect(actual).To(Equal([]Part{
+		{Text: []byte("\nyaml: foo\n"), Line: 1},
+		{Text: []byte("bar: boo\nbaz: bah\n"), Line: 5},
+		{Text: []byte("qux: quux\n"), Line: 8},
+	}))
+}
+
 func TestSplitString(t *testing.T) {
 	for i, c := range splitCases {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/galley/pkg/config/processor"
 	"istio.io/istio/galley/pkg/config/source/kube"
 	fs2 "istio.io/istio/galley/pkg/config/source/kube/fs"
+	"istio.io/istio/galley/pkg/config/source/kube/git"
 	"istio.io/istio/galley/pkg/meshconfig"
 	"istio.io/istio/galley/pkg/source/fs"
 	kubeSource "istio.io/istio/galley/pkg/source/kube"
@@ -50,6 +51,7 @@ var (
 	meshcfgNewFS        = func(path string) (event.Source, error) { return meshcfg.NewFS(path) }
 	processorInitialize = processor.Initialize
 	fsNew2              = fs2.New
+	gitNew              = func(o git.Options) (event.Source, error) { return git.New(o) }
 )
 
 func resetPatchTable() {
@@ -68,4 +70,5 @@ func resetPatchTable() {
 	meshcfgNewFS = func(path string) (event.Source, error) { return meshcfg.NewFS(path) }
 	processorInitialize = processor.Initialize
 	fsNew2 = fs2.New
+	gitNew = func(o git.Options) (event.Source, error) { return git.New(o) }
 }
@@ -44,6 +44,7 @@ import (
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver/status"
+	"istio.io/istio/galley/pkg/config/source/kube/git"
 	"istio.io/istio/galley/pkg/config/util/kuberesource"
 	"istio.io/istio/galley/pkg/server/process"
 	"istio.io/istio/galley/pkg/server/settings"
@@ -119,6 +120,7 @@ func (p *Processing2) Start() (err error) {
 			Distributor:       distributor,
 			AnalysisSnapshots: []string{metadata.Default, metadata.SyntheticServiceEntry},
 			TriggerSnapshot:   metadata.Default,
+			DomainSuffix:      p.args.DomainSuffix,
 		}
 		distributor = snapshotter.NewAnalyzingDistributor(settings)
 	}
@@ -283,6 +285,18 @@ func (p *Processing2) createSourceAndStatusUpdater(resources schema.KubeResource
 			return
 		}
 		updater = &snapshotter.InMemoryStatusUpdater{}
+	} else if p.args.ConfigGitRepository != "" {
+		o := git.Options{
+			Repository:   p.args.ConfigGitRepository,
+			Ref:          p.args.ConfigGitRef,
+			Path:         p.args.ConfigGitPath,
+			PollInterval: p.args.ConfigGitPollInterval,
+			Resources:    resources,
+		}
+		if src, err = gitNew(o); err != nil {
+			return
+		}
+		updater = &snapshotter.InMemoryStatusUpdater{}
 	} else {
 		var k kube.Interfaces
 		if k, err = p.getKubeInterfaces(); err != nil {
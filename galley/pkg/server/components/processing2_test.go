@@ -32,6 +32,7 @@ import (
 	"istio.io/istio/galley/pkg/config/processing"
 	"istio.io/istio/galley/pkg/config/processor"
 	"istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/config/source/kube/git"
 	"istio.io/istio/galley/pkg/server/settings"
 	"istio.io/istio/galley/pkg/testing/mock"
 	"istio.io/istio/pkg/mcp/monitoring"
@@ -88,6 +89,9 @@ loop:
 		case 7:
 			args.ConfigPath = "aaa"
 			fsNew2 = func(_ string, _ schema.KubeResources) (event.Source, error) { return nil, e }
+		case 8:
+			args.ConfigGitRepository = "https://example.com/config.git"
+			gitNew = func(_ git.Options) (event.Source, error) { return nil, e }
 		default:
 			break loop
 
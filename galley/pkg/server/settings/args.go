@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"time"
 
+	"istio.io/istio/galley/pkg/config/source/kube/git"
 	"istio.io/istio/galley/pkg/config/util/kuberesource"
 	"istio.io/istio/galley/pkg/crd/validation"
 	"istio.io/istio/pkg/keepalive"
@@ -37,6 +38,8 @@ const (
 	defaultAccessListFile   = defaultConfigMapFolder + "accesslist.yaml"
 	defaultMeshConfigFile   = defaultMeshConfigFolder + "mesh"
 	defaultDomainSuffix     = "cluster.local"
+
+	defaultConfigGitPollInterval = time.Minute
 )
 
 // Args contains the startup arguments to instantiate Galley.
@@ -74,6 +77,18 @@ type Args struct { // nolint:maligned
 	// ConfigPath is the path for Galley specific config files
 	ConfigPath string
 
+	// ConfigGitRepository is the URL of a Git repository to read the config files from, instead of the API Server.
+	ConfigGitRepository string
+
+	// ConfigGitRef is the branch, tag or commit of the Git repository to read the config files from.
+	ConfigGitRef string
+
+	// ConfigGitPath is the directory of the Git repository holding the config files.
+	ConfigGitPath string
+
+	// ConfigGitPollInterval is the interval at which the Git repository is polled for new commits.
+	ConfigGitPollInterval time.Duration
+
 	// ExcludedResourceKinds is a list of resource kinds for which no source events will be triggered.
 	// DEPRECATED
 	ExcludedResourceKinds []string
@@ -149,6 +164,8 @@ func DefaultArgs() *Args {
 		EnableServer:                true,
 		CredentialOptions:           creds.DefaultOptions(),
 		ConfigPath:                  "",
+		ConfigGitRef:                git.DefaultRef,
+		ConfigGitPollInterval:       defaultConfigGitPollInterval,
 		DomainSuffix:                defaultDomainSuffix,
 		DisableResourceReadyCheck:   false,
 		ExcludedResourceKinds:       kuberesource.DefaultExcludedResourceKinds(),
@@ -191,6 +208,10 @@ func (a *Args) String() string {
 	_, _ = fmt.Fprintf(buf, "CertificateFile: %s\n", a.CredentialOptions.CertificateFile)
 	_, _ = fmt.Fprintf(buf, "CACertificateFile: %s\n", a.CredentialOptions.CACertificateFile)
 	_, _ = fmt.Fprintf(buf, "ConfigFilePath: %s\n", a.ConfigPath)
+	_, _ = fmt.Fprintf(buf, "ConfigGitRepository: %s\n", a.ConfigGitRepository)
+	_, _ = fmt.Fprintf(buf, "ConfigGitRef: %s\n", a.ConfigGitRef)
+	_, _ = fmt.Fprintf(buf, "ConfigGitPath: %s\n", a.ConfigGitPath)
+	_, _ = fmt.Fprintf(buf, "ConfigGitPollInterval: %v\n", a.ConfigGitPollInterval)
 	_, _ = fmt.Fprintf(buf, "MeshConfigFile: %s\n", a.MeshConfigFile)
 	_, _ = fmt.Fprintf(buf, "DomainSuffix: %s\n", a.DomainSuffix)
 	_, _ = fmt.Fprintf(buf, "DisableResourceReadyCheck: %v\n", a.DisableResourceReadyCheck)
@@ -21,16 +21,34 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"istio.io/istio/galley/pkg/config/analysis"
 	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/local"
 	"istio.io/istio/galley/pkg/config/meta/metadata"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
 	cfgKube "istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/istioctl/pkg/util/formatting"
 	"istio.io/istio/pkg/kube"
 )
 
+// AnalyzerFoundIssuesError indicates that at least one message at or above the failure threshold was found.
+type AnalyzerFoundIssuesError struct{}
+
+// FoundIssueString is the error message returned when analysis finds issues at or above the failure threshold.
+const FoundIssueString = "Analyzers found issues."
+
+func (f AnalyzerFoundIssuesError) Error() string {
+	return FoundIssueString
+}
+
 var (
-	useKube      bool
-	useDiscovery string
+	useKube          bool
+	useDiscovery     string
+	failureThreshold string
+	msgOutputFormat  string
+	suppress         []string
+	analyzeMeshFile  string
 )
 
 // Analyze command
@@ -55,8 +73,32 @@ istioctl experimental analyze -d true a.yaml b.yaml services.yaml
 
 # Analyze the current live cluster, overriding service discovery to disabled
 istioctl experimental analyze -k -d false
+
+# Analyze yaml files, printing the messages as JSON and failing only on errors
+istioctl experimental analyze -o json --failure-threshold Error a.yaml b.yaml
+
+# Analyze the current live cluster, suppressing the NamespaceNotInjected message for the default namespace
+istioctl experimental analyze -k --suppress "IST0102=Namespace default"
+
+# Analyze yaml files, suppressing ReferencedResourceNotFound for every VirtualService in the default namespace
+istioctl experimental analyze --suppress "IST0101=VirtualService default/*" a.yaml b.yaml
+
+# Analyze yaml files with the mesh configuration of mesh.yaml
+istioctl experimental analyze --meshConfigFile mesh.yaml a.yaml b.yaml
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			threshold, ok := diag.ParseLevel(failureThreshold)
+			if !ok {
+				return fmt.Errorf("invalid failure threshold %q, expected one of %v", failureThreshold, diag.GetAllLevelStrings())
+			}
+			if !formatting.MsgOutputFormats[msgOutputFormat] {
+				return fmt.Errorf("%q not a valid option for format. See istioctl x analyze --help", msgOutputFormat)
+			}
+			suppressions, err := parseSuppressions(suppress)
+			if err != nil {
+				return err
+			}
+
 			files, err := gatherFiles(args)
 			if err != nil {
 				return err
@@ -102,6 +144,13 @@ istioctl experimental analyze -k -d false
 			}
 
 			sa := local.NewSourceAnalyzer(metadata.MustGet(), analyzers.AllCombined(), selectedNamespace, nil, sd)
+			sa.SetSuppressions(suppressions)
+
+			if analyzeMeshFile != "" {
+				if err = sa.AddFileKubeMeshConfig(analyzeMeshFile); err != nil {
+					return err
+				}
+			}
 
 			// If we're using kube, use that as a base source.
 			if k != nil {
@@ -120,8 +169,19 @@ istioctl experimental analyze -k -d false
 				return err
 			}
 
+			output, err := formatting.Print(messages, msgOutputFormat)
+			if err != nil {
+				return err
+			}
+			if output != "" {
+				fmt.Fprintln(cmd.OutOrStdout(), output)
+			}
+
+			// Return a different exit code if any message at or above the failure threshold was found.
 			for _, m := range messages {
-				fmt.Fprintf(cmd.OutOrStdout(), "%v\n", m.String())
+				if m.Type.Level().IsWorseThanOrEqualTo(threshold) {
+					return AnalyzerFoundIssuesError{}
+				}
 			}
 
 			return nil
@@ -134,10 +194,38 @@ istioctl experimental analyze -k -d false
 		"'true' to enable service discovery, 'false' to disable it. "+
 			"Defaults to true if --use-kube is set, false otherwise. "+
 			"Analyzers requiring resources made available by enabling service discovery will be skipped.")
+	analysisCmd.PersistentFlags().StringVar(&failureThreshold, "failure-threshold", diag.Warning.String(),
+		fmt.Sprintf("The severity level of analysis at which to set a non-zero exit code. Valid values: %v", diag.GetAllLevelStrings()))
+	analysisCmd.PersistentFlags().StringVarP(&msgOutputFormat, "output", "o", formatting.LogFormat,
+		fmt.Sprintf("Output format: one of %v", formatting.MsgOutputFormatKeys))
+	analysisCmd.PersistentFlags().StringArrayVarP(&suppress, "suppress", "S", []string{},
+		"Suppress reporting a message code on a specific resource. Values are supplied in the form "+
+			`<code>=<kind> [<namespace>/]<name> (e.g. '--suppress "IST0102=Namespace default"'). Can be repeated. `+
+			`You can include the wildcard character '*' to support a partial match `+
+			`(e.g. '--suppress "IST0101=VirtualService default/*"'). Resources can also suppress messages with the `+
+			analysis.SuppressAnnotation.Name+" annotation.")
+	analysisCmd.PersistentFlags().StringVar(&analyzeMeshFile, "meshConfigFile", "",
+		"Mesh configuration filename. Defaults to the default mesh configuration")
 
 	return analysisCmd
 }
 
+// parseSuppressions converts values of the form "<code>=<kind> [<namespace>/]<name>" into suppressions. The resource
+// is converted to the friendly name used by message origins, "<kind>/[<namespace>/]<name>".
+func parseSuppressions(values []string) ([]snapshotter.AnalysisSuppression, error) {
+	var result []snapshotter.AnalysisSuppression
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("%q is not a valid suppression value. See istioctl x analyze --help", v)
+		}
+
+		resourceName := strings.Join(strings.Fields(parts[1]), "/")
+		result = append(result, snapshotter.NewAnalysisSuppression(strings.TrimSpace(parts[0]), resourceName))
+	}
+	return result, nil
+}
+
 func gatherFiles(args []string) ([]string, error) {
 	var result []string
 	for _, a := range args {
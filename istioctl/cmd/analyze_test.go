@@ -0,0 +1,144 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+)
+
+const missingGatewayFile = "testdata/analyze/missing-gateway.yaml"
+
+func runAnalyze(t *testing.T, args string) (string, error) {
+	t.Helper()
+
+	// Flag values are package globals and persist between runs, so reset them to their defaults each time.
+	failureThreshold = "Warn"
+	msgOutputFormat = "log"
+	suppress = []string{}
+	analyzeMeshFile = ""
+
+	var out bytes.Buffer
+	rootCmd := GetRootCmd(strings.Split(args, " "))
+	rootCmd.SetOutput(&out)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestAnalyzeFailureThreshold(t *testing.T) {
+	out, err := runAnalyze(t, "x analyze "+missingGatewayFile)
+	if _, ok := err.(AnalyzerFoundIssuesError); !ok {
+		t.Fatalf("expected AnalyzerFoundIssuesError, got %v", err)
+	}
+	if GetExitCode(err) != ExitAnalyzerFoundIssues {
+		t.Fatalf("expected exit code %d, got %d", ExitAnalyzerFoundIssues, GetExitCode(err))
+	}
+	if !strings.Contains(out, "IST0101") {
+		t.Fatalf("expected output to contain message code IST0101, got %q", out)
+	}
+
+	// Error messages are at or above every threshold.
+	if _, err = runAnalyze(t, "x analyze --failure-threshold Error "+missingGatewayFile); err == nil {
+		t.Fatalf("expected an error at threshold Error")
+	}
+}
+
+func TestAnalyzeNoIssues(t *testing.T) {
+	out, err := runAnalyze(t, "x analyze testdata/analyze/valid.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+}
+
+func TestAnalyzeInvalidFlags(t *testing.T) {
+	if _, err := runAnalyze(t, "x analyze --failure-threshold Fatal "+missingGatewayFile); err == nil {
+		t.Fatalf("expected an error for an invalid failure threshold")
+	}
+	if _, err := runAnalyze(t, "x analyze -o xml "+missingGatewayFile); err == nil {
+		t.Fatalf("expected an error for an invalid output format")
+	}
+}
+
+func TestAnalyzeJSONOutput(t *testing.T) {
+	out, err := runAnalyze(t, "x analyze -o json "+missingGatewayFile)
+	if _, ok := err.(AnalyzerFoundIssuesError); !ok {
+		t.Fatalf("expected AnalyzerFoundIssuesError, got %v", err)
+	}
+
+	// The error message is printed after the JSON output; only parse the JSON part.
+	out = out[:strings.LastIndex(out, "]")+1]
+	var parsed []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("unable to parse output as JSON: %v\n%s", err, out)
+	}
+	if len(parsed) != 1 || parsed[0]["code"] != "IST0101" {
+		t.Fatalf("unexpected messages: %v", parsed)
+	}
+}
+
+func TestAnalyzeSuppress(t *testing.T) {
+	out, err := runAnalyze(t, "x analyze --suppress IST0101=VirtualService/default/httpbin-bogus "+missingGatewayFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "IST0101") {
+		t.Fatalf("expected IST0101 to be suppressed, got %q", out)
+	}
+}
+
+func TestAnalyzeMeshConfigFile(t *testing.T) {
+	out, err := runAnalyze(t, "x analyze --meshConfigFile testdata/analyze/mesh.yaml testdata/analyze/valid.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	if _, err = runAnalyze(t, "x analyze --meshConfigFile testdata/analyze/missing.yaml testdata/analyze/valid.yaml"); err == nil {
+		t.Fatalf("expected an error for a missing mesh config file")
+	}
+}
+
+func TestParseSuppressions(t *testing.T) {
+	got, err := parseSuppressions([]string{"IST0102=Namespace default", "IST0101= VirtualService  default/* "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []snapshotter.AnalysisSuppression{
+		snapshotter.NewAnalysisSuppression("IST0102", "Namespace/default"),
+		snapshotter.NewAnalysisSuppression("IST0101", "VirtualService/default/*"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{"IST0102", "=Namespace default", "IST0102="} {
+		if _, err := parseSuppressions([]string{invalid}); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestGetExitCode(t *testing.T) {
+	if got := GetExitCode(AnalyzerFoundIssuesError{}); got != ExitAnalyzerFoundIssues {
+		t.Errorf("got %d, want %d", got, ExitAnalyzerFoundIssues)
+	}
+	if got := GetExitCode(bytes.ErrTooLarge); got != ExitGeneralError {
+		t.Errorf("got %d, want %d", got, ExitGeneralError)
+	}
+}
@@ -18,10 +18,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
 	kubernetes2 "k8s.io/client-go/kubernetes"
 
+	security "istio.io/api/security/v1beta1"
 	"istio.io/pkg/log"
 
 	"istio.io/istio/istioctl/pkg/auth"
@@ -29,6 +37,8 @@ import (
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/istioctl/pkg/util/handlers"
 	"istio.io/istio/pilot/pkg/model"
+	authz_model "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pkg/config/schemas"
 	"istio.io/istio/pkg/kube"
 )
 
@@ -163,6 +173,240 @@ THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
 	}
 )
 
+func simulateCmd() *cobra.Command {
+	var (
+		files              []string
+		domainSuffix       string
+		trustDomain        string
+		trustDomainAliases []string
+		workload           auth.Workload
+		request            auth.Request
+		sourceNamespace    string
+		headers            []string
+		claims             []string
+	)
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate the authorization of a request to a workload",
+		Long: `Simulate builds the Envoy RBAC filter of a workload from authorization policy files, like Pilot does, and
+evaluates a described request against it. It prints whether the request is allowed or denied and the policy
+that decided it. It does not require access to the cluster as the simulation is against local files.
+
+The files could include ServiceRole, ServiceRoleBinding, ClusterRbacConfig and AuthorizationPolicy. The request
+is from a peer without identity unless --source-principal or --source-namespace is specified, in the latter case
+the identity is the default service account of the namespace.
+
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		Example: `  # Simulate a GET request from the sleep service account to the httpbin workload in namespace foo:
+  istioctl experimental auth simulate -f policy.yaml -n foo --labels app=httpbin --service httpbin \
+    --source-principal cluster.local/ns/foo/sa/sleep --method GET --path /headers
+
+  # Simulate a request with a JWT:
+  istioctl experimental auth simulate -f policy.yaml -n foo --labels app=httpbin \
+    --request-principal issuer.example.com/alice --claim groups=admin --header x-token=abc`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			simulator, err := auth.NewSimulator(files, domainSuffix, trustDomain, trustDomainAliases)
+			if err != nil {
+				return err
+			}
+			workload.Namespace = handlers.HandleNamespace(namespace, defaultNamespace)
+			if request.SourcePrincipal == "" && sourceNamespace != "" {
+				request.SourcePrincipal = fmt.Sprintf("%s/ns/%s/sa/default", trustDomain, sourceNamespace)
+			}
+			if request.Headers, err = parseKeyValues("header", headers); err != nil {
+				return err
+			}
+			request.Claims = map[string][]string{}
+			for _, c := range claims {
+				kv := strings.SplitN(c, "=", 2)
+				if len(kv) != 2 {
+					return fmt.Errorf("invalid claim %q, expecting key=value", c)
+				}
+				request.Claims[kv[0]] = append(request.Claims[kv[0]], kv[1])
+			}
+
+			decision, err := simulator.Simulate(workload, request)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), decision)
+			if decision.Shadow != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Shadow: %s\n", decision.Shadow)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringSliceVarP(&files, "file", "f", nil, "Authorization policy files")
+	cmd.PersistentFlags().StringVar(&domainSuffix, "domain", "cluster.local", "DNS domain suffix of the services")
+	cmd.PersistentFlags().StringVar(&trustDomain, "trust-domain", "cluster.local", "Trust domain of the mesh")
+	cmd.PersistentFlags().StringSliceVar(&trustDomainAliases, "trust-domain-aliases", nil, "Aliases of the trust domain")
+	cmd.PersistentFlags().StringToStringVar(&workload.Labels, "labels", nil, "Labels of the workload")
+	cmd.PersistentFlags().StringVar(&workload.Service, "service", "",
+		"Short name of the service of the workload, required to apply ServiceRole")
+	cmd.PersistentFlags().StringVar(&workload.ServiceAccount, "serviceaccount", "default", "Service account of the workload")
+	cmd.PersistentFlags().StringVar(&request.SourcePrincipal, "source-principal", "",
+		"Identity of the source, e.g. cluster.local/ns/default/sa/sleep")
+	cmd.PersistentFlags().StringVar(&sourceNamespace, "source-namespace", "", "Namespace of the source")
+	cmd.PersistentFlags().StringVar(&request.SourceIP, "source-ip", "", "IP of the source")
+	cmd.PersistentFlags().StringVar(&request.DestinationIP, "destination-ip", "", "IP of the workload")
+	cmd.PersistentFlags().IntVar(&request.DestinationPort, "port", 0, "Port of the workload")
+	cmd.PersistentFlags().StringVar(&request.SNI, "sni", "", "Server name indication of the connection")
+	cmd.PersistentFlags().StringVar(&request.Host, "host", "", "Host of the request")
+	cmd.PersistentFlags().StringVar(&request.Method, "method", "GET", "Method of the request")
+	cmd.PersistentFlags().StringVar(&request.Path, "path", "/", "Path of the request")
+	cmd.PersistentFlags().StringArrayVar(&headers, "header", nil, "Header of the request, as key=value")
+	cmd.PersistentFlags().StringVar(&request.RequestPrincipal, "request-principal", "",
+		"Principal of the JWT of the request, e.g. issuer/subject")
+	cmd.PersistentFlags().StringVar(&request.Audiences, "audiences", "", "Audiences of the JWT of the request")
+	cmd.PersistentFlags().StringVar(&request.Presenter, "presenter", "", "Authorized presenter of the JWT of the request")
+	cmd.PersistentFlags().StringArrayVar(&claims, "claim", nil,
+		"Claim of the JWT of the request, as key=value. Repeat the flag for list claims, e.g. groups")
+	cmd.PersistentFlags().BoolVar(&request.TCP, "tcp", false, "Simulate a TCP connection instead of an HTTP request")
+	return cmd
+}
+
+func parseKeyValues(name string, values []string) (map[string]string, error) {
+	out := make(map[string]string, len(values))
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid %s %q, expecting key=value", name, v)
+		}
+		out[kv[0]] = kv[1]
+	}
+	return out, nil
+}
+
+func dryRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dry-run",
+		Short: "Summarize the requests the authorization policies in dry-run mode would deny",
+		Long: fmt.Sprintf(`Dry-run lists the AuthorizationPolicies in dry-run mode of a namespace, i.e. with the annotation
+%s: "true", and prints the shadow_allowed and shadow_denied stats of the RBAC filter of each pod they
+select. A request is counted as shadow denied if it would be denied with the policies in dry-run mode enforced.
+
+The stats are totals of the pod, for all the policies in dry-run mode selecting it together: Envoy does not
+report them per policy. They are reset when the proxy of the pod restarts.
+
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`, authz_model.DryRunAnnotation),
+		Example: `  # Summarize the requests the policies in dry-run mode of namespace foo would deny:
+  istioctl experimental auth dry-run -n foo`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ns := handlers.HandleNamespace(namespace, defaultNamespace)
+			configClient, err := clientFactory()
+			if err != nil {
+				return err
+			}
+			configs, err := configClient.List(schemas.AuthorizationPolicy.Type, ns)
+			if err != nil {
+				return err
+			}
+			policies := make([]model.Config, 0)
+			for _, config := range configs {
+				if authz_model.IsDryRun(config) {
+					policies = append(policies, config)
+				}
+			}
+			if len(policies) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No authorization policy in dry-run mode in namespace %s\n", ns)
+				return nil
+			}
+
+			client, err := interfaceFactory(kubeconfig)
+			if err != nil {
+				return err
+			}
+			pods, err := client.CoreV1().Pods(ns).List(metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			execClient, err := clientExecFactory(kubeconfig, configContext)
+			if err != nil {
+				return err
+			}
+
+			sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+			sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 1, ' ', 0)
+			fmt.Fprintln(w, "POD\tDRY-RUN POLICIES\tPOD SHADOW ALLOWED\tPOD SHADOW DENIED")
+			for _, pod := range pods.Items {
+				if !hasProxy(pod) {
+					continue
+				}
+				var selecting []string
+				for _, policy := range policies {
+					selector := k8s_labels.SelectorFromSet(policy.Spec.(*security.AuthorizationPolicy).GetSelector().GetMatchLabels())
+					if selector.Matches(k8s_labels.Set(pod.Labels)) {
+						selecting = append(selecting, policy.Name)
+					}
+				}
+				if len(selecting) == 0 {
+					continue
+				}
+				s, err := getShadowStats(execClient, pod.Name, ns)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", pod.Name, strings.Join(selecting, ","), s.allowed, s.denied)
+			}
+			return w.Flush()
+		},
+	}
+	return cmd
+}
+
+// shadowStats are the stats of the RBAC shadow rules of a proxy.
+type shadowStats struct {
+	allowed uint64
+	denied  uint64
+}
+
+func hasProxy(pod v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "istio-proxy" {
+			return true
+		}
+	}
+	return false
+}
+
+// getShadowStats sums the shadow stats of the RBAC HTTP and TCP filters of a proxy.
+func getShadowStats(execClient kubernetes.ExecClient, podName, podNamespace string) (*shadowStats, error) {
+	data, err := execClient.EnvoyDo(podName, podNamespace, "GET", "stats?filter=rbac.shadow_", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats of %s.%s: %v", podName, podNamespace, err)
+	}
+	out := &shadowStats{}
+	for _, line := range strings.Split(string(data), "\n") {
+		kv := strings.SplitN(line, ": ", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		var counter *uint64
+		switch {
+		case strings.HasSuffix(kv[0], "rbac.shadow_allowed"):
+			counter = &out.allowed
+		case strings.HasSuffix(kv[0], "rbac.shadow_denied"):
+			counter = &out.denied
+		default:
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stat %q of %s.%s: %v", line, podName, podNamespace, err)
+		}
+		*counter += value
+	}
+	return out, nil
+}
+
 func getConfigDumpFromFile(filename string) (*configdump.Wrapper, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -246,6 +490,8 @@ func Auth() *cobra.Command {
 		Long: `Commands to inspect and interact with the authentication (TLS, JWT) and authorization (RBAC) policies in the mesh
   check - check the TLS/JWT/RBAC settings based on the Envoy config
 	validate - check for potential incorrect usage in authorization policy files.
+	simulate - simulate the authorization of a request against authorization policy files.
+	dry-run - summarize the requests the authorization policies in dry-run mode would deny.
 `,
 		Example: `  # Check the TLS/JWT/RBAC settings for pod httpbin-88ddbcfdd-nt5jb:
   istioctl experimental auth check httpbin-88ddbcfdd-nt5jb`,
@@ -254,6 +500,8 @@ func Auth() *cobra.Command {
 	cmd.AddCommand(checkCmd)
 	cmd.AddCommand(validatorCmd)
 	cmd.AddCommand(upgradeCmd)
+	cmd.AddCommand(simulateCmd())
+	cmd.AddCommand(dryRunCmd())
 	return cmd
 }
 
@@ -20,8 +20,18 @@ import (
 	"strings"
 	"testing"
 
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	security "istio.io/api/security/v1beta1"
+	"istio.io/api/type/v1beta1"
+
 	"istio.io/istio/istioctl/pkg/auth"
+	"istio.io/istio/pilot/pkg/model"
+	authz_model "istio.io/istio/pilot/pkg/security/authz/model"
 	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/config/schemas"
 )
 
 func runCommandAndCheckGoldenFile(name, command, golden string, t *testing.T) {
@@ -155,3 +165,120 @@ func TestAuthUpgrade(t *testing.T) {
 		}
 	}
 }
+
+func TestAuthSimulate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "allowed",
+			args:     "--source-namespace bar --header x-version=v1",
+			expected: "ALLOWED by policy ns[foo]-policy[httpbin]-rule[0]\n",
+		},
+		{
+			name:     "denied",
+			args:     "--source-namespace bar --header x-version=v1 --method POST",
+			expected: "DENIED: no policy matched\n",
+		},
+		{
+			name:          "invalid header",
+			args:          "--header x-version",
+			expectedError: "Error: invalid header \"x-version\", expecting key=value\n",
+		},
+	}
+	for _, c := range testCases {
+		command := "experimental auth simulate -f testdata/auth/simulate-policy.yaml -n foo --labels app=httpbin " + c.args
+		if c.expectedError != "" {
+			runCommandAndCheckExpectedCmdError(c.name, command, c.expectedError, t)
+		} else {
+			runCommandAndCheckExpectedString(c.name, command, c.expected, t)
+		}
+	}
+}
+
+func authorizationPolicy(name string, selector map[string]string, dryRun bool) model.Config {
+	config := model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Name:      name,
+			Namespace: "foo",
+			Type:      schemas.AuthorizationPolicy.Type,
+			Group:     schemas.AuthorizationPolicy.Group,
+			Version:   schemas.AuthorizationPolicy.Version,
+		},
+		Spec: &security.AuthorizationPolicy{
+			Selector: &v1beta1.WorkloadSelector{MatchLabels: selector},
+		},
+	}
+	if dryRun {
+		config.Annotations = map[string]string{authz_model.DryRunAnnotation: "true"}
+	}
+	return config
+}
+
+func proxyPod(name string, labels map[string]string) *coreV1.Pod {
+	return &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: "foo", Labels: labels},
+		Spec: coreV1.PodSpec{
+			Containers: []coreV1.Container{{Name: "app"}, {Name: "istio-proxy"}},
+		},
+		Status: coreV1.PodStatus{Phase: coreV1.PodRunning},
+	}
+}
+
+func TestAuthDryRun(t *testing.T) {
+	pods := []runtime.Object{
+		proxyPod("httpbin-v1", map[string]string{"app": "httpbin", "version": "v1"}),
+		proxyPod("httpbin-v2", map[string]string{"app": "httpbin", "version": "v2"}),
+		proxyPod("sleep", map[string]string{"app": "sleep"}),
+		&coreV1.Pod{
+			ObjectMeta: metaV1.ObjectMeta{Name: "httpbin-no-proxy", Namespace: "foo", Labels: map[string]string{"app": "httpbin"}},
+			Status:     coreV1.PodStatus{Phase: coreV1.PodRunning},
+		},
+	}
+	stats := map[string][]byte{
+		"httpbin-v1": []byte("http.10.0.0.1_8000.rbac.shadow_allowed: 10\nhttp.10.0.0.1_8000.rbac.shadow_denied: 3\n" +
+			"tcp.rbac.shadow_denied: 1\n"),
+		"httpbin-v2": []byte("http.10.0.0.2_8000.rbac.shadow_allowed: 7\nhttp.10.0.0.2_8000.rbac.shadow_denied: 0\n"),
+		"sleep":      []byte(""),
+	}
+	cases := []execAndK8sConfigTestCase{
+		{
+			configs:        []model.Config{authorizationPolicy("httpbin", map[string]string{"app": "httpbin"}, false)},
+			args:           strings.Split("experimental auth dry-run -n foo", " "),
+			expectedOutput: "No authorization policy in dry-run mode in namespace foo\n",
+		},
+		{
+			configs: []model.Config{
+				authorizationPolicy("enforced", map[string]string{"app": "sleep"}, false),
+				authorizationPolicy("httpbin", map[string]string{"app": "httpbin"}, true),
+				authorizationPolicy("httpbin-v2", map[string]string{"version": "v2"}, true),
+				authorizationPolicy("namespace", nil, true),
+			},
+			k8sConfigs:       pods,
+			execClientConfig: stats,
+			args:             strings.Split("experimental auth dry-run -n foo", " "),
+			expectedOutput: `POD        DRY-RUN POLICIES             POD SHADOW ALLOWED POD SHADOW DENIED
+httpbin-v1 httpbin,namespace            10                 4
+httpbin-v2 httpbin,httpbin-v2,namespace 7                  0
+sleep      namespace                    0                  0
+`,
+		},
+		{
+			configs:          []model.Config{authorizationPolicy("httpbin", map[string]string{"app": "httpbin"}, true)},
+			k8sConfigs:       pods,
+			execClientConfig: map[string][]byte{"httpbin-v1": []byte("http.rbac.shadow_denied: x\n")},
+			args:             strings.Split("experimental auth dry-run -n foo", " "),
+			expectedString:   "invalid stat",
+			wantException:    true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyExecAndK8sConfigTestCaseTestOutput(t, c)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+func caCmd() *cobra.Command {
+	caCmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the certificates issued by the Istio CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.HelpFunc()(cmd, args)
+			if len(args) != 0 {
+				return fmt.Errorf("unknown subcommand %q", args[0])
+			}
+			return nil
+		},
+	}
+	caCmd.AddCommand(caRevokeCmd())
+	caCmd.AddCommand(caListRevokedCmd())
+	return caCmd
+}
+
+func caRevokeCmd() *cobra.Command {
+	var (
+		serial   string
+		identity string
+		reason   string
+	)
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke a workload certificate or deny an identity",
+		Long: `istioctl experimental ca revoke adds an entry to the revocation list of the Istio CA.
+A certificate is revoked by its serial number: it is added to the CRL published by the CA and
+rejected by the proxies once Pilot pushes the CRL. The CA only publishes a CRL if it signs with
+its own root certificate, certificates cannot be revoked with an external signer or an intermediate
+CA certificate. An identity is denied by its SPIFFE URI: the CA refuses to sign new certificates for it.
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		Example: `  # Revoke a certificate by its serial number
+  istioctl experimental ca revoke --serial 7f:3a:91 --reason "key compromise"
+
+  # Deny new certificates for a service account
+  istioctl experimental ca revoke --identity spiffe://cluster.local/ns/default/sa/foo`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (serial == "") == (identity == "") {
+				return fmt.Errorf("exactly one of --serial or --identity is required")
+			}
+			client, err := interfaceFactory(kubeconfig)
+			if err != nil {
+				return err
+			}
+			if serial != "" {
+				published, err := ca.IsCRLPublished(istioNamespace, client.CoreV1())
+				if err != nil {
+					return err
+				}
+				if !published {
+					return fmt.Errorf("the Istio CA does not publish a CRL in configmap %s, so certificates cannot be "+
+						"revoked: it must have the revocation list enabled and sign with its own root certificate",
+						ca.RevocationConfigMapName)
+				}
+			}
+			entry := ca.RevocationEntry{SerialNumber: serial, Identity: identity, Reason: reason}
+			if err := ca.AddRevocationEntries(istioNamespace, client.CoreV1(), entry); err != nil {
+				return err
+			}
+			if serial != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Certificate with serial number %s revoked\n", serial)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Identity %s denied\n", identity)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringVar(&serial, "serial", "",
+		"Serial number of the certificate to revoke, in hexadecimal")
+	cmd.PersistentFlags().StringVar(&identity, "identity", "",
+		"SPIFFE identity to deny certificates for")
+	cmd.PersistentFlags().StringVar(&reason, "reason", "",
+		"Reason of the revocation")
+	return cmd
+}
+
+func caListRevokedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list-revoked",
+		Short:   "List the revoked certificates and denied identities",
+		Example: `istioctl experimental ca list-revoked`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := interfaceFactory(kubeconfig)
+			if err != nil {
+				return err
+			}
+			entries, err := ca.ReadRevocationEntries(istioNamespace, client.CoreV1())
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No revoked certificate or denied identity")
+				return nil
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 1, ' ', 0)
+			fmt.Fprintln(w, "SERIAL NUMBER\tIDENTITY\tREVOKED AT\tREASON")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", orDash(e.SerialNumber), orDash(e.Identity),
+					e.RevokedAt.Format(time.RFC3339), orDash(e.Reason))
+			}
+			return w.Flush()
+		},
+	}
+	return cmd
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
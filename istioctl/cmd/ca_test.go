@@ -0,0 +1,100 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+var cannedRevocationConfigs = []runtime.Object{
+	&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ca.RevocationConfigMapName, Namespace: "istio-system"},
+		Data: map[string]string{
+			ca.RevocationListID: `[
+  {"serialNumber": "7f3a91", "reason": "key compromise", "revokedAt": "2019-11-05T10:00:00Z"},
+  {"identity": "spiffe://cluster.local/ns/default/sa/foo", "revokedAt": "2019-11-06T10:00:00Z"}
+]`,
+			ca.CRLID: "-----BEGIN X509 CRL-----\n-----END X509 CRL-----\n",
+		},
+	},
+}
+
+func TestCA(t *testing.T) {
+	cases := []testcase{
+		{
+			description:       "revoke without serial or identity",
+			args:              strings.Split("experimental ca revoke", " "),
+			expectedException: true,
+			expectedOutput:    "Error: exactly one of --serial or --identity is required\n",
+		},
+		{
+			description:       "revoke with both serial and identity",
+			args:              strings.Split("experimental ca revoke --serial 1 --identity spiffe://cluster.local/ns/default/sa/foo", " "),
+			expectedException: true,
+			expectedOutput:    "Error: exactly one of --serial or --identity is required\n",
+		},
+		{
+			description:       "revoke an invalid serial number",
+			args:              strings.Split("experimental ca revoke --serial xyz", " "),
+			expectedException: true,
+		},
+		{
+			description:    "revoke a serial number",
+			args:           strings.Split("experimental ca revoke --serial 7f:3a:92 --reason leaked", " "),
+			k8sConfigs:     cannedRevocationConfigs,
+			expectedOutput: "Certificate with serial number 7f:3a:92 revoked\n",
+		},
+		{
+			description:       "revoke a serial number without CRL",
+			args:              strings.Split("experimental ca revoke --serial 7f:3a:92", " "),
+			expectedException: true,
+			expectedOutput: "Error: the Istio CA does not publish a CRL in configmap istio-ca-revocation-list, so " +
+				"certificates cannot be revoked: it must have the revocation list enabled and sign with its own root certificate\n",
+		},
+		{
+			description:    "deny an identity",
+			args:           strings.Split("experimental ca revoke --identity spiffe://cluster.local/ns/default/sa/bar", " "),
+			expectedOutput: "Identity spiffe://cluster.local/ns/default/sa/bar denied\n",
+		},
+		{
+			description:    "list an empty revocation list",
+			args:           strings.Split("experimental ca list-revoked", " "),
+			expectedOutput: "No revoked certificate or denied identity\n",
+		},
+		{
+			description: "list the revocation list",
+			args:        strings.Split("experimental ca list-revoked", " "),
+			k8sConfigs:  cannedRevocationConfigs,
+			expectedOutput: `SERIAL NUMBER IDENTITY                                 REVOKED AT           REASON
+7f3a91        -                                        2019-11-05T10:00:00Z key compromise
+-             spiffe://cluster.local/ns/default/sa/foo 2019-11-06T10:00:00Z -
+`,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, c.description), func(t *testing.T) {
+			verifyAddToMeshOutput(t, c)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/istioctl/pkg/configdiff"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/pilot/cmd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+func configDiffCmd() *cobra.Command {
+	var (
+		before         []string
+		after          []string
+		labels         map[string]string
+		serviceAccount string
+		proxyType      string
+		proxyIP        string
+		meshFile       string
+		diffContext    int
+	)
+	cmd := &cobra.Command{
+		Use:   "config-diff",
+		Short: "Diff the Envoy config generated for a proxy by two sets of Istio configuration",
+		Long: `config-diff runs the Pilot config generator in-process against two sets of Istio configuration and
+Kubernetes Services, e.g. before and after a change, and prints the listeners, clusters and routes of a
+proxy that differ. This predicts the impact of a change on a proxy before it is applied.
+THIS COMMAND IS STILL UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		Example: `  # Diff the config of the reviews-v1 sidecars before and after a change
+  istioctl experimental config-diff --before base/ --after change/ -n bookinfo \
+    --labels app=reviews,version=v1 --serviceaccount bookinfo-reviews
+
+  # Diff the config of the ingress gateway
+  istioctl experimental config-diff --before base.yaml --after change.yaml -n istio-system \
+    --type router --labels istio=ingressgateway`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(before) == 0 || len(after) == 0 {
+				return fmt.Errorf("--before and --after are required")
+			}
+			if !model.IsApplicationNodeType(model.NodeType(proxyType)) {
+				return fmt.Errorf("invalid proxy type %q", proxyType)
+			}
+			meshConfig, err := readMeshConfig(meshFile)
+			if err != nil {
+				return err
+			}
+			ns := handlers.HandleNamespace(namespace, defaultNamespace)
+			proxy := configdiff.Proxy{
+				Type:           model.NodeType(proxyType),
+				Namespace:      ns,
+				Labels:         labels,
+				ServiceAccount: serviceAccount,
+				IP:             proxyIP,
+			}
+
+			beforeConfig, err := generateConfig(before, ns, meshConfig, proxy)
+			if err != nil {
+				return err
+			}
+			afterConfig, err := generateConfig(after, ns, meshConfig, proxy)
+			if err != nil {
+				return err
+			}
+			differ, err := configdiff.Diff(c.OutOrStdout(), beforeConfig, afterConfig, diffContext)
+			if err != nil {
+				return err
+			}
+			if !differ {
+				fmt.Fprintln(c.OutOrStdout(), "No difference")
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringSliceVar(&before, "before", nil,
+		"Files or directories of the Istio configuration and Kubernetes Services before the change")
+	cmd.PersistentFlags().StringSliceVar(&after, "after", nil,
+		"Files or directories of the Istio configuration and Kubernetes Services after the change")
+	cmd.PersistentFlags().StringToStringVar(&labels, "labels", nil, "Labels of the proxy pod")
+	cmd.PersistentFlags().StringVar(&serviceAccount, "serviceaccount", "default", "Service account of the proxy pod")
+	cmd.PersistentFlags().StringVar(&proxyType, "type", string(model.SidecarProxy), "Type of the proxy: sidecar or router")
+	cmd.PersistentFlags().StringVar(&proxyIP, "ip", "10.0.0.1", "IP of the proxy pod")
+	cmd.PersistentFlags().StringVar(&meshFile, "meshConfigFile", "", "Mesh configuration filename. Defaults to the default mesh configuration")
+	cmd.PersistentFlags().IntVar(&diffContext, "context", 3, "Number of context lines around each change")
+	return cmd
+}
+
+func readMeshConfig(filename string) (*meshconfig.MeshConfig, error) {
+	if filename == "" {
+		m := mesh.DefaultMeshConfig()
+		return &m, nil
+	}
+	return cmd.ReadMeshConfig(filename)
+}
+
+func generateConfig(paths []string, ns string, meshConfig *meshconfig.MeshConfig,
+	proxy configdiff.Proxy) (*configdiff.EnvoyConfig, error) {
+	input, err := readConfigFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	return configdiff.Generate(input, ns, meshConfig, proxy)
+}
+
+// readConfigFiles concatenates the YAML files of paths, which can be files or directories.
+func readConfigFiles(paths []string) (string, error) {
+	docs := make([]string, 0)
+	for _, p := range paths {
+		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if path != p {
+				if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+					return nil
+				}
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, string(b))
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(docs, "\n---\n"), nil
+}
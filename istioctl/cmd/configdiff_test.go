@@ -0,0 +1,65 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConfigDiff(t *testing.T) {
+	cases := []testcase{
+		{
+			description:       "missing after",
+			args:              strings.Split("experimental config-diff --before testdata/configdiff/services.yaml", " "),
+			expectedException: true,
+			expectedOutput:    "Error: --before and --after are required\n",
+		},
+		{
+			description: "invalid proxy type",
+			args: strings.Split("experimental config-diff --before testdata/configdiff/services.yaml "+
+				"--after testdata/configdiff/services.yaml --type ingress", " "),
+			expectedException: true,
+			expectedOutput:    "Error: invalid proxy type \"ingress\"\n",
+		},
+		{
+			description: "no difference",
+			args: strings.Split("experimental config-diff --before testdata/configdiff/services.yaml "+
+				"--after testdata/configdiff/services.yaml -n bookinfo --labels app=reviews", " "),
+			expectedOutput: "No difference\n",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, c.description), func(t *testing.T) {
+			verifyAddToMeshOutput(t, c)
+		})
+	}
+}
+
+func TestConfigDiffDirectory(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd := GetRootCmd(strings.Split("experimental config-diff --before testdata/configdiff/services.yaml "+
+		"--after testdata/configdiff/after -n bookinfo --labels app=reviews", " "))
+	rootCmd.SetOutput(&out)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Clusters:\n+ outbound|443||www.google.com\n") {
+		t.Errorf("Expected the ServiceEntry cluster to be added, got:\n%s", out.String())
+	}
+}
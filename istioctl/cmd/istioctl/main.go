@@ -26,6 +26,6 @@ import (
 func main() {
 	rootCmd := cmd.GetRootCmd(os.Args[1:])
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.GetExitCode(err))
 	}
 }
@@ -142,6 +142,8 @@ debug and diagnose their Istio mesh.
 	experimentalCmd.AddCommand(removeFromMeshCmd())
 	experimentalCmd.AddCommand(Analyze())
 	experimentalCmd.AddCommand(waitCmd())
+	experimentalCmd.AddCommand(caCmd())
+	experimentalCmd.AddCommand(configDiffCmd())
 
 	postInstallCmd.AddCommand(Webhook())
 	experimentalCmd.AddCommand(postInstallCmd)
@@ -176,6 +178,25 @@ debug and diagnose their Istio mesh.
 	return rootCmd
 }
 
+// Exit codes returned by istioctl
+const (
+	// ExitGeneralError is returned for any error that doesn't have a more specific exit code
+	ExitGeneralError = 1
+
+	// ExitAnalyzerFoundIssues is returned when analysis found messages at or above the failure threshold
+	ExitAnalyzerFoundIssues = 79
+)
+
+// GetExitCode returns the process exit code to use for an error returned by a command.
+func GetExitCode(e error) int {
+	switch e.(type) {
+	case AnalyzerFoundIssuesError:
+		return ExitAnalyzerFoundIssues
+	default:
+		return ExitGeneralError
+	}
+}
+
 func hideInheritedFlags(orig *cobra.Command, hidden ...string) {
 	orig.SetHelpFunc(func(cmd *cobra.Command, args []string) {
 		for _, hidden := range hidden {
@@ -0,0 +1,451 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
+	http_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
+	envoy_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/security/authz/builder"
+	authn_model "istio.io/istio/pilot/pkg/security/model"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+// Workload is the workload receiving the simulated request.
+type Workload struct {
+	Namespace string
+	Labels    map[string]string
+	// Service is the short name of the service of the workload, used by ServiceRole.
+	Service        string
+	ServiceAccount string
+}
+
+// Request describes the attributes of a request matched by the RBAC filter. Empty attributes are not set
+// on the request, e.g. a request without SourcePrincipal is a plain text request.
+type Request struct {
+	// SourcePrincipal is the identity of the peer, e.g. cluster.local/ns/default/sa/sleep.
+	SourcePrincipal string
+	SourceIP        string
+	DestinationIP   string
+	DestinationPort int
+	SNI             string
+	Host            string
+	Method          string
+	Path            string
+	Headers         map[string]string
+	// RequestPrincipal is the principal of the JWT of the request, e.g. issuer/subject.
+	RequestPrincipal string
+	Audiences        string
+	Presenter        string
+	Claims           map[string][]string
+	// TCP is true to evaluate the RBAC TCP filter instead of the HTTP one.
+	TCP bool
+}
+
+// Decision is the result of the evaluation of a request.
+type Decision struct {
+	Allowed bool
+	// Policy is the name of the Envoy RBAC policy that decided, empty if no policy matched.
+	Policy string
+	// Reason explains the decision when no policy matched.
+	Reason string
+	// Shadow is the decision of the shadow rules generated for policies in permissive or dry-run mode, nil
+	// if there are no shadow rules.
+	Shadow *Decision
+}
+
+func (d *Decision) String() string {
+	result := "DENIED"
+	if d.Allowed {
+		result = "ALLOWED"
+	}
+	if d.Policy != "" {
+		return fmt.Sprintf("%s by policy %s", result, d.Policy)
+	}
+	return fmt.Sprintf("%s: %s", result, d.Reason)
+}
+
+// Simulator evaluates requests against a set of authorization policies, as the RBAC filter generated by
+// Pilot would.
+type Simulator struct {
+	policies           *model.AuthorizationPolicies
+	domainSuffix       string
+	trustDomain        string
+	trustDomainAliases []string
+}
+
+// NewSimulator creates a simulator for the ServiceRole, ServiceRoleBinding, ClusterRbacConfig and
+// AuthorizationPolicy of the policy files. The services of the workloads are named with the given DNS domain
+// suffix, e.g. cluster.local.
+func NewSimulator(policyFiles []string, domainSuffix, trustDomain string, trustDomainAliases []string) (*Simulator, error) {
+	if len(policyFiles) == 0 {
+		return nil, fmt.Errorf("no input file provided")
+	}
+	store := model.MakeIstioStore(memory.Make(schemas.Istio))
+	for _, file := range policyFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		configs, _, err := crd.ParseInputs(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		for _, cfg := range configs {
+			if _, err := store.Create(cfg); err != nil {
+				return nil, fmt.Errorf("failed to add %s %s/%s: %v", cfg.Type, cfg.Namespace, cfg.Name, err)
+			}
+		}
+	}
+	policies, err := model.GetAuthorizationPolicies(&model.Environment{IstioConfigStore: store})
+	if err != nil {
+		return nil, err
+	}
+	return &Simulator{
+		policies:           policies,
+		domainSuffix:       domainSuffix,
+		trustDomain:        trustDomain,
+		trustDomainAliases: trustDomainAliases,
+	}, nil
+}
+
+// Simulate builds the RBAC filter of a workload and evaluates a request against it.
+func (s *Simulator) Simulate(w Workload, r Request) (*Decision, error) {
+	rbac, err := s.buildRBAC(w, r.TCP)
+	if err != nil {
+		return nil, err
+	}
+	if rbac == nil {
+		return &Decision{Allowed: true, Reason: "no authorization policy applies to the workload"}, nil
+	}
+	e := newEvaluator(r)
+	d := &Decision{Allowed: true, Reason: "all the policies of the workload are in dry-run mode"}
+	if rbac.Rules != nil {
+		d = e.evaluate(rbac.Rules)
+	}
+	if rbac.ShadowRules != nil {
+		d.Shadow = e.evaluate(rbac.ShadowRules)
+	}
+	return d, nil
+}
+
+// rbacConfig is the config common to the RBAC HTTP and TCP filters.
+type rbacConfig struct {
+	Rules       *envoy_rbac.RBAC
+	ShadowRules *envoy_rbac.RBAC
+}
+
+func (s *Simulator) buildRBAC(w Workload, tcp bool) (*rbacConfig, error) {
+	serviceInstance := &model.ServiceInstance{
+		Labels:         w.Labels,
+		ServiceAccount: fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", s.trustDomain, w.Namespace, w.ServiceAccount),
+	}
+	if w.Service != "" {
+		serviceInstance.Service = &model.Service{
+			Attributes: model.ServiceAttributes{
+				Name:      w.Service,
+				Namespace: w.Namespace,
+			},
+			Hostname: host.Name(fmt.Sprintf("%s.%s.svc.%s", w.Service, w.Namespace, s.domainSuffix)),
+		}
+	}
+	b := builder.NewBuilder(s.trustDomain, s.trustDomainAliases, serviceInstance, labels.Collection{w.Labels},
+		w.Namespace, s.policies, true)
+
+	if tcp {
+		filter := b.BuildTCPFilter()
+		if filter == nil {
+			return nil, nil
+		}
+		cfg := &tcp_config.RBAC{}
+		if err := ptypes.UnmarshalAny(filter.ConfigType.(*listener.Filter_TypedConfig).TypedConfig, cfg); err != nil {
+			return nil, err
+		}
+		return &rbacConfig{Rules: cfg.Rules, ShadowRules: cfg.ShadowRules}, nil
+	}
+	filter := b.BuildHTTPFilter()
+	if filter == nil {
+		return nil, nil
+	}
+	cfg := &http_config.RBAC{}
+	if err := ptypes.UnmarshalAny(filter.ConfigType.(*http_filter.HttpFilter_TypedConfig).TypedConfig, cfg); err != nil {
+		return nil, err
+	}
+	return &rbacConfig{Rules: cfg.Rules, ShadowRules: cfg.ShadowRules}, nil
+}
+
+// evaluator matches a request against Envoy RBAC rules.
+type evaluator struct {
+	request Request
+	headers map[string]string
+	// metadata is the dynamic metadata set by the Istio authn filter.
+	metadata map[string]interface{}
+}
+
+func newEvaluator(r Request) *evaluator {
+	headers := make(map[string]string, len(r.Headers)+3)
+	for k, v := range r.Headers {
+		headers[strings.ToLower(k)] = v
+	}
+	for k, v := range map[string]string{":authority": r.Host, ":method": r.Method, ":path": r.Path} {
+		if v != "" {
+			headers[k] = v
+		}
+	}
+
+	metadata := make(map[string]interface{})
+	for k, v := range map[string]string{
+		"source.principal":       r.SourcePrincipal,
+		"request.auth.principal": r.RequestPrincipal,
+		"request.auth.audiences": r.Audiences,
+		"request.auth.presenter": r.Presenter,
+	} {
+		if v != "" {
+			metadata[k] = v
+		}
+	}
+	if len(r.Claims) > 0 {
+		claims := make(map[string]interface{}, len(r.Claims))
+		for k, v := range r.Claims {
+			claims[k] = v
+		}
+		metadata["request.auth.claims"] = claims
+	}
+	return &evaluator{request: r, headers: headers, metadata: metadata}
+}
+
+// evaluate returns the decision of rules. Policies are tried in the order of their names.
+func (e *evaluator) evaluate(rules *envoy_rbac.RBAC) *Decision {
+	names := make([]string, 0, len(rules.Policies))
+	for name := range rules.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	allow := rules.Action == envoy_rbac.RBAC_ALLOW
+	for _, name := range names {
+		if e.matchPolicy(rules.Policies[name]) {
+			return &Decision{Allowed: allow, Policy: name}
+		}
+	}
+	if allow {
+		return &Decision{Allowed: false, Reason: "no policy matched"}
+	}
+	return &Decision{Allowed: true, Reason: "no deny policy matched"}
+}
+
+func (e *evaluator) matchPolicy(p *envoy_rbac.Policy) bool {
+	permission := false
+	for _, perm := range p.Permissions {
+		if e.matchPermission(perm) {
+			permission = true
+			break
+		}
+	}
+	if !permission {
+		return false
+	}
+	for _, principal := range p.Principals {
+		if e.matchPrincipal(principal) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *evaluator) matchPermission(p *envoy_rbac.Permission) bool {
+	switch r := p.Rule.(type) {
+	case *envoy_rbac.Permission_AndRules:
+		for _, rule := range r.AndRules.Rules {
+			if !e.matchPermission(rule) {
+				return false
+			}
+		}
+		return true
+	case *envoy_rbac.Permission_OrRules:
+		for _, rule := range r.OrRules.Rules {
+			if e.matchPermission(rule) {
+				return true
+			}
+		}
+		return false
+	case *envoy_rbac.Permission_Any:
+		return r.Any
+	case *envoy_rbac.Permission_NotRule:
+		return !e.matchPermission(r.NotRule)
+	case *envoy_rbac.Permission_Header:
+		return e.matchHeader(r.Header)
+	case *envoy_rbac.Permission_DestinationIp:
+		return matchCidr(e.request.DestinationIP, r.DestinationIp.AddressPrefix, r.DestinationIp.PrefixLen.GetValue())
+	case *envoy_rbac.Permission_DestinationPort:
+		return e.request.DestinationPort == int(r.DestinationPort)
+	case *envoy_rbac.Permission_Metadata:
+		return e.matchMetadata(r.Metadata)
+	case *envoy_rbac.Permission_RequestedServerName:
+		return matchString(e.request.SNI, r.RequestedServerName)
+	}
+	return false
+}
+
+func (e *evaluator) matchPrincipal(p *envoy_rbac.Principal) bool {
+	switch id := p.Identifier.(type) {
+	case *envoy_rbac.Principal_AndIds:
+		for _, principal := range id.AndIds.Ids {
+			if !e.matchPrincipal(principal) {
+				return false
+			}
+		}
+		return true
+	case *envoy_rbac.Principal_OrIds:
+		for _, principal := range id.OrIds.Ids {
+			if e.matchPrincipal(principal) {
+				return true
+			}
+		}
+		return false
+	case *envoy_rbac.Principal_Any:
+		return id.Any
+	case *envoy_rbac.Principal_NotId:
+		return !e.matchPrincipal(id.NotId)
+	case *envoy_rbac.Principal_Authenticated_:
+		if e.request.SourcePrincipal == "" {
+			return false
+		}
+		if id.Authenticated.PrincipalName == nil {
+			return true
+		}
+		return matchString("spiffe://"+e.request.SourcePrincipal, id.Authenticated.PrincipalName)
+	case *envoy_rbac.Principal_SourceIp:
+		return matchCidr(e.request.SourceIP, id.SourceIp.AddressPrefix, id.SourceIp.PrefixLen.GetValue())
+	case *envoy_rbac.Principal_Header:
+		return e.matchHeader(id.Header)
+	case *envoy_rbac.Principal_Metadata:
+		return e.matchMetadata(id.Metadata)
+	}
+	return false
+}
+
+func (e *evaluator) matchHeader(m *route.HeaderMatcher) bool {
+	v, present := e.headers[strings.ToLower(m.Name)]
+	var match bool
+	switch s := m.HeaderMatchSpecifier.(type) {
+	case *route.HeaderMatcher_ExactMatch:
+		match = present && v == s.ExactMatch
+	case *route.HeaderMatcher_PrefixMatch:
+		match = present && strings.HasPrefix(v, s.PrefixMatch)
+	case *route.HeaderMatcher_SuffixMatch:
+		match = present && strings.HasSuffix(v, s.SuffixMatch)
+	case *route.HeaderMatcher_PresentMatch:
+		match = present == s.PresentMatch
+	case *route.HeaderMatcher_RegexMatch:
+		match = present && matchRegex(v, s.RegexMatch)
+	case *route.HeaderMatcher_SafeRegexMatch:
+		match = present && matchRegex(v, s.SafeRegexMatch.GetRegex())
+	case *route.HeaderMatcher_RangeMatch:
+		i, err := strconv.ParseInt(v, 10, 64)
+		match = present && err == nil && i >= s.RangeMatch.Start && i < s.RangeMatch.End
+	default:
+		match = present
+	}
+	return match != m.InvertMatch
+}
+
+func (e *evaluator) matchMetadata(m *envoy_matcher.MetadataMatcher) bool {
+	if m.Filter != authn_model.AuthnFilterName {
+		return false
+	}
+	var value interface{} = e.metadata
+	for _, segment := range m.Path {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok = fields[segment.GetKey()]; !ok {
+			return false
+		}
+	}
+	return matchValue(value, m.Value)
+}
+
+func matchValue(value interface{}, m *envoy_matcher.ValueMatcher) bool {
+	switch p := m.MatchPattern.(type) {
+	case *envoy_matcher.ValueMatcher_StringMatch:
+		s, ok := value.(string)
+		return ok && matchString(s, p.StringMatch)
+	case *envoy_matcher.ValueMatcher_PresentMatch:
+		return p.PresentMatch
+	case *envoy_matcher.ValueMatcher_ListMatch:
+		list, ok := value.([]string)
+		if !ok {
+			return false
+		}
+		for _, v := range list {
+			if matchValue(v, p.ListMatch.GetOneOf()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchString(v string, m *envoy_matcher.StringMatcher) bool {
+	switch p := m.MatchPattern.(type) {
+	case *envoy_matcher.StringMatcher_Exact:
+		return v == p.Exact
+	case *envoy_matcher.StringMatcher_Prefix:
+		return strings.HasPrefix(v, p.Prefix)
+	case *envoy_matcher.StringMatcher_Suffix:
+		return strings.HasSuffix(v, p.Suffix)
+	case *envoy_matcher.StringMatcher_Regex:
+		return matchRegex(v, p.Regex)
+	case *envoy_matcher.StringMatcher_SafeRegex:
+		return matchRegex(v, p.SafeRegex.GetRegex())
+	}
+	return false
+}
+
+// matchRegex returns true if the regex matches the whole value, as in Envoy.
+func matchRegex(v, regex string) bool {
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	return err == nil && re.MatchString(v)
+}
+
+func matchCidr(ip, prefix string, prefixLen uint32) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(fmt.Sprintf("%s/%d", prefix, prefixLen))
+	return err == nil && cidr.Contains(addr)
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	httpbin := Workload{Namespace: "foo", Labels: map[string]string{"app": "httpbin"}, ServiceAccount: "httpbin"}
+	productpage := Workload{Namespace: "default", Labels: map[string]string{"app": "productpage"}, Service: "productpage"}
+	cases := []struct {
+		name     string
+		workload Workload
+		request  Request
+		expected string
+	}{
+		{
+			name:     "allowed by principal, method and path",
+			workload: httpbin,
+			request:  Request{SourcePrincipal: "cluster.local/ns/foo/sa/sleep", Method: "GET", Path: "/ip/v4"},
+			expected: "ALLOWED by policy ns[foo]-policy[httpbin]-rule[0]",
+		},
+		{
+			name:     "denied method",
+			workload: httpbin,
+			request:  Request{SourcePrincipal: "cluster.local/ns/foo/sa/sleep", Method: "POST", Path: "/headers"},
+			expected: "DENIED: no policy matched",
+		},
+		{
+			name:     "allowed by namespace and port",
+			workload: httpbin,
+			request:  Request{SourcePrincipal: "cluster.local/ns/bar/sa/default", DestinationPort: 8000},
+			expected: "ALLOWED by policy ns[foo]-policy[httpbin]-rule[1]",
+		},
+		{
+			name:     "allowed by namespace over TCP",
+			workload: httpbin,
+			request:  Request{SourcePrincipal: "cluster.local/ns/bar/sa/default", DestinationPort: 8000, TCP: true},
+			expected: "ALLOWED by policy ns[foo]-policy[httpbin]-rule[1]",
+		},
+		{
+			name:     "denied plain text",
+			workload: httpbin,
+			request:  Request{DestinationPort: 8000},
+			expected: "DENIED: no policy matched",
+		},
+		{
+			name:     "allowed by JWT",
+			workload: httpbin,
+			request: Request{
+				RequestPrincipal: "issuer.example.com/alice",
+				Claims:           map[string][]string{"groups": {"dev", "admin"}},
+				Headers:          map[string]string{"X-Token": "abc"},
+			},
+			expected: "ALLOWED by policy ns[foo]-policy[httpbin]-rule[2]",
+		},
+		{
+			name:     "denied claim",
+			workload: httpbin,
+			request: Request{
+				RequestPrincipal: "issuer.example.com/alice",
+				Claims:           map[string][]string{"groups": {"dev"}},
+				Headers:          map[string]string{"x-token": "abc"},
+			},
+			expected: "DENIED: no policy matched",
+		},
+		{
+			name:     "no policy for the workload",
+			workload: Workload{Namespace: "foo", Labels: map[string]string{"app": "sleep"}},
+			request:  Request{Method: "GET"},
+			expected: "ALLOWED: no authorization policy applies to the workload",
+		},
+		{
+			name:     "allowed by service role",
+			workload: productpage,
+			request:  Request{SourcePrincipal: "cluster.local/ns/default/sa/reviews", Method: "GET"},
+			expected: "ALLOWED by policy viewer",
+		},
+		{
+			name:     "denied by service role",
+			workload: productpage,
+			request:  Request{SourcePrincipal: "cluster.local/ns/default/sa/ratings", Method: "GET"},
+			expected: "DENIED: no policy matched",
+		},
+	}
+
+	s, err := NewSimulator([]string{
+		"./testdata/simulator/authorization-policy.yaml",
+		"./testdata/simulator/rbac-policy.yaml",
+	}, "cluster.local", "cluster.local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, err := s.Simulate(c.workload, c.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.String() != c.expected {
+				t.Errorf("Expected %q, got %q", c.expected, d.String())
+			}
+		})
+	}
+}
+
+func TestSimulateTrustDomain(t *testing.T) {
+	s, err := NewSimulator([]string{"./testdata/simulator/rbac-policy.yaml"}, "cluster.local", "example.com",
+		[]string{"cluster.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	productpage := Workload{Namespace: "default", Labels: map[string]string{"app": "productpage"}, Service: "productpage"}
+	d, err := s.Simulate(productpage, Request{SourcePrincipal: "example.com/ns/default/sa/reviews", Method: "GET"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "ALLOWED by policy viewer"; d.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, d.String())
+	}
+}
+
+func TestNewSimulatorNoFile(t *testing.T) {
+	if _, err := NewSimulator(nil, "cluster.local", "cluster.local", nil); err == nil {
+		t.Errorf("Expected an error without policy file")
+	}
+}
+
+func TestSimulateDryRun(t *testing.T) {
+	s, err := NewSimulator([]string{"./testdata/simulator/dry-run-policy.yaml"}, "cluster.local", "cluster.local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := s.Simulate(Workload{Namespace: "foo", Labels: map[string]string{"app": "httpbin"}}, Request{Method: "POST"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "ALLOWED: all the policies of the workload are in dry-run mode"; d.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, d.String())
+	}
+	if d.Shadow == nil || d.Shadow.String() != "DENIED: no policy matched" {
+		t.Errorf("Expected the request to be denied by the shadow rules, got %v", d.Shadow)
+	}
+}
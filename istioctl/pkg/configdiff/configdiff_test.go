@@ -0,0 +1,185 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdiff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+const baseConfig = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: reviews
+spec:
+  selector:
+    app: reviews
+  ports:
+  - name: http
+    port: 9080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: ratings
+spec:
+  selector:
+    app: ratings
+  ports:
+  - name: http
+    port: 9080
+`
+
+const virtualService = `
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: VirtualService
+metadata:
+  name: ratings
+spec:
+  hosts:
+  - ratings
+  http:
+  - timeout: 5s
+    route:
+    - destination:
+        host: ratings
+`
+
+const serviceEntry = `
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: google
+spec:
+  hosts:
+  - www.google.com
+  ports:
+  - number: 443
+    name: tls
+    protocol: TLS
+  resolution: DNS
+`
+
+var reviews = Proxy{
+	Type:           model.SidecarProxy,
+	Namespace:      "default",
+	Labels:         map[string]string{"app": "reviews"},
+	ServiceAccount: "reviews",
+}
+
+func generate(t *testing.T, input string) *EnvoyConfig {
+	t.Helper()
+	m := mesh.DefaultMeshConfig()
+	out, err := Generate(input, "default", &m, reviews)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGenerate(t *testing.T) {
+	config := generate(t, baseConfig)
+
+	inbound := false
+	for _, c := range config.Clusters {
+		if c.Name == "inbound|9080|http|reviews.default.svc.cluster.local" {
+			inbound = true
+		}
+	}
+	if !inbound {
+		t.Errorf("Expected an inbound cluster for the Service selecting the proxy")
+	}
+
+	names := routeNames(config.Listeners)
+	if len(names) != 1 || names[0] != "9080" {
+		t.Errorf("Expected the route 9080, got %v", names)
+	}
+	if len(config.Routes) != 1 || config.Routes[0].Name != "9080" {
+		t.Errorf("Expected the routes to be generated, got %v", config.Routes)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name     string
+		after    string
+		differ   bool
+		expected []string
+	}{
+		{
+			name:     "no change",
+			after:    baseConfig,
+			expected: []string{},
+		},
+		{
+			name:   "virtual service",
+			after:  baseConfig + virtualService,
+			differ: true,
+			expected: []string{
+				"Routes:\n~ 9080\n",
+				"--- before\n+++ after\n",
+				`"timeout": "5s"`,
+			},
+		},
+		{
+			name:   "service entry",
+			after:  baseConfig + serviceEntry,
+			differ: true,
+			expected: []string{
+				"Clusters:\n+ outbound|443||www.google.com\n",
+				"Listeners:\n",
+			},
+		},
+	}
+	before := generate(t, baseConfig)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			differ, err := Diff(&out, before, generate(t, c.after), 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if differ != c.differ {
+				t.Errorf("Expected differ to be %v, got %v:\n%s", c.differ, differ, out.String())
+			}
+			if !c.differ && out.Len() != 0 {
+				t.Errorf("Expected no output, got:\n%s", out.String())
+			}
+			for _, e := range c.expected {
+				if !strings.Contains(out.String(), e) {
+					t.Errorf("Expected %q in the output:\n%s", e, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDiffRemoved(t *testing.T) {
+	var out bytes.Buffer
+	differ, err := Diff(&out, generate(t, baseConfig+serviceEntry), generate(t, baseConfig), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !differ || !strings.Contains(out.String(), "- outbound|443||www.google.com\n") {
+		t.Errorf("Expected the cluster to be removed, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdiff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff writes the differences between the listeners, clusters and routes of two configs, and returns
+// whether they differ. Resources are matched by name: added and removed resources are listed, and
+// changed resources are shown as a unified diff of their JSON, with context lines around each change.
+func Diff(w io.Writer, before, after *EnvoyConfig, context int) (bool, error) {
+	differ := false
+	for _, kind := range []struct {
+		name          string
+		before, after map[string]proto.Message
+	}{
+		{"Listeners", listenersByName(before), listenersByName(after)},
+		{"Clusters", clustersByName(before), clustersByName(after)},
+		{"Routes", routesByName(before), routesByName(after)},
+	} {
+		d, err := diffResources(w, kind.name, kind.before, kind.after, context)
+		if err != nil {
+			return false, err
+		}
+		differ = differ || d
+	}
+	return differ, nil
+}
+
+func listenersByName(c *EnvoyConfig) map[string]proto.Message {
+	out := make(map[string]proto.Message, len(c.Listeners))
+	for _, l := range c.Listeners {
+		out[l.Name] = l
+	}
+	return out
+}
+
+func clustersByName(c *EnvoyConfig) map[string]proto.Message {
+	out := make(map[string]proto.Message, len(c.Clusters))
+	for _, cl := range c.Clusters {
+		out[cl.Name] = cl
+	}
+	return out
+}
+
+func routesByName(c *EnvoyConfig) map[string]proto.Message {
+	out := make(map[string]proto.Message, len(c.Routes))
+	for _, r := range c.Routes {
+		out[r.Name] = r
+	}
+	return out
+}
+
+func diffResources(w io.Writer, kind string, before, after map[string]proto.Message, context int) (bool, error) {
+	names := make([]string, 0, len(before)+len(after))
+	for n := range before {
+		names = append(names, n)
+	}
+	for n := range after {
+		if _, f := before[n]; !f {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	jsonm := &jsonpb.Marshaler{Indent: "  "}
+	header := false
+	for _, n := range names {
+		b, inBefore := before[n]
+		a, inAfter := after[n]
+		if inBefore && inAfter && proto.Equal(b, a) {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "%s:\n", kind)
+			header = true
+		}
+		switch {
+		case !inBefore:
+			fmt.Fprintf(w, "+ %s\n", n)
+		case !inAfter:
+			fmt.Fprintf(w, "- %s\n", n)
+		default:
+			fmt.Fprintf(w, "~ %s\n", n)
+			bJSON, err := jsonm.MarshalToString(b)
+			if err != nil {
+				return false, err
+			}
+			aJSON, err := jsonm.MarshalToString(a)
+			if err != nil {
+				return false, err
+			}
+			text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				FromFile: "before",
+				A:        difflib.SplitLines(bJSON + "\n"),
+				ToFile:   "after",
+				B:        difflib.SplitLines(aJSON + "\n"),
+				Context:  context,
+			})
+			if err != nil {
+				return false, err
+			}
+			fmt.Fprint(w, text)
+		}
+	}
+	if header {
+		fmt.Fprintln(w)
+	}
+	return header, nil
+}
@@ -0,0 +1,328 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	hcm_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/conversion"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes"
+	coreV1 "k8s.io/api/core/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pilot/pkg/serviceregistry/external"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+// domainSuffix is the domain suffix of the Kubernetes Services.
+const domainSuffix = "cluster.local"
+
+// plugins are the plugins Pilot uses by default to generate the config.
+var plugins = []string{
+	plugin.Authn,
+	plugin.Authz,
+	plugin.Health,
+	plugin.Mixer,
+}
+
+// Proxy is the identity of the proxy the config is generated for.
+type Proxy struct {
+	// Type is the type of the proxy, sidecar or router.
+	Type model.NodeType
+	// Namespace of the proxy.
+	Namespace string
+	// Labels of the proxy pod.
+	Labels map[string]string
+	// ServiceAccount of the proxy pod.
+	ServiceAccount string
+	// IP of the proxy pod.
+	IP string
+}
+
+// EnvoyConfig is the config generated by Pilot for a proxy.
+type EnvoyConfig struct {
+	Listeners []*xdsapi.Listener
+	Clusters  []*xdsapi.Cluster
+	Routes    []*xdsapi.RouteConfiguration
+}
+
+// Generate runs the Pilot config generator in-process for a proxy, against the Istio config and Kubernetes
+// Services of a YAML input. Resources without a namespace are put in defaultNamespace.
+func Generate(input string, defaultNamespace string, mesh *meshconfig.MeshConfig, p Proxy) (*EnvoyConfig, error) {
+	configs, others, err := crd.ParseInputs(input)
+	if err != nil {
+		return nil, err
+	}
+
+	store := memory.Make(schemas.Istio)
+	for _, cfg := range configs {
+		if cfg.Namespace == "" {
+			cfg.Namespace = defaultNamespace
+		}
+		// Short host names are resolved as in Kubernetes.
+		cfg.Domain = domainSuffix
+		if _, err := store.Create(cfg); err != nil {
+			return nil, fmt.Errorf("failed to add %s %s/%s: %v", cfg.Type, cfg.Namespace, cfg.Name, err)
+		}
+	}
+	istioStore := model.MakeIstioStore(store)
+
+	services, err := kubeServices(others, defaultNamespace)
+	if err != nil {
+		return nil, err
+	}
+	registry := aggregate.NewController()
+	registry.AddRegistry(aggregate.Registry{
+		Name:             serviceregistry.KubernetesRegistry,
+		ServiceDiscovery: services,
+		Controller:       services,
+	})
+	serviceEntries := external.NewServiceDiscovery(nil, istioStore)
+	registry.AddRegistry(aggregate.Registry{
+		Name:             serviceregistry.MCPRegistry,
+		ServiceDiscovery: serviceEntries,
+		Controller:       serviceEntries,
+	})
+
+	env := &model.Environment{
+		ServiceDiscovery: registry,
+		IstioConfigStore: istioStore,
+		Mesh:             mesh,
+		MeshNetworks:     &meshconfig.MeshNetworks{},
+	}
+	env.PushContext = model.NewPushContext()
+	if err := env.PushContext.InitContext(env, nil, nil); err != nil {
+		return nil, err
+	}
+
+	node, err := newNode(env, p)
+	if err != nil {
+		return nil, err
+	}
+
+	generator := core.NewConfigGenerator(plugins)
+	out := &EnvoyConfig{
+		Listeners: generator.BuildListeners(env, node, env.PushContext),
+		Clusters:  generator.BuildClusters(env, node, env.PushContext),
+	}
+	out.Routes = generator.BuildHTTPRoutes(env, node, env.PushContext, routeNames(out.Listeners))
+	return out, nil
+}
+
+func newNode(env *model.Environment, p Proxy) (*model.Proxy, error) {
+	ip := p.IP
+	if ip == "" {
+		ip = "10.0.0.1"
+	}
+	node := &model.Proxy{
+		Type:            p.Type,
+		IPAddresses:     []string{ip},
+		ID:              fmt.Sprintf("simulated.%s", p.Namespace),
+		DNSDomain:       p.Namespace + ".svc." + domainSuffix,
+		ConfigNamespace: p.Namespace,
+		Metadata: &model.NodeMetadata{
+			Labels:         p.Labels,
+			Namespace:      p.Namespace,
+			ServiceAccount: p.ServiceAccount,
+		},
+		IstioVersion: model.MaxIstioVersion,
+	}
+	if err := node.SetServiceInstances(env); err != nil {
+		return nil, err
+	}
+	if err := node.SetWorkloadLabels(env); err != nil {
+		return nil, err
+	}
+	node.SetSidecarScope(env.PushContext)
+	node.SetGatewaysForProxy(env.PushContext)
+	return node, nil
+}
+
+// routeNames returns the names of the routes referenced by the HTTP connection managers of listeners.
+func routeNames(listeners []*xdsapi.Listener) []string {
+	names := make(map[string]bool)
+	for _, l := range listeners {
+		for _, fc := range l.FilterChains {
+			for _, f := range fc.Filters {
+				if f.Name != wellknown.HTTPConnectionManager {
+					continue
+				}
+				cm := &hcm_filter.HttpConnectionManager{}
+				if err := filterConfig(f, cm); err != nil {
+					continue
+				}
+				if rds := cm.GetRds(); rds != nil {
+					names[rds.RouteConfigName] = true
+				}
+			}
+		}
+	}
+	out := make([]string, 0, len(names))
+	for n := range names {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func filterConfig(filter *listener.Filter, out *hcm_filter.HttpConnectionManager) error {
+	switch c := filter.ConfigType.(type) {
+	case *listener.Filter_Config:
+		return conversion.StructToMessage(c.Config, out)
+	case *listener.Filter_TypedConfig:
+		return ptypes.UnmarshalAny(c.TypedConfig, out)
+	}
+	return nil
+}
+
+// kubeServices converts the Kubernetes Services of the input.
+func kubeServices(objects []crd.IstioKind, defaultNamespace string) (*serviceDiscovery, error) {
+	sd := &serviceDiscovery{}
+	for _, obj := range objects {
+		if obj.Kind != "Service" {
+			continue
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		svc := coreV1.Service{}
+		if err := json.Unmarshal(b, &svc); err != nil {
+			return nil, fmt.Errorf("invalid Service %s: %v", obj.Name, err)
+		}
+		if svc.Namespace == "" {
+			svc.Namespace = defaultNamespace
+		}
+		sd.services = append(sd.services, svc)
+	}
+	return sd, nil
+}
+
+// serviceDiscovery is a static registry of Kubernetes Services. The only endpoint it knows is the proxy the
+// config is generated for, when it is selected by a Service.
+type serviceDiscovery struct {
+	services []coreV1.Service
+}
+
+// Services implements model.ServiceDiscovery
+func (sd *serviceDiscovery) Services() ([]*model.Service, error) {
+	out := make([]*model.Service, 0, len(sd.services))
+	for _, svc := range sd.services {
+		out = append(out, kube.ConvertService(svc, domainSuffix, ""))
+	}
+	return out, nil
+}
+
+// GetService implements model.ServiceDiscovery
+func (sd *serviceDiscovery) GetService(hostname host.Name) (*model.Service, error) {
+	services, _ := sd.Services()
+	for _, s := range services {
+		if s.Hostname == hostname {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+// InstancesByPort implements model.ServiceDiscovery
+func (sd *serviceDiscovery) InstancesByPort(*model.Service, int, labels.Collection) ([]*model.ServiceInstance, error) {
+	return nil, nil
+}
+
+// GetProxyServiceInstances implements model.ServiceDiscovery. It returns an instance of each port of the
+// Services selecting the proxy.
+func (sd *serviceDiscovery) GetProxyServiceInstances(node *model.Proxy) ([]*model.ServiceInstance, error) {
+	out := make([]*model.ServiceInstance, 0)
+	if node.Metadata == nil || len(node.IPAddresses) == 0 {
+		return out, nil
+	}
+	for _, svc := range sd.services {
+		if svc.Namespace != node.ConfigNamespace || len(svc.Spec.Selector) == 0 ||
+			!labels.Instance(svc.Spec.Selector).SubsetOf(node.Metadata.Labels) {
+			continue
+		}
+		service := kube.ConvertService(svc, domainSuffix, "")
+		for i, port := range svc.Spec.Ports {
+			targetPort := int(port.Port)
+			if port.TargetPort.IntValue() > 0 {
+				targetPort = port.TargetPort.IntValue()
+			}
+			out = append(out, &model.ServiceInstance{
+				Service: service,
+				Endpoint: model.NetworkEndpoint{
+					Address:     node.IPAddresses[0],
+					Port:        targetPort,
+					ServicePort: service.Ports[i],
+				},
+				Labels:         node.Metadata.Labels,
+				ServiceAccount: node.Metadata.ServiceAccount,
+			})
+		}
+	}
+	return out, nil
+}
+
+// GetProxyWorkloadLabels implements model.ServiceDiscovery
+func (sd *serviceDiscovery) GetProxyWorkloadLabels(node *model.Proxy) (labels.Collection, error) {
+	if node.Metadata == nil || len(node.Metadata.Labels) == 0 {
+		return nil, nil
+	}
+	return labels.Collection{node.Metadata.Labels}, nil
+}
+
+// ManagementPorts implements model.ServiceDiscovery
+func (sd *serviceDiscovery) ManagementPorts(string) model.PortList {
+	return nil
+}
+
+// WorkloadHealthCheckInfo implements model.ServiceDiscovery
+func (sd *serviceDiscovery) WorkloadHealthCheckInfo(string) model.ProbeList {
+	return nil
+}
+
+// GetIstioServiceAccounts implements model.ServiceDiscovery
+func (sd *serviceDiscovery) GetIstioServiceAccounts(*model.Service, []int) []string {
+	return nil
+}
+
+// AppendServiceHandler implements model.Controller
+func (sd *serviceDiscovery) AppendServiceHandler(func(*model.Service, model.Event)) error {
+	return nil
+}
+
+// AppendInstanceHandler implements model.Controller
+func (sd *serviceDiscovery) AppendInstanceHandler(func(*model.ServiceInstance, model.Event)) error {
+	return nil
+}
+
+// Run implements model.Controller
+func (sd *serviceDiscovery) Run(<-chan struct{}) {}
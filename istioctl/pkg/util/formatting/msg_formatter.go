@@ -0,0 +1,89 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// Formatting options for Messages
+const (
+	LogFormat   = "log"
+	JSONFormat  = "json"
+	YAMLFormat  = "yaml"
+	SARIFFormat = "sarif"
+)
+
+var (
+	// MsgOutputFormatKeys lists the supported output formats, in the order they should be displayed.
+	MsgOutputFormatKeys = []string{LogFormat, JSONFormat, YAMLFormat, SARIFFormat}
+
+	// MsgOutputFormats is a set of the supported output formats.
+	MsgOutputFormats = make(map[string]bool)
+)
+
+func init() {
+	for _, key := range MsgOutputFormatKeys {
+		MsgOutputFormats[key] = true
+	}
+}
+
+// Print returns a string representation of the specified messages in the given format.
+func Print(ms diag.Messages, format string) (string, error) {
+	switch format {
+	case LogFormat:
+		return printLog(ms), nil
+	case JSONFormat:
+		return printJSON(ms)
+	case YAMLFormat:
+		return printYAML(ms)
+	case SARIFFormat:
+		return printSARIF(ms)
+	default:
+		return "", fmt.Errorf("invalid format, expected one of %v but got %q", MsgOutputFormatKeys, format)
+	}
+}
+
+func printLog(ms diag.Messages) string {
+	var logOutput []string
+	for _, m := range ms {
+		logOutput = append(logOutput, m.String())
+	}
+	return strings.Join(logOutput, "\n")
+}
+
+func printJSON(ms diag.Messages) (string, error) {
+	jsonOutput, err := json.MarshalIndent(unstructured(ms), "", "\t")
+	return string(jsonOutput), err
+}
+
+func printYAML(ms diag.Messages) (string, error) {
+	yamlOutput, err := yaml.Marshal(unstructured(ms))
+	return string(yamlOutput), err
+}
+
+func unstructured(ms diag.Messages) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(ms))
+	for _, m := range ms {
+		result = append(result, m.Unstructured(true))
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+type testOrigin string
+
+func (o testOrigin) FriendlyName() string {
+	return string(o)
+}
+
+func (o testOrigin) Namespace() string {
+	return ""
+}
+
+func (o testOrigin) Reference() resource.Reference {
+	return &resource.Position{Filename: "toppings.yaml", Line: 4, Column: 1, EndLine: 9, EndColumn: 12}
+}
+
+var (
+	errorType   = diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v")
+	warningType = diag.NewMessageType(diag.Warning, "A1", "Template: %v")
+)
+
+func testMessages() diag.Messages {
+	return diag.Messages{
+		diag.NewMessage(errorType, testOrigin("toppings/cheese"), "the bubble is too big"),
+		diag.NewMessage(warningType, nil, "oops"),
+	}
+}
+
+func TestFormatter_PrintLog(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output, err := Print(testMessages(), LogFormat)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(output).To(Equal(
+		"Error [B1](toppings/cheese toppings.yaml:4:1) Explosion accident: the bubble is too big\n" +
+			"Warn [A1] Template: oops"))
+}
+
+func TestFormatter_PrintJSON(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output, err := Print(testMessages(), JSONFormat)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var parsed []map[string]interface{}
+	g.Expect(json.Unmarshal([]byte(output), &parsed)).To(Succeed())
+	g.Expect(parsed).To(HaveLen(2))
+	g.Expect(parsed[0]).To(HaveKeyWithValue("code", "B1"))
+	g.Expect(parsed[0]).To(HaveKeyWithValue("level", "Error"))
+	g.Expect(parsed[0]).To(HaveKeyWithValue("origin", "toppings/cheese"))
+	g.Expect(parsed[1]).NotTo(HaveKey("origin"))
+}
+
+func TestFormatter_PrintYAML(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output, err := Print(testMessages(), YAMLFormat)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(output).To(ContainSubstring("code: B1"))
+	g.Expect(output).To(ContainSubstring("message: 'Template: oops'"))
+}
+
+func TestFormatter_PrintJSONEmpty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output, err := Print(diag.Messages{}, JSONFormat)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(output).To(Equal("[]"))
+}
+
+func TestFormatter_PrintSARIF(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output, err := Print(testMessages(), SARIFFormat)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var parsed sarifLog
+	g.Expect(json.Unmarshal([]byte(output), &parsed)).To(Succeed())
+	g.Expect(parsed.Version).To(Equal(sarifVersion))
+	g.Expect(parsed.Runs).To(HaveLen(1))
+
+	run := parsed.Runs[0]
+	g.Expect(run.Tool.Driver.Rules).To(HaveLen(2))
+	g.Expect(run.Results).To(HaveLen(2))
+	g.Expect(run.Results[0].RuleID).To(Equal("B1"))
+	g.Expect(run.Results[0].Level).To(Equal("error"))
+	g.Expect(run.Results[0].Message.Text).To(Equal("Explosion accident: the bubble is too big"))
+	g.Expect(run.Results[0].Locations[0].LogicalLocations[0].FullyQualifiedName).To(Equal("toppings/cheese"))
+	g.Expect(run.Results[0].Locations[0].PhysicalLocation).To(Equal(&sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "toppings.yaml"},
+		Region:           &sarifRegion{StartLine: 4, StartColumn: 1, EndLine: 9, EndColumn: 13},
+	}))
+	g.Expect(run.Results[1].Level).To(Equal("warning"))
+	g.Expect(run.Results[1].Locations).To(BeEmpty())
+}
+
+func TestFormatter_PrintInvalidFormat(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := Print(testMessages(), "not-a-format")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,174 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// The types below are a minimal subset of the SARIF 2.1.0 object model
+// (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html), sufficient to report analysis messages to
+// tools that consume static analysis results.
+
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json"
+	sarifToolName = "istioctl"
+	sarifToolURI  = "https://istio.io"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func printSARIF(ms diag.Messages) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           sarifToolName,
+				InformationURI: sarifToolURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	seenRules := make(map[string]bool)
+	for _, m := range ms {
+		code := m.Type.Code()
+		if !seenRules[code] {
+			seenRules[code] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               code,
+				ShortDescription: sarifMessage{Text: m.Type.Template()},
+			})
+		}
+
+		r := sarifResult{
+			RuleID:  code,
+			Level:   sarifLevel(m.Type.Level()),
+			Message: sarifMessage{Text: fmt.Sprintf(m.Type.Template(), m.Parameters...)},
+		}
+		if m.Origin != nil {
+			r.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysical(m.Origin.Reference()),
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: m.Origin.FriendlyName()}},
+			}}
+		}
+		run.Results = append(run.Results, r)
+	}
+
+	l := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	}
+	out, err := json.MarshalIndent(l, "", "\t")
+	return string(out), err
+}
+
+func sarifPhysical(ref resource.Reference) *sarifPhysicalLocation {
+	p, ok := ref.(*resource.Position)
+	if !ok || p.Filename == "" {
+		return nil
+	}
+
+	l := &sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: p.Filename},
+	}
+	if p.Line > 0 {
+		// The SARIF end column is exclusive, whereas the position's is inclusive.
+		l.Region = &sarifRegion{
+			StartLine:   p.Line,
+			StartColumn: p.Column,
+			EndLine:     p.EndLine,
+			EndColumn:   p.EndColumn + 1,
+		}
+	}
+	return l
+}
+
+func sarifLevel(l diag.Level) string {
+	switch l {
+	case diag.Error:
+		return "error"
+	case diag.Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -29,6 +29,9 @@ import (
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+	grpcHealth "google.golang.org/grpc/health/grpc_health_v1"
+
 	"istio.io/istio/pilot/pkg/model"
 
 	"istio.io/istio/pilot/cmd/pilot-agent/status/ready"
@@ -47,8 +50,13 @@ const (
 	// The json encoded string to pass app HTTP probe information from injector(istioctl or webhook).
 	// For example, ISTIO_KUBE_APP_PROBERS='{"/app-health/httpbin/livez":{"path": "/hello", "port": 8080}.
 	// indicates that httpbin container liveness prober port is 8080 and probing path is /hello.
+	// TCP and gRPC probers are wrapped, e.g. '{"/app-health/grpc/readyz":{"grpc": {"port": 9090}}}'.
 	// This environment variable should never be set manually.
 	KubeAppProberEnvName = "ISTIO_KUBE_APP_PROBERS"
+
+	// appProbeTimeout is the timeout of the requests sent to the application.
+	// TODO: figure out the appropriate timeout?
+	appProbeTimeout = 10 * time.Second
 )
 
 var (
@@ -59,7 +67,48 @@ var (
 // It's a map from the prober URL path to the Kubernetes Prober config.
 // For example, "/app-health/hello-world/livez" entry contains livenss prober config for
 // container "hello-world".
-type KubeAppProbers map[string]*corev1.HTTPGetAction
+type KubeAppProbers map[string]*Prober
+
+// Prober is the config of an application probe. Exactly one of its actions is set.
+type Prober struct {
+	HTTPGet   *corev1.HTTPGetAction   `json:"httpGet,omitempty"`
+	TCPSocket *corev1.TCPSocketAction `json:"tcpSocket,omitempty"`
+	GRPC      *GRPCAction             `json:"grpc,omitempty"`
+}
+
+// GRPCAction describes a health check of the grpc.health.v1 health checking protocol.
+type GRPCAction struct {
+	Port int `json:"port"`
+	// Service is the name of the service to check, or empty to check the overall health of the server.
+	Service string `json:"service,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. HTTP probers are encoded as their HTTPGetAction, which is the format
+// understood by all the versions of the agent.
+func (p *Prober) MarshalJSON() ([]byte, error) {
+	if p.HTTPGet != nil && p.TCPSocket == nil && p.GRPC == nil {
+		return json.Marshal(p.HTTPGet)
+	}
+	type prober Prober
+	return json.Marshal((*prober)(p))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Prober) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	_, isHTTP := fields["httpGet"]
+	_, isTCP := fields["tcpSocket"]
+	_, isGRPC := fields["grpc"]
+	if !isHTTP && !isTCP && !isGRPC {
+		p.HTTPGet = &corev1.HTTPGetAction{}
+		return json.Unmarshal(b, p.HTTPGet)
+	}
+	type prober Prober
+	return json.Unmarshal(b, (*prober)(p))
+}
 
 // Config for the status server.
 type Config struct {
@@ -103,13 +152,35 @@ func NewServer(config Config) (*Server, error) {
 		if !appProberPattern.Match([]byte(path)) {
 			return nil, fmt.Errorf(`invalid key, must be in form of regex pattern ^/app-health/[^\/]+/(livez|readyz)$`)
 		}
-		if prober.Port.Type != intstr.Int {
-			return nil, fmt.Errorf("invalid prober config for %v, the port must be int type", path)
+		if err := validateProber(prober); err != nil {
+			return nil, fmt.Errorf("invalid prober config for %v, %v", path, err)
 		}
 	}
 	return s, nil
 }
 
+func validateProber(prober *Prober) error {
+	switch {
+	case prober == nil:
+		return fmt.Errorf("the prober must not be empty")
+	case prober.HTTPGet != nil && prober.TCPSocket == nil && prober.GRPC == nil:
+		if prober.HTTPGet.Port.Type != intstr.Int {
+			return fmt.Errorf("the port must be int type")
+		}
+	case prober.TCPSocket != nil && prober.HTTPGet == nil && prober.GRPC == nil:
+		if prober.TCPSocket.Port.Type != intstr.Int {
+			return fmt.Errorf("the port must be int type")
+		}
+	case prober.GRPC != nil && prober.HTTPGet == nil && prober.TCPSocket == nil:
+		if prober.GRPC.Port <= 0 {
+			return fmt.Errorf("the port must be set")
+		}
+	default:
+		return fmt.Errorf("exactly one of httpGet, tcpSocket and grpc must be set")
+	}
+	return nil
+}
+
 // FormatProberURL returns a pair of HTTP URLs that pilot agent will serve to take over Kubernetes
 // app probers.
 func FormatProberURL(container string) (string, string) {
@@ -216,10 +287,20 @@ func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	switch {
+	case prober.TCPSocket != nil:
+		s.handleAppTCPProbe(w, path, prober.TCPSocket)
+	case prober.GRPC != nil:
+		s.handleAppGRPCProbe(w, req, path, prober.GRPC)
+	default:
+		s.handleAppHTTPProbe(w, req, path, prober.HTTPGet)
+	}
+}
+
+func (s *Server) handleAppHTTPProbe(w http.ResponseWriter, req *http.Request, path string, prober *corev1.HTTPGetAction) {
 	// Construct a request sent to the application.
 	httpClient := &http.Client{
-		// TODO: figure out the appropriate timeout?
-		Timeout: 10 * time.Second,
+		Timeout: appProbeTimeout,
 		// We skip the verification since kubelet skips the verification for HTTPS prober as well
 		// https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-probes/#configure-probes
 		Transport: &http.Transport{
@@ -259,6 +340,47 @@ func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(response.StatusCode)
 }
 
+// handleAppTCPProbe succeeds if a connection can be opened to the application port, like the kubelet TCP probe.
+func (s *Server) handleAppTCPProbe(w http.ResponseWriter, path string, prober *corev1.TCPSocketAction) {
+	addr := net.JoinHostPort("localhost", strconv.Itoa(prober.Port.IntValue()))
+	conn, err := net.DialTimeout("tcp", addr, appProbeTimeout)
+	if err != nil {
+		log.Errorf("Connection to probe app failed: %v, original URL path = %v\napp address = %v", err, path, addr)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_ = conn.Close()
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleAppGRPCProbe succeeds if the application reports that it is serving through the gRPC health
+// checking protocol, like grpc_health_probe.
+func (s *Server) handleAppGRPCProbe(w http.ResponseWriter, req *http.Request, path string, prober *GRPCAction) {
+	ctx, cancel := context.WithTimeout(req.Context(), appProbeTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort("localhost", strconv.Itoa(prober.Port))
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock(), grpc.FailOnNonTempDialError(true))
+	if err != nil {
+		log.Errorf("Connection to probe app failed: %v, original URL path = %v\napp address = %v", err, path, addr)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	resp, err := grpcHealth.NewHealthClient(conn).Check(ctx, &grpcHealth.HealthCheckRequest{Service: prober.Service})
+	if err != nil {
+		log.Errorf("Request to probe app failed: %v, original URL path = %v\napp service = %q", err, path, prober.Service)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if resp.Status != grpcHealth.HealthCheckResponse_SERVING {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // notifyExit sends SIGTERM to itself
 func notifyExit() {
 	p, err := os.FindProcess(os.Getpid())
@@ -16,6 +16,7 @@ package status
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -27,6 +28,10 @@ import (
 	"testing"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	grpcHealth "google.golang.org/grpc/health/grpc_health_v1"
+
 	"istio.io/istio/pkg/test/util/retry"
 
 	"istio.io/istio/pkg/test/env"
@@ -76,6 +81,27 @@ func TestNewServer(t *testing.T) {
 		{
 			httpProbe: `{}`,
 		},
+		// A valid input with TCP and gRPC probers.
+		{
+			httpProbe: `{"/app-health/hello-world/readyz": {"tcpSocket": {"port": 8080}},` +
+				`"/app-health/hello-world/livez": {"grpc": {"port": 9090, "service": "hello"}},` +
+				`"/app-health/business/livez": {"httpGet": {"path": "/buisiness/live", "port": 9090}}}`,
+		},
+		// TCP port is not Int typed.
+		{
+			httpProbe: `{"/app-health/hello-world/readyz": {"tcpSocket": {"port": "container-port-dontknow"}}}`,
+			err:       "must be int type",
+		},
+		// gRPC port is not set.
+		{
+			httpProbe: `{"/app-health/hello-world/readyz": {"grpc": {"service": "hello"}}}`,
+			err:       "port must be set",
+		},
+		// Multiple probers.
+		{
+			httpProbe: `{"/app-health/hello-world/readyz": {"tcpSocket": {"port": 8080}, "grpc": {"port": 8080}}}`,
+			err:       "exactly one",
+		},
 	}
 	for _, tc := range testCases {
 		_, err := NewServer(Config{
@@ -231,6 +257,115 @@ func TestHttpsAppProbe(t *testing.T) {
 	}
 }
 
+func TestTCPAndGRPCAppProbe(t *testing.T) {
+	// Starts the application first, serving gRPC health checks.
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to allocate unused port %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("serving", grpcHealth.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("not-serving", grpcHealth.HealthCheckResponse_NOT_SERVING)
+	grpcHealth.RegisterHealthServer(grpcServer, healthServer)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+	appPort := listener.Addr().(*net.TCPAddr).Port
+
+	// A port nothing listens on.
+	closed, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to allocate unused port %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	// Starts the pilot agent status server.
+	server, err := NewServer(Config{
+		StatusPort: 0,
+		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/tcp/readyz": {"tcpSocket": {"port": %v}},
+"/app-health/tcp/livez": {"tcpSocket": {"port": %v}},
+"/app-health/grpc/readyz": {"grpc": {"port": %v, "service": "serving"}},
+"/app-health/grpc/livez": {"grpc": {"port": %v, "service": "not-serving"}},
+"/app-health/grpc-closed/livez": {"grpc": {"port": %v}}}`, appPort, closedPort, appPort, appPort, closedPort),
+	})
+	if err != nil {
+		t.Fatalf("failed to create status server %v", err)
+	}
+	go server.Run(context.Background())
+
+	var statusPort uint16
+	if err := retry.UntilSuccess(func() error {
+		server.mutex.RLock()
+		statusPort = server.statusPort
+		server.mutex.RUnlock()
+		if statusPort == 0 {
+			return fmt.Errorf("no port allocated")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to getport: %v", err)
+	}
+	testCases := []struct {
+		probePath  string
+		statusCode int
+	}{
+		{
+			probePath:  "/app-health/tcp/readyz",
+			statusCode: http.StatusOK,
+		},
+		{
+			probePath:  "/app-health/tcp/livez",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			probePath:  "/app-health/grpc/readyz",
+			statusCode: http.StatusOK,
+		},
+		{
+			probePath:  "/app-health/grpc/livez",
+			statusCode: http.StatusServiceUnavailable,
+		},
+		{
+			probePath:  "/app-health/grpc-closed/livez",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%v%s", statusPort, tc.probePath))
+		if err != nil {
+			t.Fatalf("[%v] request failed: %v", tc.probePath, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.statusCode {
+			t.Errorf("[%v] unexpected status code, want = %v, got = %v", tc.probePath, tc.statusCode, resp.StatusCode)
+		}
+	}
+}
+
+func TestProberJSON(t *testing.T) {
+	probers := KubeAppProbers{}
+	in := `{"/app-health/http/livez":{"path":"/live","port":8080},"/app-health/tcp/livez":{"tcpSocket":{"port":8080}}}`
+	if err := json.Unmarshal([]byte(in), &probers); err != nil {
+		t.Fatal(err)
+	}
+	if h := probers["/app-health/http/livez"].HTTPGet; h == nil || h.Path != "/live" || h.Port.IntValue() != 8080 {
+		t.Errorf("unexpected HTTP prober %v", probers["/app-health/http/livez"])
+	}
+	if tcp := probers["/app-health/tcp/livez"].TCPSocket; tcp == nil || tcp.Port.IntValue() != 8080 {
+		t.Errorf("unexpected TCP prober %v", probers["/app-health/tcp/livez"])
+	}
+
+	// HTTP probers are encoded in the format of older agents
+	out, err := json.Marshal(probers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("unexpected encoding, want = %v, got = %v", in, string(out))
+	}
+}
+
 func TestHandleQuit(t *testing.T) {
 	statusPort := 15020
 	s, err := NewServer(Config{StatusPort: uint16(statusPort)})
@@ -127,6 +127,8 @@ func init() {
 			"It is recommended to be disable for highly available setups.")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.FileDir, "configDir", "",
 		"Directory to watch for updates to config yaml files. If specified, the files will be used as the source of config, rather than a CRD client.")
+	discoveryCmd.PersistentFlags().BoolVar(&serverArgs.Config.WatchFileDir, "watchConfigDir", false,
+		"Use file system notifications to detect updates to the files in configDir and only re-read the files that changed, rather than periodically re-reading the whole directory.")
 	discoveryCmd.PersistentFlags().StringVarP(&serverArgs.Config.ControllerOptions.WatchedNamespace, "appNamespace",
 		"a", metav1.NamespaceAll,
 		"Restrict the applications namespace the controller manages; if not set, controller watches all namespaces")
@@ -15,6 +15,7 @@
 package bootstrap
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -68,6 +69,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/proxy/envoy"
 	envoyv2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	"istio.io/istio/pilot/pkg/serviceregistry/consul"
@@ -175,6 +177,9 @@ type ConfigArgs struct {
 	KubeConfig                 string
 	FileDir                    string
 
+	// WatchFileDir if set, FileDir is watched for file system notifications rather than polled.
+	WatchFileDir bool
+
 	// Controller if specified, this controller overrides the other config settings.
 	Controller model.ConfigStoreCache
 
@@ -297,6 +302,7 @@ func NewServer(args PilotArgs) (*Server, error) {
 	if err := s.initMeshNetworks(&args); err != nil {
 		return nil, fmt.Errorf("mesh networks: %v", err)
 	}
+	s.initCRL()
 	// Certificate controller is created before MCP
 	// controller in case MCP server pod waits to mount a certificate
 	// to be provisioned by the certificate controller.
@@ -529,6 +535,34 @@ func (s *Server) initMeshNetworks(args *PilotArgs) error { //nolint: unparam
 	return nil
 }
 
+// initCRL loads the CRL of the Istio CA from the file provided, if any, and watches it for changes.
+func (s *Server) initCRL() {
+	if features.CACRLFile == "" {
+		return
+	}
+	if crl, err := ioutil.ReadFile(features.CACRLFile); err != nil {
+		log.Warnf("failed to read the CA CRL from %q: %v", features.CACRLFile, err)
+	} else {
+		authn_model.SetCRL(crl)
+	}
+
+	// Watch the CRL file for changes and push the new CRL if it got modified
+	s.addFileWatcher(features.CACRLFile, func() {
+		crl, err := ioutil.ReadFile(features.CACRLFile)
+		if err != nil {
+			log.Warnf("failed to read the CA CRL from %q: %v", features.CACRLFile, err)
+			return
+		}
+		if !bytes.Equal(crl, authn_model.GetCRL()) {
+			log.Infof("CA CRL file %s updated", features.CACRLFile)
+			authn_model.SetCRL(crl)
+			if s.EnvoyXdsServer != nil {
+				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+			}
+		}
+	})
+}
+
 func (s *Server) getKubeCfgFile(args *PilotArgs) string {
 	return args.Config.KubeConfig
 }
@@ -582,7 +616,7 @@ func (s *Server) initMCPConfigController(args *PilotArgs) error {
 				store := memory.MakeWithLedger(schemas.Istio, args.Config.buildLedger())
 				configController := memory.NewController(store)
 
-				err := s.makeFileMonitor(srcAddress.Path, configController)
+				err := s.makeFileMonitor(srcAddress.Path, args.Config.WatchFileDir, configController)
 				if err != nil {
 					cancel()
 					return err
@@ -833,7 +867,7 @@ func (s *Server) initConfigController(args *PilotArgs) error {
 		store := memory.Make(schemas.Istio)
 		configController := memory.NewController(store)
 
-		err := s.makeFileMonitor(args.Config.FileDir, configController)
+		err := s.makeFileMonitor(args.Config.FileDir, args.Config.WatchFileDir, configController)
 		if err != nil {
 			return err
 		}
@@ -902,7 +936,18 @@ func (s *Server) makeKubeConfigController(args *PilotArgs) (model.ConfigStoreCac
 	return controller.NewController(configClient, args.Config.ControllerOptions), nil
 }
 
-func (s *Server) makeFileMonitor(fileDir string, configController model.ConfigStore) error {
+func (s *Server) makeFileMonitor(fileDir string, watch bool, configController model.ConfigStore) error {
+	if watch {
+		fileWatcher := configmonitor.NewFileWatcher("file-watcher", configController, fileDir, schemas.Istio, FilepathWalkInterval)
+
+		// Defer starting the file watcher until after the service is created.
+		s.addStartFunc(func(stop <-chan struct{}) error {
+			return fileWatcher.Start(stop)
+		})
+
+		return nil
+	}
+
 	fileSnapshot := configmonitor.NewFileSnapshot(fileDir, schemas.Istio)
 	fileMonitor := configmonitor.NewMonitor("file-monitor", configController, FilepathWalkInterval, fileSnapshot.ReadConfigFiles)
 
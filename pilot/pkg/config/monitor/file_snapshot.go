@@ -72,27 +72,14 @@ func (f *FileSnapshot) ReadConfigFiles() ([]*model.Config, error) {
 	err := filepath.Walk(f.root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
-		} else if !supportedExtensions[filepath.Ext(path)] || (info.Mode()&os.ModeType) != 0 {
+		} else if !isConfigFile(path, info) {
 			return nil
 		}
-		data, err := ioutil.ReadFile(path)
+		configs, err := f.readConfigFile(path)
 		if err != nil {
-			log.Warnf("Failed to read %s: %v", path, err)
 			return err
 		}
-		configs, err := parseInputs(data)
-		if err != nil {
-			log.Warnf("Failed to parse %s: %v", path, err)
-			return err
-		}
-
-		// Filter any unsupported types before appending to the result.
-		for _, cfg := range configs {
-			if !f.configTypeFilter[cfg.Type] {
-				continue
-			}
-			result = append(result, cfg)
-		}
+		result = append(result, configs...)
 		return nil
 	})
 	if err != nil {
@@ -104,6 +91,34 @@ func (f *FileSnapshot) ReadConfigFiles() ([]*model.Config, error) {
 	return result, err
 }
 
+// readConfigFile parses a single config file and returns the eligible model.Config it contains.
+func (f *FileSnapshot) readConfigFile(path string) ([]*model.Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Warnf("Failed to read %s: %v", path, err)
+		return nil, err
+	}
+	configs, err := parseInputs(data)
+	if err != nil {
+		log.Warnf("Failed to parse %s: %v", path, err)
+		return nil, err
+	}
+
+	// Filter any unsupported types.
+	result := make([]*model.Config, 0, len(configs))
+	for _, cfg := range configs {
+		if f.configTypeFilter[cfg.Type] {
+			result = append(result, cfg)
+		}
+	}
+	return result, nil
+}
+
+// isConfigFile returns true if the path refers to a regular file with a supported extension.
+func isConfigFile(path string, info os.FileInfo) bool {
+	return supportedExtensions[filepath.Ext(path)] && (info.Mode()&os.ModeType) == 0
+}
+
 // parseInputs is identical to crd.ParseInputs, except that it returns an array of config pointers.
 func parseInputs(data []byte) ([]*model.Config, error) {
 	configs, _, err := crd.ParseInputs(string(data))
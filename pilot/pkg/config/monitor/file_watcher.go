@@ -0,0 +1,247 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schema"
+	"istio.io/pkg/log"
+)
+
+// FileWatcher keeps a ConfigStore in sync with the config files in a directory tree. Unlike the
+// Monitor, which periodically re-reads the whole tree, it reacts to file system notifications and
+// only re-parses the files that changed.
+type FileWatcher struct {
+	name     string
+	root     string
+	store    model.ConfigStore
+	snapshot *FileSnapshot
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+
+	// dirs is the set of directories being watched.
+	dirs map[string]bool
+	// files holds the configs last successfully parsed from each file, keyed by path.
+	files map[string][]*model.Config
+	// applied holds the configs currently in the store, keyed by model.Config.Key().
+	applied map[string]*model.Config
+}
+
+// NewFileWatcher creates a FileWatcher for the config files under root. File system events are
+// batched for the debounce duration before being processed, so that editors writing a file in
+// several steps only cause a single update.
+// If no types are provided in the descriptor, all Istio types will be allowed.
+func NewFileWatcher(name string, delegateStore model.ConfigStore, root string, descriptor schema.Set,
+	debounce time.Duration) *FileWatcher {
+	return &FileWatcher{
+		name:     name,
+		root:     filepath.Clean(root),
+		store:    delegateStore,
+		snapshot: NewFileSnapshot(root, descriptor),
+		debounce: debounce,
+		dirs:     make(map[string]bool),
+		files:    make(map[string][]*model.Config),
+		applied:  make(map[string]*model.Config),
+	}
+}
+
+// Start loads the config files under the root directory into the store before returning. It then
+// kicks off an asynchronous event loop that applies changes to the files until stop is closed.
+func (w *FileWatcher) Start(stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w.watcher = watcher
+
+	changed := make(map[string]bool)
+	if err := w.addDir(w.root, changed); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	w.sync(changed)
+
+	go w.run(stop)
+	return nil
+}
+
+func (w *FileWatcher) run(stop <-chan struct{}) {
+	defer func() { _ = w.watcher.Close() }()
+
+	pending := make(map[string]bool)
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case e, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			pending[filepath.Clean(e.Name)] = true
+			if timer == nil {
+				timer = time.After(w.debounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("%s: error watching %s: %v", w.name, w.root, err)
+		case <-timer:
+			timer = nil
+			w.processEvents(pending)
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// processEvents works out which config files are affected by events on the given paths, then
+// applies their changes to the store.
+func (w *FileWatcher) processEvents(paths map[string]bool) {
+	changed := make(map[string]bool)
+	for path := range paths {
+		info, err := os.Lstat(path)
+		switch {
+		case err != nil:
+			// The path was removed or renamed away
+			w.removePath(path, changed)
+		case info.IsDir():
+			if !w.dirs[path] {
+				if err := w.addDir(path, changed); err != nil {
+					log.Warnf("%s: failed to watch %s: %v", w.name, path, err)
+				}
+			}
+		case isConfigFile(path, info):
+			changed[path] = true
+		}
+	}
+	w.sync(changed)
+}
+
+// addDir watches dir and all of its subdirectories, and marks the config files within them as changed.
+func (w *FileWatcher) addDir(dir string, changed map[string]bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if err := w.watcher.Add(path); err != nil {
+				return err
+			}
+			w.dirs[path] = true
+		} else if isConfigFile(path, info) {
+			changed[path] = true
+		}
+		return nil
+	})
+}
+
+// removePath marks the config files at or below path as changed, and stops tracking removed directories.
+func (w *FileWatcher) removePath(path string, changed map[string]bool) {
+	prefix := path + string(filepath.Separator)
+	for dir := range w.dirs {
+		if dir == path || strings.HasPrefix(dir, prefix) {
+			// The watch is dropped automatically when a directory is removed, so errors are expected here
+			_ = w.watcher.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	for file := range w.files {
+		if file == path || strings.HasPrefix(file, prefix) {
+			changed[file] = true
+		}
+	}
+}
+
+// sync re-parses the changed files and applies the resulting additions, updates and deletions to the store.
+// A file that fails to parse keeps contributing the configs it last parsed successfully.
+func (w *FileWatcher) sync(changed map[string]bool) {
+	if len(changed) == 0 {
+		return
+	}
+
+	for path := range changed {
+		if _, err := os.Lstat(path); err != nil {
+			delete(w.files, path)
+			continue
+		}
+		configs, err := w.snapshot.readConfigFile(path)
+		if err != nil {
+			// readConfigFile has already logged the error
+			continue
+		}
+		w.files[path] = configs
+	}
+
+	// Build the desired contents of the store. If the same config is defined in more than one file,
+	// the first file in lexical order wins.
+	paths := make([]string, 0, len(w.files))
+	for path := range w.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	desired := make(map[string]*model.Config)
+	for _, path := range paths {
+		for _, c := range w.files[path] {
+			if _, ok := desired[c.Key()]; ok {
+				log.Warnf("%s: ignoring duplicate definition of %s in %s", w.name, c.Key(), path)
+				continue
+			}
+			desired[c.Key()] = c
+		}
+	}
+
+	for key, old := range w.applied {
+		if _, ok := desired[key]; !ok {
+			deleteConfig(w.store, old)
+			delete(w.applied, key)
+		}
+	}
+
+	for key, c := range desired {
+		old, ok := w.applied[key]
+		switch {
+		case !ok:
+			createConfig(w.store, copyConfig(c))
+		case !configEqual(old, c):
+			updateConfig(w.store, copyConfig(c))
+		default:
+			continue
+		}
+		w.applied[key] = c
+	}
+}
+
+// copyConfig makes a deep copy of c, so that the store never shares a config with the watcher.
+func copyConfig(c *model.Config) *model.Config {
+	cpy := *c
+	cpy.Spec = proto.Clone(c.Spec)
+	return &cpy
+}
+
+func configEqual(a, b *model.Config) bool {
+	return reflect.DeepEqual(a.ConfigMeta, b.ConfigMeta) && proto.Equal(a.Spec, b.Spec)
+}
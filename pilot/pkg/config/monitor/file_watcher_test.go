@@ -0,0 +1,162 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/config/monitor"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+const watchDebounce = 10 * time.Millisecond
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) handle(c model.Config, e model.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e.String()+" "+c.Type+" "+c.Name)
+}
+
+func (r *eventRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.events...)
+}
+
+func startFileWatcher(t *testing.T, root string) (model.ConfigStoreCache, *eventRecorder, chan struct{}) {
+	t.Helper()
+
+	controller := memory.NewController(memory.Make(schemas.Istio))
+	recorder := &eventRecorder{}
+	controller.RegisterEventHandler(schemas.Gateway.Type, recorder.handle)
+	controller.RegisterEventHandler(schemas.VirtualService.Type, recorder.handle)
+
+	stop := make(chan struct{})
+	go controller.Run(stop)
+
+	w := monitor.NewFileWatcher("test", controller, root, nil, watchDebounce)
+	if err := w.Start(stop); err != nil {
+		t.Fatal(err)
+	}
+	return controller, recorder, stop
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileWatcherEvents(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{"gateway.yml": []byte(gatewayYAML)},
+	}
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	store, recorder, stop := startFileWatcher(t, ts.rootPath)
+	defer close(stop)
+
+	// The initial contents are loaded before Start returns
+	g.Expect(store.Get(schemas.Gateway.Type, "some-ingress", "")).NotTo(gomega.BeNil())
+
+	// The store is read only after the corresponding event was seen, as the memory store isn't safe for
+	// concurrent access.
+
+	// Update
+	writeFile(t, filepath.Join(ts.rootPath, "gateway.yml"), strings.Replace(gatewayYAML, "protocol: http", "protocol: http2", 1))
+	g.Eventually(recorder.get).Should(gomega.HaveLen(2))
+	gw := store.Get(schemas.Gateway.Type, "some-ingress", "")
+	g.Expect(gw.Spec.(*networking.Gateway).Servers[0].Port.Protocol).To(gomega.Equal("http2"))
+
+	// Create, in a directory that did not exist when the watcher started
+	dir := filepath.Join(ts.rootPath, "sub")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "vs.yaml"), virtualServiceYAML)
+	g.Eventually(recorder.get).Should(gomega.HaveLen(3))
+	g.Expect(store.Get(schemas.VirtualService.Type, "route-for-myapp", "")).NotTo(gomega.BeNil())
+
+	// Delete
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	g.Eventually(recorder.get).Should(gomega.HaveLen(4))
+	g.Expect(store.Get(schemas.VirtualService.Type, "route-for-myapp", "")).To(gomega.BeNil())
+
+	g.Consistently(recorder.get, 100*time.Millisecond).Should(gomega.Equal([]string{
+		"add gateway some-ingress",
+		"update gateway some-ingress",
+		"add virtual-service route-for-myapp",
+		"delete virtual-service route-for-myapp",
+	}))
+}
+
+func TestFileWatcherParseError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{
+			"gateway.yml":         []byte(gatewayYAML),
+			"virtual_service.yml": []byte(virtualServiceYAML),
+			"broken.yml":          []byte("kind: [not valid"),
+		},
+	}
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	store, recorder, stop := startFileWatcher(t, ts.rootPath)
+	defer close(stop)
+
+	// A file that fails to parse does not prevent the others from loading
+	g.Expect(store.Get(schemas.Gateway.Type, "some-ingress", "")).NotTo(gomega.BeNil())
+	g.Expect(store.Get(schemas.VirtualService.Type, "route-for-myapp", "")).NotTo(gomega.BeNil())
+
+	// Breaking a file keeps its last good contents
+	writeFile(t, filepath.Join(ts.rootPath, "gateway.yml"), "kind: [not valid")
+	writeFile(t, filepath.Join(ts.rootPath, "virtual_service.yml"),
+		strings.Replace(virtualServiceYAML, "some.example.com", "other.example.com", 1))
+	g.Eventually(recorder.get).Should(gomega.HaveLen(3))
+	vs := store.Get(schemas.VirtualService.Type, "route-for-myapp", "")
+	g.Expect(vs.Spec.(*networking.VirtualService).Hosts).To(gomega.Equal([]string{"other.example.com"}))
+	g.Expect(store.Get(schemas.Gateway.Type, "some-ingress", "")).NotTo(gomega.BeNil())
+
+	g.Consistently(recorder.get, 100*time.Millisecond).Should(gomega.ConsistOf(
+		"add gateway some-ingress",
+		"add virtual-service route-for-myapp",
+		"update virtual-service route-for-myapp",
+	))
+}
@@ -126,24 +126,36 @@ func (m *Monitor) checkAndUpdate() {
 }
 
 func (m *Monitor) createConfig(c *model.Config) {
-	if _, err := m.store.Create(*c); err != nil {
+	createConfig(m.store, c)
+}
+
+func (m *Monitor) updateConfig(c *model.Config) {
+	updateConfig(m.store, c)
+}
+
+func (m *Monitor) deleteConfig(c *model.Config) {
+	deleteConfig(m.store, c)
+}
+
+func createConfig(store model.ConfigStore, c *model.Config) {
+	if _, err := store.Create(*c); err != nil {
 		log.Warnf("Failed to create config %s %s/%s: %v (%+v)", c.Type, c.Namespace, c.Name, err, *c)
 	}
 }
 
-func (m *Monitor) updateConfig(c *model.Config) {
+func updateConfig(store model.ConfigStore, c *model.Config) {
 	// Set the resource version based on the existing config.
-	if prev := m.store.Get(c.Type, c.Name, c.Namespace); prev != nil {
+	if prev := store.Get(c.Type, c.Name, c.Namespace); prev != nil {
 		c.ResourceVersion = prev.ResourceVersion
 	}
 
-	if _, err := m.store.Update(*c); err != nil {
+	if _, err := store.Update(*c); err != nil {
 		log.Warnf("Failed to update config (%+v): %v ", *c, err)
 	}
 }
 
-func (m *Monitor) deleteConfig(c *model.Config) {
-	if err := m.store.Delete(c.Type, c.Name, c.Namespace); err != nil {
+func deleteConfig(store model.ConfigStore, c *model.Config) {
+	if err := store.Delete(c.Type, c.Name, c.Namespace); err != nil {
 		log.Warnf("Failed to delete config (%+v): %v ", *c, err)
 	}
 }
@@ -75,6 +75,22 @@ var (
 			" EDS pushes may be delayed, but there will be fewer pushes. By default this is enabled",
 	)
 
+	// PushTraceSize is the number of recent pushes whose timeline is kept for /debug/pushz.
+	PushTraceSize = env.RegisterIntVar(
+		"PILOT_PUSH_TRACE_SIZE",
+		0,
+		"The number of recent pushes whose timeline, from the config events merged by debouncing to the "+
+			"generation of each proxy's configuration, is kept for /debug/pushz. Push tracing is disabled by default.",
+	).Get()
+
+	// EnablePushTraceSpans exports the push timelines as OpenCensus spans.
+	EnablePushTraceSpans = env.RegisterBoolVar(
+		"PILOT_ENABLE_PUSH_TRACE_SPANS",
+		false,
+		"If enabled, the timeline of each push is also exported as OpenCensus spans, using the trace exporters "+
+			"registered by Pilot. Requires PILOT_PUSH_TRACE_SIZE to be greater than 0.",
+	).Get()
+
 	// BaseDir is the base directory for locating configs.
 	// File based certificates are located under $BaseDir/etc/certs/. If not set, the original 1.0 locations will
 	// be used, "/"
@@ -191,6 +207,14 @@ var (
 		"If enabled, protocol sniffing will be used for inbound listeners whose port protocol is not specified or unsupported",
 	)
 
+	EnableUDPProxy = env.RegisterBoolVar(
+		"PILOT_ENABLE_UDP_PROXY",
+		false,
+		"If enabled, pilot will generate UDP proxy listeners for the UDP service ports of sidecars and the "+
+			"UDP servers of gateways. The outbound and inbound UDP listeners of sidecars only receive traffic "+
+			"if UDP traffic is redirected to the sidecar, which the default iptables rules do not do.",
+	)
+
 	ScopePushes = env.RegisterBoolVar(
 		"PILOT_SCOPE_PUSHES",
 		true,
@@ -246,6 +270,15 @@ var (
 		"If enabled, Pilot will keep track of old versions of distributed config for this duration.",
 	).Get()
 
+	CACRLFile = env.RegisterStringVar(
+		"PILOT_CA_CRL_FILE",
+		"",
+		"The path to the PEM encoded CRL of the Istio CA, e.g. the ca-crl.pem key of the istio-ca-revocation-list "+
+			"configmap mounted as a file. If set, the CRL is added to the validation contexts of Istio mutual TLS, "+
+			"so that proxies reject the revoked certificates. Proxies reject all the Istio mutual TLS connections if the "+
+			"CRL expired, or if the certificates are not issued by the root certificate signing the CRL.",
+	).Get()
+
 	EnableUnsafeRegex = env.RegisterBoolVar(
 		"PILOT_ENABLE_UNSAFE_REGEX",
 		false,
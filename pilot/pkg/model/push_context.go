@@ -195,6 +195,10 @@ type PushRequest struct {
 	// Start represents the time a push was started. This represents the time of adding to the PushQueue.
 	// Note that this does not include time spent debouncing.
 	Start time.Time
+
+	// Trace records the timeline of the push. It is created once debouncing completes, and may be nil
+	// if push tracing is disabled.
+	Trace *PushTrace
 }
 
 // Merge two update requests together
@@ -215,6 +219,12 @@ func (first *PushRequest) Merge(other *PushRequest) *PushRequest {
 
 		// The other push context is presumed to be later and more up to date
 		Push: other.Push,
+
+		// The other push is also the one that will be traced from now on
+		Trace: other.Trace,
+	}
+	if merged.Trace == nil {
+		merged.Trace = first.Trace
 	}
 
 	// Only merge EdsUpdates when incremental eds push needed.
@@ -344,6 +354,13 @@ var (
 		"Number of conflicting tcp listeners with current tcp listener.",
 	)
 
+	// ProxyStatusConflictOutboundListenerUDPOverUDP metric tracks number of
+	// UDP listeners that conflicted with existing UDP listeners on same port
+	ProxyStatusConflictOutboundListenerUDPOverUDP = monitoring.NewGauge(
+		"pilot_conflict_outbound_listener_udp_over_current_udp",
+		"Number of conflicting udp listeners with current udp listener.",
+	)
+
 	// ProxyStatusConflictOutboundListenerHTTPOverTCP metric tracks number of
 	// wildcard HTTP listeners that conflicted with existing wildcard TCP listener on same port
 	ProxyStatusConflictOutboundListenerHTTPOverTCP = monitoring.NewGauge(
@@ -403,6 +420,7 @@ var (
 		ProxyStatusConflictOutboundListenerTCPOverHTTP,
 		ProxyStatusConflictOutboundListenerHTTPoverHTTPS,
 		ProxyStatusConflictOutboundListenerTCPOverTCP,
+		ProxyStatusConflictOutboundListenerUDPOverUDP,
 		ProxyStatusConflictOutboundListenerHTTPOverTCP,
 		ProxyStatusConflictInboundListener,
 		DuplicatedClusters,
@@ -0,0 +1,310 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pilot/pkg/features"
+)
+
+// lastPushTraceID is the ID of the last PushTrace created. IDs are unique within a Pilot instance.
+var lastPushTraceID = atomic.NewUint64(0)
+
+// PushTrigger is a config or endpoint event that requested a push. All the events received while
+// debouncing are merged into a single push.
+type PushTrigger struct {
+	Time        time.Time `json:"time"`
+	Full        bool      `json:"full"`
+	ConfigTypes []string  `json:"configTypes,omitempty"`
+	Namespaces  []string  `json:"namespaces,omitempty"`
+	EdsUpdates  []string  `json:"edsUpdates,omitempty"`
+	// Proxy is set if the event only requested a push to a single proxy.
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// NewPushTrigger describes the event that created a push request.
+func NewPushTrigger(req *PushRequest) PushTrigger {
+	return PushTrigger{
+		Time:        time.Now(),
+		Full:        req.Full,
+		ConfigTypes: sortedKeys(req.ConfigTypesUpdated),
+		Namespaces:  sortedKeys(req.NamespacesUpdated),
+		EdsUpdates:  sortedKeys(req.EdsUpdates),
+	}
+}
+
+// PushTrace records the timeline of a push: the events merged by debouncing, the creation of the
+// PushContext, and for each proxy the time spent in the push queue and generating each xDS type.
+// All methods can be called on a nil trace, which records nothing.
+type PushTrace struct {
+	mu sync.Mutex
+
+	ID       uint64        `json:"id"`
+	Triggers []PushTrigger `json:"triggers"`
+	// DebounceStart is the time the first event was received, Start the time debouncing completed.
+	DebounceStart time.Time `json:"debounceStart"`
+	Start         time.Time `json:"start"`
+	// End is set once the push was sent to all the proxies, or failed.
+	End time.Time `json:"end"`
+
+	Version         string `json:"version,omitempty"`
+	InitContextTime string `json:"initContextTime,omitempty"`
+	Error           string `json:"error,omitempty"`
+
+	// Proxies is keyed by connection ID.
+	Proxies map[string]*ProxyPushTrace `json:"proxies,omitempty"`
+
+	// pending counts the proxies enqueued and not done yet.
+	pending int
+	// enqueued is set once the push was enqueued for all proxies.
+	enqueued bool
+
+	ctx  context.Context
+	span *trace.Span
+}
+
+// ProxyPushTrace records the timeline of a push to a single proxy.
+type ProxyPushTrace struct {
+	Enqueued time.Time `json:"enqueued"`
+	Dequeued time.Time `json:"dequeued"`
+	Done     time.Time `json:"done"`
+	// QueueTime is the time spent waiting in the push queue.
+	QueueTime string `json:"queueTime,omitempty"`
+	// GenerationTime is keyed by xDS type (CDS, EDS, LDS or RDS).
+	GenerationTime map[string]string `json:"generationTime,omitempty"`
+	// Skipped is set if the push did not apply to the proxy.
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// SupersededBy is set if a newer push for the proxy was merged with this one while it was queued.
+	SupersededBy uint64 `json:"supersededBy,omitempty"`
+
+	span *trace.Span
+}
+
+// NewPushTrace creates the trace of a push triggered by the given events. If enabled, the push is
+// also exported as an OpenCensus span.
+func NewPushTrace(triggers []PushTrigger, debounceStart time.Time) *PushTrace {
+	t := &PushTrace{
+		ID:            lastPushTraceID.Inc(),
+		Triggers:      triggers,
+		DebounceStart: debounceStart,
+		Start:         time.Now(),
+		Proxies:       make(map[string]*ProxyPushTrace),
+	}
+
+	if features.EnablePushTraceSpans {
+		t.ctx, t.span = trace.StartSpan(context.Background(), "pilot.push")
+		t.span.AddAttributes(trace.Int64Attribute("push.id", int64(t.ID)),
+			trace.StringAttribute("push.debounce", t.Start.Sub(debounceStart).String()))
+		for _, trigger := range triggers {
+			t.span.Annotate([]trace.Attribute{
+				trace.BoolAttribute("full", trigger.Full),
+				trace.StringAttribute("time", trigger.Time.Format(time.RFC3339Nano)),
+			}, "trigger")
+		}
+	}
+	return t
+}
+
+// ContextInitialized records the creation of the PushContext for the push.
+func (t *PushTrace) ContextInitialized(version string, initContextTime time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Version = version
+	t.InitContextTime = initContextTime.String()
+	if t.span != nil {
+		t.span.Annotate([]trace.Attribute{trace.StringAttribute("version", version)}, "context initialized")
+	}
+}
+
+// Failed records that the push was aborted.
+func (t *PushTrace) Failed(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Error = err.Error()
+	if t.span != nil {
+		t.span.SetStatus(trace.Status{Code: trace.StatusCodeInternal, Message: t.Error})
+	}
+	t.end()
+}
+
+// Enqueued records that the push was enqueued for all the proxies it applies to.
+func (t *PushTrace) Enqueued() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.enqueued = true
+	if t.pending == 0 {
+		t.end()
+	}
+}
+
+// ProxyEnqueued records that the push was added to the push queue for a proxy.
+func (t *PushTrace) ProxyEnqueued(proxy string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, f := t.Proxies[proxy]; f {
+		return
+	}
+	t.Proxies[proxy] = &ProxyPushTrace{Enqueued: time.Now()}
+	t.pending++
+}
+
+// ProxySuperseded records that a newer push for a proxy was merged with this one before it was dequeued.
+func (t *PushTrace) ProxySuperseded(proxy string, newer *PushTrace) {
+	if t == nil || newer == nil || t == newer {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, f := t.Proxies[proxy]; f && p.Done.IsZero() {
+		p.SupersededBy = newer.ID
+		t.proxyDone(p)
+	}
+}
+
+// ProxyDequeued records that the push to a proxy was taken from the push queue.
+func (t *PushTrace) ProxyDequeued(proxy string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, f := t.Proxies[proxy]; f {
+		p.Dequeued = time.Now()
+		p.QueueTime = p.Dequeued.Sub(p.Enqueued).String()
+		if t.span != nil {
+			_, p.span = trace.StartSpan(t.ctx, "pilot.push.proxy")
+			p.span.AddAttributes(trace.StringAttribute("proxy", proxy),
+				trace.StringAttribute("queue_time", p.QueueTime))
+		}
+	}
+}
+
+// ProxySkipped records that the push did not apply to a proxy.
+func (t *PushTrace) ProxySkipped(proxy string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, f := t.Proxies[proxy]; f {
+		p.Skipped = true
+	}
+}
+
+// ProxyGenerated records the time spent generating and sending an xDS type to a proxy.
+func (t *PushTrace) ProxyGenerated(proxy string, xdsType string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, f := t.Proxies[proxy]; f {
+		if p.GenerationTime == nil {
+			p.GenerationTime = make(map[string]string)
+		}
+		p.GenerationTime[xdsType] = d.String()
+		if p.span != nil {
+			p.span.Annotate([]trace.Attribute{trace.StringAttribute("time", d.String())}, xdsType)
+		}
+	}
+}
+
+// ProxyDone records that the push to a proxy completed, with an optional error.
+func (t *PushTrace) ProxyDone(proxy string, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p, f := t.Proxies[proxy]; f && p.Done.IsZero() {
+		if err != nil {
+			p.Error = err.Error()
+			if p.span != nil {
+				p.span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: p.Error})
+			}
+		}
+		t.proxyDone(p)
+	}
+}
+
+func (t *PushTrace) proxyDone(p *ProxyPushTrace) {
+	p.Done = time.Now()
+	if p.span != nil {
+		p.span.End()
+	}
+	t.pending--
+	if t.enqueued && t.pending == 0 {
+		t.end()
+	}
+}
+
+func (t *PushTrace) end() {
+	if !t.End.IsZero() {
+		return
+	}
+	t.End = time.Now()
+	if t.span != nil {
+		t.span.End()
+	}
+}
+
+// HasProxy returns true if the push was enqueued for the proxy with the given connection ID.
+func (t *PushTrace) HasProxy(proxy string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, f := t.Proxies[proxy]
+	return f
+}
+
+// MarshalJSON implements json.Marshaler, as the trace may be updated concurrently.
+func (t *PushTrace) MarshalJSON() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	type pushTrace PushTrace
+	return json.Marshal((*pushTrace)(t))
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
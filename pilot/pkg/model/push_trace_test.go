@@ -0,0 +1,96 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPushTraceNil(t *testing.T) {
+	// A nil trace records nothing, and does not panic
+	var trace *PushTrace
+	trace.ContextInitialized("v1", time.Second)
+	trace.ProxyEnqueued("proxy")
+	trace.ProxyDequeued("proxy")
+	trace.ProxySkipped("proxy")
+	trace.ProxyGenerated("proxy", "CDS", time.Second)
+	trace.ProxyDone("proxy", nil)
+	trace.ProxySuperseded("proxy", NewPushTrace(nil, time.Now()))
+	trace.Enqueued()
+	trace.Failed(errors.New("failed"))
+}
+
+func TestPushTraceTimeline(t *testing.T) {
+	trace := NewPushTrace([]PushTrigger{NewPushTrigger(&PushRequest{
+		Full:               true,
+		ConfigTypesUpdated: map[string]struct{}{"gateway": {}, "virtual-service": {}},
+	})}, time.Now())
+	trace.ContextInitialized("v1", time.Second)
+
+	trace.ProxyEnqueued("a")
+	trace.ProxyEnqueued("b")
+	trace.Enqueued()
+
+	trace.ProxyDequeued("a")
+	trace.ProxyGenerated("a", "LDS", 2*time.Millisecond)
+	trace.ProxyDone("a", nil)
+	if !trace.End.IsZero() {
+		t.Fatal("expected the push to be in progress while a proxy is pending")
+	}
+
+	trace.ProxyDequeued("b")
+	trace.ProxySkipped("b")
+	trace.ProxyDone("b", errors.New("failed"))
+	if trace.End.IsZero() {
+		t.Fatal("expected the push to be done")
+	}
+	if !trace.HasProxy("a") || trace.HasProxy("c") {
+		t.Fatal("unexpected proxies in the push")
+	}
+
+	b, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Version  string
+		Triggers []PushTrigger
+		Proxies  map[string]ProxyPushTrace
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Version != "v1" || len(out.Triggers) != 1 || len(out.Triggers[0].ConfigTypes) != 2 {
+		t.Fatalf("unexpected trace %s", b)
+	}
+	if out.Proxies["a"].GenerationTime["LDS"] != "2ms" || !out.Proxies["b"].Skipped || out.Proxies["b"].Error != "failed" {
+		t.Fatalf("unexpected proxy traces %s", b)
+	}
+}
+
+func TestPushRequestMergeTrace(t *testing.T) {
+	first := &PushRequest{Full: true, Trace: NewPushTrace(nil, time.Now())}
+	second := &PushRequest{Full: true}
+	if got := first.Merge(second).Trace; got != first.Trace {
+		t.Fatalf("expected the trace of the first request to be kept, got %v", got)
+	}
+	second.Trace = NewPushTrace(nil, time.Now())
+	if got := first.Merge(second).Trace; got != second.Trace {
+		t.Fatalf("expected the trace of the newest request to be kept, got %v", got)
+	}
+}
@@ -232,8 +232,24 @@ type NetworkEndpoint struct {
 
 	// The load balancing weight associated with this endpoint.
 	LbWeight uint32
+
+	// The health status of the endpoint, as reported by the service registry.
+	HealthStatus HealthStatus
 }
 
+// HealthStatus is the health of an endpoint, as reported by the service registry.
+type HealthStatus int32
+
+const (
+	// Healthy endpoints receive traffic. Registries that don't track the health of endpoints report them all as
+	// healthy.
+	Healthy HealthStatus = iota
+	// Unhealthy endpoints don't receive traffic.
+	Unhealthy
+	// Degraded endpoints only receive traffic if there are not enough healthy endpoints.
+	Degraded
+)
+
 // Probe represents a health probe associated with an instance of service.
 type Probe struct {
 	Port *Port  `json:"port,omitempty"`
@@ -348,6 +364,9 @@ type IstioEndpoint struct {
 	// The load balancing weight associated with this endpoint.
 	LbWeight uint32
 
+	// The health status of the endpoint, as reported by the service registry.
+	HealthStatus HealthStatus
+
 	// Attributes contains additional attributes associated with the service
 	// used mostly by mixer and RBAC for policy enforcement purposes.
 	Attributes ServiceAttributes
@@ -500,6 +519,16 @@ func (ports PortList) GetByPort(num int) (*Port, bool) {
 	return nil, false
 }
 
+// GetByPortAndProtocol retrieves a port declaration by port value and protocol
+func (ports PortList) GetByPortAndProtocol(num int, p protocol.Instance) (*Port, bool) {
+	for _, port := range ports {
+		if port.Port == num && port.Protocol == p {
+			return port, true
+		}
+	}
+	return nil, false
+}
+
 // External predicate checks whether the service is external
 func (s *Service) External() bool {
 	return s.MeshExternal
@@ -19,6 +19,7 @@ import (
 
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
 )
 
 var validServiceKeys = map[string]struct {
@@ -124,6 +125,35 @@ func TestGetByPort(t *testing.T) {
 	if port, exists := ports.GetByPort(88); exists || port != nil {
 		t.Errorf("GetByPort(88) => want none but got %v, %t", port, exists)
 	}
+
+	ports = PortList{
+		{Name: "dns", Port: 53, Protocol: protocol.UDP},
+		{Name: "tcp-dns", Port: 53, Protocol: protocol.TCP},
+		{Name: "syslog", Port: 514, Protocol: protocol.UDP},
+	}
+	if port, exists := ports.GetByPort(53); !exists || port == nil || port.Name != "tcp-dns" {
+		t.Errorf("GetByPort(53) => want tcp-dns but got %v, %t", port, exists)
+	}
+	if port, exists := ports.GetByPort(514); exists || port != nil {
+		t.Errorf("GetByPort(514) => want none but got %v, %t", port, exists)
+	}
+}
+
+func TestGetByPortAndProtocol(t *testing.T) {
+	ports := PortList{
+		{Name: "dns", Port: 53, Protocol: protocol.UDP},
+		{Name: "tcp-dns", Port: 53, Protocol: protocol.TCP},
+		{Name: "syslog", Port: 514, Protocol: protocol.UDP},
+	}
+	if port, exists := ports.GetByPortAndProtocol(53, protocol.UDP); !exists || port == nil || port.Name != "dns" {
+		t.Errorf("GetByPortAndProtocol(53, UDP) => want dns but got %v, %t", port, exists)
+	}
+	if port, exists := ports.GetByPortAndProtocol(53, protocol.TCP); !exists || port == nil || port.Name != "tcp-dns" {
+		t.Errorf("GetByPortAndProtocol(53, TCP) => want tcp-dns but got %v, %t", port, exists)
+	}
+	if port, exists := ports.GetByPortAndProtocol(514, protocol.TCP); exists || port != nil {
+		t.Errorf("GetByPortAndProtocol(514, TCP) => want none but got %v, %t", port, exists)
+	}
 }
 
 func BenchmarkParseSubsetKey(b *testing.B) {
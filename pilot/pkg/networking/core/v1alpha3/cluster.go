@@ -188,7 +188,14 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(env *model.Environme
 		destRule := push.DestinationRule(proxy, service)
 		for _, port := range service.Ports {
 			if port.Protocol == protocol.UDP {
-				continue
+				// UDP clusters are only used by the UDP proxy listeners. A UDP port declared with the same number
+				// as a port of another protocol shares its cluster.
+				if !features.EnableUDPProxy.Get() {
+					continue
+				}
+				if _, exists := service.Ports.GetByPort(port.Port); exists {
+					continue
+				}
 			}
 			inputParams.Service = service
 			inputParams.Port = port
@@ -1137,6 +1144,11 @@ func applyUpstreamTLSSettings(env *model.Environment, cluster *apiv2.Cluster, tl
 				},
 			}
 		}
+		if tls.Mode == networking.TLSSettings_ISTIO_MUTUAL {
+			// Certificates issued by the Istio CA are checked against its CRL
+			authn_model.ApplyCRL(cluster.TlsContext.CommonTlsContext.GetValidationContext())
+			authn_model.ApplyCRL(cluster.TlsContext.CommonTlsContext.GetCombinedValidationContext().GetDefaultValidationContext())
+		}
 
 		// Set default SNI of cluster name for istio_mutual if sni is not set.
 		if len(tls.Sni) == 0 && tls.Mode == networking.TLSSettings_ISTIO_MUTUAL {
@@ -1850,3 +1850,67 @@ func TestAutoMTLSClusterPerPortStrictMode(t *testing.T) {
 		g.Expect(cluster.TlsContext).To(BeNil())
 	}
 }
+
+func TestUDPClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+
+	configgen := NewConfigGenerator([]plugin.Plugin{})
+
+	proxy := &model.Proxy{Type: model.Router, Metadata: &model.NodeMetadata{}}
+
+	serviceDiscovery := &fakes.ServiceDiscovery{}
+
+	service := &model.Service{
+		Hostname:    host.Name("dns.com"),
+		Address:     "1.1.1.1",
+		ClusterVIPs: make(map[string]string),
+		Ports: model.PortList{
+			&model.Port{Name: "udp-dns", Port: 53, Protocol: protocol.UDP},
+			&model.Port{Name: "tcp-dns", Port: 53, Protocol: protocol.TCP},
+			&model.Port{Name: "udp-syslog", Port: 514, Protocol: protocol.UDP},
+		},
+		Resolution: model.ClientSideLB,
+	}
+	serviceDiscovery.ServicesReturns([]*model.Service{service}, nil)
+
+	env := newTestEnvironment(serviceDiscovery, testMesh, &fakes.IstioConfigStore{})
+
+	clusters := configgen.BuildClusters(env, proxy, env.PushContext)
+	names := make(map[string]int)
+	for _, cluster := range clusters {
+		names[cluster.Name]++
+	}
+	// The UDP port sharing its number with the TCP port shares its cluster
+	g.Expect(names["outbound|53||dns.com"]).To(Equal(1))
+	g.Expect(names["outbound|514||dns.com"]).To(Equal(1))
+
+	// UDP clusters are built for sidecars too
+	sidecar := &model.Proxy{
+		Type:        model.SidecarProxy,
+		IPAddresses: []string{"6.6.6.6"},
+		Metadata:    &model.NodeMetadata{},
+	}
+	sidecar.SetSidecarScope(env.PushContext)
+	clusters = configgen.BuildClusters(env, sidecar, env.PushContext)
+	names = make(map[string]int)
+	for _, cluster := range clusters {
+		names[cluster.Name]++
+	}
+	g.Expect(names["outbound|53||dns.com"]).To(Equal(1))
+	g.Expect(names["outbound|514||dns.com"]).To(Equal(1))
+
+	// Without the UDP proxy, no UDP clusters are built
+	_ = os.Unsetenv(features.EnableUDPProxy.Name)
+	for _, p := range []*model.Proxy{proxy, sidecar} {
+		clusters = configgen.BuildClusters(env, p, env.PushContext)
+		names = make(map[string]int)
+		for _, cluster := range clusters {
+			names[cluster.Name]++
+		}
+		g.Expect(names["outbound|53||dns.com"]).To(Equal(1))
+		g.Expect(names["outbound|514||dns.com"]).To(Equal(0))
+	}
+}
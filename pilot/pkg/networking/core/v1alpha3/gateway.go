@@ -74,6 +74,13 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(
 
 		p := protocol.Parse(servers[0].Port.Protocol)
 		listenerProtocol := plugin.ModelProtocolToListenerProtocol(node, p, core.TrafficDirection_OUTBOUND)
+		if p == protocol.UDP && features.EnableUDPProxy.Get() {
+			// UDP servers can't share a port with other servers, and their listeners have no filter chains
+			if l := buildGatewayUDPListener(node, push, opts.bind, portNumber, servers, mergedGateway); l != nil {
+				listeners = append(listeners, l)
+			}
+			continue
+		}
 		if p.IsHTTP() {
 			// We have a list of HTTP servers on this port. Build a single listener for the server port.
 			// We only need to look at the first server in the list as the merge logic
@@ -511,6 +518,9 @@ func buildGatewayListenerTLSContext(
 				},
 			}
 		}
+		// Certificates issued by the Istio CA are checked against its CRL
+		authn_model.ApplyCRL(tls.CommonTlsContext.GetValidationContext())
+		authn_model.ApplyCRL(tls.CommonTlsContext.GetCombinedValidationContext().GetDefaultValidationContext())
 	} else {
 		// Fall back to the read-from-file approach when SDS is not enabled or Tls.CredentialName is not specified.
 		tls.CommonTlsContext.TlsCertificates = []*auth.TlsCertificate{
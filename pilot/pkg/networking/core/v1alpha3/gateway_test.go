@@ -15,6 +15,7 @@
 package v1alpha3
 
 import (
+	"os"
 	"reflect"
 	"testing"
 
@@ -949,6 +950,102 @@ func TestGatewayHTTPRouteConfig(t *testing.T) {
 
 }
 
+func TestGatewayUDPListener(t *testing.T) {
+	udpGateway := pilot_model.Config{
+		ConfigMeta: pilot_model.ConfigMeta{
+			Name:      "gateway",
+			Namespace: "default",
+		},
+		Spec: &networking.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers: []*networking.Server{
+				{
+					Hosts: []string{"dns.example.org"},
+					Port:  &networking.Port{Name: "udp-dns", Number: 53, Protocol: "UDP"},
+				},
+			},
+		},
+	}
+	virtualService := pilot_model.Config{
+		ConfigMeta: pilot_model.ConfigMeta{
+			Type:      schemas.VirtualService.Type,
+			Name:      "virtual-service",
+			Namespace: "default",
+		},
+		Spec: &networking.VirtualService{
+			Hosts:    []string{"dns.example.org"},
+			Gateways: []string{"gateway"},
+			Tcp: []*networking.TCPRoute{
+				{
+					Route: []*networking.RouteDestination{
+						{
+							Destination: &networking.Destination{
+								Host: "dns.example.org",
+								Port: &networking.PortSelector{
+									Number: 5353,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cases := []struct {
+		name            string
+		enableUDPProxy  bool
+		virtualServices []pilot_model.Config
+		expectedCluster string
+	}{
+		{
+			"no listener without a route",
+			true,
+			[]pilot_model.Config{},
+			"",
+		},
+		{
+			"listener for a virtual service",
+			true,
+			[]pilot_model.Config{virtualService},
+			"outbound|5353||dns.example.org",
+		},
+		{
+			"no listener with the UDP proxy disabled",
+			false,
+			[]pilot_model.Config{virtualService},
+			"",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.enableUDPProxy {
+				_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+				defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+			}
+			configgen := NewConfigGenerator([]plugin.Plugin{&fakePlugin{}})
+			env := buildEnv(t, []pilot_model.Config{udpGateway}, tt.virtualServices)
+			proxy13Gateway.SetGatewaysForProxy(env.PushContext)
+			builder := configgen.buildGatewayListeners(&env, &proxy13Gateway, env.PushContext, &ListenerBuilder{})
+			if tt.expectedCluster == "" {
+				for _, l := range builder.gatewayListeners {
+					if l.Address.GetSocketAddress().Protocol == core.SocketAddress_UDP {
+						t.Fatalf("expected no UDP listeners, found %s", l.Name)
+					}
+				}
+				return
+			}
+			if len(builder.gatewayListeners) != 1 {
+				t.Fatalf("expected %d listeners, found %d", 1, len(builder.gatewayListeners))
+			}
+			l := builder.gatewayListeners[0]
+			if l.Name != "0.0.0.0_53_udp" {
+				t.Fatalf("expected listener 0.0.0.0_53_udp, found %s", l.Name)
+			}
+			verifyUDPListener(t, l, tt.expectedCluster)
+		})
+	}
+}
+
 func buildEnv(t *testing.T, gateways []pilot_model.Config, virtualServices []pilot_model.Config) pilot_model.Environment {
 	serviceDiscovery := new(fakes.ServiceDiscovery)
 
@@ -538,6 +538,13 @@ func (configgen *ConfigGeneratorImpl) buildSidecarInboundListenerForPortOrUDS(no
 	// route config. Endpoint IP is handled below and Service IP is handled
 	// by outbound routes. Traffic sent to our service VIP is redirected by
 	// remote services' kubeproxy to our specific endpoint IP.
+	if pluginParams.ListenerProtocol == plugin.ListenerProtocolUDP {
+		if !features.EnableUDPProxy.Get() {
+			return nil
+		}
+		return buildSidecarInboundUDPListener(listenerOpts, pluginParams, listenerMap)
+	}
+
 	listenerMapKey := fmt.Sprintf("%s:%d", listenerOpts.bind, listenerOpts.port)
 
 	if old, exists := listenerMap[listenerMapKey]; exists {
@@ -680,6 +687,8 @@ func protocolName(node *model.Proxy, p protocol.Instance) string {
 		return "HTTP"
 	case plugin.ListenerProtocolTCP:
 		return "TCP"
+	case plugin.ListenerProtocolUDP:
+		return "UDP"
 	default:
 		return "UNKNOWN"
 	}
@@ -1317,8 +1326,15 @@ func (configgen *ConfigGeneratorImpl) buildSidecarOutboundListenerForPortOrUDS(n
 			}
 		}
 
+	case plugin.ListenerProtocolUDP:
+		// UDP listeners have no filter chains to merge, so they are handled separately
+		if features.EnableUDPProxy.Get() {
+			configgen.buildSidecarOutboundUDPListener(node, listenerOpts, pluginParams, listenerMap, virtualServices, actualWildcard)
+		}
+		return
+
 	default:
-		// UDP or other protocols: no need to log, it's too noisy
+		// Other protocols: no need to log, it's too noisy
 		return
 	}
 
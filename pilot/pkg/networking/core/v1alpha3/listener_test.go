@@ -23,6 +23,7 @@ import (
 	"time"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/conversion"
@@ -1422,6 +1423,159 @@ func getFilterConfig(filter *listener.Filter, out proto.Message) error {
 	return nil
 }
 
+func TestOutboundListenerUDP(t *testing.T) {
+	_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+
+	services := []*model.Service{
+		buildService("dns.com", "1.2.3.4", protocol.UDP, tnow),
+		buildService("syslog.com", "1.2.3.5", protocol.UDP, tnow),
+	}
+	p := &fakePlugin{}
+	listeners := buildOutboundListeners(p, &proxy, nil, nil, services...)
+	if len(listeners) != 2 {
+		t.Fatalf("expected %d listeners, found %d", 2, len(listeners))
+	}
+
+	expected := map[string]string{
+		"1.2.3.4_8080_udp": "outbound|8080||dns.com",
+		"1.2.3.5_8080_udp": "outbound|8080||syslog.com",
+	}
+	for _, l := range listeners {
+		verifyUDPListener(t, l, expected[l.Name])
+	}
+	// UDP listeners have no filter chains, so the plugins are not invoked
+	if len(p.outboundListenerParams) != 0 {
+		t.Fatalf("expected no plugin invocation, found %d", len(p.outboundListenerParams))
+	}
+}
+
+func TestOutboundListenerConflict_UDPWithCurrentUDP(t *testing.T) {
+	_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+
+	services := []*model.Service{
+		buildService("test1.com", "1.2.3.4", protocol.UDP, tnow.Add(1*time.Second)),
+		buildService("test2.com", "1.2.3.4", protocol.UDP, tnow),
+	}
+	listeners := buildOutboundListeners(&fakePlugin{}, &proxy, nil, nil, services...)
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+	// The oldest service keeps the listener
+	verifyUDPListener(t, listeners[0], "outbound|8080||test2.com")
+}
+
+func TestOutboundListenerUDPWithTCP(t *testing.T) {
+	_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+
+	// A UDP and a TCP service port sharing the same address and port get a listener each
+	services := []*model.Service{
+		buildService("test1.com", "1.2.3.4", protocol.UDP, tnow),
+		buildService("test2.com", "1.2.3.4", protocol.TCP, tnow),
+	}
+	listeners := buildOutboundListeners(&fakePlugin{}, &proxy, nil, nil, services...)
+	if len(listeners) != 2 {
+		t.Fatalf("expected %d listeners, found %d", 2, len(listeners))
+	}
+	for _, l := range listeners {
+		if l.Name == "1.2.3.4_8080_udp" {
+			verifyUDPListener(t, l, "outbound|8080||test1.com")
+		} else {
+			verifyOutboundTCPListenerHostname(t, l, "test2.com")
+		}
+	}
+}
+
+func TestOutboundListenerUDPWithVS(t *testing.T) {
+	_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+
+	services := []*model.Service{
+		buildService("dns.com", "1.2.3.4", protocol.UDP, tnow),
+	}
+	virtualService := model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      schemas.VirtualService.Type,
+			Version:   schemas.VirtualService.Version,
+			Name:      "dns_vs",
+			Namespace: "default",
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"dns.com"},
+			Tcp: []*networking.TCPRoute{
+				{
+					Match: []*networking.L4MatchAttributes{{Port: 53}},
+					Route: []*networking.RouteDestination{{Destination: &networking.Destination{Host: "unmatched.com"}}},
+				},
+				{
+					Route: []*networking.RouteDestination{
+						{Destination: &networking.Destination{Host: "dns.com", Subset: "v1"}, Weight: 20},
+						{Destination: &networking.Destination{Host: "dns.com", Subset: "v2"}, Weight: 80},
+					},
+				},
+			},
+		},
+	}
+	listeners := buildOutboundListeners(&fakePlugin{}, &proxy, nil, &virtualService, services...)
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+	verifyUDPListener(t, listeners[0], "outbound|8080|v2|dns.com")
+}
+
+func TestInboundListenerUDP(t *testing.T) {
+	_ = os.Setenv(features.EnableUDPProxy.Name, "true")
+	defer func() { _ = os.Unsetenv(features.EnableUDPProxy.Name) }()
+
+	services := []*model.Service{
+		buildService("dns.com", wildcardIP, protocol.UDP, tnow),
+	}
+	listeners := buildInboundListeners(&fakePlugin{}, &proxy, nil, services...)
+	if len(listeners) != 1 {
+		t.Fatalf("expected %d listeners, found %d", 1, len(listeners))
+	}
+	verifyUDPListener(t, listeners[0], "inbound|8080|default|dns.com")
+	if listeners[0].TrafficDirection != core.TrafficDirection_INBOUND {
+		t.Fatalf("expected inbound listener, found %v", listeners[0].TrafficDirection)
+	}
+}
+
+func TestListenerUDPDisabled(t *testing.T) {
+	// Without the UDP proxy, no UDP listeners are built
+	services := []*model.Service{
+		buildService("dns.com", "1.2.3.4", protocol.UDP, tnow),
+	}
+	if listeners := buildOutboundListeners(&fakePlugin{}, &proxy, nil, nil, services...); len(listeners) != 0 {
+		t.Fatalf("expected no outbound listeners, found %d", len(listeners))
+	}
+
+	services = []*model.Service{
+		buildService("dns.com", wildcardIP, protocol.UDP, tnow),
+	}
+	if listeners := buildInboundListeners(&fakePlugin{}, &proxy, nil, services...); len(listeners) != 0 {
+		t.Fatalf("expected no inbound listeners, found %d", len(listeners))
+	}
+}
+
+func verifyUDPListener(t *testing.T, l *xdsapi.Listener, cluster string) {
+	t.Helper()
+	if l.Address.GetSocketAddress().Protocol != core.SocketAddress_UDP {
+		t.Fatalf("expected UDP socket address for listener %s, found %v", l.Name, l.Address.GetSocketAddress().Protocol)
+	}
+	if len(l.FilterChains) != 0 {
+		t.Fatalf("expected no filter chains for listener %s, found %d", l.Name, len(l.FilterChains))
+	}
+	if len(l.ListenerFilters) != 1 || l.ListenerFilters[0].Name != envoyUDPProxy {
+		t.Fatalf("expected a single %s listener filter for listener %s, found %v", envoyUDPProxy, l.Name, l.ListenerFilters)
+	}
+	got := l.ListenerFilters[0].GetConfig().Fields["cluster"].GetStringValue()
+	if got != cluster {
+		t.Fatalf("expected cluster %s for listener %s, found %s", cluster, l.Name, got)
+	}
+}
+
 func buildOutboundListeners(p plugin.Plugin, proxy *model.Proxy, sidecarConfig *model.Config,
 	virtualService *model.Config, services ...*model.Service) []*xdsapi.Listener {
 	configgen := NewConfigGenerator([]plugin.Plugin{p})
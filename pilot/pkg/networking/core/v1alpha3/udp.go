@@ -0,0 +1,266 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	pstruct "github.com/golang/protobuf/ptypes/struct"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	istio_route "istio.io/istio/pilot/pkg/networking/core/v1alpha3/route"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/proto"
+	"istio.io/pkg/log"
+)
+
+const (
+	// envoyUDPProxy is the name of the Envoy listener filter that proxies UDP datagrams to a cluster
+	envoyUDPProxy = "envoy.filters.udp_listener.udp_proxy"
+)
+
+// udpListenerKey returns the key of a UDP listener in the listener conflict maps. A service may expose
+// the same port over TCP and UDP (e.g. DNS), so UDP listeners never conflict with TCP or HTTP ones.
+func udpListenerKey(bind string, port int) string {
+	return fmt.Sprintf("%s:%d/udp", bind, port)
+}
+
+// buildUDPListener builds a listener proxying the datagrams received on bind:port to a single cluster.
+// UDP listeners have no filter chains, so they are not passed through the plugins. They are only built with
+// the UDP proxy enabled (PILOT_ENABLE_UDP_PROXY), for sidecars and gateways alike.
+func buildUDPListener(node *model.Proxy, bind string, port int, bindToPort bool, clusterName string) *xdsapi.Listener {
+	address := util.BuildAddress(bind, uint32(port))
+	address.GetSocketAddress().Protocol = core.SocketAddress_UDP
+
+	var deprecatedV1 *xdsapi.Listener_DeprecatedV1
+	if !bindToPort {
+		deprecatedV1 = &xdsapi.Listener_DeprecatedV1{
+			BindToPort: proto.BoolFalse,
+		}
+	}
+
+	return &xdsapi.Listener{
+		Name:            fmt.Sprintf("%s_%d_udp", bind, port),
+		Address:         address,
+		ListenerFilters: []*listener.ListenerFilter{buildUDPProxyFilter(node, clusterName, clusterName)},
+		DeprecatedV1:    deprecatedV1,
+	}
+}
+
+// buildUDPProxyFilter builds the UDP proxy listener filter. The go-control-plane version in use does not
+// include the UdpProxyConfig message, so the filter is configured with its JSON equivalent.
+func buildUDPProxyFilter(node *model.Proxy, statPrefix, clusterName string) *listener.ListenerFilter {
+	config := &pstruct.Struct{
+		Fields: map[string]*pstruct.Value{
+			"stat_prefix": {Kind: &pstruct.Value_StringValue{StringValue: statPrefix}},
+			"cluster":     {Kind: &pstruct.Value_StringValue{StringValue: clusterName}},
+		},
+	}
+
+	idleTimeout, err := time.ParseDuration(node.Metadata.IdleTimeout)
+	if idleTimeout > 0 && err == nil {
+		config.Fields["idle_timeout"] = &pstruct.Value{
+			Kind: &pstruct.Value_StringValue{StringValue: fmt.Sprintf("%gs", idleTimeout.Seconds())},
+		}
+	}
+
+	return &listener.ListenerFilter{
+		Name:       envoyUDPProxy,
+		ConfigType: &listener.ListenerFilter_Config{Config: config},
+	}
+}
+
+// udpRouteCluster returns the cluster datagrams matching a set of VirtualService routes are sent to, or an
+// empty string if the destination has no UDP port to send them to. The UDP proxy forwards to a single cluster,
+// so only the destination with the highest weight is used.
+func udpRouteCluster(node *model.Proxy, push *model.PushContext, routes []*networking.RouteDestination, port int) string {
+	var best *networking.RouteDestination
+	for _, route := range routes {
+		if best == nil || route.Weight > best.Weight {
+			best = route
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	if len(routes) > 1 {
+		log.Debugf("UDP route for port %d has %d destinations, only %s is used", port, len(routes), best.Destination.Host)
+	}
+	service := node.SidecarScope.ServiceForHostname(host.Name(best.Destination.Host), push.ServiceByHostnameAndNamespace)
+	if service != nil {
+		destinationPort := port
+		if best.Destination.GetPort() != nil {
+			destinationPort = int(best.Destination.GetPort().GetNumber())
+		}
+		if _, exists := service.Ports.GetByPortAndProtocol(destinationPort, protocol.UDP); !exists {
+			log.Debugf("UDP route for port %d: service %s has no UDP port %d", port, service.Hostname, destinationPort)
+			return ""
+		}
+	}
+	return istio_route.GetDestinationCluster(best.Destination, service, port)
+}
+
+// buildSidecarInboundUDPListener builds the inbound listener for a UDP service port of the proxy's service instance.
+func buildSidecarInboundUDPListener(listenerOpts buildListenerOpts, pluginParams *plugin.InputParams,
+	listenerMap map[string]*inboundListenerEntry) *xdsapi.Listener {
+	instance := pluginParams.ServiceInstance
+	listenerMapKey := udpListenerKey(listenerOpts.bind, listenerOpts.port)
+	if old, exists := listenerMap[listenerMapKey]; exists {
+		pluginParams.Push.Add(model.ProxyStatusConflictInboundListener, pluginParams.Node.ID, pluginParams.Node,
+			fmt.Sprintf("Conflicting inbound listener:%s. existing: %s, incoming: %s", listenerMapKey,
+				old.instanceHostname, instance.Service.Hostname))
+		return nil
+	}
+
+	clusterName := pluginParams.InboundClusterName
+	if clusterName == "" {
+		clusterName = model.BuildSubsetKey(model.TrafficDirectionInbound, instance.Endpoint.ServicePort.Name,
+			instance.Service.Hostname, instance.Endpoint.ServicePort.Port)
+	}
+	l := buildUDPListener(pluginParams.Node, listenerOpts.bind, listenerOpts.port, listenerOpts.bindToPort, clusterName)
+	l.TrafficDirection = core.TrafficDirection_INBOUND
+
+	listenerMap[listenerMapKey] = &inboundListenerEntry{
+		bind:             listenerOpts.bind,
+		instanceHostname: instance.Service.Hostname,
+	}
+	return l
+}
+
+// buildSidecarOutboundUDPListener builds the outbound listener for a UDP service port and adds it to the listener map.
+// Datagrams are sent to the destination of the first VirtualService TCP route matching the port, if any, or to the
+// service itself otherwise.
+func (configgen *ConfigGeneratorImpl) buildSidecarOutboundUDPListener(node *model.Proxy, listenerOpts buildListenerOpts,
+	pluginParams *plugin.InputParams, listenerMap map[string]*outboundListenerEntry,
+	virtualServices []model.Config, actualWildcard string) {
+	service := pluginParams.Service
+	if service == nil {
+		// UDP egress listeners declared in the Sidecar API have no service to send datagrams to
+		return
+	}
+
+	if len(listenerOpts.bind) == 0 {
+		// Listen on the service VIP if it is an IP address. Datagrams can't be matched on their destination
+		// address, so CIDRs fall back to the wildcard address.
+		svcListenAddress := service.GetServiceAddressForProxy(node)
+		if len(svcListenAddress) > 0 && !strings.Contains(svcListenAddress, "/") {
+			listenerOpts.bind = svcListenAddress
+		} else {
+			listenerOpts.bind = actualWildcard
+		}
+	}
+
+	listenerMapKey := udpListenerKey(listenerOpts.bind, pluginParams.Port.Port)
+	if currentListenerEntry, exists := listenerMap[listenerMapKey]; exists {
+		if !currentListenerEntry.locked {
+			outboundListenerConflict{
+				metric:          model.ProxyStatusConflictOutboundListenerUDPOverUDP,
+				node:            node,
+				listenerName:    listenerMapKey,
+				currentServices: currentListenerEntry.services,
+				currentProtocol: currentListenerEntry.servicePort.Protocol,
+				newHostname:     service.Hostname,
+				newProtocol:     pluginParams.Port.Protocol,
+			}.addMetric(node, pluginParams.Push)
+		}
+		return
+	}
+
+	clusterName := ""
+	meshGateway := map[string]bool{constants.IstioMeshGateway: true}
+routes:
+	for _, config := range getConfigsForHost(service.Hostname, virtualServices) {
+		for _, tcp := range config.Spec.(*networking.VirtualService).Tcp {
+			if udpRouteMatches(tcp.Match, node, meshGateway, pluginParams.Port.Port) {
+				clusterName = udpRouteCluster(node, pluginParams.Push, tcp.Route, pluginParams.Port.Port)
+				break routes
+			}
+		}
+	}
+	if clusterName == "" {
+		clusterName = model.BuildSubsetKey(model.TrafficDirectionOutbound, "", service.Hostname, pluginParams.Port.Port)
+	}
+
+	l := buildUDPListener(node, listenerOpts.bind, pluginParams.Port.Port, listenerOpts.bindToPort, clusterName)
+	l.TrafficDirection = core.TrafficDirection_OUTBOUND
+
+	listenerMap[listenerMapKey] = &outboundListenerEntry{
+		services:    []*model.Service{service},
+		servicePort: pluginParams.Port,
+		bind:        listenerOpts.bind,
+		listener:    l,
+		protocol:    pluginParams.Port.Protocol,
+	}
+}
+
+// udpRouteMatches returns true if a VirtualService TCP route with the given match conditions applies to the UDP
+// traffic of the proxy on the given port. Destination subnets can't be honored by a UDP listener, so routes
+// matching on them are ignored.
+func udpRouteMatches(matches []*networking.L4MatchAttributes, node *model.Proxy, gateways map[string]bool, port int) bool {
+	if len(matches) == 0 {
+		return true
+	}
+	for _, match := range matches {
+		if len(match.DestinationSubnets) == 0 && matchTCP(match, node.WorkloadLabels, gateways, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildGatewayUDPListener builds the listener for the UDP servers of a gateway port. Datagrams are sent to the
+// destination of the first VirtualService TCP route bound to the servers.
+func buildGatewayUDPListener(node *model.Proxy, push *model.PushContext, bind string, portNumber uint32,
+	servers []*networking.Server, mergedGateway *model.MergedGateway) *xdsapi.Listener {
+	for _, server := range servers {
+		gatewaysForWorkload := map[string]bool{mergedGateway.GatewayNameForServer[server]: true}
+		gatewayServerHosts := make(map[host.Name]bool, len(server.Hosts))
+		for _, hostname := range server.Hosts {
+			gatewayServerHosts[host.Name(hostname)] = true
+		}
+
+		for _, v := range push.VirtualServices(node, gatewaysForWorkload) {
+			if len(pickMatchingGatewayHosts(gatewayServerHosts, v)) == 0 {
+				continue
+			}
+			for _, tcp := range v.Spec.(*networking.VirtualService).Tcp {
+				if !l4MultiMatch(tcp.Match, server, gatewaysForWorkload) {
+					continue
+				}
+				clusterName := udpRouteCluster(node, push, tcp.Route, int(portNumber))
+				if clusterName == "" {
+					continue
+				}
+				l := buildUDPListener(node, bind, int(portNumber), true, clusterName)
+				l.TrafficDirection = core.TrafficDirection_OUTBOUND
+				return l
+			}
+		}
+	}
+
+	log.Debugf("buildGatewayListeners: no route for UDP port %d of gateway proxy %s", portNumber, node.ID)
+	return nil
+}
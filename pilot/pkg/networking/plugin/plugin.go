@@ -40,6 +40,8 @@ const (
 	ListenerProtocolHTTP
 	// ListenerProtocolAuto enables auto protocol detection
 	ListenerProtocolAuto
+	// ListenerProtocolUDP is a UDP listener.
+	ListenerProtocolUDP
 
 	// Authn is the name of the authentication plugin passed through the command line
 	Authn = "authn"
@@ -77,7 +79,7 @@ func ModelProtocolToListenerProtocol(node *model.Proxy, p protocol.Instance,
 		protocol.Mongo, protocol.Redis, protocol.MySQL:
 		return ListenerProtocolTCP
 	case protocol.UDP:
-		return ListenerProtocolUnknown
+		return ListenerProtocolUDP
 	default:
 		return ListenerProtocolAuto
 	}
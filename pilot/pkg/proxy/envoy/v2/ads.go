@@ -51,6 +51,9 @@ var (
 	// SendTimeout is the max time to wait for a ADS send to complete. This helps detect
 	// clients in a bad state (not reading). In future it may include checking for ACK
 	SendTimeout = 5 * time.Second
+
+	// errConnectionClosed is recorded in the push trace of a proxy that disconnected before its push was sent.
+	errConnectionClosed = errors.New("connection closed")
 )
 
 // DiscoveryStream is a common interface for EDS and ADS. It also has a
@@ -129,6 +132,9 @@ type XdsEvent struct {
 	// start represents the time a push was started.
 	start time.Time
 
+	// trace records the timeline of the push, if enabled.
+	trace *model.PushTrace
+
 	// function to call once a push is finished. This must be called or future changes may be blocked.
 	done func()
 
@@ -174,6 +180,18 @@ func receiveThread(con *XdsConnection, reqChannel chan *xdsapi.DiscoveryRequest,
 
 // StreamAggregatedResources implements the ADS interface.
 func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return s.stream(stream)
+}
+
+// DeltaAggregatedResources implements the Delta ADS interface. Requests and pushes are handled as for
+// StreamAggregatedResources, but only the resources that changed since the last response acknowledged by
+// the proxy are sent.
+func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return s.stream(newDeltaStream(stream))
+}
+
+// stream handles an ADS stream, for both the state of the world and the delta protocols.
+func (s *DiscoveryServer) stream(stream DiscoveryStream) error {
 	peerInfo, ok := peer.FromContext(stream.Context())
 	peerAddr := "0.0.0.0"
 	if ok {
@@ -396,6 +414,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 			// from it.
 
 			err := s.pushConnection(con, pushEv)
+			pushEv.trace.ProxyDone(con.ConID, err)
 			pushEv.done()
 			if err != nil {
 				return nil
@@ -481,11 +500,6 @@ func (s *DiscoveryServer) initConnectionNode(node *core.Node, con *XdsConnection
 	return nil
 }
 
-// DeltaAggregatedResources is not implemented.
-func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
-	return status.Errorf(codes.Unimplemented, "not implemented")
-}
-
 // Compute and send the new configuration for a connection. This is blocking and may be slow
 // for large configs. The method will hold a lock on con.pushMutex.
 func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) error {
@@ -494,12 +508,16 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 	if pushEv.edsUpdatedServices != nil {
 		if !ProxyNeedsPush(con.node, pushEv) {
 			adsLog.Debugf("Skipping EDS push to %v, no updates required", con.ConID)
+			pushEv.trace.ProxySkipped(con.ConID)
 			return nil
 		}
 		// Push only EDS. This is indexed already - push immediately
 		// (may need a throttle)
 		if len(con.Clusters) > 0 {
-			if err := s.pushEds(pushEv.push, con, versionInfo(), pushEv.edsUpdatedServices); err != nil {
+			t0 := time.Now()
+			err := s.pushEds(pushEv.push, con, versionInfo(), pushEv.edsUpdatedServices)
+			pushEv.trace.ProxyGenerated(con.ConID, "EDS", time.Since(t0))
+			if err != nil {
 				return err
 			}
 		}
@@ -532,6 +550,7 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 	// This depends on SidecarScope updates, so it should be called after SetSidecarScope.
 	if !ProxyNeedsPush(con.node, pushEv) {
 		adsLog.Debugf("Skipping push to %v, no updates required", con.ConID)
+		pushEv.trace.ProxySkipped(con.ConID)
 		return nil
 	}
 
@@ -540,27 +559,41 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 	// check version, suppress if changed.
 	currentVersion := versionInfo()
 
-	if con.CDSWatch {
+	// Delta connections are only pushed the resource types affected by the changed configs
+	pushTypes := allPushTypes
+	if _, ok := con.stream.(*deltaStream); ok {
+		pushTypes = deltaPushTypes(con.node, pushEv.configTypesUpdated)
+	}
+
+	if con.CDSWatch && pushTypes[ClusterType] {
+		t0 := time.Now()
 		err := s.pushCds(con, pushEv.push, currentVersion)
+		pushEv.trace.ProxyGenerated(con.ConID, "CDS", time.Since(t0))
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(con.Clusters) > 0 {
+	if len(con.Clusters) > 0 && pushTypes[EndpointType] {
+		t0 := time.Now()
 		err := s.pushEds(pushEv.push, con, currentVersion, nil)
+		pushEv.trace.ProxyGenerated(con.ConID, "EDS", time.Since(t0))
 		if err != nil {
 			return err
 		}
 	}
-	if con.LDSWatch {
+	if con.LDSWatch && pushTypes[ListenerType] {
+		t0 := time.Now()
 		err := s.pushLds(con, pushEv.push, currentVersion)
+		pushEv.trace.ProxyGenerated(con.ConID, "LDS", time.Since(t0))
 		if err != nil {
 			return err
 		}
 	}
-	if len(con.Routes) > 0 {
+	if len(con.Routes) > 0 && pushTypes[RouteType] {
+		t0 := time.Now()
 		err := s.pushRoute(con, pushEv.push, currentVersion)
+		pushEv.trace.ProxyGenerated(con.ConID, "RDS", time.Since(t0))
 		if err != nil {
 			return err
 		}
@@ -601,11 +634,14 @@ func (s *DiscoveryServer) ProxyUpdate(clusterID, ip string) {
 		}
 	}
 
-	s.pushQueue.Enqueue(connection, &model.PushRequest{
+	req := &model.PushRequest{
 		Full:  true,
 		Push:  s.globalPushContext(),
 		Start: time.Now(),
-	})
+	}
+	req.Trace = s.pushTraces.New([]model.PushTrigger{{Time: req.Start, Full: true, Proxy: connection.ConID}}, req.Start)
+	s.pushQueue.Enqueue(connection, req)
+	req.Trace.Enqueued()
 }
 
 // AdsPushAll will send updates to all nodes, for a full config or incremental EDS.
@@ -675,6 +711,7 @@ func (s *DiscoveryServer) startPush(req *model.PushRequest) {
 	for _, p := range pending {
 		s.pushQueue.Enqueue(p, req)
 	}
+	req.Trace.Enqueued()
 }
 
 func ProxyNeedsPush(proxy *model.Proxy, pushEv *XdsEvent) bool {
@@ -768,7 +805,10 @@ func (conn *XdsConnection) send(res *xdsapi.DiscoveryResponse) error {
 	t := time.NewTimer(SendTimeout)
 	go func() {
 		err := conn.stream.Send(res)
-		done <- err
+		if err == errDeltaUnchanged {
+			// Nothing was sent, the proxy already has all the resources of the response
+			err = nil
+		}
 		conn.mu.Lock()
 		if res.Nonce != "" {
 			switch res.TypeUrl {
@@ -786,6 +826,7 @@ func (conn *XdsConnection) send(res *xdsapi.DiscoveryResponse) error {
 			conn.RouteVersionInfoSent = res.VersionInfo
 		}
 		conn.mu.Unlock()
+		done <- err
 	}()
 	select {
 	case <-t.C:
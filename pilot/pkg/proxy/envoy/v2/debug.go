@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/http"
 	"sort"
+	"strconv"
 
 	"istio.io/istio/pilot/pkg/features"
 
@@ -77,6 +78,7 @@ func (s *DiscoveryServer) InitDebug(mux *http.ServeMux, sctl *aggregate.Controll
 	mux.HandleFunc("/debug/authenticationz", s.Authenticationz)
 	mux.HandleFunc("/debug/config_dump", s.ConfigDump)
 	mux.HandleFunc("/debug/push_status", s.PushStatusHandler)
+	mux.HandleFunc("/debug/pushz", s.pushz)
 }
 
 // SyncStatus is the synchronization status between Pilot and a given Envoy
@@ -597,6 +599,56 @@ func (s *DiscoveryServer) PushStatusHandler(w http.ResponseWriter, req *http.Req
 	_, _ = w.Write(out)
 }
 
+// pushz dumps the timeline of the recent pushes, newest first. A single push can be selected with
+// the id parameter, and the pushes to a proxy with the proxy parameter (its connection ID).
+func (s *DiscoveryServer) pushz(w http.ResponseWriter, req *http.Request) {
+	if !s.pushTraces.Enabled() {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprint(w, "Push tracing is disabled. Please set the "+
+			"PILOT_PUSH_TRACE_SIZE environment variable to a positive value to enable.")
+		return
+	}
+	_ = req.ParseForm()
+
+	var out interface{}
+	if id := req.Form.Get("id"); id != "" {
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, "invalid push id %q", id)
+			return
+		}
+		trace := s.pushTraces.Get(n)
+		if trace == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprintf(w, "push %d not found", n)
+			return
+		}
+		out = trace
+	} else {
+		traces := s.pushTraces.List()
+		if proxy := req.Form.Get("proxy"); proxy != "" {
+			filtered := make([]*model.PushTrace, 0, len(traces))
+			for _, t := range traces {
+				if t.HasProxy(proxy) {
+					filtered = append(filtered, t)
+				}
+			}
+			traces = filtered
+		}
+		out = traces
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal push traces: %v", err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
 func writeAllADS(w io.Writer) {
 	adsClientsMutex.RLock()
 	defer adsClientsMutex.RUnlock()
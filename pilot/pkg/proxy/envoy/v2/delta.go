@@ -0,0 +1,343 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+// errDeltaUnchanged is returned by deltaStream.Send when the proxy already has all the resources of a
+// response. Nothing is sent in that case.
+var errDeltaUnchanged = errors.New("no resource changed")
+
+// deltaStream adapts a Delta ADS stream to the state of the world DiscoveryStream, so that delta
+// connections are handled by the same request and push code. Delta requests are converted to the
+// equivalent state of the world requests. Pushes only generate the resource types affected by the
+// changed configs (see deltaPushTypes), and the generated responses are diffed against the resources the
+// proxy already has: only the changed and removed resources are sent.
+type deltaStream struct {
+	grpc.ServerStream
+	delta ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+
+	// mu protects types and partial, as requests are received and responses sent from different goroutines.
+	mu sync.Mutex
+	// types holds the state of each resource type, keyed by type URL.
+	types map[string]*deltaTypeState
+	// partial holds the nonces of the responses with only some of the resources of their type, such as
+	// incremental EDS responses. The resources missing from them are not removed.
+	partial map[string]struct{}
+}
+
+// deltaTypeState is the state of a resource type on a delta stream.
+type deltaTypeState struct {
+	// versions holds the version of each resource the proxy has, keyed by name. The version is a hash of
+	// the resource, so that it is consistent across Pilot instances.
+	versions map[string]string
+	// subscribed is the set of resources the proxy subscribed to. It is not used for clusters and
+	// listeners, of which proxies always get all the resources.
+	subscribed map[string]struct{}
+	// pending holds the responses sent and neither acked nor nacked yet, oldest first.
+	pending []deltaResponse
+	// lastVersion is the version of the last response sent.
+	lastVersion string
+}
+
+// deltaResponse records a response sent on a delta stream.
+type deltaResponse struct {
+	nonce   string
+	version string
+	// names of the resources updated by the response.
+	names []string
+}
+
+func newDeltaStream(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) *deltaStream {
+	return &deltaStream{
+		ServerStream: stream,
+		delta:        stream,
+		types:        make(map[string]*deltaTypeState),
+		partial:      make(map[string]struct{}),
+	}
+}
+
+// isWildcardType returns true if proxies get all the resources of a type, rather than the ones they
+// subscribe to.
+func isWildcardType(typeURL string) bool {
+	return typeURL == ClusterType || typeURL == ListenerType
+}
+
+func (d *deltaStream) state(typeURL string) *deltaTypeState {
+	st, f := d.types[typeURL]
+	if !f {
+		st = &deltaTypeState{
+			versions:   make(map[string]string),
+			subscribed: make(map[string]struct{}),
+		}
+		d.types[typeURL] = st
+	}
+	return st
+}
+
+// Recv receives the next delta request and returns the equivalent state of the world request.
+func (d *deltaStream) Recv() (*xdsapi.DiscoveryRequest, error) {
+	req, err := d.delta.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := d.state(req.TypeUrl)
+
+	// Resources the proxy got from a previous connection don't need to be sent again if they are unchanged
+	for name, version := range req.InitialResourceVersions {
+		st.versions[name] = version
+	}
+
+	subscriptionChanged := len(req.ResourceNamesSubscribe) > 0 || len(req.ResourceNamesUnsubscribe) > 0
+	for _, name := range req.ResourceNamesSubscribe {
+		st.subscribed[name] = struct{}{}
+	}
+	for _, name := range req.ResourceNamesUnsubscribe {
+		delete(st.subscribed, name)
+		// The proxy drops the resource, so it must be sent again if it is subscribed to later
+		delete(st.versions, name)
+	}
+
+	out := &xdsapi.DiscoveryRequest{
+		Node:        req.Node,
+		TypeUrl:     req.TypeUrl,
+		ErrorDetail: req.ErrorDetail,
+	}
+	if !isWildcardType(req.TypeUrl) {
+		out.ResourceNames = make([]string, 0, len(st.subscribed))
+		for name := range st.subscribed {
+			out.ResourceNames = append(out.ResourceNames, name)
+		}
+		sort.Strings(out.ResourceNames)
+	}
+
+	if req.ResponseNonce != "" {
+		res := st.ack(req.ResponseNonce, req.ErrorDetail != nil)
+		if subscriptionChanged {
+			// A request changing the subscriptions must not be mistaken for an ACK, or the new resources
+			// would not be pushed. The response it acks or nacks was handled above.
+			out.ErrorDetail = nil
+			return out, nil
+		}
+		out.ResponseNonce = req.ResponseNonce
+		if res != nil && req.ErrorDetail == nil {
+			out.VersionInfo = res.version
+		} else if res == nil {
+			// Expired or duplicate nonce
+			out.VersionInfo = st.lastVersion
+		}
+	}
+	return out, nil
+}
+
+// ack handles the ACK or NACK of the response with the given nonce, and returns it. It returns nil if
+// the nonce is not one of a pending response.
+func (st *deltaTypeState) ack(nonce string, nack bool) *deltaResponse {
+	for i := range st.pending {
+		res := st.pending[i]
+		if res.nonce != nonce {
+			continue
+		}
+		if nack {
+			// The proxy rejected the resources of the response: forget them, so that they are sent again
+			// by the next push.
+			for _, name := range res.names {
+				delete(st.versions, name)
+			}
+		}
+		// Responses sent before this one are implicitly acked
+		st.pending = st.pending[i+1:]
+		return &res
+	}
+	return nil
+}
+
+// markPartial records that the response with the given nonce has only some of the resources of its type.
+func (d *deltaStream) markPartial(nonce string) {
+	d.mu.Lock()
+	d.partial[nonce] = struct{}{}
+	d.mu.Unlock()
+}
+
+// Send sends the resources of a state of the world response that changed since they were last sent, along
+// with the names of the removed resources. It returns errDeltaUnchanged without sending anything if there
+// is no change.
+func (d *deltaStream) Send(res *xdsapi.DiscoveryResponse) error {
+	d.mu.Lock()
+	st := d.state(res.TypeUrl)
+	_, partial := d.partial[res.Nonce]
+	delete(d.partial, res.Nonce)
+
+	out := &xdsapi.DeltaDiscoveryResponse{
+		SystemVersionInfo: res.VersionInfo,
+		TypeUrl:           res.TypeUrl,
+		Nonce:             res.Nonce,
+	}
+	sent := deltaResponse{
+		nonce:   res.Nonce,
+		version: res.VersionInfo,
+	}
+	names := make(map[string]struct{}, len(res.Resources))
+	for _, r := range res.Resources {
+		if r == nil {
+			continue
+		}
+		name := resourceName(r)
+		names[name] = struct{}{}
+		version := resourceVersion(r)
+		if v, f := st.versions[name]; f && v == version {
+			continue
+		}
+		st.versions[name] = version
+		out.Resources = append(out.Resources, &xdsapi.Resource{
+			Name:     name,
+			Version:  version,
+			Resource: r,
+		})
+		sent.names = append(sent.names, name)
+	}
+	if !partial {
+		// The resources the proxy has and which are missing from the response no longer exist. Proxies only
+		// have the resources they subscribed to for the types other than clusters and listeners, as the
+		// unsubscribed ones are forgotten.
+		for name := range st.versions {
+			if _, f := names[name]; !f {
+				out.RemovedResources = append(out.RemovedResources, name)
+				delete(st.versions, name)
+			}
+		}
+		sort.Strings(out.RemovedResources)
+	}
+
+	if len(out.Resources) == 0 && len(out.RemovedResources) == 0 {
+		d.mu.Unlock()
+		return errDeltaUnchanged
+	}
+	st.pending = append(st.pending, sent)
+	st.lastVersion = res.VersionInfo
+	d.mu.Unlock()
+
+	return d.delta.Send(out)
+}
+
+// resourceName returns the name of a Cluster, Listener, RouteConfiguration or ClusterLoadAssignment,
+// without unmarshaling the whole resource. The name is the first field of all of them.
+func resourceName(r *any.Any) string {
+	b := proto.NewBuffer(r.Value)
+	for {
+		key, err := b.DecodeVarint()
+		if err != nil {
+			return ""
+		}
+		field, wireType := key>>3, key&7
+		switch wireType {
+		case proto.WireVarint:
+			_, err = b.DecodeVarint()
+		case proto.WireFixed64:
+			_, err = b.DecodeFixed64()
+		case proto.WireFixed32:
+			_, err = b.DecodeFixed32()
+		case proto.WireBytes:
+			var v []byte
+			v, err = b.DecodeRawBytes(false)
+			if err == nil && field == 1 {
+				return string(v)
+			}
+		default:
+			// Groups are not used by xDS resources
+			return ""
+		}
+		if err != nil {
+			return ""
+		}
+	}
+}
+
+// resourceVersion returns the version of a resource. Resources are marshaled deterministically, so the
+// hash of a resource only changes with its content.
+func resourceVersion(r *any.Any) string {
+	sum := sha256.Sum256(r.Value)
+	return hex.EncodeToString(sum[:16])
+}
+
+// allPushTypes are the resource types generated by a push for state of the world connections.
+var allPushTypes = map[string]bool{
+	ClusterType:  true,
+	EndpointType: true,
+	ListenerType: true,
+	RouteType:    true,
+}
+
+// deltaPushTypes returns the resource types a full push generates for a delta connection, given the types
+// of the configs changed by the push. The resources of the other types are unchanged, so they are not
+// generated. A push changing clusters also pushes endpoints, as Envoy needs them to warm the clusters.
+func deltaPushTypes(proxy *model.Proxy, configTypesUpdated map[string]struct{}) map[string]bool {
+	if len(configTypesUpdated) == 0 {
+		// The configs changed are unknown, e.g. for a mesh config or service registry update
+		return allPushTypes
+	}
+
+	out := make(map[string]bool)
+	for configType := range configTypesUpdated {
+		switch configType {
+		case schemas.VirtualService.Type:
+			out[ListenerType] = true
+			out[RouteType] = true
+		case schemas.Gateway.Type:
+			if proxy.Type == model.Router {
+				out[ListenerType] = true
+				out[RouteType] = true
+			}
+		case schemas.DestinationRule.Type:
+			out[ClusterType] = true
+			out[EndpointType] = true
+			out[RouteType] = true
+		case schemas.HTTPAPISpec.Type, schemas.HTTPAPISpecBinding.Type,
+			schemas.QuotaSpec.Type, schemas.QuotaSpecBinding.Type:
+			// Listeners must be pushed along with routes, or the routes are not reloaded
+			out[ListenerType] = true
+			out[RouteType] = true
+		case schemas.AuthenticationPolicy.Type, schemas.AuthenticationMeshPolicy.Type:
+			out[ClusterType] = true
+			out[EndpointType] = true
+			out[ListenerType] = true
+		case schemas.ServiceRole.Type, schemas.ServiceRoleBinding.Type, schemas.RbacConfig.Type,
+			schemas.ClusterRbacConfig.Type, schemas.AuthorizationPolicy.Type:
+			out[ListenerType] = true
+		default:
+			// Service entries, sidecars and Envoy filters may change any resource
+			return allPushTypes
+		}
+	}
+	return out
+}
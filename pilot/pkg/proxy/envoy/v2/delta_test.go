@@ -0,0 +1,306 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+type fakeDeltaStream struct {
+	grpc.ServerStream
+	requests  []*xdsapi.DeltaDiscoveryRequest
+	responses []*xdsapi.DeltaDiscoveryResponse
+}
+
+func (h *fakeDeltaStream) Send(res *xdsapi.DeltaDiscoveryResponse) error {
+	h.responses = append(h.responses, res)
+	return nil
+}
+
+func (h *fakeDeltaStream) Recv() (*xdsapi.DeltaDiscoveryRequest, error) {
+	req := h.requests[0]
+	h.requests = h.requests[1:]
+	return req, nil
+}
+
+func (h *fakeDeltaStream) Context() context.Context {
+	return context.Background()
+}
+
+func clusterResources(clusters ...*xdsapi.Cluster) []*any.Any {
+	var resources []*any.Any
+	for _, c := range clusters {
+		resources = append(resources, util.MessageToAny(c))
+	}
+	return resources
+}
+
+func deltaNames(res *xdsapi.DeltaDiscoveryResponse) []string {
+	var names []string
+	for _, r := range res.Resources {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestDeltaStreamSend(t *testing.T) {
+	fake := &fakeDeltaStream{}
+	stream := newDeltaStream(fake)
+
+	a := &xdsapi.Cluster{Name: "a"}
+	b := &xdsapi.Cluster{Name: "b"}
+	push := func(nonce string, clusters ...*xdsapi.Cluster) error {
+		return stream.Send(&xdsapi.DiscoveryResponse{
+			TypeUrl:     ClusterType,
+			VersionInfo: nonce,
+			Nonce:       nonce,
+			Resources:   clusterResources(clusters...),
+		})
+	}
+
+	if err := push("1", a, b); err != nil {
+		t.Fatal(err)
+	}
+	if got := deltaNames(fake.responses[0]); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("expected all the clusters to be sent, got %v", got)
+	}
+
+	if err := push("2", a, b); err != errDeltaUnchanged {
+		t.Fatalf("expected unchanged push, got %v", err)
+	}
+	if len(fake.responses) != 1 {
+		t.Fatalf("expected nothing to be sent, got %d responses", len(fake.responses))
+	}
+
+	changed := &xdsapi.Cluster{Name: "a", AltStatName: "changed"}
+	if err := push("3", changed, b); err != nil {
+		t.Fatal(err)
+	}
+	res := fake.responses[1]
+	if got := deltaNames(res); !reflect.DeepEqual(got, []string{"a"}) || len(res.RemovedResources) != 0 {
+		t.Fatalf("expected only cluster a to be sent, got %v", res)
+	}
+	version := resourceVersion(util.MessageToAny(changed))
+	if res.Nonce != "3" || res.SystemVersionInfo != "3" || res.TypeUrl != ClusterType ||
+		res.Resources[0].Version != version {
+		t.Fatalf("unexpected response %v", res)
+	}
+
+	if err := push("4", b); err != nil {
+		t.Fatal(err)
+	}
+	res = fake.responses[2]
+	if len(res.Resources) != 0 || !reflect.DeepEqual(res.RemovedResources, []string{"a"}) {
+		t.Fatalf("expected removed cluster a, got %v", res)
+	}
+}
+
+func TestDeltaStreamAck(t *testing.T) {
+	fake := &fakeDeltaStream{}
+	stream := newDeltaStream(fake)
+
+	a := &xdsapi.Cluster{Name: "a"}
+	push := func(nonce string) error {
+		return stream.Send(&xdsapi.DiscoveryResponse{
+			TypeUrl:     ClusterType,
+			VersionInfo: "v" + nonce,
+			Nonce:       nonce,
+			Resources:   clusterResources(a),
+		})
+	}
+	if err := push("1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// NACK: the rejected resources are sent again by the next push
+	fake.requests = append(fake.requests, &xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:       ClusterType,
+		ResponseNonce: "1",
+		ErrorDetail:   &status.Status{Message: "rejected"},
+	})
+	req, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ResponseNonce != "1" || req.ErrorDetail == nil || req.VersionInfo != "" {
+		t.Fatalf("unexpected NACK %v", req)
+	}
+	if err := push("2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.responses) != 2 {
+		t.Fatalf("expected the rejected cluster to be sent again, got %d responses", len(fake.responses))
+	}
+
+	// ACK
+	fake.requests = append(fake.requests, &xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:       ClusterType,
+		ResponseNonce: "2",
+	})
+	if req, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if req.ResponseNonce != "2" || req.VersionInfo != "v2" || req.ErrorDetail != nil {
+		t.Fatalf("unexpected ACK %v", req)
+	}
+	if err := push("3"); err != errDeltaUnchanged {
+		t.Fatalf("expected the acked cluster not to be sent again, got %v", err)
+	}
+
+	// Expired nonce
+	fake.requests = append(fake.requests, &xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:       ClusterType,
+		ResponseNonce: "1",
+	})
+	if req, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if req.ResponseNonce != "1" || req.VersionInfo != "v2" {
+		t.Fatalf("unexpected ACK of an expired nonce %v", req)
+	}
+}
+
+func TestDeltaStreamSubscriptions(t *testing.T) {
+	fake := &fakeDeltaStream{}
+	stream := newDeltaStream(fake)
+
+	recv := func(req *xdsapi.DeltaDiscoveryRequest) *xdsapi.DiscoveryRequest {
+		t.Helper()
+		fake.requests = append(fake.requests, req)
+		out, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	out := recv(&xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:                 EndpointType,
+		ResourceNamesSubscribe:  []string{"b", "a"},
+		InitialResourceVersions: map[string]string{"a": "1"},
+	})
+	if !reflect.DeepEqual(out.ResourceNames, []string{"a", "b"}) || out.ResponseNonce != "" {
+		t.Fatalf("unexpected request %v", out)
+	}
+
+	// Incremental EDS pushes only the endpoints of b, the endpoints of a are not removed
+	stream.markPartial("1")
+	err := stream.Send(&xdsapi.DiscoveryResponse{
+		TypeUrl: EndpointType,
+		Nonce:   "1",
+		Resources: []*any.Any{
+			util.MessageToAny(&xdsapi.ClusterLoadAssignment{ClusterName: "b",
+				Endpoints: []*endpoint.LocalityLbEndpoints{{Priority: 1}}}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := deltaNames(fake.responses[0]); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("expected only b to be sent, got %v", got)
+	}
+	if len(fake.responses[0].RemovedResources) != 0 {
+		t.Fatalf("expected no removed endpoints, got %v", fake.responses[0].RemovedResources)
+	}
+
+	// Changing the subscriptions is not an ACK, so that the new resources are pushed
+	out = recv(&xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:                  EndpointType,
+		ResponseNonce:            "1",
+		ResourceNamesSubscribe:   []string{"c"},
+		ResourceNamesUnsubscribe: []string{"a"},
+	})
+	if !reflect.DeepEqual(out.ResourceNames, []string{"b", "c"}) || out.ResponseNonce != "" {
+		t.Fatalf("unexpected request %v", out)
+	}
+
+	// A full push without the endpoints of b removes them
+	err = stream.Send(&xdsapi.DiscoveryResponse{
+		TypeUrl: EndpointType,
+		Nonce:   "2",
+		Resources: []*any.Any{
+			util.MessageToAny(&xdsapi.ClusterLoadAssignment{ClusterName: "c"}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := fake.responses[1]
+	if got := deltaNames(res); !reflect.DeepEqual(got, []string{"c"}) ||
+		!reflect.DeepEqual(res.RemovedResources, []string{"b"}) {
+		t.Fatalf("expected c to be sent and b removed, got %v", res)
+	}
+}
+
+func TestDeltaPushTypes(t *testing.T) {
+	sidecar := &model.Proxy{Type: model.SidecarProxy}
+	router := &model.Proxy{Type: model.Router}
+	cases := []struct {
+		name     string
+		proxy    *model.Proxy
+		configs  []string
+		expected map[string]bool
+	}{
+		{"unknown configs", sidecar, nil, allPushTypes},
+		{"virtual service", sidecar, []string{schemas.VirtualService.Type},
+			map[string]bool{ListenerType: true, RouteType: true}},
+		{"gateway for a sidecar", sidecar, []string{schemas.Gateway.Type}, map[string]bool{}},
+		{"gateway for a router", router, []string{schemas.Gateway.Type},
+			map[string]bool{ListenerType: true, RouteType: true}},
+		{"destination rule and authorization policy", sidecar,
+			[]string{schemas.DestinationRule.Type, schemas.AuthorizationPolicy.Type},
+			map[string]bool{ClusterType: true, EndpointType: true, ListenerType: true, RouteType: true}},
+		{"rbac", sidecar, []string{schemas.ServiceRoleBinding.Type}, map[string]bool{ListenerType: true}},
+		{"service entry", sidecar, []string{schemas.ServiceEntry.Type, schemas.VirtualService.Type}, allPushTypes},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			configs := make(map[string]struct{})
+			for _, config := range c.configs {
+				configs[config] = struct{}{}
+			}
+			if got := deltaPushTypes(c.proxy, configs); !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	cases := []proto.Message{
+		&xdsapi.Cluster{Name: "name", AltStatName: "alt"},
+		&xdsapi.Listener{Name: "name"},
+		&xdsapi.RouteConfiguration{Name: "name"},
+		&xdsapi.ClusterLoadAssignment{ClusterName: "name"},
+	}
+	for _, c := range cases {
+		if got := resourceName(util.MessageToAny(c)); got != "name" {
+			t.Errorf("%T: expected name, got %q", c, got)
+		}
+	}
+}
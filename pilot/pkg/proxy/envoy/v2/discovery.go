@@ -113,6 +113,9 @@ type DiscoveryServer struct {
 
 	// pushQueue is the buffer that used after debounce and before the real xds push.
 	pushQueue *PushQueue
+
+	// pushTraces keeps the timeline of the recent pushes, for /debug/pushz.
+	pushTraces *PushTraces
 }
 
 // EndpointShards holds the set of endpoint shards of a service. Registries update
@@ -151,6 +154,7 @@ func NewDiscoveryServer(
 		concurrentPushLimit:     make(chan struct{}, features.PushThrottle),
 		pushChannel:             make(chan *model.PushRequest, 10),
 		pushQueue:               NewPushQueue(),
+		pushTraces:              NewPushTraces(features.PushTraceSize),
 	}
 
 	// Flush cached discovery responses whenever services configuration change.
@@ -269,10 +273,12 @@ func (s *DiscoveryServer) Push(req *model.PushRequest) {
 		adsLog.Errorf("XDS: Failed to update services: %v", err)
 		// We can't push if we can't read the data - stick with previous version.
 		pushContextErrors.Increment()
+		req.Trace.Failed(err)
 		return
 	}
 
 	if err := s.updateServiceShards(push); err != nil {
+		req.Trace.Failed(err)
 		return
 	}
 
@@ -284,6 +290,7 @@ func (s *DiscoveryServer) Push(req *model.PushRequest) {
 	versionNum.Inc()
 	initContextTime := time.Since(t0)
 	adsLog.Debugf("InitContext %v for push took %s", versionLocal, initContextTime)
+	req.Trace.ContextInitialized(versionLocal, initContextTime)
 
 	versionMutex.Lock()
 	version = versionLocal
@@ -329,11 +336,11 @@ func (s *DiscoveryServer) ConfigUpdate(req *model.PushRequest) {
 // It ensures that at minimum minQuiet time has elapsed since the last event before processing it.
 // It also ensures that at most maxDelay is elapsed between receiving an event and processing it.
 func (s *DiscoveryServer) handleUpdates(stopCh <-chan struct{}) {
-	debounce(s.pushChannel, stopCh, s.Push)
+	debounce(s.pushChannel, stopCh, s.pushTraces, s.Push)
 }
 
 // The debounce helper function is implemented to enable mocking
-func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, pushFn func(req *model.PushRequest)) {
+func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, traces *PushTraces, pushFn func(req *model.PushRequest)) {
 	var timeChan <-chan time.Time
 	var startDebounce time.Time
 	var lastConfigUpdateTime time.Time
@@ -343,6 +350,8 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, pushFn func(re
 
 	// Keeps track of the push requests. If updates are debounce they will be merged.
 	var req *model.PushRequest
+	// The events merged into req, recorded in the trace of the push.
+	var triggers []model.PushTrigger
 
 	free := true
 	freeCh := make(chan struct{}, 1)
@@ -364,8 +373,10 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, pushFn func(re
 					quietTime, eventDelay, req.Full)
 
 				free = false
+				req.Trace = traces.New(triggers, startDebounce)
 				go push(req)
 				req = nil
+				triggers = nil
 				debouncedEvents = 0
 			}
 		} else {
@@ -381,6 +392,7 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, pushFn func(re
 		case r := <-ch:
 			if !features.EnableEDSDebounce.Get() && !r.Full {
 				// trigger push now, just for EDS
+				r.Trace = traces.New([]model.PushTrigger{model.NewPushTrigger(r)}, time.Now())
 				go pushFn(r)
 				continue
 			}
@@ -392,6 +404,9 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, pushFn func(re
 			}
 			debouncedEvents++
 
+			if traces.Enabled() {
+				triggers = append(triggers, model.NewPushTrigger(r))
+			}
 			req = req.Merge(r)
 		case <-timeChan:
 			if free {
@@ -423,6 +438,7 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 			}
 
 			proxiesQueueTime.Record(time.Since(info.Start).Seconds())
+			info.Trace.ProxyDequeued(client.ConID)
 
 			go func() {
 				edsUpdates := info.EdsUpdates
@@ -440,9 +456,11 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 					namespacesUpdated:  info.NamespacesUpdated,
 					configTypesUpdated: info.ConfigTypesUpdated,
 					noncePrefix:        info.Push.Version,
+					trace:              info.Trace,
 				}:
 					return
 				case <-client.stream.Context().Done(): // grpc stream was closed
+					info.Trace.ProxyDone(client.ConID, errConnectionClosed)
 					doneFunc()
 					adsLog.Infof("Client closed connection %v", client.ConID)
 				}
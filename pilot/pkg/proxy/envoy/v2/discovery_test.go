@@ -284,7 +284,7 @@ func TestDebounce(t *testing.T) {
 
 			wg.Add(1)
 			go func() {
-				debounce(updateCh, stopCh, fakePush)
+				debounce(updateCh, stopCh, NewPushTraces(0), fakePush)
 				wg.Done()
 			}()
 
@@ -27,6 +27,7 @@ import (
 
 	networkingapi "istio.io/api/networking/v1alpha3"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	networking "istio.io/istio/pilot/pkg/networking/core/v1alpha3"
 	"istio.io/istio/pilot/pkg/networking/core/v1alpha3/loadbalancer"
@@ -97,7 +98,7 @@ func loadAssignment(c *EdsCluster) *xdsapi.ClusterLoadAssignment {
 
 // buildEnvoyLbEndpoint packs the endpoint based on istio info.
 func buildEnvoyLbEndpoint(uid string, family model.AddressFamily, address string, port uint32,
-	network string, weight uint32, mtlsReady bool) *endpoint.LbEndpoint {
+	network string, weight uint32, health model.HealthStatus, mtlsReady bool) *endpoint.LbEndpoint {
 
 	var addr core.Address
 	switch family {
@@ -129,6 +130,7 @@ func buildEnvoyLbEndpoint(uid string, family model.AddressFamily, address string
 				Address: &addr,
 			},
 		},
+		HealthStatus: envoyHealthStatus(health),
 	}
 
 	// Istio telemetry depends on the metadata value being set for endpoints in the mesh.
@@ -160,6 +162,7 @@ func networkEndpointToEnvoyEndpoint(e *model.NetworkEndpoint, mtlsReady bool) (*
 				Address: addr,
 			},
 		},
+		HealthStatus: envoyHealthStatus(e.HealthStatus),
 	}
 
 	// Istio telemetry depends on the metadata value being set for endpoints in the mesh.
@@ -170,6 +173,19 @@ func networkEndpointToEnvoyEndpoint(e *model.NetworkEndpoint, mtlsReady bool) (*
 	return ep, nil
 }
 
+// envoyHealthStatus converts the health status of an endpoint. Healthy endpoints are left with an unknown
+// status, which Envoy also considers healthy.
+func envoyHealthStatus(health model.HealthStatus) core.HealthStatus {
+	switch health {
+	case model.Unhealthy:
+		return core.HealthStatus_UNHEALTHY
+	case model.Degraded:
+		return core.HealthStatus_DEGRADED
+	default:
+		return core.HealthStatus_UNKNOWN
+	}
+}
+
 // Determine Service associated with a hostname when there is no Sidecar scope. Which namespace the service comes from
 // is undefined, as we do not have enough information to make a smart decision
 func legacyServiceForHostname(hostname host.Name, serviceByHostname map[host.Name]map[string]*model.Service) *model.Service {
@@ -277,7 +293,14 @@ func (s *DiscoveryServer) updateServiceShards(push *model.PushContext) error {
 			entries := make([]*model.IstioEndpoint, 0)
 			for _, port := range svc.Ports {
 				if port.Protocol == protocol.UDP {
-					continue
+					// UDP endpoints are only used by the UDP proxy listeners. A UDP port declared with
+					// the same number as a port of another protocol shares its endpoints.
+					if !features.EnableUDPProxy.Get() {
+						continue
+					}
+					if _, exists := svc.Ports.GetByPort(port.Port); exists {
+						continue
+					}
 				}
 
 				// This loses track of grouping (shards)
@@ -298,6 +321,7 @@ func (s *DiscoveryServer) updateServiceShards(push *model.PushContext) error {
 						Network:         ep.Endpoint.Network,
 						Locality:        ep.GetLocality(),
 						LbWeight:        ep.Endpoint.LbWeight,
+						HealthStatus:    ep.Endpoint.HealthStatus,
 						Attributes:      ep.Service.Attributes,
 						MTLSReady:       ep.MTLSReady,
 					})
@@ -690,6 +714,10 @@ func (s *DiscoveryServer) pushEds(push *model.PushContext, con *XdsConnection, v
 	}
 
 	response := endpointDiscoveryResponse(loadAssignments, version, push.Version)
+	if ds, ok := con.stream.(*deltaStream); ok && edsUpdatedServices != nil {
+		// The endpoints of the services not updated are not removed
+		ds.markPartial(response.Nonce)
+	}
 	err := con.send(response)
 	edsPushTime.Record(time.Since(pushStart).Seconds())
 	if err != nil {
@@ -854,7 +882,8 @@ func buildLocalityLbEndpointsFromShards(
 				localityEpMap[ep.Locality] = locLbEps
 			}
 			if ep.EnvoyEndpoint == nil {
-				ep.EnvoyEndpoint = buildEnvoyLbEndpoint(ep.UID, ep.Family, ep.Address, ep.EndpointPort, ep.Network, ep.LbWeight, ep.MTLSReady)
+				ep.EnvoyEndpoint = buildEnvoyLbEndpoint(ep.UID, ep.Family, ep.Address, ep.EndpointPort, ep.Network, ep.LbWeight,
+					ep.HealthStatus, ep.MTLSReady)
 			}
 			locLbEps.LbEndpoints = append(locLbEps.LbEndpoints, ep.EnvoyEndpoint)
 
@@ -17,6 +17,7 @@ package v2
 import (
 	"net"
 
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/golang/protobuf/ptypes/wrappers"
 
@@ -73,8 +74,8 @@ func EndpointsByNetworkFilter(endpoints []*endpoint.LocalityLbEndpoints, conn *X
 					Value: uint32(multiples),
 				}
 				lbEndpoints = append(lbEndpoints, lbEp)
-			} else {
-				// Remote endpoint. Increase the weight counter
+			} else if lbEp.HealthStatus != core.HealthStatus_UNHEALTHY {
+				// Remote endpoint. Increase the weight counter, unless it does not receive traffic
 				remoteEps[epNetwork]++
 			}
 		}
@@ -214,8 +214,9 @@ func (sd *MemServiceDiscovery) SetEndpoints(service string, namespace string, en
 					Port:     p.Port,
 					Protocol: protocol.HTTP,
 				},
-				Locality: e.Locality,
-				LbWeight: e.LbWeight,
+				Locality:     e.Locality,
+				LbWeight:     e.LbWeight,
+				HealthStatus: e.HealthStatus,
 			},
 			ServiceAccount: e.ServiceAccount,
 		}
@@ -53,13 +53,19 @@ func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *model.PushRequest) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	pushInfo.Trace.ProxyEnqueued(proxy.ConID)
+
 	// If its already in progress, merge the info and return
 	if event, f := p.inProgress[proxy]; f {
+		if event != nil {
+			event.Trace.ProxySuperseded(proxy.ConID, pushInfo.Trace)
+		}
 		p.inProgress[proxy] = event.Merge(pushInfo)
 		return
 	}
 
 	if event, f := p.eventsMap[proxy]; f {
+		event.Trace.ProxySuperseded(proxy.ConID, pushInfo.Trace)
 		p.eventsMap[proxy] = event.Merge(pushInfo)
 		return
 	}
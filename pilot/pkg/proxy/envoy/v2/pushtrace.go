@@ -0,0 +1,87 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// PushTraces keeps the traces of the most recent pushes, for /debug/pushz.
+type PushTraces struct {
+	mu sync.RWMutex
+	// traces is a ring buffer holding up to size traces, next is the index of the oldest one.
+	traces []*model.PushTrace
+	next   int
+	size   int
+}
+
+// NewPushTraces creates a PushTraces keeping up to size traces. Tracing is disabled if size is 0.
+func NewPushTraces(size int) *PushTraces {
+	return &PushTraces{
+		traces: make([]*model.PushTrace, 0, size),
+		size:   size,
+	}
+}
+
+// Enabled returns true if traces are kept.
+func (p *PushTraces) Enabled() bool {
+	return p != nil && p.size > 0
+}
+
+// New creates the trace of a push triggered by the given events and keeps it, or returns nil if
+// tracing is disabled.
+func (p *PushTraces) New(triggers []model.PushTrigger, debounceStart time.Time) *model.PushTrace {
+	if !p.Enabled() {
+		return nil
+	}
+
+	t := model.NewPushTrace(triggers, debounceStart)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.traces) < p.size {
+		p.traces = append(p.traces, t)
+	} else {
+		p.traces[p.next] = t
+		p.next = (p.next + 1) % p.size
+	}
+	return t
+}
+
+// List returns the traces kept, newest first.
+func (p *PushTraces) List() []*model.PushTrace {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]*model.PushTrace, 0, len(p.traces))
+	for i := len(p.traces) - 1; i >= 0; i-- {
+		out = append(out, p.traces[(p.next+i)%len(p.traces)])
+	}
+	return out
+}
+
+// Get returns the trace with the given ID, or nil if it is not kept anymore.
+func (p *PushTraces) Get(id uint64) *model.PushTrace {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, t := range p.traces {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func TestPushTraces(t *testing.T) {
+	traces := NewPushTraces(3)
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, traces.New(nil, time.Now()).ID)
+	}
+
+	var got []uint64
+	for _, trace := range traces.List() {
+		got = append(got, trace.ID)
+	}
+	if want := []uint64{ids[4], ids[3], ids[2]}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected traces %v, got %v", want, got)
+	}
+	if traces.Get(ids[1]) != nil {
+		t.Fatalf("expected trace %d to be evicted", ids[1])
+	}
+	if traces.Get(ids[2]) == nil {
+		t.Fatalf("expected trace %d to be kept", ids[2])
+	}
+
+	disabled := NewPushTraces(0)
+	if disabled.New(nil, time.Now()) != nil || len(disabled.List()) != 0 {
+		t.Fatal("expected no trace when tracing is disabled")
+	}
+}
+
+func TestPushTraceQueue(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	queue := NewPushQueue()
+	traces := NewPushTraces(10)
+	proxy := createProxies(1)[0]
+
+	first := &model.PushRequest{Full: true, Push: &model.PushContext{}, Trace: traces.New(nil, time.Now())}
+	queue.Enqueue(proxy, first)
+	first.Trace.Enqueued()
+	second := &model.PushRequest{Full: true, Push: &model.PushContext{}, Trace: traces.New(nil, time.Now())}
+	queue.Enqueue(proxy, second)
+	second.Trace.Enqueued()
+
+	go doSendPushes(stopCh, make(chan struct{}, 1), queue)
+
+	select {
+	case ev := <-proxy.pushChannel:
+		if ev.trace != second.Trace {
+			t.Fatalf("expected the push to be traced by the newest trace")
+		}
+		ev.trace.ProxyGenerated(proxy.ConID, "CDS", time.Millisecond)
+		ev.trace.ProxyDone(proxy.ConID, nil)
+		ev.done()
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for push")
+	}
+
+	p := first.Trace.Proxies[proxy.ConID]
+	if p.SupersededBy != second.Trace.ID || first.Trace.End.IsZero() {
+		t.Fatalf("expected push %d to be superseded by %d and ended, got %+v", first.Trace.ID, second.Trace.ID, p)
+	}
+	p = second.Trace.Proxies[proxy.ConID]
+	if p.Dequeued.IsZero() || p.GenerationTime["CDS"] != "1ms" || second.Trace.End.IsZero() {
+		t.Fatalf("expected push %d to be dequeued, generated and ended, got %+v", second.Trace.ID, p)
+	}
+}
+
+func TestDebounceTrace(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	updateCh := make(chan *model.PushRequest)
+	pushCh := make(chan *model.PushRequest, 1)
+
+	go debounce(updateCh, stopCh, NewPushTraces(10), func(req *model.PushRequest) {
+		pushCh <- req
+	})
+
+	updateCh <- &model.PushRequest{Full: true, ConfigTypesUpdated: map[string]struct{}{"virtual-service": {}}}
+	updateCh <- &model.PushRequest{Full: false, EdsUpdates: map[string]struct{}{"foo.com": {}}}
+
+	select {
+	case req := <-pushCh:
+		if req.Trace == nil || len(req.Trace.Triggers) != 2 {
+			t.Fatalf("expected a trace with 2 triggers, got %+v", req.Trace)
+		}
+		if got := req.Trace.Triggers[0].ConfigTypes; !reflect.DeepEqual(got, []string{"virtual-service"}) {
+			t.Fatalf("unexpected config types %v", got)
+		}
+		if got := req.Trace.Triggers[1].EdsUpdates; !reflect.DeepEqual(got, []string{"foo.com"}) {
+			t.Fatalf("unexpected EDS updates %v", got)
+		}
+	case <-time.After(DebounceMax + time.Second):
+		t.Fatal("timed out waiting for push")
+	}
+}
@@ -373,6 +373,7 @@ func (a v1alpha1PolicyApplier) InboundFilterChain(sdsUdsPath string, meta *model
 					sdsUdsPath, meta),
 			},
 		}
+		authn_model.ApplyCRL(tls.CommonTlsContext.GetCombinedValidationContext().DefaultValidationContext)
 	}
 	mtls := GetMutualTLS(a.policy)
 	if mtls == nil {
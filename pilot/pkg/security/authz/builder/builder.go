@@ -16,6 +16,7 @@ package builder
 
 import (
 	tcp_filter "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
 	http_filter "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	tcp_config "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
 
@@ -49,24 +50,17 @@ func NewBuilder(trustDomain string, trustDomainAliases []string, serviceInstance
 	if p := policies.ListAuthorizationPolicies(configNamespace, workloadLabels); len(p) > 0 {
 		generator = v1beta1.NewGenerator(trustDomain, trustDomainAliases, p)
 		rbacLog.Debugf("v1beta1 authorization enabled for workload %v in %s", workloadLabels, configNamespace)
-	} else {
-		if serviceInstance.Service == nil {
-			rbacLog.Errorf("no service for serviceInstance: %v", serviceInstance)
-			return nil
-		}
-		serviceName := serviceInstance.Service.Attributes.Name
-		serviceNamespace := serviceInstance.Service.Attributes.Namespace
-		serviceMetadata, err := authz_model.NewServiceMetadata(serviceName, serviceNamespace, serviceInstance)
-		if err != nil {
-			rbacLog.Errorf("failed to create ServiceMetadata for %s: %s", serviceName, err)
-			return nil
-		}
-
-		serviceHostname := string(serviceInstance.Service.Hostname)
-		if policies.IsRBACEnabled(serviceHostname, serviceNamespace) {
-			generator = v1alpha1.NewGenerator(trustDomain, trustDomainAliases, serviceMetadata, policies, policies.IsGlobalPermissiveEnabled())
-			rbacLog.Debugf("v1alpha1 RBAC enabled for service %s", serviceHostname)
+		// The v1alpha1 policies are still enforced if all the v1beta1 policies are in dry-run mode, along with
+		// the shadow rules of the v1beta1 policies.
+		if isDryRunOnly(p) && serviceInstance.Service != nil {
+			if g := newV1alpha1Generator(trustDomain, trustDomainAliases, serviceInstance, policies); g != nil {
+				rbacLog.Debugf("v1alpha1 RBAC enforced with v1beta1 policies in dry-run mode for workload %v in %s",
+					workloadLabels, configNamespace)
+				generator = &dryRunGenerator{enforced: g, dryRun: generator}
+			}
 		}
+	} else {
+		generator = newV1alpha1Generator(trustDomain, trustDomainAliases, serviceInstance, policies)
 	}
 
 	if generator == nil {
@@ -79,6 +73,60 @@ func NewBuilder(trustDomain string, trustDomainAliases []string, serviceInstance
 	}
 }
 
+func newV1alpha1Generator(trustDomain string, trustDomainAliases []string, serviceInstance *model.ServiceInstance,
+	policies *model.AuthorizationPolicies) policy.Generator {
+	if serviceInstance.Service == nil {
+		rbacLog.Errorf("no service for serviceInstance: %v", serviceInstance)
+		return nil
+	}
+	serviceName := serviceInstance.Service.Attributes.Name
+	serviceNamespace := serviceInstance.Service.Attributes.Namespace
+	serviceMetadata, err := authz_model.NewServiceMetadata(serviceName, serviceNamespace, serviceInstance)
+	if err != nil {
+		rbacLog.Errorf("failed to create ServiceMetadata for %s: %s", serviceName, err)
+		return nil
+	}
+
+	serviceHostname := string(serviceInstance.Service.Hostname)
+	if !policies.IsRBACEnabled(serviceHostname, serviceNamespace) {
+		return nil
+	}
+	rbacLog.Debugf("v1alpha1 RBAC enabled for service %s", serviceHostname)
+	return v1alpha1.NewGenerator(trustDomain, trustDomainAliases, serviceMetadata, policies, policies.IsGlobalPermissiveEnabled())
+}
+
+// dryRunGenerator generates the rules of the enforced generator, and the shadow rules of the dry-run one.
+type dryRunGenerator struct {
+	enforced policy.Generator
+	dryRun   policy.Generator
+}
+
+func (g *dryRunGenerator) Generate(forTCPFilter bool) *http_config.RBAC {
+	config := g.enforced.Generate(forTCPFilter)
+	if config == nil {
+		return nil
+	}
+	if config.ShadowRules != nil {
+		// The shadow rules are already used by the policies in permissive mode
+		rbacLog.Warnf("ignored v1beta1 policies in dry-run mode, v1alpha1 RBAC permissive mode is enabled")
+		return config
+	}
+	if dryRun := g.dryRun.Generate(forTCPFilter); dryRun != nil {
+		config.ShadowRules = dryRun.ShadowRules
+	}
+	return config
+}
+
+// isDryRunOnly returns true if all the policies are in dry-run mode.
+func isDryRunOnly(policies []model.Config) bool {
+	for _, p := range policies {
+		if !authz_model.IsDryRun(p) {
+			return false
+		}
+	}
+	return true
+}
+
 // BuildHTTPFilter builds the RBAC HTTP filter.
 func (b *Builder) BuildHTTPFilter() *http_filter.HttpFilter {
 	if b == nil {
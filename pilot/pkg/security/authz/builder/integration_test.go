@@ -66,6 +66,21 @@ func TestBuildHTTPFilter(t *testing.T) {
 			policies: getPolicies("testdata/v1beta1-override-v1alpha1-in.yaml", t),
 			want:     getProto("testdata/v1beta1-override-v1alpha1-out.yaml", t),
 		},
+		{
+			name:     "v1beta1 dry-run",
+			policies: getPolicies("testdata/v1beta1-dry-run-in.yaml", t),
+			want:     getProto("testdata/v1beta1-dry-run-out.yaml", t),
+		},
+		{
+			name:     "v1beta1 dry-run only",
+			policies: getPolicies("testdata/v1beta1-dry-run-only-in.yaml", t),
+			want:     getProto("testdata/v1beta1-dry-run-only-out.yaml", t),
+		},
+		{
+			name:     "v1beta1 dry-run not override v1alpha1",
+			policies: getPolicies("testdata/v1beta1-dry-run-not-override-v1alpha1-in.yaml", t),
+			want:     getProto("testdata/v1beta1-dry-run-not-override-v1alpha1-out.yaml", t),
+		},
 		{
 			name:     "v1beta1 single policy",
 			policies: getPolicies("testdata/v1beta1-single-policy-in.yaml", t),
@@ -16,6 +16,7 @@ package model
 
 import (
 	"fmt"
+	"strconv"
 
 	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
 
@@ -34,6 +35,11 @@ const (
 	RBACTCPFilterName       = "envoy.filters.network.rbac"
 	RBACTCPFilterStatPrefix = "tcp."
 
+	// DryRunAnnotation is the annotation of an AuthorizationPolicy to enable its dry-run mode. A policy in
+	// dry-run mode is only rendered in the shadow rules of the RBAC filter: the requests it would deny are
+	// counted in the shadow_denied stats but not denied.
+	DryRunAnnotation = "istio.io/dry-run"
+
 	// attributes that could be used in both ServiceRoleBinding and ServiceRole.
 	attrRequestHeader = "request.headers" // header name is surrounded by brackets, e.g. "request.headers[User-Agent]".
 
@@ -80,6 +86,20 @@ type ServiceMetadata struct {
 	Attributes map[string]string // additional attributes of the service
 }
 
+// IsDryRun returns true if the policy is in dry-run mode.
+func IsDryRun(policy model.Config) bool {
+	value, found := policy.Annotations[DryRunAnnotation]
+	if !found {
+		return false
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		rbacLog.Errorf("ignored invalid %s annotation of policy %s/%s: %v", DryRunAnnotation, policy.Namespace, policy.Name, err)
+		return false
+	}
+	return dryRun
+}
+
 func NewServiceMetadata(name string, namespace string, service *model.ServiceInstance) (*ServiceMetadata, error) {
 	if namespace == "" {
 		return nil, fmt.Errorf("found empty namespace")
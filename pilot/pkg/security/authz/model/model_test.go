@@ -85,6 +85,38 @@ func TestNewServiceMetadata(t *testing.T) {
 	}
 }
 
+func TestIsDryRun(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:        "dry-run",
+			annotations: map[string]string{DryRunAnnotation: "true"},
+			want:        true,
+		},
+		{
+			name:        "not dry-run",
+			annotations: map[string]string{DryRunAnnotation: "false"},
+		},
+		{
+			name:        "invalid value",
+			annotations: map[string]string{DryRunAnnotation: "yes please"},
+		},
+	}
+
+	for _, tc := range testCases {
+		policy := model.Config{ConfigMeta: model.ConfigMeta{Annotations: tc.annotations}}
+		if got := IsDryRun(policy); got != tc.want {
+			t.Errorf("%s: got %v but want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
 func TestNewModel(t *testing.T) {
 	role := &istio_rbac.ServiceRole{
 		Rules: []*istio_rbac.AccessRule{
@@ -52,18 +52,42 @@ func (g *v1beta1Generator) Generate(forTCPFilter bool) *http_config.RBAC {
 		Action:   envoy_rbac.RBAC_ALLOW,
 		Policies: map[string]*envoy_rbac.Policy{},
 	}
+	// The shadow rules include the policies in dry-run mode, in addition to the enforced ones, to evaluate
+	// the requests as if the dry-run policies were enforced.
+	shadowRBAC := &envoy_rbac.RBAC{
+		Action:   envoy_rbac.RBAC_ALLOW,
+		Policies: map[string]*envoy_rbac.Policy{},
+	}
+	enforced, dryRun := false, false
 
 	for _, config := range g.policies {
+		isDryRun := authz_model.IsDryRun(config)
+		if isDryRun {
+			dryRun = true
+		} else {
+			enforced = true
+		}
 		spec := config.Spec.(*istio_rbac.AuthorizationPolicy)
 		for i, rule := range spec.Rules {
 			if p := g.generatePolicy(g.trustDomain, g.trustDomainAliases, rule, forTCPFilter); p != nil {
 				name := fmt.Sprintf("ns[%s]-policy[%s]-rule[%d]", config.Namespace, config.Name, i)
-				rbac.Policies[name] = p
-				rbacLog.Debugf("generated policy %s: %+v", name, p)
+				shadowRBAC.Policies[name] = p
+				if !isDryRun {
+					rbac.Policies[name] = p
+				}
+				rbacLog.Debugf("generated policy %s (dry-run %t): %+v", name, isDryRun, p)
 			}
 		}
 	}
-	return &http_config.RBAC{Rules: rbac}
+
+	if !dryRun {
+		return &http_config.RBAC{Rules: rbac}
+	}
+	if !enforced {
+		// Without rules, the RBAC filter does not enforce any policy.
+		return &http_config.RBAC{ShadowRules: shadowRBAC}
+	}
+	return &http_config.RBAC{Rules: rbac, ShadowRules: shadowRBAC}
 }
 
 func (g *v1beta1Generator) generatePolicy(trustDomain string, trustDomainAliases []string, rule *istio_rbac.Rule, forTCPFilter bool) *envoy_rbac.Policy {
@@ -177,7 +177,39 @@ func ConstructSdsSecretConfig(name, sdsUdsPath string, metadata *model.NodeMetad
 	}
 }
 
-// ConstructValidationContext constructs ValidationContext in CommonTLSContext.
+var (
+	crlMutex sync.RWMutex
+	crl      []byte
+)
+
+// SetCRL sets the PEM encoded CRL of the Istio CA, added to the validation contexts of Istio mutual TLS.
+func SetCRL(pem []byte) {
+	crlMutex.Lock()
+	defer crlMutex.Unlock()
+	crl = pem
+}
+
+// GetCRL returns the PEM encoded CRL of the Istio CA, if any.
+func GetCRL() []byte {
+	crlMutex.RLock()
+	defer crlMutex.RUnlock()
+	return crl
+}
+
+// ApplyCRL adds the CRL of the Istio CA, if any, to a validation context of Istio mutual TLS. Envoy then requires
+// a CRL for every CA of the chain of the peer certificates, so the CA only publishes a CRL if it signs with the root
+// certificate.
+func ApplyCRL(ctx *auth.CertificateValidationContext) {
+	if c := GetCRL(); len(c) > 0 && ctx != nil {
+		ctx.Crl = &core.DataSource{
+			Specifier: &core.DataSource_InlineBytes{
+				InlineBytes: c,
+			},
+		}
+	}
+}
+
+// ConstructValidationContext constructs ValidationContext in CommonTLSContext, for Istio mutual TLS.
 func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string) *auth.CommonTlsContext_ValidationContext {
 	ret := &auth.CommonTlsContext_ValidationContext{
 		ValidationContext: &auth.CertificateValidationContext{
@@ -192,6 +224,7 @@ func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string)
 	if len(subjectAltNames) > 0 {
 		ret.ValidationContext.VerifySubjectAltName = subjectAltNames
 	}
+	ApplyCRL(ret.ValidationContext)
 
 	return ret
 }
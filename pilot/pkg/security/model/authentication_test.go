@@ -201,3 +201,26 @@ func constructsdsconfighelper(tokenFileName, headerKey string, metaConfig *envoy
 		},
 	}
 }
+
+func TestConstructValidationContextWithCRL(t *testing.T) {
+	defer SetCRL(nil)
+
+	ctx := ConstructValidationContext("/etc/certs/root-cert.pem", []string{"spiffe://cluster.local/ns/foo/sa/bar"})
+	if ctx.ValidationContext.Crl != nil {
+		t.Errorf("Expected no CRL, got %v", ctx.ValidationContext.Crl)
+	}
+
+	SetCRL([]byte("crl"))
+	ctx = ConstructValidationContext("/etc/certs/root-cert.pem", []string{"spiffe://cluster.local/ns/foo/sa/bar"})
+	expected := &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{
+			InlineBytes: []byte("crl"),
+		},
+	}
+	if !reflect.DeepEqual(ctx.ValidationContext.Crl, expected) {
+		t.Errorf("ConstructValidationContext: got(%#v) != want(%#v)\n", ctx.ValidationContext.Crl, expected)
+	}
+
+	// A nil validation context is left untouched.
+	ApplyCRL(nil)
+}
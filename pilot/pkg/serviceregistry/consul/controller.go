@@ -16,6 +16,7 @@ package consul
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 
 	"github.com/hashicorp/consul/api"
@@ -37,6 +38,8 @@ type Controller struct {
 	serviceInstances map[string][]*model.ServiceInstance //key hostname value serviceInstance array
 	cacheMutex       sync.Mutex
 	initDone         bool
+	serviceHandlers  []func(*model.Service, model.Event)
+	instanceHandlers []func(*model.ServiceInstance, model.Event)
 }
 
 // NewController creates a new Consul controller
@@ -51,9 +54,8 @@ func NewController(addr string) (*Controller, error) {
 		client:  client,
 	}
 
-	//Watch the change events to refresh local caches
+	//Watch the change events to update local caches
 	monitor.AppendServiceHandler(controller.ServiceChanged)
-	monitor.AppendInstanceHandler(controller.InstanceChanged)
 	return &controller, err
 }
 
@@ -208,19 +210,13 @@ func (c *Controller) Run(stop <-chan struct{}) {
 
 // AppendServiceHandler implements a service catalog operation
 func (c *Controller) AppendServiceHandler(f func(*model.Service, model.Event)) error {
-	c.monitor.AppendServiceHandler(func(instances []*api.CatalogService, event model.Event) error {
-		f(convertService(instances), event)
-		return nil
-	})
+	c.serviceHandlers = append(c.serviceHandlers, f)
 	return nil
 }
 
 // AppendInstanceHandler implements a service catalog operation
 func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
-	c.monitor.AppendInstanceHandler(func(instance *api.CatalogService, event model.Event) error {
-		f(convertInstance(instance), event)
-		return nil
-	})
+	c.instanceHandlers = append(c.instanceHandlers, f)
 	return nil
 }
 
@@ -253,26 +249,31 @@ func (c *Controller) initCache() error {
 
 	for serviceName := range consulServices {
 		// get endpoints of a service from consul
-		endpoints, err := c.getCatalogService(serviceName, nil)
+		endpoints, err := c.getServiceEntries(serviceName, nil)
 		if err != nil {
 			return err
 		}
-		c.services[serviceName] = convertService(endpoints)
-
-		instances := make([]*model.ServiceInstance, len(endpoints))
-		for i, endpoint := range endpoints {
-			instances[i] = convertInstance(endpoint)
-		}
-		c.serviceInstances[serviceName] = instances
+		c.services[serviceName], c.serviceInstances[serviceName] = convertServiceEntries(endpoints)
 	}
+	c.updateServicesList()
+
+	c.initDone = true
+	return nil
+}
 
+func (c *Controller) updateServicesList() {
 	c.servicesList = make([]*model.Service, 0, len(c.services))
 	for _, value := range c.services {
 		c.servicesList = append(c.servicesList, value)
 	}
+}
 
-	c.initDone = true
-	return nil
+func convertServiceEntries(endpoints []*api.ServiceEntry) (*model.Service, []*model.ServiceInstance) {
+	instances := make([]*model.ServiceInstance, len(endpoints))
+	for i, endpoint := range endpoints {
+		instances[i] = convertInstance(endpoint)
+	}
+	return convertService(endpoints), instances
 }
 
 func (c *Controller) getServices() (map[string][]string, error) {
@@ -286,28 +287,83 @@ func (c *Controller) getServices() (map[string][]string, error) {
 }
 
 // nolint: unparam
-func (c *Controller) getCatalogService(name string, q *api.QueryOptions) ([]*api.CatalogService, error) {
-	endpoints, _, err := c.client.Catalog().Service(name, "", q)
+func (c *Controller) getServiceEntries(name string, q *api.QueryOptions) ([]*api.ServiceEntry, error) {
+	endpoints, _, err := c.client.Health().Service(name, "", false, q)
 	if err != nil {
-		log.Warnf("Could not retrieve service catalog from consul: %v", err)
+		log.Warnf("Could not retrieve service health from consul: %v", err)
 		return nil, err
 	}
 
 	return endpoints, nil
 }
 
-func (c *Controller) refreshCache() {
+// ServiceChanged updates the cache with the current instances of a service, and notifies the handlers of the
+// changes to the service and to its instances.
+func (c *Controller) ServiceChanged(name string, entries []*api.ServiceEntry, event model.Event) error {
+	var svc *model.Service
+	var instances []*model.ServiceInstance
+	if len(entries) > 0 {
+		svc, instances = convertServiceEntries(entries)
+	}
+
 	c.cacheMutex.Lock()
-	defer c.cacheMutex.Unlock()
-	c.initDone = false
-}
+	var prevSvc *model.Service
+	var prevInstances []*model.ServiceInstance
+	// Until the cache is initialized, the instances are fetched on the first access
+	if c.initDone {
+		prevSvc, prevInstances = c.services[name], c.serviceInstances[name]
+		if svc == nil {
+			delete(c.services, name)
+			delete(c.serviceInstances, name)
+		} else {
+			c.services[name] = svc
+			c.serviceInstances[name] = instances
+		}
+		c.updateServicesList()
+	}
+	c.cacheMutex.Unlock()
+
+	switch {
+	case svc == nil && prevSvc != nil:
+		c.notifyService(prevSvc, model.EventDelete)
+	case svc != nil && prevSvc == nil:
+		c.notifyService(svc, model.EventAdd)
+	case svc != nil && !reflect.DeepEqual(svc, prevSvc):
+		c.notifyService(svc, model.EventUpdate)
+	}
 
-func (c *Controller) InstanceChanged(instance *api.CatalogService, event model.Event) error {
-	c.refreshCache()
+	// Instances are keyed by endpoint, as a health change must only notify the changed instance
+	prev := make(map[string]*model.ServiceInstance, len(prevInstances))
+	for _, instance := range prevInstances {
+		prev[instanceKey(instance)] = instance
+	}
+	for _, instance := range instances {
+		key := instanceKey(instance)
+		if old, f := prev[key]; !f {
+			c.notifyInstance(instance, model.EventAdd)
+		} else if !reflect.DeepEqual(old, instance) {
+			c.notifyInstance(instance, model.EventUpdate)
+		}
+		delete(prev, key)
+	}
+	for _, instance := range prev {
+		c.notifyInstance(instance, model.EventDelete)
+	}
 	return nil
 }
 
-func (c *Controller) ServiceChanged(instances []*api.CatalogService, event model.Event) error {
-	c.refreshCache()
-	return nil
+func instanceKey(instance *model.ServiceInstance) string {
+	return fmt.Sprintf("%s:%d", instance.Endpoint.Address, instance.Endpoint.Port)
+}
+
+func (c *Controller) notifyService(svc *model.Service, event model.Event) {
+	for _, f := range c.serviceHandlers {
+		f(svc, event)
+	}
+}
+
+func (c *Controller) notifyInstance(instance *model.ServiceInstance, event model.Event) {
+	for _, f := range c.instanceHandlers {
+		f(instance, event)
+	}
 }
@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -38,8 +39,70 @@ type mockServer struct {
 	productpage []*api.CatalogService
 	reviews     []*api.CatalogService
 	rating      []*api.CatalogService
-	lock        sync.Mutex
+	// checks holds the health checks of the service instances, keyed by address
+	checks map[string]api.HealthChecks
+	lock   sync.Mutex
+	// consulIndex is the index of the catalog
 	consulIndex int
+	// serviceIndexes holds the index of the instances of each service, keyed by name
+	serviceIndexes map[string]int
+}
+
+// index returns the current index of the result of a query.
+func (m *mockServer) index(path string) int {
+	if name := strings.TrimPrefix(path, "/v1/health/service/"); name != path {
+		return m.serviceIndexes[name]
+	}
+	return m.consulIndex
+}
+
+// block emulates Consul blocking queries: a query waiting for a change of the current index blocks until
+// the index changes, for a second at most.
+func (m *mockServer) block(r *http.Request) {
+	index, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		m.lock.Lock()
+		changed := index != m.index(r.URL.Path)
+		m.lock.Unlock()
+		if changed {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// serviceEntries returns the result of a health query for the given instances.
+func (m *mockServer) serviceEntries(instances []*api.CatalogService) []*api.ServiceEntry {
+	entries := make([]*api.ServiceEntry, 0, len(instances))
+	for _, instance := range instances {
+		entries = append(entries, &api.ServiceEntry{
+			Node: &api.Node{
+				ID:         instance.ID,
+				Node:       instance.Node,
+				Address:    instance.Address,
+				Datacenter: instance.Datacenter,
+				Meta:       instance.NodeMeta,
+			},
+			Service: &api.AgentService{
+				ID:      instance.ServiceID,
+				Service: instance.ServiceName,
+				Tags:    instance.ServiceTags,
+				Meta:    instance.ServiceMeta,
+				Port:    instance.ServicePort,
+				Address: instance.ServiceAddress,
+			},
+			Checks: m.checks[instance.ServiceAddress],
+		})
+	}
+	return entries
 }
 
 func newServer() *mockServer {
@@ -106,46 +169,31 @@ func newServer() *mockServer {
 			"reviews":     {"version|v1", "version|v2", "version|v3"},
 			"rating":      {"version|v1"},
 		},
-		consulIndex: 1,
+		consulIndex:    1,
+		serviceIndexes: map[string]int{"productpage": 1, "reviews": 1, "rating": 1},
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/v1/catalog/services" {
-			m.lock.Lock()
-			data, _ := json.Marshal(&m.services)
-			w.Header().Set("X-Consul-Index", strconv.Itoa(m.consulIndex))
-			m.lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/reviews" {
-			m.lock.Lock()
-			data, _ := json.Marshal(&m.reviews)
-			w.Header().Set("X-Consul-Index", strconv.Itoa(m.consulIndex))
-			m.lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/productpage" {
-			m.lock.Lock()
-			data, _ := json.Marshal(&m.productpage)
-			w.Header().Set("X-Consul-Index", strconv.Itoa(m.consulIndex))
-			m.lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/rating" {
-			m.lock.Lock()
-			data, _ := json.Marshal(&m.rating)
-			w.Header().Set("X-Consul-Index", strconv.Itoa(m.consulIndex))
-			m.lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else {
-			m.lock.Lock()
-			data, _ := json.Marshal(&[]*api.CatalogService{})
-			w.Header().Set("X-Consul-Index", strconv.Itoa(m.consulIndex))
-			m.lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
+		m.block(r)
+
+		m.lock.Lock()
+		var data []byte
+		switch r.URL.Path {
+		case "/v1/catalog/services":
+			data, _ = json.Marshal(&m.services)
+		case "/v1/health/service/reviews":
+			data, _ = json.Marshal(m.serviceEntries(m.reviews))
+		case "/v1/health/service/productpage":
+			data, _ = json.Marshal(m.serviceEntries(m.productpage))
+		case "/v1/health/service/rating":
+			data, _ = json.Marshal(m.serviceEntries(m.rating))
+		default:
+			data, _ = json.Marshal(&[]*api.ServiceEntry{})
 		}
+		w.Header().Set("X-Consul-Index", strconv.Itoa(m.index(r.URL.Path)))
+		m.lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, string(data))
 	}))
 
 	m.server = server
@@ -582,6 +630,7 @@ func TestGetInstanceByCacheAfterChanged(t *testing.T) {
 		},
 	}
 	ts.consulIndex++
+	ts.serviceIndexes["reviews"]++
 	ts.lock.Unlock()
 
 	time.Sleep(notifyThreshold)
@@ -599,3 +648,55 @@ func TestGetInstanceByCacheAfterChanged(t *testing.T) {
 		}
 	}
 }
+
+func TestInstanceHealthChanged(t *testing.T) {
+	ts := newServer()
+	defer ts.server.Close()
+	controller, err := NewController(ts.server.URL)
+	if err != nil {
+		t.Errorf("could not create Consul Controller: %v", err)
+	}
+	instanceEvents := make(chan *model.ServiceInstance, 100)
+	_ = controller.AppendInstanceHandler(func(instance *model.ServiceInstance, event model.Event) {
+		if event == model.EventUpdate {
+			instanceEvents <- instance
+		}
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go controller.Run(stop)
+
+	hostname := serviceHostname("reviews")
+	svc := &model.Service{Hostname: hostname}
+	if _, err := controller.InstancesByPort(svc, 0, labels.Collection{}); err != nil {
+		t.Fatalf("client encountered error during Instances(): %v", err)
+	}
+
+	ts.lock.Lock()
+	ts.checks = map[string]api.HealthChecks{"172.19.0.7": {{Status: api.HealthCritical}}}
+	ts.serviceIndexes["reviews"]++
+	ts.lock.Unlock()
+
+	select {
+	case instance := <-instanceEvents:
+		if instance.Endpoint.Address != "172.19.0.7" || instance.Endpoint.HealthStatus != model.Unhealthy {
+			t.Fatalf("unexpected instance update %v", instance.Endpoint)
+		}
+	case <-time.After(notifyThreshold):
+		t.Fatal("got no instance update")
+	}
+
+	instances, err := controller.InstancesByPort(svc, 0, labels.Collection{})
+	if err != nil {
+		t.Fatalf("client encountered error during Instances(): %v", err)
+	}
+	for _, instance := range instances {
+		want := model.Healthy
+		if instance.Endpoint.Address == "172.19.0.7" {
+			want = model.Unhealthy
+		}
+		if instance.Endpoint.HealthStatus != want {
+			t.Errorf("instance %s has health %v, want %v", instance.Endpoint.Address, instance.Endpoint.HealthStatus, want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package consul
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/consul/api"
@@ -32,6 +33,11 @@ import (
 const (
 	protocolTagName = "protocol"
 	externalTagName = "external"
+
+	// The locality of a service instance is made of the datacenter of its node, used as the region,
+	// and of the zone and subzone set in the node metadata.
+	zoneMetaName    = "zone"
+	subzoneMetaName = "subzone"
 )
 
 func convertLabels(labelsStr []string) labels.Instance {
@@ -60,7 +66,7 @@ func convertPort(port int, name string) *model.Port {
 	}
 }
 
-func convertService(endpoints []*api.CatalogService) *model.Service {
+func convertService(endpoints []*api.ServiceEntry) *model.Service {
 	name := ""
 
 	meshExternal := false
@@ -68,9 +74,9 @@ func convertService(endpoints []*api.CatalogService) *model.Service {
 
 	ports := make(map[int]*model.Port)
 	for _, endpoint := range endpoints {
-		name = endpoint.ServiceName
+		name = endpoint.Service.Service
 
-		port := convertPort(endpoint.ServicePort, endpoint.ServiceMeta[protocolTagName])
+		port := convertPort(endpoint.Service.Port, endpoint.Service.Meta[protocolTagName])
 
 		if svcPort, exists := ports[port.Port]; exists && svcPort.Protocol != port.Protocol {
 			log.Warnf("Service %v has two instances on same port %v but different protocols (%v, %v)",
@@ -81,7 +87,7 @@ func convertService(endpoints []*api.CatalogService) *model.Service {
 
 		// TODO This will not work if service is a mix of external and local services
 		// or if a service has more than one external name
-		if endpoint.ServiceMeta[externalTagName] != "" {
+		if endpoint.Service.Meta[externalTagName] != "" {
 			meshExternal = true
 			resolution = model.Passthrough
 		}
@@ -91,6 +97,8 @@ func convertService(endpoints []*api.CatalogService) *model.Service {
 	for _, port := range ports {
 		svcPorts = append(svcPorts, port)
 	}
+	// Keep the ports ordered, so that unchanged services are equal
+	sort.Slice(svcPorts, func(i, j int) bool { return svcPorts[i].Port < svcPorts[j].Port })
 
 	hostname := serviceHostname(name)
 	out := &model.Service{
@@ -109,34 +117,36 @@ func convertService(endpoints []*api.CatalogService) *model.Service {
 	return out
 }
 
-func convertInstance(instance *api.CatalogService) *model.ServiceInstance {
-	svcLabels := convertLabels(instance.ServiceTags)
-	port := convertPort(instance.ServicePort, instance.ServiceMeta[protocolTagName])
+func convertInstance(entry *api.ServiceEntry) *model.ServiceInstance {
+	instance := entry.Service
+	svcLabels := convertLabels(instance.Tags)
+	port := convertPort(instance.Port, instance.Meta[protocolTagName])
 
-	addr := instance.ServiceAddress
+	addr := instance.Address
 	if addr == "" {
-		addr = instance.Address
+		addr = entry.Node.Address
 	}
 
 	meshExternal := false
 	resolution := model.ClientSideLB
-	externalName := instance.ServiceMeta[externalTagName]
+	externalName := instance.Meta[externalTagName]
 	if externalName != "" {
 		meshExternal = true
 		resolution = model.DNSLB
 	}
 
-	hostname := serviceHostname(instance.ServiceName)
+	hostname := serviceHostname(instance.Service)
 	return &model.ServiceInstance{
 		Endpoint: model.NetworkEndpoint{
-			Address:     addr,
-			Port:        instance.ServicePort,
-			ServicePort: port,
-			Locality:    instance.Datacenter,
+			Address:      addr,
+			Port:         instance.Port,
+			ServicePort:  port,
+			Locality:     convertLocality(entry.Node),
+			HealthStatus: convertHealthStatus(entry.Checks),
 		},
 		Service: &model.Service{
 			Hostname:     hostname,
-			Address:      instance.ServiceAddress,
+			Address:      instance.Address,
 			Ports:        model.PortList{port},
 			MeshExternal: meshExternal,
 			Resolution:   resolution,
@@ -149,6 +159,31 @@ func convertInstance(instance *api.CatalogService) *model.ServiceInstance {
 	}
 }
 
+// convertLocality returns the locality of the service instances of a node.
+func convertLocality(node *api.Node) string {
+	locality := node.Datacenter
+	if zone := node.Meta[zoneMetaName]; zone != "" {
+		locality += "/" + zone
+		if subzone := node.Meta[subzoneMetaName]; subzone != "" {
+			locality += "/" + subzone
+		}
+	}
+	return locality
+}
+
+// convertHealthStatus returns the health of a service instance from its service and node health checks.
+// Instances in maintenance are unhealthy.
+func convertHealthStatus(checks api.HealthChecks) model.HealthStatus {
+	switch checks.AggregatedStatus() {
+	case api.HealthCritical, api.HealthMaint:
+		return model.Unhealthy
+	case api.HealthWarning:
+		return model.Degraded
+	default:
+		return model.Healthy
+	}
+}
+
 // serviceHostname produces FQDN for a consul service
 func serviceHostname(name string) host.Name {
 	// TODO include datacenter in Hostname?
@@ -20,6 +20,7 @@ import (
 
 	"github.com/hashicorp/consul/api"
 
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/protocol"
 )
 
@@ -80,19 +81,23 @@ func TestConvertInstance(t *testing.T) {
 	tagKey2 := "zone"
 	tagVal2 := "prod"
 	dc := "dc1"
-	consulServiceInst := api.CatalogService{
-		Node:        "istio-node",
-		Address:     "172.19.0.5",
-		ID:          "1111-22-3333-444",
-		ServiceName: name,
-		ServiceTags: []string{
-			fmt.Sprintf("%v|%v", tagKey1, tagVal1),
-			fmt.Sprintf("%v|%v", tagKey2, tagVal2),
+	consulServiceInst := api.ServiceEntry{
+		Node: &api.Node{
+			Node:       "istio-node",
+			Address:    "172.19.0.5",
+			ID:         "1111-22-3333-444",
+			Datacenter: dc,
+		},
+		Service: &api.AgentService{
+			Service: name,
+			Tags: []string{
+				fmt.Sprintf("%v|%v", tagKey1, tagVal1),
+				fmt.Sprintf("%v|%v", tagKey2, tagVal2),
+			},
+			Address: ip,
+			Port:    port,
+			Meta:    map[string]string{protocolTagName: p},
 		},
-		ServiceAddress: ip,
-		ServicePort:    port,
-		Datacenter:     dc,
-		ServiceMeta:    map[string]string{protocolTagName: p},
 	}
 
 	out := convertInstance(&consulServiceInst)
@@ -117,6 +122,10 @@ func TestConvertInstance(t *testing.T) {
 		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.Address, ip)
 	}
 
+	if out.Endpoint.HealthStatus != model.Healthy {
+		t.Errorf("convertInstance() => %v, want %v", out.Endpoint.HealthStatus, model.Healthy)
+	}
+
 	if len(out.Labels) != 2 {
 		t.Errorf("convertInstance() len(Labels) => %v, want %v", len(out.Labels), 2)
 	}
@@ -157,31 +166,39 @@ func TestServiceHostname(t *testing.T) {
 
 func TestConvertService(t *testing.T) {
 	name := "productpage"
-	consulServiceInsts := []*api.CatalogService{
+	consulServiceInsts := []*api.ServiceEntry{
 		{
-			Node:        "istio-node",
-			Address:     "172.19.0.5",
-			ID:          "1111-22-3333-444",
-			ServiceName: name,
-			ServiceTags: []string{
-				"version=v1",
-				"zone=prod",
+			Node: &api.Node{
+				Node:    "istio-node",
+				Address: "172.19.0.5",
+				ID:      "1111-22-3333-444",
+			},
+			Service: &api.AgentService{
+				Service: name,
+				Tags: []string{
+					"version=v1",
+					"zone=prod",
+				},
+				Address: "172.19.0.11",
+				Port:    9080,
+				Meta:    map[string]string{protocolTagName: "udp"},
 			},
-			ServiceAddress: "172.19.0.11",
-			ServicePort:    9080,
-			ServiceMeta:    map[string]string{protocolTagName: "udp"},
 		},
 		{
-			Node:        "istio-node",
-			Address:     "172.19.0.5",
-			ID:          "1111-22-3333-444",
-			ServiceName: name,
-			ServiceTags: []string{
-				"version=v2",
+			Node: &api.Node{
+				Node:    "istio-node",
+				Address: "172.19.0.5",
+				ID:      "1111-22-3333-444",
+			},
+			Service: &api.AgentService{
+				Service: name,
+				Tags: []string{
+					"version=v2",
+				},
+				Address: "172.19.0.12",
+				Port:    9080,
+				Meta:    map[string]string{protocolTagName: "udp"},
 			},
-			ServiceAddress: "172.19.0.12",
-			ServicePort:    9080,
-			ServiceMeta:    map[string]string{protocolTagName: "udp"},
 		},
 	}
 
@@ -201,3 +218,44 @@ func TestConvertService(t *testing.T) {
 			len(out.Ports), 1)
 	}
 }
+
+func TestConvertLocality(t *testing.T) {
+	cases := []struct {
+		name string
+		node *api.Node
+		want string
+	}{
+		{"datacenter", &api.Node{Datacenter: "dc1"}, "dc1"},
+		{"zone", &api.Node{Datacenter: "dc1", Meta: map[string]string{zoneMetaName: "a"}}, "dc1/a"},
+		{"subzone", &api.Node{Datacenter: "dc1", Meta: map[string]string{zoneMetaName: "a", subzoneMetaName: "b"}}, "dc1/a/b"},
+		{"subzone without zone", &api.Node{Datacenter: "dc1", Meta: map[string]string{subzoneMetaName: "b"}}, "dc1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := convertLocality(c.node); got != c.want {
+				t.Errorf("convertLocality() => %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestConvertHealthStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		checks api.HealthChecks
+		want   model.HealthStatus
+	}{
+		{"no checks", nil, model.Healthy},
+		{"passing", api.HealthChecks{{Status: api.HealthPassing}}, model.Healthy},
+		{"warning", api.HealthChecks{{Status: api.HealthPassing}, {Status: api.HealthWarning}}, model.Degraded},
+		{"critical", api.HealthChecks{{Status: api.HealthWarning}, {Status: api.HealthCritical}}, model.Unhealthy},
+		{"maintenance", api.HealthChecks{{CheckID: api.NodeMaint, Status: api.HealthCritical}}, model.Unhealthy},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := convertHealthStatus(c.checks); got != c.want {
+				t.Errorf("convertHealthStatus() => %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@
 package consul
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -27,128 +29,166 @@ import (
 type Monitor interface {
 	Start(<-chan struct{})
 	AppendServiceHandler(ServiceHandler)
-	AppendInstanceHandler(InstanceHandler)
 }
 
-// InstanceHandler processes service instance change events
-type InstanceHandler func(instance *api.CatalogService, event model.Event) error
-
-// ServiceHandler processes service change events
-type ServiceHandler func(instances []*api.CatalogService, event model.Event) error
+// ServiceHandler processes the changes of a service and of its instances. instances holds all the current
+// instances of the service along with their health checks, and is empty if the service was deleted.
+type ServiceHandler func(name string, instances []*api.ServiceEntry, event model.Event) error
 
 type consulMonitor struct {
-	discovery        *api.Client
-	instanceHandlers []InstanceHandler
-	serviceHandlers  []ServiceHandler
+	discovery       *api.Client
+	serviceHandlers []ServiceHandler
+
+	// mutex serializes the handling of the catalog and service changes, which are watched concurrently
+	mutex sync.Mutex
+	// services holds the cancel function of the watch of each service of the catalog
+	services map[string]context.CancelFunc
+	// indexes holds the index of the last instances fetched for each service
+	indexes map[string]uint64
 }
 
 const (
-	refreshIdleTime    time.Duration = 5 * time.Second
-	periodicCheckTime  time.Duration = 2 * time.Second
 	blockQueryWaitTime time.Duration = 10 * time.Minute
+	// Failed queries are retried with an exponential backoff
+	initialRetryTime time.Duration = 100 * time.Millisecond
+	maxRetryTime     time.Duration = 30 * time.Second
 )
 
-// NewConsulMonitor watches for changes in Consul services and CatalogServices
+// NewConsulMonitor watches for changes in Consul services and their instances. Changes are watched with
+// blocking queries, on the catalog and on the instances of each service, so they are notified as soon as
+// they are made, and only the services that changed are fetched.
 func NewConsulMonitor(client *api.Client) Monitor {
 	return &consulMonitor{
-		discovery:        client,
-		instanceHandlers: make([]InstanceHandler, 0),
-		serviceHandlers:  make([]ServiceHandler, 0),
+		discovery:       client,
+		serviceHandlers: make([]ServiceHandler, 0),
+		services:        make(map[string]context.CancelFunc),
+		indexes:         make(map[string]uint64),
 	}
 }
 
 func (m *consulMonitor) Start(stop <-chan struct{}) {
-	change := make(chan struct{})
-	go m.watchConsul(change, stop)
-	go m.updateRecord(change, stop)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Abort the pending queries when stopping
+		<-stop
+		cancel()
+	}()
+	go m.watch(ctx, "services", func(q *api.QueryOptions) (*api.QueryMeta, error) {
+		return m.catalogChanged(ctx, q)
+	})
 }
 
-func (m *consulMonitor) watchConsul(change chan struct{}, stop <-chan struct{}) {
-	var consulWaitIndex uint64
+// catalogChanged fetches the services of the catalog, and starts or stops watching the instances of the
+// services added or deleted. The catalog does not tell which services had instances registered or
+// deregistered, so each service is watched with its own blocking query.
+func (m *consulMonitor) catalogChanged(ctx context.Context, q *api.QueryOptions) (*api.QueryMeta, error) {
+	services, meta, err := m.discovery.Catalog().Services(q)
+	if err != nil {
+		return nil, err
+	}
+	if meta.LastIndex == q.WaitIndex {
+		return meta, nil
+	}
 
-	for {
-		select {
-		case <-stop:
-			return
-		default:
-			queryOptions := api.QueryOptions{
-				WaitIndex: consulWaitIndex,
-				WaitTime:  blockQueryWaitTime,
-			}
-			// This Consul REST API will block until service changes or timeout
-			_, queryMeta, err := m.discovery.Catalog().Services(&queryOptions)
-			if err != nil {
-				log.Warnf("Could not fetch services: %v", err)
-			} else if consulWaitIndex != queryMeta.LastIndex {
-				consulWaitIndex = queryMeta.LastIndex
-				change <- struct{}{}
-			}
-			time.Sleep(periodicCheckTime)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for name, cancel := range m.services {
+		if _, f := services[name]; !f {
+			cancel()
+			delete(m.services, name)
+			delete(m.indexes, name)
+			m.notify(name, nil, model.EventDelete)
 		}
 	}
+	for name := range services {
+		if _, f := m.services[name]; f {
+			continue
+		}
+		serviceCtx, cancel := context.WithCancel(ctx)
+		m.services[name] = cancel
+		name := name
+		go m.watch(serviceCtx, "service "+name, func(q *api.QueryOptions) (*api.QueryMeta, error) {
+			return m.serviceChanged(name, q)
+		})
+	}
+	return meta, nil
 }
 
-func (m *consulMonitor) updateRecord(change <-chan struct{}, stop <-chan struct{}) {
-	lastChange := int64(0)
-	ticker := time.NewTicker(periodicCheckTime)
+// serviceChanged fetches the instances of a service and their health, and notifies them if the index of the
+// service changed. The index changes when instances are registered or deregistered, and when their health
+// checks or the ones of their nodes change.
+func (m *consulMonitor) serviceChanged(name string, q *api.QueryOptions) (*api.QueryMeta, error) {
+	instances, meta, err := m.discovery.Health().Service(name, "", false, q)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if q.Context().Err() != nil {
+		// The service was deleted while it was fetched
+		return meta, nil
+	}
+	index, f := m.indexes[name]
+	if f && index == meta.LastIndex {
+		return meta, nil
+	}
+	m.indexes[name] = meta.LastIndex
+	event := model.EventUpdate
+	if !f {
+		event = model.EventAdd
+	}
+	m.notify(name, instances, event)
+	return meta, nil
+}
 
+// watch runs a blocking query until ctx is canceled. Each query blocks until the index of the result changes
+// from the index of the previous result, or until blockQueryWaitTime elapses.
+func (m *consulMonitor) watch(ctx context.Context, what string, query func(*api.QueryOptions) (*api.QueryMeta, error)) {
+	var index uint64
+	retry := initialRetryTime
 	for {
-		select {
-		case <-change:
-			lastChange = time.Now().Unix()
-		case <-ticker.C:
-			currentTime := time.Now().Unix()
-			if lastChange > 0 && currentTime-lastChange > int64(refreshIdleTime.Seconds()) {
-				log.Infof("Consul service changed")
-				m.updateServiceRecord()
-				m.updateInstanceRecord()
-				lastChange = int64(0)
-			}
-		case <-stop:
-			ticker.Stop()
+		q := &api.QueryOptions{
+			WaitIndex: index,
+			WaitTime:  blockQueryWaitTime,
+		}
+		meta, err := query(q.WithContext(ctx))
+		if ctx.Err() != nil {
 			return
 		}
-	}
-}
 
-func (m *consulMonitor) updateServiceRecord() {
-	// This is only a work-around solution currently
-	// Since Handler functions generally act as a refresher
-	// regardless of the input, thus passing in meaningless
-	// input should make functionalities work
-	//TODO
-	var obj []*api.CatalogService
-	var event model.Event
-	for _, f := range m.serviceHandlers {
-		go func(handler ServiceHandler) {
-			if err := handler(obj, event); err != nil {
-				log.Warnf("Error executing service handler function: %v", err)
+		if err != nil {
+			log.Warnf("Could not fetch %s: %v", what, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry):
+			}
+			retry *= 2
+			if retry > maxRetryTime {
+				retry = maxRetryTime
 			}
-		}(f)
+			continue
+		}
+		retry = initialRetryTime
+
+		// The index may go backwards, e.g. when the Consul servers are restored from a snapshot
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
 	}
 }
 
-func (m *consulMonitor) updateInstanceRecord() {
-	// This is only a work-around solution currently
-	// Since Handler functions generally act as a refresher
-	// regardless of the input, thus passing in meaningless
-	// input should make functionalities work
-	// TODO
-	obj := &api.CatalogService{}
-	var event model.Event
-	for _, f := range m.instanceHandlers {
-		go func(handler InstanceHandler) {
-			if err := handler(obj, event); err != nil {
-				log.Warnf("Error executing instance handler function: %v", err)
-			}
-		}(f)
+func (m *consulMonitor) notify(name string, instances []*api.ServiceEntry, event model.Event) {
+	for _, f := range m.serviceHandlers {
+		if err := f(name, instances, event); err != nil {
+			log.Warnf("Error executing service handler function: %v", err)
+		}
 	}
 }
 
 func (m *consulMonitor) AppendServiceHandler(h ServiceHandler) {
 	m.serviceHandlers = append(m.serviceHandlers, h)
 }
-
-func (m *consulMonitor) AppendInstanceHandler(h InstanceHandler) {
-	m.instanceHandlers = append(m.instanceHandlers, h)
-}
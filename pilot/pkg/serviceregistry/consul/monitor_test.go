@@ -25,6 +25,12 @@ import (
 
 const notifyThreshold = 10 * time.Second
 
+type serviceEvent struct {
+	name      string
+	instances []*api.ServiceEntry
+	event     model.Event
+}
+
 func TestController(t *testing.T) {
 	ts := newServer()
 	defer ts.server.Close()
@@ -36,16 +42,11 @@ func TestController(t *testing.T) {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
 
-	updateChannel := make(chan struct{}, 10)
+	updateChannel := make(chan serviceEvent, 100)
 
 	ctl := NewConsulMonitor(cl)
-	ctl.AppendInstanceHandler(func(instance *api.CatalogService, event model.Event) error {
-		updateChannel <- struct{}{}
-		return nil
-	})
-
-	ctl.AppendServiceHandler(func(instances []*api.CatalogService, event model.Event) error {
-		updateChannel <- struct{}{}
+	ctl.AppendServiceHandler(func(name string, instances []*api.ServiceEntry, event model.Event) error {
+		updateChannel <- serviceEvent{name, instances, event}
 		return nil
 	})
 
@@ -53,27 +54,65 @@ func TestController(t *testing.T) {
 	go ctl.Start(stop)
 	defer close(stop)
 
-	expectNotify := func(t *testing.T, times int) {
+	// expectNotify waits for the given event of a service. The events of other services are kept for later
+	// calls, as the services are watched concurrently.
+	var skipped []serviceEvent
+	expectNotify := func(t *testing.T, name string, event model.Event) serviceEvent {
 		t.Helper()
-		for i := 0; i < times; i++ {
+		for i, e := range skipped {
+			if e.name == name && e.event == event {
+				skipped = append(skipped[:i], skipped[i+1:]...)
+				return e
+			}
+		}
+		timeout := time.After(notifyThreshold)
+		for {
 			select {
-			case <-updateChannel:
-				continue
-			case <-time.After(notifyThreshold):
-				t.Fatalf("got %d notifications from controller, want %d", i, times)
+			case e := <-updateChannel:
+				if e.name == name && e.event == event {
+					return e
+				}
+				skipped = append(skipped, e)
+			case <-timeout:
+				t.Fatalf("got no %v notification for service %s", event, name)
 			}
 		}
 	}
 
 	//The first query from monitor to Consul always doesn't block because the index is 0
-	expectNotify(t, 2)
+	for _, name := range []string{"productpage", "reviews", "rating"} {
+		if e := expectNotify(t, name, model.EventAdd); len(e.instances) == 0 {
+			t.Fatalf("expected instances for service %s", name)
+		}
+	}
 
 	//There won't be any notifications if X-Consul-Index doesn't change
-	expectNotify(t, 0)
+	select {
+	case e := <-updateChannel:
+		t.Fatalf("unexpected notification %v", e)
+	case <-time.After(2 * time.Second):
+	}
+
+	//X-Consul-Index change of a service means that its instances changed, so only it is notified
+	ts.lock.Lock()
+	ts.checks = map[string]api.HealthChecks{"172.19.0.6": {{Status: api.HealthCritical}}}
+	ts.serviceIndexes["reviews"]++
+	ts.lock.Unlock()
+	e := expectNotify(t, "reviews", model.EventUpdate)
+	if status := e.instances[0].Checks.AggregatedStatus(); status != api.HealthCritical {
+		t.Fatalf("expected critical instance, got %s", status)
+	}
+	select {
+	case e := <-updateChannel:
+		t.Fatalf("unexpected notification %v", e)
+	case <-time.After(2 * time.Second):
+	}
 
-	//X-Consul-Index change means that the Consul Catalog changes, so there will be notifications
 	ts.lock.Lock()
+	delete(ts.services, "rating")
 	ts.consulIndex++
 	ts.lock.Unlock()
-	expectNotify(t, 2)
+	if e := expectNotify(t, "rating", model.EventDelete); len(e.instances) != 0 {
+		t.Fatalf("expected no instances for deleted service, got %v", e.instances)
+	}
 }
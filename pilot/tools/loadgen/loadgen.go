@@ -0,0 +1,334 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/golang/protobuf/proto"
+	pstruct "github.com/golang/protobuf/ptypes/struct"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/adsc"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schemas"
+	"istio.io/pkg/log"
+)
+
+const routerNamespace = "istio-system"
+
+// mutation is a config change, tracked until all the proxies that should see it received it.
+type mutation struct {
+	start time.Time
+
+	// hosts added by the mutation, by namespace.
+	hosts map[string][]string
+
+	// pending is the number of proxies which did not receive the mutation yet.
+	pending int
+
+	// latencies are the times the proxies took to receive the mutation.
+	latencies []time.Duration
+}
+
+// expectation is a mutation a proxy is waiting for, with the hosts it should see once it receives it.
+type expectation struct {
+	m     *mutation
+	hosts []string
+}
+
+// proxy is a simulated proxy.
+type proxy struct {
+	nodeType  string
+	namespace string
+	client    *adsc.ADSC
+
+	mutex   sync.Mutex
+	pending []expectation
+}
+
+type pushKey struct {
+	nodeType string
+	typeURL  string
+}
+
+// LoadGenerator connects simulated proxies to Pilot and measures how fast they receive config mutations.
+type LoadGenerator struct {
+	scenario  *Scenario
+	store     model.ConfigStore
+	pilotAddr string
+
+	// generations of the services, incremented each time a service is replaced.
+	generations []int
+	// next is the index of the next service replaced by the churn.
+	next int
+
+	proxies []*proxy
+
+	mutex     sync.Mutex
+	initial   *mutation
+	mutations []*mutation
+	pushes    map[pushKey][]int
+}
+
+// NewLoadGenerator returns a load generator running a scenario against the Pilot listening on pilotAddr,
+// and reading its config from store.
+func NewLoadGenerator(scenario *Scenario, store model.ConfigStore, pilotAddr string) *LoadGenerator {
+	return &LoadGenerator{
+		scenario:    scenario,
+		store:       store,
+		pilotAddr:   pilotAddr,
+		generations: make([]int, scenario.Services.Count),
+		pushes:      make(map[pushKey][]int),
+	}
+}
+
+// Run creates the config of the scenario, connects the proxies and applies the churn. It returns once all
+// the mutations have been received by all the proxies, or after timeout once the last mutation was applied.
+func (lg *LoadGenerator) Run(timeout time.Duration) (*Report, error) {
+	if err := lg.createConfig(); err != nil {
+		return nil, err
+	}
+	defer lg.close()
+
+	hosts := make(map[string][]string)
+	for i := 0; i < lg.scenario.Services.Count; i++ {
+		ns := lg.scenario.namespace(i)
+		hosts[ns] = append(hosts[ns], lg.scenario.serviceHost(i, 0))
+	}
+	lg.initial = &mutation{start: time.Now(), hosts: hosts}
+	if err := lg.connect(); err != nil {
+		return nil, err
+	}
+	if !lg.waitConverged(lg.initial, timeout) {
+		return nil, fmt.Errorf("proxies did not receive their initial config after %v", timeout)
+	}
+	log.Infof("%d proxies received their initial config in %v", len(lg.proxies), lg.initial.convergence())
+
+	for i := 0; i < lg.scenario.Churn.Mutations; i++ {
+		time.Sleep(lg.scenario.Churn.Interval.Duration)
+		if err := lg.mutate(); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range lg.mutations {
+		lg.waitConverged(m, timeout)
+	}
+
+	return lg.report(), nil
+}
+
+func (lg *LoadGenerator) createConfig() error {
+	if lg.scenario.SidecarScoping {
+		for i := 0; i < lg.scenario.Namespaces; i++ {
+			if _, err := lg.store.Create(lg.scenario.sidecar(lg.scenario.namespace(i))); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i < lg.scenario.Services.Count; i++ {
+		if _, err := lg.store.Create(lg.scenario.serviceEntry(i, 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lg *LoadGenerator) connect() error {
+	n := 0
+	for _, spec := range lg.scenario.Proxies {
+		meta := &pstruct.Struct{Fields: map[string]*pstruct.Value{
+			"ISTIO_VERSION": {Kind: &pstruct.Value_StringValue{StringValue: "65536.65536.65536"}},
+		}}
+		for k, v := range spec.Metadata {
+			meta.Fields[k] = &pstruct.Value{Kind: &pstruct.Value_StringValue{StringValue: v}}
+		}
+		for i := 0; i < spec.Count; i++ {
+			p := &proxy{
+				nodeType:  spec.Type,
+				namespace: routerNamespace,
+			}
+			if spec.Type == string(model.SidecarProxy) {
+				p.namespace = lg.scenario.namespace(n)
+			}
+			if hosts := lg.visibleHosts(p, lg.initial); len(hosts) > 0 {
+				p.pending = append(p.pending, expectation{m: lg.initial, hosts: hosts})
+				lg.mutex.Lock()
+				lg.initial.pending++
+				lg.mutex.Unlock()
+			}
+			nodeType := spec.Type
+			client, err := adsc.Dial(lg.pilotAddr, "", &adsc.Config{
+				Namespace: p.namespace,
+				Workload:  fmt.Sprintf("%s-%d", spec.Type, n),
+				NodeType:  spec.Type,
+				IP:        net.IPv4(10, byte(n/65536), byte(n/256), byte(n)).String(),
+				Meta:      meta,
+				ResponseHandler: func(msg *xdsapi.DiscoveryResponse) {
+					lg.recordPush(nodeType, msg)
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to connect proxy %d to %s: %v", n, lg.pilotAddr, err)
+			}
+			p.client = client
+			lg.proxies = append(lg.proxies, p)
+			go lg.watch(p)
+			client.Watch()
+			n++
+		}
+	}
+	return nil
+}
+
+func (lg *LoadGenerator) close() {
+	for _, p := range lg.proxies {
+		p.client.Close()
+	}
+}
+
+// visibleHosts returns the hosts of a mutation a proxy should see.
+func (lg *LoadGenerator) visibleHosts(p *proxy, m *mutation) []string {
+	if lg.scenario.SidecarScoping && p.nodeType == string(model.SidecarProxy) {
+		return m.hosts[p.namespace]
+	}
+	hosts := make([]string, 0)
+	for _, h := range m.hosts {
+		hosts = append(hosts, h...)
+	}
+	return hosts
+}
+
+// mutate replaces the next services of the churn by new ones.
+func (lg *LoadGenerator) mutate() error {
+	m := &mutation{hosts: make(map[string][]string)}
+	configs := make([]model.Config, 0, lg.scenario.Churn.Services)
+	for i := 0; i < lg.scenario.Churn.Services; i++ {
+		svc := lg.next
+		lg.next = (lg.next + 1) % lg.scenario.Services.Count
+		lg.generations[svc]++
+		cfg := lg.scenario.serviceEntry(svc, lg.generations[svc])
+		current := lg.store.Get(schemas.ServiceEntry.Type, cfg.Name, cfg.Namespace)
+		if current == nil {
+			return fmt.Errorf("service entry %s/%s not found", cfg.Namespace, cfg.Name)
+		}
+		cfg.ResourceVersion = current.ResourceVersion
+		configs = append(configs, cfg)
+		m.hosts[cfg.Namespace] = append(m.hosts[cfg.Namespace], lg.scenario.serviceHost(svc, lg.generations[svc]))
+	}
+
+	expectations := make(map[*proxy]expectation)
+	for _, p := range lg.proxies {
+		if hosts := lg.visibleHosts(p, m); len(hosts) > 0 {
+			expectations[p] = expectation{m: m, hosts: hosts}
+		}
+	}
+	lg.mutex.Lock()
+	m.start = time.Now()
+	m.pending = len(expectations)
+	lg.mutations = append(lg.mutations, m)
+	lg.mutex.Unlock()
+	// The proxies cannot receive the mutation before the store is updated, so its expectations can be
+	// added after its start.
+	for p, e := range expectations {
+		p.mutex.Lock()
+		p.pending = append(p.pending, e)
+		p.mutex.Unlock()
+	}
+
+	for _, cfg := range configs {
+		if _, err := lg.store.Update(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watch checks the pending mutations of a proxy each time it receives clusters.
+func (lg *LoadGenerator) watch(p *proxy) {
+	for u := range p.client.Updates {
+		switch u {
+		case "close":
+			return
+		case "cds":
+			clusters := p.client.GetEdsClusters()
+			now := time.Now()
+			p.mutex.Lock()
+			pending := p.pending[:0]
+			for _, e := range p.pending {
+				if received(clusters, e.hosts) {
+					lg.converge(e.m, now.Sub(e.m.start))
+				} else {
+					pending = append(pending, e)
+				}
+			}
+			p.pending = pending
+			p.mutex.Unlock()
+		}
+	}
+}
+
+func received(clusters map[string]*xdsapi.Cluster, hosts []string) bool {
+	for _, h := range hosts {
+		if _, f := clusters[model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(h), servicePort)]; !f {
+			return false
+		}
+	}
+	return true
+}
+
+func (lg *LoadGenerator) converge(m *mutation, latency time.Duration) {
+	lg.mutex.Lock()
+	defer lg.mutex.Unlock()
+	m.pending--
+	m.latencies = append(m.latencies, latency)
+}
+
+func (lg *LoadGenerator) waitConverged(m *mutation, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		lg.mutex.Lock()
+		pending := m.pending
+		lg.mutex.Unlock()
+		if pending == 0 {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func (lg *LoadGenerator) recordPush(nodeType string, msg *xdsapi.DiscoveryResponse) {
+	size := proto.Size(msg)
+	lg.mutex.Lock()
+	defer lg.mutex.Unlock()
+	k := pushKey{nodeType: nodeType, typeURL: msg.TypeUrl}
+	lg.pushes[k] = append(lg.pushes[k], size)
+}
+
+// convergence returns the time all the proxies took to receive the mutation.
+func (m *mutation) convergence() time.Duration {
+	var max time.Duration
+	for _, l := range m.latencies {
+		if l > max {
+			max = l
+		}
+	}
+	return max
+}
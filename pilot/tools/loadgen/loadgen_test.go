@@ -0,0 +1,166 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config/schemas"
+)
+
+func TestReadScenario(t *testing.T) {
+	s, err := ReadScenario("testdata/scenario.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Namespaces != 2 || s.Services.Count != 10 || !s.SidecarScoping || len(s.Proxies) != 2 {
+		t.Errorf("Unexpected scenario %+v", s)
+	}
+	if s.Proxies[0].Metadata["INTERCEPTION_MODE"] != "REDIRECT" {
+		t.Errorf("Unexpected metadata %v", s.Proxies[0].Metadata)
+	}
+	if s.Churn.Interval.Duration != 500*time.Millisecond {
+		t.Errorf("Unexpected churn interval %v", s.Churn.Interval)
+	}
+}
+
+func TestParseScenario(t *testing.T) {
+	cases := []struct {
+		name     string
+		scenario string
+		err      bool
+	}{
+		{
+			name:     "defaults",
+			scenario: "services: {count: 1}\nproxies: [{count: 1}]",
+		},
+		{
+			name:     "unknown field",
+			scenario: "services: {count: 1}\nproxies: [{count: 1}]\nfoo: bar",
+			err:      true,
+		},
+		{
+			name:     "no services",
+			scenario: "proxies: [{count: 1}]",
+			err:      true,
+		},
+		{
+			name:     "no proxies",
+			scenario: "services: {count: 1}",
+			err:      true,
+		},
+		{
+			name:     "unknown proxy type",
+			scenario: "services: {count: 1}\nproxies: [{count: 1, type: ingress}]",
+			err:      true,
+		},
+		{
+			name:     "churn larger than services",
+			scenario: "services: {count: 1}\nproxies: [{count: 1}]\nchurn: {services: 2, interval: 1s, mutations: 1}",
+			err:      true,
+		},
+		{
+			name:     "churn without interval",
+			scenario: "services: {count: 1}\nproxies: [{count: 1}]\nchurn: {services: 1, mutations: 1}",
+			err:      true,
+		},
+		{
+			name:     "invalid interval",
+			scenario: "services: {count: 1}\nproxies: [{count: 1}]\nchurn: {services: 1, interval: 1, mutations: 1}",
+			err:      true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := ParseScenario([]byte(c.scenario))
+			if c.err {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Namespaces != 1 || s.Services.Endpoints != 1 || s.Proxies[0].Type != "sidecar" {
+				t.Errorf("Unexpected defaults %+v", s)
+			}
+		})
+	}
+}
+
+func TestConfigIsValid(t *testing.T) {
+	s, err := ReadScenario("testdata/scenario.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	se := s.serviceEntry(3, 2)
+	if err := schemas.ServiceEntry.Validate(se.Name, se.Namespace, se.Spec); err != nil {
+		t.Errorf("Invalid ServiceEntry: %v", err)
+	}
+	if se.Namespace != "ns-1" || s.serviceHost(3, 2) != "svc-3-2.ns-1.example.com" {
+		t.Errorf("Unexpected ServiceEntry %v", se)
+	}
+	sc := s.sidecar("ns-1")
+	if err := schemas.Sidecar.Validate(sc.Name, sc.Namespace, sc.Spec); err != nil {
+		t.Errorf("Invalid Sidecar: %v", err)
+	}
+}
+
+func TestVisibleHosts(t *testing.T) {
+	m := &mutation{hosts: map[string][]string{
+		"ns-0": {"a.ns-0"},
+		"ns-1": {"b.ns-1"},
+	}}
+	sidecar := &proxy{nodeType: "sidecar", namespace: "ns-0"}
+	router := &proxy{nodeType: "router", namespace: routerNamespace}
+
+	lg := &LoadGenerator{scenario: &Scenario{}}
+	hosts := lg.visibleHosts(sidecar, m)
+	sort.Strings(hosts)
+	if !reflect.DeepEqual(hosts, []string{"a.ns-0", "b.ns-1"}) {
+		t.Errorf("Expected a sidecar without scoping to see all the hosts, got %v", hosts)
+	}
+
+	lg.scenario.SidecarScoping = true
+	if hosts := lg.visibleHosts(sidecar, m); !reflect.DeepEqual(hosts, []string{"a.ns-0"}) {
+		t.Errorf("Expected a scoped sidecar to see the hosts of its namespace, got %v", hosts)
+	}
+	if hosts := lg.visibleHosts(router, m); len(hosts) != 2 {
+		t.Errorf("Expected a router to see all the hosts, got %v", hosts)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	if p := percentiles(nil); p != (Percentiles{}) {
+		t.Errorf("Expected empty percentiles, got %+v", p)
+	}
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	expected := Percentiles{
+		P50: 50 * time.Millisecond,
+		P90: 90 * time.Millisecond,
+		P99: 99 * time.Millisecond,
+		Max: 100 * time.Millisecond,
+	}
+	if p := percentiles(durations); p != expected {
+		t.Errorf("Expected %+v, got %+v", expected, p)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Load generator simulating many proxies connected to an in-process Pilot, to size Pilot.
+//
+// Pilot runs with the memory service registry and an in-memory config store. The ServiceEntries and Sidecars
+// described by the scenario are created in the store, the simulated proxies connect over ADS, then some
+// services are replaced at a fixed interval. The tool reports the push latency and convergence time of
+// these mutations, and the size of the pushes by proxy type.
+//
+// Usage:
+// ```bash
+// go run ./pilot/tools/loadgen --scenario pilot/tools/loadgen/testdata/scenario.yaml
+// ```
+//
+// See testdata/scenario.yaml for a description of the scenario.
+
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+	"time"
+
+	"istio.io/istio/pilot/pkg/bootstrap"
+	"istio.io/istio/pilot/pkg/config/memory"
+	envoy "istio.io/istio/pilot/pkg/proxy/envoy"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schemas"
+	"istio.io/istio/pkg/keepalive"
+	"istio.io/pkg/log"
+)
+
+var (
+	scenarioFile = flag.String("scenario", "", "YAML description of the scenario")
+	timeout      = flag.Duration("timeout", time.Minute, "Maximum time to wait for the proxies to receive a mutation")
+)
+
+func main() {
+	flag.Parse()
+	if *scenarioFile == "" {
+		log.Errorf("--scenario is required")
+		os.Exit(2)
+	}
+	scenario, err := ReadScenario(*scenarioFile)
+	if err != nil {
+		log.Errora(err)
+		os.Exit(1)
+	}
+
+	report, err := run(scenario, *timeout)
+	if err != nil {
+		log.Errora(err)
+		os.Exit(1)
+	}
+	if err := report.Print(os.Stdout); err != nil {
+		log.Errora(err)
+		os.Exit(1)
+	}
+}
+
+// run starts an in-process Pilot and runs a scenario against it.
+func run(scenario *Scenario, timeout time.Duration) (*Report, error) {
+	// The config is changed through the controller, so that Pilot is notified.
+	controller := memory.NewController(memory.Make(schemas.Istio))
+	meshConfig := mesh.DefaultMeshConfig()
+	args := bootstrap.PilotArgs{
+		Namespace: "istio-system",
+		DiscoveryOptions: envoy.DiscoveryServiceOptions{
+			HTTPAddr:      "localhost:0",
+			GrpcAddr:      "localhost:0",
+			EnableCaching: true,
+		},
+		Config: bootstrap.ConfigArgs{
+			Controller: controller,
+		},
+		Service: bootstrap.ServiceArgs{
+			// The mock registry is the memory service registry. The services of the scenario are ServiceEntries.
+			Registries: []string{string(serviceregistry.MockRegistry)},
+		},
+		MeshConfig:        &meshConfig,
+		MCPMaxMessageSize: bootstrap.DefaultMCPMaxMsgSize,
+		KeepaliveOptions:  keepalive.DefaultOption(),
+		ForceStop:         true,
+		Plugins:           bootstrap.DefaultPlugins,
+	}
+
+	s, err := bootstrap.NewServer(args)
+	if err != nil {
+		return nil, err
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := s.Start(stop); err != nil {
+		return nil, err
+	}
+	_, port, err := net.SplitHostPort(s.GRPCListeningAddr.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLoadGenerator(scenario, controller, net.JoinHostPort("localhost", port)).Run(timeout)
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Percentiles summarizes a distribution of durations.
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// PushStats summarizes the pushes of an xDS type to a type of proxy.
+type PushStats struct {
+	ProxyType string
+	TypeURL   string
+	Pushes    int
+	MeanBytes int
+	MaxBytes  int
+}
+
+// Report is the result of a load test.
+type Report struct {
+	// Proxies is the number of simulated proxies.
+	Proxies int
+
+	// InitialConvergence is the time all the proxies took to receive their initial config.
+	InitialConvergence time.Duration
+
+	// Mutations is the number of config mutations applied, and Unconverged the number of them not received
+	// by all the proxies before the timeout.
+	Mutations   int
+	Unconverged int
+
+	// PushLatency is the distribution of the times each proxy took to receive each mutation.
+	PushLatency Percentiles
+
+	// Convergence is the distribution of the times all the proxies took to receive each mutation.
+	Convergence Percentiles
+
+	// Pushes are the push statistics, by proxy type and xDS type.
+	Pushes []PushStats
+}
+
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	return Percentiles{P50: at(50), P90: at(90), P99: at(99), Max: sorted[len(sorted)-1]}
+}
+
+func (lg *LoadGenerator) report() *Report {
+	lg.mutex.Lock()
+	defer lg.mutex.Unlock()
+
+	r := &Report{
+		Proxies:            len(lg.proxies),
+		InitialConvergence: lg.initial.convergence(),
+		Mutations:          len(lg.mutations),
+	}
+	latencies := make([]time.Duration, 0)
+	convergences := make([]time.Duration, 0, len(lg.mutations))
+	for _, m := range lg.mutations {
+		latencies = append(latencies, m.latencies...)
+		if m.pending > 0 {
+			r.Unconverged++
+			continue
+		}
+		convergences = append(convergences, m.convergence())
+	}
+	r.PushLatency = percentiles(latencies)
+	r.Convergence = percentiles(convergences)
+
+	for k, sizes := range lg.pushes {
+		s := PushStats{ProxyType: k.nodeType, TypeURL: k.typeURL, Pushes: len(sizes)}
+		total := 0
+		for _, size := range sizes {
+			total += size
+			if size > s.MaxBytes {
+				s.MaxBytes = size
+			}
+		}
+		s.MeanBytes = total / len(sizes)
+		r.Pushes = append(r.Pushes, s)
+	}
+	sort.Slice(r.Pushes, func(i, j int) bool {
+		if r.Pushes[i].ProxyType != r.Pushes[j].ProxyType {
+			return r.Pushes[i].ProxyType < r.Pushes[j].ProxyType
+		}
+		return r.Pushes[i].TypeURL < r.Pushes[j].TypeURL
+	})
+	return r
+}
+
+// Print writes a human readable report.
+func (r *Report) Print(w io.Writer) error {
+	fmt.Fprintf(w, "Proxies: %d\n", r.Proxies)
+	fmt.Fprintf(w, "Initial convergence: %v\n", r.InitialConvergence)
+	fmt.Fprintf(w, "Mutations: %d (%d not converged)\n\n", r.Mutations, r.Unconverged)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tP50\tP90\tP99\tMAX")
+	for _, row := range []struct {
+		name string
+		p    Percentiles
+	}{
+		{"Push latency", r.PushLatency},
+		{"Convergence", r.Convergence},
+	} {
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\n", row.name, row.p.P50, row.p.P90, row.p.P99, row.p.Max)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROXY TYPE\tXDS TYPE\tPUSHES\tMEAN BYTES\tMAX BYTES")
+	for _, s := range r.Pushes {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", s.ProxyType, s.TypeURL[strings.LastIndex(s.TypeURL, ".")+1:],
+			s.Pushes, s.MeanBytes, s.MaxBytes)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+const (
+	serviceDomain = "example.com"
+	servicePort   = 80
+)
+
+// Scenario describes the services registered in Pilot, the simulated proxies connected to it and the
+// config churn applied during the test.
+type Scenario struct {
+	// Namespaces is the number of namespaces the services and sidecars are spread over.
+	Namespaces int `json:"namespaces"`
+
+	// Services describes the ServiceEntries registered in Pilot.
+	Services ServicesSpec `json:"services"`
+
+	// SidecarScoping adds a Sidecar resource to each namespace, restricting the egress of its sidecars
+	// to the services of the namespace.
+	SidecarScoping bool `json:"sidecarScoping"`
+
+	// Proxies describes the simulated proxies.
+	Proxies []ProxiesSpec `json:"proxies"`
+
+	// Churn describes the config mutations applied once all the proxies received their initial config.
+	Churn ChurnSpec `json:"churn"`
+}
+
+// ServicesSpec describes the services of a scenario.
+type ServicesSpec struct {
+	// Count is the total number of services.
+	Count int `json:"count"`
+
+	// Endpoints is the number of endpoints of each service.
+	Endpoints int `json:"endpoints"`
+}
+
+// ProxiesSpec describes a group of simulated proxies of the same type.
+type ProxiesSpec struct {
+	// Type of the proxies: sidecar (default) or router.
+	Type string `json:"type"`
+
+	// Count is the number of proxies of the group.
+	Count int `json:"count"`
+
+	// Metadata is added to the node metadata of the proxies.
+	Metadata map[string]string `json:"metadata"`
+}
+
+// ChurnSpec describes the config mutations of a scenario. Each mutation replaces some services by new ones.
+type ChurnSpec struct {
+	// Interval between two mutations.
+	Interval Duration `json:"interval"`
+
+	// Services is the number of services replaced by each mutation.
+	Services int `json:"services"`
+
+	// Mutations is the number of mutations applied.
+	Mutations int `json:"mutations"`
+}
+
+// Duration is a time.Duration read from a string such as "5s".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	var err error
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+// ReadScenario reads and validates a YAML scenario.
+func ReadScenario(path string) (*Scenario, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScenario(b)
+}
+
+// ParseScenario parses and validates a YAML scenario.
+func ParseScenario(b []byte) (*Scenario, error) {
+	s := &Scenario{}
+	if err := yaml.UnmarshalStrict(b, s); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %v", err)
+	}
+	if s.Namespaces <= 0 {
+		s.Namespaces = 1
+	}
+	if s.Services.Endpoints <= 0 {
+		s.Services.Endpoints = 1
+	}
+	if s.Services.Count <= 0 {
+		return nil, fmt.Errorf("invalid scenario: services.count must be positive")
+	}
+	if len(s.Proxies) == 0 {
+		return nil, fmt.Errorf("invalid scenario: no proxies")
+	}
+	for i := range s.Proxies {
+		p := &s.Proxies[i]
+		if p.Type == "" {
+			p.Type = string(model.SidecarProxy)
+		}
+		if !model.IsApplicationNodeType(model.NodeType(p.Type)) {
+			return nil, fmt.Errorf("invalid scenario: unknown proxy type %q", p.Type)
+		}
+		if p.Count <= 0 {
+			return nil, fmt.Errorf("invalid scenario: proxies[%d].count must be positive", i)
+		}
+	}
+	if s.Churn.Services > s.Services.Count {
+		return nil, fmt.Errorf("invalid scenario: churn.services is larger than services.count")
+	}
+	if s.Churn.Mutations > 0 && (s.Churn.Services <= 0 || s.Churn.Interval.Duration <= 0) {
+		return nil, fmt.Errorf("invalid scenario: churn.services and churn.interval must be positive")
+	}
+	return s, nil
+}
+
+// namespace returns the namespace of the i-th service or sidecar.
+func (s *Scenario) namespace(i int) string {
+	return fmt.Sprintf("ns-%d", i%s.Namespaces)
+}
+
+// serviceHost returns the host of a generation of the i-th service.
+func (s *Scenario) serviceHost(i int, generation int) string {
+	return fmt.Sprintf("svc-%d-%d.%s.%s", i, generation, s.namespace(i), serviceDomain)
+}
+
+// serviceEntry returns the ServiceEntry of a generation of the i-th service.
+func (s *Scenario) serviceEntry(i int, generation int) model.Config {
+	endpoints := make([]*networking.ServiceEntry_Endpoint, 0, s.Services.Endpoints)
+	for e := 0; e < s.Services.Endpoints; e++ {
+		n := i*s.Services.Endpoints + e
+		endpoints = append(endpoints, &networking.ServiceEntry_Endpoint{
+			Address: fmt.Sprintf("172.%d.%d.%d", 16+n/65536%16, n/256%256, n%256),
+			Labels:  map[string]string{"app": fmt.Sprintf("svc-%d", i)},
+		})
+	}
+	return model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      schemas.ServiceEntry.Type,
+			Group:     schemas.ServiceEntry.Group,
+			Version:   schemas.ServiceEntry.Version,
+			Name:      fmt.Sprintf("svc-%d", i),
+			Namespace: s.namespace(i),
+		},
+		Spec: &networking.ServiceEntry{
+			Hosts: []string{s.serviceHost(i, generation)},
+			Ports: []*networking.Port{
+				{Number: servicePort, Name: "http", Protocol: "http"},
+			},
+			Endpoints:  endpoints,
+			Location:   networking.ServiceEntry_MESH_INTERNAL,
+			Resolution: networking.ServiceEntry_STATIC,
+		},
+	}
+}
+
+// sidecar returns the Sidecar restricting the egress of the sidecars of a namespace to its services.
+func (s *Scenario) sidecar(namespace string) model.Config {
+	return model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      schemas.Sidecar.Type,
+			Group:     schemas.Sidecar.Group,
+			Version:   schemas.Sidecar.Version,
+			Name:      "default",
+			Namespace: namespace,
+		},
+		Spec: &networking.Sidecar{
+			Egress: []*networking.IstioEgressListener{
+				{Hosts: []string{"./*"}},
+			},
+		},
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tool to record the xDS responses sent by Pilot to a simulated proxy, and to replay them later
+// as a fake ADS server.
+//
+// Usage:
+//
+// Record the config sent to a sidecar of the reviews workload, until interrupted:
+// ```bash
+// go run ./pilot/tools/xdsrecord record --pilot localhost:15010 --workload reviews-v1-abc --namespace default \
+// --ip 10.8.0.12 --out reviews.xds
+// ```
+//
+// Replay the recording to an Envoy or a test connecting to localhost:15010, twice as fast as recorded:
+// ```bash
+// go run ./pilot/tools/xdsrecord replay --in reviews.xds --listen :15010 --speed 2
+// ```
+//
+// A recording has one JSON record per line, holding the time a DiscoveryResponse was received and the
+// response in protobuf binary format. Two recordings of the same proxy against different Pilot releases
+// can be compared to diff the output of Pilot.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pkg/adsc"
+	"istio.io/istio/pkg/cmd"
+	"istio.io/pkg/log"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "record":
+		err = record(os.Args[2:])
+	case "replay":
+		err = replay(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Errora(err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s record|replay [flags]\n", os.Args[0])
+	os.Exit(2)
+}
+
+func record(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	pilotURL := fs.String("pilot", "localhost:15010", "Pilot address")
+	certDir := fs.String("certDir", "", "Directory of the proxy certificates, for mutual TLS with Pilot")
+	out := fs.String("out", "", "File to write the recording to. Defaults to the standard output")
+	duration := fs.Duration("duration", 0, "Duration of the recording. Records until interrupted if 0")
+	cfg := &adsc.Config{}
+	fs.StringVar(&cfg.Namespace, "namespace", "default", "Namespace of the simulated proxy")
+	fs.StringVar(&cfg.Workload, "workload", "test-1", "Workload name of the simulated proxy")
+	fs.StringVar(&cfg.IP, "ip", "", "IP of the simulated proxy. Defaults to a private IP of the host")
+	fs.StringVar(&cfg.NodeType, "nodeType", "sidecar", "Type of the simulated proxy: sidecar, ingress or router")
+	_ = fs.Parse(args)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	cfg.Recorder = adsc.NewRecorder(w)
+
+	client, err := adsc.Dial(*pilotURL, *certDir, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", *pilotURL, err)
+	}
+	defer client.Close()
+	client.Watch()
+
+	stop := make(chan struct{})
+	if *duration > 0 {
+		go func() {
+			time.Sleep(*duration)
+			close(stop)
+		}()
+	} else {
+		go cmd.WaitSignal(stop)
+	}
+	for {
+		select {
+		case u := <-client.Updates:
+			if u == "close" {
+				return fmt.Errorf("connection to %s closed", *pilotURL)
+			}
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	in := fs.String("in", "", "Recording to replay")
+	listen := fs.String("listen", ":15010", "Address to serve ADS on")
+	speed := fs.Float64("speed", 1, "Speed factor of the replay. Responses are sent without delay if 0")
+	_ = fs.Parse(args)
+
+	if *in == "" {
+		return fmt.Errorf("--in is required")
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	records, err := adsc.ReadRecords(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer()
+	adsc.NewReplayServer(records, *speed).Register(grpcServer)
+	log.Infof("Replaying %d records from %s on %s", len(records), *in, l.Addr())
+
+	stop := make(chan struct{})
+	go cmd.WaitSignal(stop)
+	go func() {
+		<-stop
+		grpcServer.Stop()
+	}()
+	return grpcServer.Serve(l)
+}
@@ -55,6 +55,12 @@ type Config struct {
 	// IP is currently the primary key used to locate inbound configs. It is sent by client,
 	// must match a known endpoint IP. Tests can use a ServiceEntry to register fake IPs.
 	IP string
+
+	// Recorder, if set, records all the responses received from the server.
+	Recorder *Recorder
+
+	// ResponseHandler, if set, is called with each response received from the server, before it is handled.
+	ResponseHandler func(*xdsapi.DiscoveryResponse)
 }
 
 // ADSC implements a basic client for ADS, for use in stress tests and tools
@@ -72,6 +78,9 @@ type ADSC struct {
 	certDir string
 	url     string
 
+	recorder        *Recorder
+	responseHandler func(*xdsapi.DiscoveryResponse)
+
 	watchTime time.Time
 
 	// InitialLoad tracks the time to receive the initial configuration.
@@ -128,10 +137,12 @@ var (
 // Dial connects to a ADS server, with optional MTLS authentication if a cert dir is specified.
 func Dial(url string, certDir string, opts *Config) (*ADSC, error) {
 	adsc := &ADSC{
-		Updates:     make(chan string, 100),
-		VersionInfo: map[string]string{},
-		certDir:     certDir,
-		url:         url,
+		Updates:         make(chan string, 100),
+		VersionInfo:     map[string]string{},
+		certDir:         certDir,
+		url:             url,
+		recorder:        opts.Recorder,
+		responseHandler: opts.ResponseHandler,
 	}
 	if opts.Namespace == "" {
 		opts.Namespace = "default"
@@ -254,6 +265,14 @@ func (a *ADSC) handleRecv() {
 			a.Updates <- "close"
 			return
 		}
+		if a.recorder != nil {
+			if err := a.recorder.Record(msg); err != nil {
+				adscLog.Warnf("Failed to record response %s for node %v: %v", msg.TypeUrl, a.nodeID, err)
+			}
+		}
+		if a.responseHandler != nil {
+			a.responseHandler(msg)
+		}
 
 		listeners := []*xdsapi.Listener{}
 		clusters := []*xdsapi.Cluster{}
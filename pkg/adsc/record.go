@@ -0,0 +1,98 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/golang/protobuf/proto"
+)
+
+// Record is a DiscoveryResponse received from an ADS server, with the time it was received.
+// A recording is a stream of records, one JSON object per line.
+type Record struct {
+	// Time the response was received.
+	Time time.Time `json:"time"`
+
+	// TypeURL, VersionInfo and Resources summarize the response, to make recordings easier to read.
+	TypeURL     string `json:"typeUrl"`
+	VersionInfo string `json:"versionInfo"`
+	Resources   int    `json:"resources"`
+
+	// Response is the response, in protobuf binary format.
+	Response []byte `json:"response"`
+}
+
+// DiscoveryResponse returns the recorded response.
+func (r *Record) DiscoveryResponse() (*xdsapi.DiscoveryResponse, error) {
+	msg := &xdsapi.DiscoveryResponse{}
+	if err := proto.Unmarshal(r.Response, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Recorder writes the responses received by an ADS client to a recording.
+type Recorder struct {
+	mutex sync.Mutex
+	enc   *json.Encoder
+	now   func() time.Time
+}
+
+// NewRecorder returns a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		enc: json.NewEncoder(w),
+		now: time.Now,
+	}
+}
+
+// Record appends a response to the recording.
+func (r *Recorder) Record(msg *xdsapi.DiscoveryResponse) error {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.enc.Encode(&Record{
+		Time:        r.now(),
+		TypeURL:     msg.TypeUrl,
+		VersionInfo: msg.VersionInfo,
+		Resources:   len(msg.Resources),
+		Response:    b,
+	})
+}
+
+// ReadRecords reads all the records of a recording.
+func ReadRecords(r io.Reader) ([]*Record, error) {
+	records := make([]*Record, 0)
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		rec := &Record{}
+		if err := dec.Decode(rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid record %d: %v", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+}
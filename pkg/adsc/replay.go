@@ -0,0 +1,105 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReplayServer is a fake ADS server sending a recorded stream of responses to each client that connects.
+// A response is sent once the client has requested its type, so the config sequence seen by an Envoy
+// is the same as the recorded one.
+type ReplayServer struct {
+	records []*Record
+
+	// speed scales the delays between the responses. Responses are sent as fast as possible if it is 0.
+	speed float64
+}
+
+// NewReplayServer returns a server replaying records. Delays between records are divided by speed,
+// e.g. 2 replays twice as fast as recorded, and 0 replays without any delay.
+func NewReplayServer(records []*Record, speed float64) *ReplayServer {
+	return &ReplayServer{
+		records: records,
+		speed:   speed,
+	}
+}
+
+// Register registers the server as the ADS service of a gRPC server.
+func (s *ReplayServer) Register(grpcServer *grpc.Server) {
+	ads.RegisterAggregatedDiscoveryServiceServer(grpcServer, s)
+}
+
+// StreamAggregatedResources implements the ADS interface. It replays the records, then keeps the
+// stream open until the client closes it.
+func (s *ReplayServer) StreamAggregatedResources(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	requests := make(chan *xdsapi.DiscoveryRequest, 100)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			requests <- req
+		}
+	}()
+
+	requested := map[string]bool{}
+	var last time.Time
+	for i, rec := range s.records {
+		msg, err := rec.DiscoveryResponse()
+		if err != nil {
+			return status.Errorf(codes.Internal, "invalid record %d: %v", i+1, err)
+		}
+		for !requested[msg.TypeUrl] {
+			select {
+			case req := <-requests:
+				requested[req.TypeUrl] = true
+			case err := <-recvErr:
+				return err
+			}
+		}
+		if s.speed > 0 && !last.IsZero() {
+			time.Sleep(time.Duration(float64(rec.Time.Sub(last)) / s.speed))
+		}
+		last = rec.Time
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+		adscLog.Debugf("Replayed record %d: %s version %s", i+1, msg.TypeUrl, msg.VersionInfo)
+	}
+	adscLog.Infof("Replayed %d records", len(s.records))
+
+	for {
+		select {
+		case <-requests:
+		case err := <-recvErr:
+			return err
+		}
+	}
+}
+
+// DeltaAggregatedResources is not supported by the replay server.
+func (s *ReplayServer) DeltaAggregatedResources(ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return status.Error(codes.Unimplemented, "not implemented")
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc"
+)
+
+func response(t *testing.T, typeURL string, version string, resources ...proto.Message) *xdsapi.DiscoveryResponse {
+	t.Helper()
+	msg := &xdsapi.DiscoveryResponse{
+		TypeUrl:     typeURL,
+		VersionInfo: version,
+		Nonce:       version,
+	}
+	for _, r := range resources {
+		a, err := ptypes.MarshalAny(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Resources = append(msg.Resources, a)
+	}
+	return msg
+}
+
+func TestReadRecords(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	now := time.Date(2019, 11, 5, 10, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	cds := response(t, clusterType, "1", &xdsapi.Cluster{Name: "foo"})
+	if err := r.Record(cds); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	rec := records[0]
+	if !rec.Time.Equal(now) || rec.TypeURL != clusterType || rec.VersionInfo != "1" || rec.Resources != 1 {
+		t.Errorf("Unexpected record %+v", rec)
+	}
+	msg, err := rec.DiscoveryResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(msg, cds) {
+		t.Errorf("Expected response %v, got %v", cds, msg)
+	}
+
+	if _, err := ReadRecords(strings.NewReader("{\"time\": 1}")); err == nil {
+		t.Errorf("Expected an error reading an invalid record")
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	var recording bytes.Buffer
+	r := NewRecorder(&recording)
+	cluster := &xdsapi.Cluster{
+		Name:                 "outbound|80||foo.default.svc.cluster.local",
+		ClusterDiscoveryType: &xdsapi.Cluster_Type{Type: xdsapi.Cluster_EDS},
+	}
+	responses := []*xdsapi.DiscoveryResponse{
+		response(t, clusterType, "1", cluster),
+		response(t, endpointType, "1", &xdsapi.ClusterLoadAssignment{ClusterName: cluster.Name}),
+	}
+	for _, msg := range responses {
+		if err := r.Record(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := ReadRecords(&recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	NewReplayServer(records, 1).Register(grpcServer)
+	go func() {
+		_ = grpcServer.Serve(l)
+	}()
+	defer grpcServer.Stop()
+
+	var replayed bytes.Buffer
+	client, err := Dial(l.Addr().String(), "", &Config{IP: "10.0.0.1", Recorder: NewRecorder(&replayed)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Watch()
+
+	if _, err := client.Wait(5*time.Second, "cds", "eds"); err != nil {
+		t.Fatal(err)
+	}
+	if _, f := client.GetEdsClusters()[cluster.Name]; !f {
+		t.Errorf("Expected cluster %s, got %v", cluster.Name, client.GetEdsClusters())
+	}
+	if _, f := client.GetEndpoints()[cluster.Name]; !f {
+		t.Errorf("Expected endpoints for cluster %s, got %v", cluster.Name, client.GetEndpoints())
+	}
+
+	// Responses are recorded before they are handled, so they are all recorded by now.
+	records, err = ReadRecords(&replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(responses) {
+		t.Fatalf("Expected %d replayed records, got %d", len(responses), len(records))
+	}
+	for i, rec := range records {
+		msg, err := rec.DiscoveryResponse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(msg, responses[i]) {
+			t.Errorf("Expected response %v, got %v", responses[i], msg)
+		}
+	}
+}
@@ -50,6 +50,10 @@ const (
 	requiredEnvoyStatsMatcherInclusionPrefixes = "cluster_manager,listener_manager,http_mixer_filter,tcp_mixer_filter,server,cluster.xds-grpc"
 	requiredEnvoyStatsMatcherInclusionSuffix   = "ssl_context_update_by_sds"
 
+	// rbacShadowStatsSuffixes are the stats of the RBAC shadow rules, which count the requests the
+	// authorization policies in dry-run mode would allow or deny.
+	rbacShadowStatsSuffixes = "rbac.shadow_allowed,rbac.shadow_denied"
+
 	// Prefixes of V2 metrics.
 	// "reporter" prefix is for istio standard metrics.
 	// "component" prefix is for istio_build metric.
@@ -189,7 +193,8 @@ func getStatsOptions(meta *model.NodeMetadata, nodeIPs []string) []option.Instan
 
 	return []option.Instance{
 		option.EnvoyStatsMatcherInclusionPrefix(parseOption(meta.StatsInclusionPrefixes, requiredEnvoyStatsMatcherInclusionPrefixes)),
-		option.EnvoyStatsMatcherInclusionSuffix(parseOption(meta.StatsInclusionSuffixes, requiredEnvoyStatsMatcherInclusionSuffix)),
+		option.EnvoyStatsMatcherInclusionSuffix(parseOption(meta.StatsInclusionSuffixes,
+			requiredEnvoyStatsMatcherInclusionSuffix+","+rbacShadowStatsSuffixes)),
 		option.EnvoyStatsMatcherInclusionRegexp(parseOption(meta.StatsInclusionRegexps, "")),
 	}
 }
@@ -411,9 +411,9 @@ func checkStatsMatcher(t *testing.T, got, want *v2.Bootstrap, stats stats) {
 	}
 
 	if stats.suffixes == "" {
-		stats.suffixes = requiredEnvoyStatsMatcherInclusionSuffix
+		stats.suffixes = requiredEnvoyStatsMatcherInclusionSuffix + "," + rbacShadowStatsSuffixes
 	} else {
-		stats.suffixes += "," + requiredEnvoyStatsMatcherInclusionSuffix
+		stats.suffixes += "," + requiredEnvoyStatsMatcherInclusionSuffix + "," + rbacShadowStatsSuffixes
 	}
 
 	if err := gsm.Validate(); err != nil {
@@ -38,7 +38,7 @@ const (
 	// TLS traffic is assumed to contain SNI as part of the handshake.
 	TLS Instance = "TLS"
 	// UDP declares that the port uses UDP.
-	// Note that UDP protocol is not currently supported by the proxy.
+	// Datagrams are forwarded to a single destination by the proxy, without any L7 processing.
 	UDP Instance = "UDP"
 	// Mongo declares that the port carries MongoDB traffic.
 	Mongo Instance = "Mongo"
@@ -18,7 +18,11 @@ package inject
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -105,10 +109,21 @@ func extractStatusPort(sidecar *corev1.Container) int {
 }
 
 // convertAppProber returns a overwritten `HTTPGetAction` for pilot agent to take over.
+// TCP and gRPC probers are replaced by HTTP probers of pilot agent.
 func convertAppProber(probe *corev1.Probe, newURL string, statusPort int) *corev1.HTTPGetAction {
-	if probe == nil || probe.HTTPGet == nil {
+	if probe == nil {
 		return nil
 	}
+	if probe.HTTPGet == nil {
+		if probe.TCPSocket == nil && convertGRPCProber(probe.Exec) == nil {
+			return nil
+		}
+		return &corev1.HTTPGetAction{
+			Path:   newURL,
+			Port:   intstr.FromInt(statusPort),
+			Scheme: corev1.URISchemeHTTP,
+		}
+	}
 	c := probe.HTTPGet.DeepCopy()
 	// Change the application container prober config.
 	c.Port = intstr.FromInt(statusPort)
@@ -122,23 +137,82 @@ func convertAppProber(probe *corev1.Probe, newURL string, statusPort int) *corev
 	return c
 }
 
+// convertGRPCProber returns the gRPC health check performed by an exec prober running grpc_health_probe
+// (https://github.com/grpc-ecosystem/grpc-health-probe), which declares a gRPC health checking probe.
+// It returns nil if the prober runs another command, or if the check is done over TLS.
+func convertGRPCProber(exec *corev1.ExecAction) *status.GRPCAction {
+	if exec == nil || len(exec.Command) == 0 {
+		return nil
+	}
+	if name := path.Base(exec.Command[0]); name != "grpc_health_probe" && name != "grpc-health-probe" {
+		return nil
+	}
+
+	flags := flag.NewFlagSet(exec.Command[0], flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	addr := flags.String("addr", "", "")
+	service := flags.String("service", "", "")
+	useTLS := flags.Bool("tls", false, "")
+	flags.String("user-agent", "", "")
+	flags.Duration("connect-timeout", 0, "")
+	flags.Duration("rpc-timeout", 0, "")
+	if err := flags.Parse(exec.Command[1:]); err != nil || flags.NArg() > 0 {
+		log.Debugf("Unsupported grpc_health_probe command %v, skip app probe rewriting: %v", exec.Command, err)
+		return nil
+	}
+	if *useTLS {
+		return nil
+	}
+
+	_, portStr, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 {
+		return nil
+	}
+	return &status.GRPCAction{
+		Port:    port,
+		Service: *service,
+	}
+}
+
 // DumpAppProbers returns a json encoded string as `status.KubeAppProbers`.
 // Also update the probers so that all usages of named port will be resolved to integer.
 func DumpAppProbers(podspec *corev1.PodSpec) string {
 	out := status.KubeAppProbers{}
-	updateNamedPort := func(p *corev1.Probe, portMap map[string]int32) *corev1.HTTPGetAction {
-		if p == nil || p.HTTPGet == nil {
+	resolveNamedPort := func(port *intstr.IntOrString, portMap map[string]int32) bool {
+		if port.Type == intstr.String {
+			p, exists := portMap[port.StrVal]
+			if !exists {
+				return false
+			}
+			*port = intstr.FromInt(int(p))
+		}
+		return true
+	}
+	updateNamedPort := func(p *corev1.Probe, portMap map[string]int32) *status.Prober {
+		if p == nil {
 			return nil
 		}
-		h := p.HTTPGet
-		if h.Port.Type == intstr.String {
-			port, exists := portMap[h.Port.StrVal]
-			if !exists {
+		switch {
+		case p.HTTPGet != nil:
+			if !resolveNamedPort(&p.HTTPGet.Port, portMap) {
 				return nil
 			}
-			h.Port = intstr.FromInt(int(port))
+			return &status.Prober{HTTPGet: p.HTTPGet}
+		case p.TCPSocket != nil:
+			if !resolveNamedPort(&p.TCPSocket.Port, portMap) {
+				return nil
+			}
+			return &status.Prober{TCPSocket: p.TCPSocket}
+		default:
+			if g := convertGRPCProber(p.Exec); g != nil {
+				return &status.Prober{GRPC: g}
+			}
 		}
-		return h
+		return nil
 	}
 	for _, c := range podspec.Containers {
 		if c.Name == ProxyContainerName {
@@ -193,10 +267,10 @@ func rewriteAppHTTPProbe(annotations map[string]string, podSpec *corev1.PodSpec,
 		}
 		readyz, livez := status.FormatProberURL(c.Name)
 		if hg := convertAppProber(c.ReadinessProbe, readyz, statusPort); hg != nil {
-			*c.ReadinessProbe.HTTPGet = *hg
+			c.ReadinessProbe.Handler = corev1.Handler{HTTPGet: hg}
 		}
 		if hg := convertAppProber(c.LivenessProbe, livez, statusPort); hg != nil {
-			*c.LivenessProbe.HTTPGet = *hg
+			c.LivenessProbe.Handler = corev1.Handler{HTTPGet: hg}
 		}
 	}
 }
@@ -227,19 +301,30 @@ func createProbeRewritePatch(annotations map[string]string, podSpec *corev1.PodS
 		}
 		readyz, livez := status.FormatProberURL(c.Name)
 		if after := convertAppProber(c.ReadinessProbe, readyz, statusPort); after != nil {
-			patch = append(patch, rfc6902PatchOperation{
-				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%v/readinessProbe/httpGet", i),
-				Value: *after,
-			})
+			patch = append(patch, probeRewritePatch(c.ReadinessProbe, after, fmt.Sprintf("/spec/containers/%v/readinessProbe", i)))
 		}
 		if after := convertAppProber(c.LivenessProbe, livez, statusPort); after != nil {
-			patch = append(patch, rfc6902PatchOperation{
-				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%v/livenessProbe/httpGet", i),
-				Value: *after,
-			})
+			patch = append(patch, probeRewritePatch(c.LivenessProbe, after, fmt.Sprintf("/spec/containers/%v/livenessProbe", i)))
 		}
 	}
 	return patch
 }
+
+// probeRewritePatch generates the patch replacing the action of a prober by the given HTTP action.
+func probeRewritePatch(probe *corev1.Probe, after *corev1.HTTPGetAction, probePath string) rfc6902PatchOperation {
+	if probe.HTTPGet != nil {
+		return rfc6902PatchOperation{
+			Op:    "replace",
+			Path:  probePath + "/httpGet",
+			Value: *after,
+		}
+	}
+	// TCP and gRPC probers are replaced as a whole, as a prober must have a single action
+	p := probe.DeepCopy()
+	p.Handler = corev1.Handler{HTTPGet: after}
+	return rfc6902PatchOperation{
+		Op:    "replace",
+		Path:  probePath,
+		Value: *p,
+	}
+}
@@ -14,9 +14,11 @@
 package inject
 
 import (
+	"reflect"
 	"testing"
 
 	"istio.io/api/annotation"
+	"istio.io/istio/pilot/cmd/pilot-agent/status"
 
 	corev1 "k8s.io/api/core/v1"
 )
@@ -109,3 +111,43 @@ func TestShouldRewriteAppHTTPProbers(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertGRPCProber(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		command  []string
+		expected *status.GRPCAction
+	}{
+		{
+			name:     "addr",
+			command:  []string{"/bin/grpc_health_probe", "-addr=:9090"},
+			expected: &status.GRPCAction{Port: 9090},
+		},
+		{
+			name:     "addr-and-service",
+			command:  []string{"grpc-health-probe", "--addr", "localhost:9090", "-service", "hello", "-rpc-timeout=5s"},
+			expected: &status.GRPCAction{Port: 9090, Service: "hello"},
+		},
+		{
+			name:    "tls",
+			command: []string{"/bin/grpc_health_probe", "-addr=:9090", "-tls"},
+		},
+		{
+			name:    "no-port",
+			command: []string{"/bin/grpc_health_probe", "-addr=localhost"},
+		},
+		{
+			name:    "unknown-flag",
+			command: []string{"/bin/grpc_health_probe", "-addr=:9090", "-unknown"},
+		},
+		{
+			name:    "other-command",
+			command: []string{"cat", "/tmp/healthy"},
+		},
+	} {
+		got := convertGRPCProber(&corev1.ExecAction{Command: tc.command})
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("[%v] failed, want %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -30,10 +31,12 @@ import (
 	pkgcmd "istio.io/istio/pkg/cmd"
 	kubelib "istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/adapter/vault"
 	"istio.io/istio/security/pkg/caclient"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/k8s/controller"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
 	probecontroller "istio.io/istio/security/pkg/probe"
 	"istio.io/istio/security/pkg/registry"
 	"istio.io/istio/security/pkg/registry/kube"
@@ -73,6 +76,8 @@ type cliOptions struct { // nolint: maligned
 	selfSignedRootCertCheckInterval         time.Duration
 	selfSignedRootCertGracePeriodPercentile int
 	enableJitterForRootCertRotator          bool
+	// The algorithm used to generate the self-signed CA key, one of util.SupportedKeyAlgorithms.
+	selfSignedCAKeyAlgorithm string
 
 	workloadCertTTL    time.Duration
 	maxWorkloadCertTTL time.Duration
@@ -92,9 +97,14 @@ type cliOptions struct { // nolint: maligned
 	signCACerts bool
 	// Whether to generate PKCS#8 private keys.
 	pkcs8Keys bool
+	// Whether Citadel approves the Kubernetes CSRs submitted by the node agent for workload certificates.
+	approveWorkloadCSRs bool
 
 	cAClientConfig caclient.Config
 
+	// The Vault signer configuration. Citadel signs with Vault if the address of the Vault server is set.
+	vaultConfig vault.Config
+
 	// Monitoring port number
 	monitoringPort int
 	// Enable profiling in monitoring
@@ -123,6 +133,11 @@ type cliOptions struct { // nolint: maligned
 
 	// Whether SDS is enabled on.
 	sdsEnabled bool
+
+	// The interval of reading the revocation list of the CA. The revocation list is disabled if it is not positive.
+	revocationCheckInterval time.Duration
+	// The validity of the CRL published by the CA.
+	crlTTL time.Duration
 }
 
 var (
@@ -222,6 +237,8 @@ func initCLI() {
 	flags.BoolVar(&opts.selfSignedCA, "self-signed-ca", false,
 		"Indicates whether to use auto-generated self-signed CA certificate. "+
 			"When set to true, the '--signing-cert' and '--signing-key' options are ignored.")
+	flags.StringVar(&opts.selfSignedCAKeyAlgorithm, "self-signed-ca-key-algorithm", string(util.RSAKey),
+		fmt.Sprintf("The algorithm used to generate the self-signed CA key, one of %v.", util.SupportedKeyAlgorithms))
 	flags.StringVar(&opts.trustDomain, "trust-domain", "",
 		"The domain serves to identify the system with SPIFFE.")
 	// Upstream CA configuration if Citadel interacts with upstream CA.
@@ -232,6 +249,29 @@ func initCLI() {
 		"The requested TTL for the CA certificate.")
 	flags.IntVar(&opts.cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated private key.")
 
+	// Configuration if Citadel signs with the PKI secrets engine of Vault, the signing key is kept in Vault.
+	flags.StringVar(&opts.vaultConfig.Address, "vault-addr", "", "The address of the Vault server, e.g. "+
+		"https://vault.vault.svc:8200. When set, Citadel signs certificates with the PKI secrets engine of Vault, "+
+		"and the '--root-cert' option is required.")
+	flags.StringVar(&opts.vaultConfig.TLSRootCertFile, "vault-tls-root-cert", "",
+		"Path to the root certificate file verifying the Vault server. Defaults to the system root certificates.")
+	flags.StringVar(&opts.vaultConfig.AuthMethod, "vault-auth-method", vault.KubernetesAuth,
+		fmt.Sprintf("The method to log in to Vault, %s or %s.", vault.KubernetesAuth, vault.AppRoleAuth))
+	flags.StringVar(&opts.vaultConfig.LoginPath, "vault-login-path", "",
+		"The path to log in to Vault. Defaults to auth/<vault-auth-method>/login.")
+	flags.StringVar(&opts.vaultConfig.KubernetesRole, "vault-kubernetes-role", "",
+		"The role to log in to Vault with the Kubernetes auth method.")
+	flags.StringVar(&opts.vaultConfig.KubernetesJWTPath, "vault-kubernetes-jwt-path", vault.DefaultKubernetesJWTPath,
+		"Path to the service account token to log in to Vault with the Kubernetes auth method.")
+	flags.StringVar(&opts.vaultConfig.AppRoleID, "vault-approle-role-id", "",
+		"The role ID to log in to Vault with the AppRole auth method.")
+	flags.StringVar(&opts.vaultConfig.AppRoleSecretIDPath, "vault-approle-secret-id-path", "",
+		"Path to the secret ID file to log in to Vault with the AppRole auth method.")
+	flags.StringVar(&opts.vaultConfig.PKIPath, "vault-pki-path", "",
+		"The mount path of the Vault PKI secrets engine signing certificates, e.g. istio_ca.")
+	flags.StringVar(&opts.vaultConfig.PKIRole, "vault-pki-role", "",
+		"The role of the Vault PKI secrets engine signing certificates.")
+
 	// Certificate signing configuration.
 	flags.DurationVar(&opts.workloadCertTTL, "workload-cert-ttl", cmd.DefaultWorkloadCertTTL,
 		"The TTL of issued workload certificates.")
@@ -251,6 +291,8 @@ func initCLI() {
 
 	flags.BoolVar(&opts.signCACerts, "sign-ca-certs", false, "Whether Citadel signs certificates for other CAs.")
 	flags.BoolVar(&opts.pkcs8Keys, "pkcs8-keys", false, "Whether to generate PKCS#8 private keys.")
+	flags.BoolVar(&opts.approveWorkloadCSRs, "approve-workload-csrs", false, "Whether Citadel approves the "+
+		"Kubernetes CSRs submitted by the node agent for workload certificates, which are signed by the cluster signer.")
 
 	// Monitoring configuration
 	flags.IntVar(&opts.monitoringPort, "monitoring-port", 15014, "The port number for monitoring Citadel. "+
@@ -265,6 +307,14 @@ func initCLI() {
 	flags.DurationVar(&opts.probeCheckInterval, "probe-check-interval", cmd.DefaultProbeCheckInterval,
 		"Interval of checking the liveness of the CA.")
 
+	flags.DurationVar(&opts.revocationCheckInterval, "revocation-check-interval", cmd.DefaultRevocationCheckInterval,
+		"Interval of reading the revocation list of the CA from configmap "+ca.RevocationConfigMapName+
+			". Certificates are not denied and no CRL is published if it is 0.")
+	flags.DurationVar(&opts.crlTTL, "crl-ttl", cmd.DefaultCRLTTL,
+		"The validity of the CRL published by the CA, which is only published if the CA signs with its root certificate. "+
+			"The CRL is published again at half of its validity. Proxies reject all the Istio mutual TLS connections "+
+			"once the CRL expired, so the CA must not be unavailable for longer than half of it.")
+
 	flags.BoolVar(&opts.appendDNSNames, "append-dns-names", true,
 		"Append DNS names to the certificates for webhook services.")
 	flags.StringVar(&opts.customDNSNames, "custom-dns-names", "",
@@ -363,6 +413,11 @@ func runCA() {
 		}
 	}
 
+	if opts.approveWorkloadCSRs {
+		log.Info("Creating Kubernetes controller to approve the CSRs of workload certificates ...")
+		controller.NewCSRApprover(cs.CertificatesV1beta1().CertificateSigningRequests()).Run(stopCh)
+	}
+
 	if opts.grpcPort > 0 {
 		// start registry if gRPC server is to be started
 		reg := registry.GetIdentityRegistry()
@@ -450,9 +505,28 @@ func runCA() {
 
 func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	var caOpts *ca.IstioCAOptions
+	var vaultSigner *vault.Signer
 	var err error
 
-	if opts.selfSignedCA {
+	if opts.vaultConfig.Address != "" {
+		log.Infof("Use the Vault server at %s to sign certificates", opts.vaultConfig.Address)
+		if vaultSigner, err = vault.NewSigner(opts.vaultConfig); err != nil {
+			fatalf("Failed to create the Vault signer (error: %v)", err)
+		}
+		certChain, err := vaultSigner.CACertChain()
+		if err != nil {
+			fatalf("Failed to read the CA certificate chain from Vault (error: %v)", err)
+		}
+		rootCert, err := ioutil.ReadFile(opts.rootCertFile)
+		if err != nil {
+			fatalf("Failed to read the root certificate (error: %v)", err)
+		}
+		caOpts, err = ca.NewExternalSignerIstioCAOptions(vaultSigner, certChain, rootCert, opts.workloadCertTTL,
+			opts.maxWorkloadCertTTL, opts.istioCaStorageNamespace, client)
+		if err != nil {
+			fatalf("Failed to create a Vault Citadel (error: %v)", err)
+		}
+	} else if opts.selfSignedCA {
 		log.Info("Use self-signed certificate as the CA certificate")
 		spiffe.SetTrustDomain(spiffe.DetermineTrustDomain(opts.trustDomain, true))
 		// Abort after 20 minutes.
@@ -464,10 +538,14 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 		} else {
 			checkInterval = -1
 		}
+		keyAlgorithm, err := util.ParseKeyAlgorithm(opts.selfSignedCAKeyAlgorithm)
+		if err != nil {
+			fatalf("Invalid self-signed CA key algorithm (error: %v)", err)
+		}
 		caOpts, err = ca.NewSelfSignedIstioCAOptions(ctx, opts.readSigningCertOnly,
 			opts.selfSignedRootCertGracePeriodPercentile, opts.selfSignedCACertTTL,
 			opts.selfSignedRootCertCheckInterval, opts.workloadCertTTL,
-			opts.maxWorkloadCertTTL, spiffe.GetTrustDomain(), opts.dualUse,
+			opts.maxWorkloadCertTTL, spiffe.GetTrustDomain(), opts.dualUse, keyAlgorithm,
 			opts.istioCaStorageNamespace, checkInterval, client, opts.rootCertFile,
 			opts.enableJitterForRootCertRotator)
 		if err != nil {
@@ -484,6 +562,9 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 
 	caOpts.LivenessProbeOptions = opts.LivenessProbeOptions
 	caOpts.ProbeCheckInterval = opts.probeCheckInterval
+	if opts.revocationCheckInterval > 0 {
+		caOpts.RevocationList = ca.NewRevocationList(opts.istioCaStorageNamespace, client, opts.revocationCheckInterval, opts.crlTTL)
+	}
 
 	istioCA, err := ca.NewIstioCA(caOpts)
 	if err != nil {
@@ -505,12 +586,21 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	rootCertRotatorChan = make(chan struct{})
 	// Start root cert rotator in a separate goroutine.
 	istioCA.Run(rootCertRotatorChan)
+	if vaultSigner != nil {
+		// Renew the Vault token in a separate goroutine.
+		go vaultSigner.Run(rootCertRotatorChan)
+	}
 	go pkgcmd.WaitSignal(rootCertRotatorChan)
 
 	return istioCA
 }
 
 func verifyCommandLineOptions() {
+	if opts.vaultConfig.Address != "" {
+		verifyVaultCommandLineOptions()
+		return
+	}
+
 	if opts.selfSignedCA {
 		return
 	}
@@ -538,3 +628,26 @@ func verifyCommandLineOptions() {
 			opts.workloadCertGracePeriodRatio)
 	}
 }
+
+func verifyVaultCommandLineOptions() {
+	if opts.selfSignedCA {
+		fatalf("The '-vault-addr' and '-self-signed-ca' options are exclusive")
+	}
+
+	if len(opts.cAClientConfig.CAAddress) != 0 {
+		fatalf("The '-vault-addr' and '-upstream-ca-address' options are exclusive")
+	}
+
+	if opts.signCACerts {
+		fatalf("Citadel does not sign CA certificates with Vault, '-sign-ca-certs' is not supported with '-vault-addr'")
+	}
+
+	if opts.rootCertFile == "" {
+		fatalf("No root cert has been specified. Specify the root cert of Vault via '-root-cert' option")
+	}
+
+	if opts.workloadCertGracePeriodRatio < 0 || opts.workloadCertGracePeriodRatio > 1 {
+		fatalf("Workload cert grace period ratio %f is invalid. It should be within [0, 1]",
+			opts.workloadCertGracePeriodRatio)
+	}
+}
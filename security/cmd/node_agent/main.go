@@ -15,6 +15,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -24,13 +25,15 @@ import (
 	pkgcmd "istio.io/istio/pkg/cmd"
 	"istio.io/istio/pkg/config/constants"
 	nvm "istio.io/istio/security/pkg/nodeagent/vm"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
 )
 
 var (
-	naConfig = nvm.NewConfig()
+	naConfig     = nvm.NewConfig()
+	keyAlgorithm string
 
 	rootCmd = &cobra.Command{
 		Use:   "node_agent",
@@ -57,6 +60,8 @@ func init() {
 	flags.DurationVar(&cAClientConfig.RequestedCertTTL, "workload-cert-ttl", 90*24*time.Hour,
 		"The requested TTL for the workload")
 	flags.IntVar(&cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated private key")
+	flags.StringVar(&keyAlgorithm, "key-algorithm", string(pkiutil.RSAKey),
+		fmt.Sprintf("Algorithm of generated private key, one of %v", pkiutil.SupportedKeyAlgorithms))
 	flags.StringVar(&cAClientConfig.CAAddress,
 		"ca-address", "istio-citadel:8060", "Istio CA address")
 
@@ -98,6 +103,12 @@ func runNodeAgent() {
 		log.Errora(err)
 		os.Exit(-1)
 	}
+	alg, err := pkiutil.ParseKeyAlgorithm(keyAlgorithm)
+	if err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	naConfig.CAClientConfig.KeyAlgorithm = alg
 	nodeAgent, err := nvm.NewNodeAgent(naConfig)
 	if err != nil {
 		log.Errora(err)
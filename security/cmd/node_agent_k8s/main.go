@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/security/pkg/nodeagent/cache"
 	"istio.io/istio/security/pkg/nodeagent/sds"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
+	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/server/monitoring"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/env"
@@ -94,6 +95,11 @@ const (
 	// validate the certificate's format which is returned by CA.
 	skipValidateCertFlag = "SKIP_CERT_VALIDATION"
 
+	// The environmental variable name for the algorithm of the private keys generated for
+	// workload CSRs, one of RSA, ECDSA-P256 or ECDSA-P384.
+	keyAlgorithm     = "KEY_ALGORITHM"
+	keyAlgorithmFlag = "keyAlgorithm"
+
 	// The environmental variable name for secret TTL, node agent decides whether a secret
 	// is expired if time.now - secret.createtime >= secretTTL.
 	// example value format like "90m"
@@ -129,6 +135,7 @@ var (
 	workloadSdsCacheOptions cache.Options
 	gatewaySdsCacheOptions  cache.Options
 	serverOptions           sds.Options
+	keyAlgorithmName        string
 	gatewaySecretChan       chan struct{}
 	loggingOptions          = log.DefaultOptions()
 	ctrlzOptions            = ctrlz.DefaultOptions()
@@ -243,6 +250,7 @@ var (
 	vaultAuthPathEnv                   = env.RegisterStringVar(vaultAuthPath, "", "").Get()
 	vaultSignCsrPathEnv                = env.RegisterStringVar(vaultSignCsrPath, "", "").Get()
 	vaultTLSRootCertEnv                = env.RegisterStringVar(vaultTLSRootCert, "", "").Get()
+	keyAlgorithmEnv                    = env.RegisterStringVar(keyAlgorithm, string(util.RSAKey), "").Get()
 	secretTTLEnv                       = env.RegisterDurationVar(secretTTL, 24*time.Hour, "").Get()
 	secretRefreshGraceDurationEnv      = env.RegisterDurationVar(SecretRefreshGraceDuration, 1*time.Hour, "").Get()
 	secretRotationIntervalEnv          = env.RegisterDurationVar(SecretRotationInterval, 10*time.Minute, "").Get()
@@ -321,6 +329,10 @@ func applyEnvVars(cmd *cobra.Command) {
 		workloadSdsCacheOptions.SkipValidateCert = skipValidateCertFlagEnv
 	}
 
+	if !cmd.Flag(keyAlgorithmFlag).Changed {
+		keyAlgorithmName = keyAlgorithmEnv
+	}
+
 	serverOptions.RecycleInterval = staledConnectionRecycleIntervalEnv
 
 	if !cmd.Flag(InitialBackoffFlag).Changed {
@@ -351,6 +363,12 @@ func validateOptions() error {
 			return fmt.Errorf("CA endpoint cannot be empty when workload SDS is enabled")
 		}
 	}
+
+	alg, err := util.ParseKeyAlgorithm(keyAlgorithmName)
+	if err != nil {
+		return err
+	}
+	workloadSdsCacheOptions.KeyAlgorithm = alg
 	return nil
 }
 
@@ -399,6 +417,9 @@ func main() {
 		false,
 		"If true, node agent skip validating format of certificate returned from CA.")
 
+	rootCmd.PersistentFlags().StringVar(&keyAlgorithmName, keyAlgorithmFlag, string(util.RSAKey),
+		fmt.Sprintf("Algorithm of the private keys generated for workload certificates, one of %v", util.SupportedKeyAlgorithms))
+
 	rootCmd.PersistentFlags().StringVar(&serverOptions.VaultAddress, vaultAddressFlag, "",
 		"Vault address")
 	rootCmd.PersistentFlags().StringVar(&serverOptions.VaultRole, vaultRoleFlag, "",
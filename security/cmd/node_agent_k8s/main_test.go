@@ -67,6 +67,13 @@ func TestValidateOptions(t *testing.T) {
 			},
 			errorMsg: "CA endpoint cannot be empty when workload SDS is enabled",
 		},
+		{
+			name: "unsupported key algorithm",
+			setExtraOptions: func() {
+				keyAlgorithmName = "DSA"
+			},
+			errorMsg: "unsupported key algorithm",
+		},
 	}
 
 	for _, c := range cases {
@@ -83,6 +90,7 @@ func TestValidateOptions(t *testing.T) {
 			CAEndpoint:              "endpoint",
 			CAProviderName:          "provider",
 		}
+		keyAlgorithmName = ""
 
 		// Set extra options from each test case
 		if c.setExtraOptions != nil {
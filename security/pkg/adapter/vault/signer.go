@@ -0,0 +1,369 @@
+// Copyright 2018 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault provides adapter to connect to vault server.
+package vault
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/pkg/log"
+)
+
+const (
+	// KubernetesAuth logs in to Vault with the Kubernetes service account token of Citadel.
+	KubernetesAuth = "kubernetes"
+	// AppRoleAuth logs in to Vault with an AppRole role ID and secret ID.
+	AppRoleAuth = "approle"
+
+	// DefaultKubernetesJWTPath is the path of the service account token of the pod.
+	DefaultKubernetesJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// reloginInterval is the initial interval between two attempts to log in to Vault. It doubles on each
+	// failed attempt, up to maxReloginInterval.
+	reloginInterval    = 10 * time.Second
+	maxReloginInterval = 5 * time.Minute
+)
+
+var vaultLog = log.RegisterScope("vault", "Vault signer debugging", 0)
+
+// Config configures a Signer.
+type Config struct {
+	// Address is the address of the Vault server, e.g. https://vault.vault.svc:8200.
+	Address string
+	// TLSRootCertFile is the file of the PEM encoded root certs verifying the Vault server. The system root certs
+	// are used if it is empty.
+	TLSRootCertFile string
+
+	// AuthMethod is the method to log in to Vault, KubernetesAuth or AppRoleAuth.
+	AuthMethod string
+	// LoginPath is the path to log in, e.g. auth/kubernetes/login. It defaults to the login path of AuthMethod
+	// mounted at its default path.
+	LoginPath string
+	// KubernetesRole is the role to log in with the Kubernetes auth method.
+	KubernetesRole string
+	// KubernetesJWTPath is the file of the service account token to log in with the Kubernetes auth method.
+	KubernetesJWTPath string
+	// AppRoleID is the role ID to log in with the AppRole auth method.
+	AppRoleID string
+	// AppRoleSecretIDPath is the file of the secret ID to log in with the AppRole auth method.
+	AppRoleSecretIDPath string
+
+	// PKIPath is the mount path of the PKI secrets engine signing the certificates, e.g. istio_ca.
+	PKIPath string
+	// PKIRole is the role of the PKI secrets engine signing the certificates. The role must allow the SPIFFE
+	// URI SANs of the workloads, and not require a common name.
+	PKIRole string
+}
+
+// Signer signs certificates with the PKI secrets engine of Vault, so that the signing key is never held by
+// Citadel. It implements ca.Signer.
+type Signer struct {
+	config Config
+	client *api.Client
+	// loginSecret is the response of the last login. It holds the token of the Signer.
+	loginSecret *api.Secret
+}
+
+// NewSigner validates the config, and returns a Signer logged in to Vault.
+func NewSigner(config Config) (*Signer, error) {
+	switch config.AuthMethod {
+	case KubernetesAuth:
+		if config.KubernetesRole == "" {
+			return nil, fmt.Errorf("the role of the Kubernetes auth method is required")
+		}
+		if config.KubernetesJWTPath == "" {
+			config.KubernetesJWTPath = DefaultKubernetesJWTPath
+		}
+	case AppRoleAuth:
+		if config.AppRoleID == "" || config.AppRoleSecretIDPath == "" {
+			return nil, fmt.Errorf("the role ID and the secret ID of the AppRole auth method are required")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Vault auth method %q, must be %s or %s", config.AuthMethod,
+			KubernetesAuth, AppRoleAuth)
+	}
+	if config.LoginPath == "" {
+		config.LoginPath = "auth/" + config.AuthMethod + "/login"
+	}
+	if config.PKIPath == "" || config.PKIRole == "" {
+		return nil, fmt.Errorf("the path and the role of the PKI secrets engine are required")
+	}
+	config.PKIPath = strings.Trim(config.PKIPath, "/")
+
+	client, err := createVaultClient(config.Address, config.TLSRootCertFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{
+		config: config,
+		client: client,
+	}
+	if err := s.login(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CACertChain returns the PEM encoded chain of the CA certificates of the PKI secrets engine, from the issuing
+// CA certificate up to, but excluding, the root certificate.
+func (s *Signer) CACertChain() ([]byte, error) {
+	path := s.config.PKIPath + "/cert/ca_chain"
+	resp, err := s.client.Logical().Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if resp == nil || resp.Data == nil {
+		return nil, fmt.Errorf("no CA cert chain at %s", path)
+	}
+	chain, ok := resp.Data["certificate"].(string)
+	if !ok || chain == "" {
+		return nil, fmt.Errorf("no CA cert chain at %s", path)
+	}
+	return withoutRootCerts([]string{chain})
+}
+
+// Sign signs the CSR with the PKI role of the Signer. The subject IDs replace the SANs of the CSR.
+func (s *Signer) Sign(csr *x509.CertificateRequest, subjectIDs []string, lifetime time.Duration,
+	forCA bool) ([]byte, []byte, error) {
+	if forCA {
+		return nil, nil, fmt.Errorf("the Vault signer does not sign CA certificates")
+	}
+
+	var uriSANs, ipSANs, dnsSANs []string
+	for _, id := range subjectIDs {
+		if net.ParseIP(id) != nil {
+			ipSANs = append(ipSANs, id)
+		} else if strings.HasPrefix(id, spiffe.URIPrefix) {
+			uriSANs = append(uriSANs, id)
+		} else {
+			dnsSANs = append(dnsSANs, id)
+		}
+	}
+	data := map[string]interface{}{
+		"csr":                  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw})),
+		"format":               "pem",
+		"ttl":                  fmt.Sprintf("%ds", int64(lifetime.Seconds())),
+		"exclude_cn_from_sans": true,
+	}
+	if len(uriSANs) > 0 {
+		data["uri_sans"] = strings.Join(uriSANs, ",")
+	}
+	if len(ipSANs) > 0 {
+		data["ip_sans"] = strings.Join(ipSANs, ",")
+	}
+	if len(dnsSANs) > 0 {
+		data["alt_names"] = strings.Join(dnsSANs, ",")
+	}
+
+	path := s.config.PKIPath + "/sign/" + s.config.PKIRole
+	resp, err := s.client.Logical().Write(path, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign the CSR with %s: %v", path, err)
+	}
+	if resp == nil || resp.Data == nil {
+		return nil, nil, fmt.Errorf("empty response from %s", path)
+	}
+	cert, ok := resp.Data["certificate"].(string)
+	if !ok || cert == "" {
+		return nil, nil, fmt.Errorf("no certificate in the response from %s", path)
+	}
+
+	// ca_chain is the full chain of the issuing CA, issuing_ca is only the issuing CA certificate.
+	var chain []string
+	if caChain, ok := resp.Data["ca_chain"].([]interface{}); ok && len(caChain) > 0 {
+		for _, c := range caChain {
+			pemCert, ok := c.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid certificate chain in the response from %s", path)
+			}
+			chain = append(chain, pemCert)
+		}
+	} else if issuingCA, ok := resp.Data["issuing_ca"].(string); ok {
+		chain = []string{issuingCA}
+	}
+	certChain, err := withoutRootCerts(chain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid certificate chain in the response from %s: %v", path, err)
+	}
+	return []byte(strings.TrimSpace(cert) + "\n"), certChain, nil
+}
+
+// Run renews the Vault token of the Signer until stopCh is closed. It logs in again when the token cannot be
+// renewed anymore, e.g. when it reaches its max TTL.
+func (s *Signer) Run(stopCh <-chan struct{}) {
+	backoff := reloginInterval
+	for {
+		lease := time.Duration(s.loginSecret.Auth.LeaseDuration) * time.Second
+		if lease <= 0 {
+			vaultLog.Infof("The Vault token does not expire")
+			return
+		}
+		renewer, err := s.client.NewRenewer(&api.RenewerInput{Secret: s.loginSecret})
+		if err != nil {
+			vaultLog.Errorf("Failed to create the renewer of the Vault token (%v), will log in again in %v", err, backoff)
+			if !s.relogin(backoff, stopCh) {
+				return
+			}
+			backoff = nextReloginInterval(backoff)
+			continue
+		}
+		backoff = reloginInterval
+		go renewer.Renew()
+
+		// Log in again at 2/3 of the lease if the token is not renewable, right away otherwise.
+		var wait time.Duration
+		select {
+		case <-stopCh:
+			renewer.Stop()
+			return
+		case err := <-renewer.DoneCh():
+			if err == api.ErrRenewerNotRenewable {
+				wait = lease * 2 / 3
+				vaultLog.Infof("The Vault token is not renewable, will log in again in %v", wait)
+			} else if err != nil {
+				vaultLog.Warnf("Failed to renew the Vault token (%v), will log in again", err)
+			}
+		}
+		renewer.Stop()
+		if !s.relogin(wait, stopCh) {
+			return
+		}
+	}
+}
+
+// relogin logs in to Vault after wait, and retries with an exponential backoff until it succeeds. It returns
+// false if stopCh is closed first.
+func (s *Signer) relogin(wait time.Duration, stopCh <-chan struct{}) bool {
+	backoff := reloginInterval
+	for {
+		select {
+		case <-stopCh:
+			return false
+		case <-time.After(wait):
+		}
+		if err := s.login(); err != nil {
+			vaultLog.Errorf("%v, will retry in %v", err, backoff)
+			wait = backoff
+			backoff = nextReloginInterval(backoff)
+			continue
+		}
+		vaultLog.Infof("Logged in to Vault at %s", s.config.Address)
+		return true
+	}
+}
+
+// nextReloginInterval returns the interval following interval in the exponential backoff of the logins.
+func nextReloginInterval(interval time.Duration) time.Duration {
+	if interval *= 2; interval > maxReloginInterval {
+		return maxReloginInterval
+	}
+	return interval
+}
+
+// login logs in to Vault with the auth method of the Signer, and sets the token of the client.
+func (s *Signer) login() error {
+	var data map[string]interface{}
+	switch s.config.AuthMethod {
+	case KubernetesAuth:
+		jwt, err := ioutil.ReadFile(s.config.KubernetesJWTPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the service account token: %v", err)
+		}
+		data = map[string]interface{}{
+			"role": s.config.KubernetesRole,
+			"jwt":  strings.TrimSpace(string(jwt)),
+		}
+	case AppRoleAuth:
+		secretID, err := ioutil.ReadFile(s.config.AppRoleSecretIDPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the AppRole secret ID: %v", err)
+		}
+		data = map[string]interface{}{
+			"role_id":   s.config.AppRoleID,
+			"secret_id": strings.TrimSpace(string(secretID)),
+		}
+	}
+
+	resp, err := s.client.Logical().Write(s.config.LoginPath, data)
+	if err != nil {
+		return fmt.Errorf("failed to log in to Vault at %s: %v", s.config.Address, err)
+	}
+	if resp == nil || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("failed to log in to Vault at %s: no token in the response", s.config.Address)
+	}
+	s.client.SetToken(resp.Auth.ClientToken)
+	s.loginSecret = resp
+	return nil
+}
+
+// createVaultClient creates a client to the Vault server at vaultAddr, verified with the root certs of
+// tlsRootCertFile, or the system root certs if it is empty.
+func createVaultClient(vaultAddr, tlsRootCertFile string) (*api.Client, error) {
+	config := api.DefaultConfig()
+	config.Address = vaultAddr
+	if tlsRootCertFile != "" {
+		if err := config.ConfigureTLS(&api.TLSConfig{CACert: tlsRootCertFile}); err != nil {
+			return nil, fmt.Errorf("failed to configure the root cert of the Vault server: %v", err)
+		}
+	}
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a Vault client: %v", err)
+	}
+	return client, nil
+}
+
+// withoutRootCerts returns the PEM encoded certificates of the chain, except the self-signed root certificates.
+func withoutRootCerts(chain []string) ([]byte, error) {
+	var certChain []byte
+	for _, c := range chain {
+		rest := []byte(c)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			if isRootCert(cert) {
+				continue
+			}
+			certChain = append(certChain, pem.EncodeToMemory(block)...)
+		}
+	}
+	return certChain, nil
+}
+
+// isRootCert returns whether the certificate is self-signed, judging by its subject and key IDs.
+func isRootCert(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return len(cert.AuthorityKeyId) == 0 || bytes.Equal(cert.AuthorityKeyId, cert.SubjectKeyId)
+}
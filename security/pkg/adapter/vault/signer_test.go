@@ -0,0 +1,543 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	testCAKeyCertFile = "testdata/istio_ca.pem"
+	testCsrFile       = "testdata/workload-1.csr"
+
+	testJWT      = "fake-service-account-token"
+	testRole     = "istio-ca"
+	testRoleID   = "fake-role-id"
+	testSecretID = "fake-secret-id"
+	testPKIPath  = "istio_ca"
+	testPKIRole  = "workload"
+)
+
+// mockVaultServer serves the login, token renewal and PKI endpoints of Vault, and signs the CSRs with the
+// signing CA key and cert of testdata/istio_ca.pem.
+type mockVaultServer struct {
+	httpServer *httptest.Server
+
+	signingKey  crypto.PrivateKey
+	signingCert *x509.Certificate
+	// caChain is the PEM encoded signing CA cert and root cert.
+	caChain []string
+
+	// leaseDuration and renewable are set on the tokens issued.
+	leaseDuration int
+	renewable     bool
+
+	mu         sync.Mutex
+	logins     int
+	renewals   int
+	token      string
+	signData   map[string]interface{}
+	signTokens []string
+}
+
+func newMockVaultServer(t *testing.T, tls bool) *mockVaultServer {
+	b, err := ioutil.ReadFile(testCAKeyCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &mockVaultServer{leaseDuration: 3600, renewable: true}
+	for block, rest := pem.Decode(b); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "PRIVATE KEY":
+			if s.signingKey, err = util.ParsePemEncodedKey(pem.EncodeToMemory(block)); err != nil {
+				t.Fatal(err)
+			}
+		case "CERTIFICATE":
+			if s.signingCert == nil {
+				if s.signingCert, err = x509.ParseCertificate(block.Bytes); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.caChain = append(s.caChain, string(pem.EncodeToMemory(block)))
+		}
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		if req.Method == http.MethodPut || req.Method == http.MethodPost {
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		switch req.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			if body["role"] != testRole || body["jwt"] != testJWT {
+				s.writeError(w, http.StatusBadRequest, "invalid role or jwt")
+				return
+			}
+			s.writeLogin(w)
+		case "/v1/auth/approle/login":
+			if body["role_id"] != testRoleID || body["secret_id"] != testSecretID {
+				s.writeError(w, http.StatusBadRequest, "invalid role ID or secret ID")
+				return
+			}
+			s.writeLogin(w)
+		case "/v1/auth/token/renew-self":
+			if !s.authorized(req) {
+				s.writeError(w, http.StatusForbidden, "permission denied")
+				return
+			}
+			s.mu.Lock()
+			s.renewals++
+			s.mu.Unlock()
+			// The token reached its max TTL, it cannot be renewed anymore.
+			s.writeJSON(w, map[string]interface{}{
+				"auth": map[string]interface{}{
+					"client_token":   req.Header.Get("X-Vault-Token"),
+					"lease_duration": s.leaseDuration,
+					"renewable":      false,
+				},
+			})
+		case "/v1/" + testPKIPath + "/cert/ca_chain":
+			s.writeJSON(w, map[string]interface{}{
+				"data": map[string]interface{}{"certificate": strings.Join(s.caChain, "")},
+			})
+		case "/v1/" + testPKIPath + "/sign/" + testPKIRole:
+			if !s.authorized(req) {
+				s.writeError(w, http.StatusForbidden, "permission denied")
+				return
+			}
+			s.mu.Lock()
+			s.signData = body
+			s.signTokens = append(s.signTokens, req.Header.Get("X-Vault-Token"))
+			s.mu.Unlock()
+			cert, err := s.sign(body)
+			if err != nil {
+				s.writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.writeJSON(w, map[string]interface{}{
+				"data": map[string]interface{}{
+					"certificate": cert,
+					"issuing_ca":  s.caChain[0],
+					"ca_chain":    s.caChain,
+				},
+			})
+		default:
+			s.writeError(w, http.StatusNotFound, "no handler for route")
+		}
+	})
+	if tls {
+		s.httpServer = httptest.NewTLSServer(handler)
+	} else {
+		s.httpServer = httptest.NewServer(handler)
+	}
+	return s
+}
+
+func (s *mockVaultServer) authorized(req *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token != "" && req.Header.Get("X-Vault-Token") == s.token
+}
+
+func (s *mockVaultServer) writeLogin(w http.ResponseWriter) {
+	s.mu.Lock()
+	s.logins++
+	s.token = fmt.Sprintf("token-%d", s.logins)
+	token := s.token
+	s.mu.Unlock()
+	s.writeJSON(w, map[string]interface{}{
+		"auth": map[string]interface{}{
+			"client_token":   token,
+			"lease_duration": s.leaseDuration,
+			"renewable":      s.renewable,
+		},
+	})
+}
+
+func (s *mockVaultServer) writeJSON(w http.ResponseWriter, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *mockVaultServer) writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{msg}})
+}
+
+// sign signs the CSR of the request for its URI SANs, as a PKI role allowing them.
+func (s *mockVaultServer) sign(body map[string]interface{}) (string, error) {
+	csrPEM, _ := body["csr"].(string)
+	csr, err := util.ParsePemEncodedCSR([]byte(csrPEM))
+	if err != nil {
+		return "", err
+	}
+	uriSANs, _ := body["uri_sans"].(string)
+	ttl, err := time.ParseDuration(body["ttl"].(string))
+	if err != nil {
+		return "", err
+	}
+	cert, err := util.GenCertFromCSR(csr, s.signingCert, csr.PublicKey, s.signingKey, strings.Split(uriSANs, ","),
+		ttl, false)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})), nil
+}
+
+func (s *mockVaultServer) counts() (logins, renewals int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins, s.renewals
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jwtPath := writeFile(t, dir, "token", testJWT+"\n")
+	secretIDPath := writeFile(t, dir, "secret-id", testSecretID)
+
+	server := newMockVaultServer(t, true)
+	defer server.httpServer.Close()
+	tlsRootCertPath := writeFile(t, dir, "vault-root-cert.pem",
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.httpServer.Certificate().Raw})))
+
+	testCases := map[string]struct {
+		config      Config
+		expectedErr string
+	}{
+		"Kubernetes auth": {
+			config: Config{AuthMethod: KubernetesAuth, KubernetesRole: testRole, KubernetesJWTPath: jwtPath},
+		},
+		"AppRole auth": {
+			config: Config{AuthMethod: AppRoleAuth, AppRoleID: testRoleID, AppRoleSecretIDPath: secretIDPath},
+		},
+		"Custom login path": {
+			config: Config{AuthMethod: KubernetesAuth, KubernetesRole: testRole, KubernetesJWTPath: jwtPath,
+				LoginPath: "auth/cluster1/login"},
+			expectedErr: "failed to log in to Vault",
+		},
+		"Wrong role": {
+			config:      Config{AuthMethod: KubernetesAuth, KubernetesRole: "wrong", KubernetesJWTPath: jwtPath},
+			expectedErr: "failed to log in to Vault",
+		},
+		"Missing service account token": {
+			config: Config{AuthMethod: KubernetesAuth, KubernetesRole: testRole,
+				KubernetesJWTPath: filepath.Join(dir, "missing")},
+			expectedErr: "failed to read the service account token",
+		},
+		"Missing Kubernetes role": {
+			config:      Config{AuthMethod: KubernetesAuth},
+			expectedErr: "the role of the Kubernetes auth method is required",
+		},
+		"Missing AppRole secret ID": {
+			config:      Config{AuthMethod: AppRoleAuth, AppRoleID: testRoleID},
+			expectedErr: "the role ID and the secret ID of the AppRole auth method are required",
+		},
+		"Unsupported auth method": {
+			config:      Config{AuthMethod: "token"},
+			expectedErr: `unsupported Vault auth method "token"`,
+		},
+		"Missing PKI role": {
+			config: Config{AuthMethod: KubernetesAuth, KubernetesRole: testRole, KubernetesJWTPath: jwtPath,
+				PKIPath: testPKIPath},
+			expectedErr: "the path and the role of the PKI secrets engine are required",
+		},
+	}
+	for id, tc := range testCases {
+		tc.config.Address = server.httpServer.URL
+		tc.config.TLSRootCertFile = tlsRootCertPath
+		if tc.config.PKIPath == "" {
+			tc.config.PKIPath = testPKIPath
+			tc.config.PKIRole = testPKIRole
+		}
+
+		signer, err := NewSigner(tc.config)
+		if tc.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("%s: expected error %q, got %v", id, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+			continue
+		}
+		if signer.client.Token() == "" {
+			t.Errorf("%s: the signer has no token", id)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := newMockVaultServer(t, false)
+	defer server.httpServer.Close()
+
+	signer, err := NewSigner(Config{
+		Address:           server.httpServer.URL,
+		AuthMethod:        KubernetesAuth,
+		KubernetesRole:    testRole,
+		KubernetesJWTPath: writeFile(t, dir, "token", testJWT),
+		PKIPath:           "/" + testPKIPath + "/",
+		PKIRole:           testPKIRole,
+	})
+	if err != nil {
+		t.Fatalf("failed to create the signer: %v", err)
+	}
+
+	csrPEM, err := ioutil.ReadFile(testCsrFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subjectIDs := []string{"spiffe://cluster.local/ns/foo/sa/bar", "istio-pilot.istio-system.svc", "10.0.0.1"}
+
+	certPEM, certChain, err := signer.Sign(csr, subjectIDs, 2*time.Hour, false)
+	if err != nil {
+		t.Fatalf("failed to sign the CSR: %v", err)
+	}
+
+	// The CSR is signed for the subject IDs, with the token of the signer.
+	expectedData := map[string]interface{}{
+		"csr":                  string(csrPEM),
+		"format":               "pem",
+		"ttl":                  "7200s",
+		"exclude_cn_from_sans": true,
+		"uri_sans":             "spiffe://cluster.local/ns/foo/sa/bar",
+		"alt_names":            "istio-pilot.istio-system.svc",
+		"ip_sans":              "10.0.0.1",
+	}
+	if !reflect.DeepEqual(server.signData, expectedData) {
+		t.Errorf("unexpected sign request %v VS (expected) %v", server.signData, expectedData)
+	}
+	if !reflect.DeepEqual(server.signTokens, []string{"token-1"}) {
+		t.Errorf("unexpected tokens of the sign requests: %v", server.signTokens)
+	}
+
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatalf("failed to parse the certificate: %v", err)
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != subjectIDs[0] {
+		t.Errorf("unexpected URI SANs of the certificate: %v", cert.URIs)
+	}
+	// The chain holds the signing CA cert, but not the root cert.
+	if string(certChain) != server.caChain[0] {
+		t.Errorf("unexpected cert chain %s VS (expected) %s", certChain, server.caChain[0])
+	}
+	caChain, err := signer.CACertChain()
+	if err != nil {
+		t.Errorf("failed to get the CA cert chain: %v", err)
+	} else if string(caChain) != server.caChain[0] {
+		t.Errorf("unexpected CA cert chain %s VS (expected) %s", caChain, server.caChain[0])
+	}
+
+	if _, _, err := signer.Sign(csr, subjectIDs, time.Hour, true); err == nil {
+		t.Errorf("expected an error signing a CA certificate")
+	}
+}
+
+func TestRunRenewsToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := newMockVaultServer(t, false)
+	defer server.httpServer.Close()
+	// The token is renewed once, then expires in a second.
+	server.leaseDuration = 1
+
+	signer, err := NewSigner(Config{
+		Address:             server.httpServer.URL,
+		AuthMethod:          AppRoleAuth,
+		AppRoleID:           testRoleID,
+		AppRoleSecretIDPath: writeFile(t, dir, "secret-id", testSecretID),
+		PKIPath:             testPKIPath,
+		PKIRole:             testPKIRole,
+	})
+	if err != nil {
+		t.Fatalf("failed to create the signer: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go signer.Run(stopCh)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		logins, renewals := server.counts()
+		if logins >= 2 && renewals >= 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the token was not renewed and replaced: %d logins, %d renewals", logins, renewals)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if token := signer.client.Token(); token == "token-1" {
+		t.Errorf("the signer still uses the expired token %s", token)
+	}
+}
+
+// TestSignerOnVaultDevServer runs the signer against a Vault dev server, e.g. started with
+// `vault server -dev -dev-root-token-id=root`, and
+// VAULT_DEV_SERVER_ADDR=http://127.0.0.1:8200 VAULT_DEV_ROOT_TOKEN=root.
+// It sets up a PKI secrets engine and an AppRole for the signer with the root token.
+func TestSignerOnVaultDevServer(t *testing.T) {
+	addr, rootToken := os.Getenv("VAULT_DEV_SERVER_ADDR"), os.Getenv("VAULT_DEV_ROOT_TOKEN")
+	if addr == "" || rootToken == "" {
+		t.Skip("VAULT_DEV_SERVER_ADDR and VAULT_DEV_ROOT_TOKEN are not set")
+	}
+	dir, err := ioutil.TempDir("", "vault-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	admin, err := createVaultClient(addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin.SetToken(rootToken)
+	pkiPath := fmt.Sprintf("istio_ca_%d", time.Now().UnixNano())
+	if err := admin.Sys().Mount(pkiPath, &api.MountInput{Type: "pki",
+		Config: api.MountConfigInput{MaxLeaseTTL: "87600h"}}); err != nil {
+		t.Fatalf("failed to mount the PKI secrets engine: %v", err)
+	}
+	defer func() { _ = admin.Sys().Unmount(pkiPath) }()
+	root, err := admin.Logical().Write(pkiPath+"/root/generate/internal",
+		map[string]interface{}{"common_name": "Istio Root CA", "ttl": "87600h"})
+	if err != nil {
+		t.Fatalf("failed to generate the root cert: %v", err)
+	}
+	if _, err := admin.Logical().Write(pkiPath+"/roles/"+testPKIRole, map[string]interface{}{
+		"allowed_uri_sans": "spiffe://*",
+		"allow_any_name":   true,
+		"require_cn":       false,
+		"max_ttl":          "24h",
+	}); err != nil {
+		t.Fatalf("failed to create the PKI role: %v", err)
+	}
+
+	policy := fmt.Sprintf(`path "%s/sign/%s" { capabilities = ["update"] }
+path "%s/cert/ca_chain" { capabilities = ["read"] }`, pkiPath, testPKIRole, pkiPath)
+	if err := admin.Sys().PutPolicy(pkiPath, policy); err != nil {
+		t.Fatalf("failed to create the policy: %v", err)
+	}
+	if err := admin.Sys().EnableAuthWithOptions(pkiPath, &api.EnableAuthOptions{Type: AppRoleAuth}); err != nil {
+		t.Fatalf("failed to enable the AppRole auth method: %v", err)
+	}
+	defer func() { _ = admin.Sys().DisableAuth(pkiPath) }()
+	rolePath := "auth/" + pkiPath + "/role/citadel"
+	if _, err := admin.Logical().Write(rolePath, map[string]interface{}{
+		"policies": pkiPath, "token_ttl": "1h", "token_max_ttl": "2h"}); err != nil {
+		t.Fatalf("failed to create the AppRole: %v", err)
+	}
+	roleID, err := admin.Logical().Read(rolePath + "/role-id")
+	if err != nil {
+		t.Fatalf("failed to read the role ID: %v", err)
+	}
+	secretID, err := admin.Logical().Write(rolePath+"/secret-id", nil)
+	if err != nil {
+		t.Fatalf("failed to create a secret ID: %v", err)
+	}
+
+	signer, err := NewSigner(Config{
+		Address:             addr,
+		AuthMethod:          AppRoleAuth,
+		LoginPath:           "auth/" + pkiPath + "/login",
+		AppRoleID:           roleID.Data["role_id"].(string),
+		AppRoleSecretIDPath: writeFile(t, dir, "secret-id", secretID.Data["secret_id"].(string)),
+		PKIPath:             pkiPath,
+		PKIRole:             testPKIRole,
+	})
+	if err != nil {
+		t.Fatalf("failed to create the signer: %v", err)
+	}
+
+	csrPEM, err := ioutil.ReadFile(testCsrFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subjectID := "spiffe://cluster.local/ns/foo/sa/bar"
+	certPEM, certChain, err := signer.Sign(csr, []string{subjectID}, time.Hour, false)
+	if err != nil {
+		t.Fatalf("failed to sign the CSR: %v", err)
+	}
+	// The root CA signs the certificates, the chain is empty.
+	if len(certChain) != 0 {
+		t.Errorf("unexpected cert chain %s", certChain)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatalf("failed to parse the certificate: %v", err)
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != subjectID {
+		t.Errorf("unexpected URI SANs of the certificate: %v", cert.URIs)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(root.Data["certificate"].(string)))
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		t.Errorf("failed to verify the certificate with the root cert of Vault: %v", err)
+	}
+}
@@ -16,6 +16,8 @@ package caclient
 
 import (
 	"time"
+
+	pkiutil "istio.io/istio/security/pkg/pki/util"
 )
 
 // Config is configuration for the CA client.
@@ -32,6 +34,9 @@ type Config struct {
 	// Size of RSA private key
 	RSAKeySize int
 
+	// Algorithm of the private key, RSA if unset
+	KeyAlgorithm pkiutil.KeyAlgorithm
+
 	// The environment this CA client is running on.
 	Env string
 
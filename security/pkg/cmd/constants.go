@@ -50,6 +50,12 @@ const (
 	// DefaultProbeCheckInterval is the default interval of checking the liveness of the CA.
 	DefaultProbeCheckInterval = 30 * time.Second
 
+	// DefaultRevocationCheckInterval is the default interval of reading the revocation list of the CA.
+	DefaultRevocationCheckInterval = time.Minute
+
+	// DefaultCRLTTL is the default validity of the CRL published by the CA.
+	DefaultCRLTTL = 24 * time.Hour
+
 	// DefaultCSRGracePeriodPercentage is the default length of certificate rotation grace period,
 	// configured as the percentage of the certificate TTL.
 	DefaultCSRGracePeriodPercentage = 50
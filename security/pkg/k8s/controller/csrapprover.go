@@ -0,0 +1,184 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// WorkloadCSRLabel is the label of the Kubernetes CSRs submitted by the node agent for workload certificates.
+	// Only the CSRs with this label set to "true" are handled by the CSRApprover.
+	WorkloadCSRLabel = "security.istio.io/workload-csr"
+
+	// The reason of the approval or denial conditions set by the CSRApprover.
+	csrApproverReason = "IstioWorkloadIdentity"
+
+	csrResyncPeriod = time.Minute
+
+	serviceAccountUserPrefix = "system:serviceaccount:"
+)
+
+// The key usages a workload CSR is allowed to request. Client auth is not allowed, so that the certificates signed by
+// the cluster signer cannot authenticate to the Kubernetes API server. Envoy does not check the extended key usages
+// of the peer certificates, so the certificates are still used by both sides of Istio mutual TLS.
+var allowedWorkloadCSRUsages = map[cert.KeyUsage]bool{
+	cert.UsageDigitalSignature: true,
+	cert.UsageKeyEncipherment:  true,
+	cert.UsageServerAuth:       true,
+}
+
+// CSRApprover approves the Kubernetes CSRs submitted by the node agent for workload certificates. A CSR is approved
+// only if the single identity it requests is the SPIFFE identity of the service account that created it, otherwise
+// it is denied. The approved CSRs are signed by the cluster signer, not by Citadel.
+type CSRApprover struct {
+	certClient certclient.CertificateSigningRequestInterface
+	controller cache.Controller
+}
+
+// NewCSRApprover returns a CSRApprover watching the workload CSRs through certClient.
+func NewCSRApprover(certClient certclient.CertificateSigningRequestInterface) *CSRApprover {
+	a := &CSRApprover{certClient: certClient}
+
+	selector := labels.SelectorFromSet(map[string]string{WorkloadCSRLabel: "true"}).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector
+			return certClient.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector
+			return certClient.Watch(options)
+		},
+	}
+	_, a.controller =
+		cache.NewInformer(lw, &cert.CertificateSigningRequest{}, csrResyncPeriod, cache.ResourceEventHandlerFuncs{
+			AddFunc: a.csrUpserted,
+			UpdateFunc: func(_, obj interface{}) {
+				a.csrUpserted(obj)
+			},
+		})
+	return a
+}
+
+// Run starts the CSRApprover until stopCh is closed.
+func (a *CSRApprover) Run(stopCh <-chan struct{}) {
+	go a.controller.Run(stopCh)
+}
+
+func (a *CSRApprover) csrUpserted(obj interface{}) {
+	csr, ok := obj.(*cert.CertificateSigningRequest)
+	if !ok || isCSRDecided(csr) {
+		return
+	}
+
+	condition := cert.CertificateSigningRequestCondition{
+		Type:           cert.CertificateApproved,
+		Reason:         csrApproverReason,
+		Message:        "The requested identity matches the service account of the requester",
+		LastUpdateTime: metav1.Now(),
+	}
+	if err := verifyWorkloadCSR(csr); err != nil {
+		k8sControllerLog.Warnf("Denying CSR %s of %q: %v", csr.Name, csr.Spec.Username, err)
+		condition.Type = cert.CertificateDenied
+		condition.Message = err.Error()
+	}
+
+	csr = csr.DeepCopy()
+	csr.Status.Conditions = append(csr.Status.Conditions, condition)
+	if _, err := a.certClient.UpdateApproval(csr); err != nil {
+		k8sControllerLog.Errorf("Failed to update the approval of CSR %s: %v", csr.Name, err)
+		return
+	}
+	k8sControllerLog.Debugf("CSR %s of %q is %s", csr.Name, csr.Spec.Username, condition.Type)
+}
+
+// isCSRDecided returns true if the CSR is already approved or denied.
+func isCSRDecided(csr *cert.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == cert.CertificateApproved || c.Type == cert.CertificateDenied {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyWorkloadCSR returns an error if the CSR is not a valid workload CSR for the service account that created it.
+func verifyWorkloadCSR(csr *cert.CertificateSigningRequest) error {
+	if !strings.HasPrefix(csr.Spec.Username, serviceAccountUserPrefix) {
+		return fmt.Errorf("the requester %q is not a service account", csr.Spec.Username)
+	}
+	parts := strings.Split(strings.TrimPrefix(csr.Spec.Username, serviceAccountUserPrefix), ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("the requester %q is not a valid service account", csr.Spec.Username)
+	}
+	expectedID, err := spiffe.GenSpiffeURI(parts[0], parts[1])
+	if err != nil {
+		return err
+	}
+
+	for _, usage := range csr.Spec.Usages {
+		if !allowedWorkloadCSRUsages[usage] {
+			return fmt.Errorf("the usage %q is not allowed for a workload certificate", usage)
+		}
+	}
+
+	req, err := util.ParsePemEncodedCSR(csr.Spec.Request)
+	if err != nil {
+		return err
+	}
+	if err := req.CheckSignature(); err != nil {
+		return fmt.Errorf("invalid signature of the certificate request: %v", err)
+	}
+	// The Kubernetes API server maps the CN and O of client certificates to a user and groups, e.g. system:masters.
+	if req.Subject.CommonName != "" || hasValue(req.Subject.Organization) || hasValue(req.Subject.OrganizationalUnit) {
+		return fmt.Errorf("the certificate request has the subject %q, only the workload identity is allowed",
+			req.Subject.String())
+	}
+	if len(req.DNSNames) > 0 || len(req.EmailAddresses) > 0 || len(req.IPAddresses) > 0 {
+		return fmt.Errorf("the certificate request has SANs other than the workload identity")
+	}
+	ids, err := util.ExtractIDs(req.Extensions)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 || ids[0] != expectedID {
+		return fmt.Errorf("the requested identities %v do not match the identity %q of the requester", ids, expectedID)
+	}
+	return nil
+}
+
+// hasValue returns true if any of the values is not empty.
+func hasValue(values []string) bool {
+	for _, v := range values {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
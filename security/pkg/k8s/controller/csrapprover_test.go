@@ -0,0 +1,151 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func genWorkloadCSR(t *testing.T, name, username, host string, usages ...cert.KeyUsage) *cert.CertificateSigningRequest {
+	return genWorkloadCSRWithOptions(t, name, username, util.CertOptions{Host: host}, usages...)
+}
+
+func genWorkloadCSRWithOptions(t *testing.T, name, username string, options util.CertOptions,
+	usages ...cert.KeyUsage) *cert.CertificateSigningRequest {
+	options.RSAKeySize = 2048
+	csrPEM, _, err := util.GenCSR(options)
+	if err != nil {
+		t.Fatalf("Failed to generate CSR: %v", err)
+	}
+	if len(usages) == 0 {
+		usages = []cert.KeyUsage{cert.UsageDigitalSignature, cert.UsageKeyEncipherment, cert.UsageServerAuth}
+	}
+	return &cert.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{WorkloadCSRLabel: "true"},
+		},
+		Spec: cert.CertificateSigningRequestSpec{
+			Request:  csrPEM,
+			Username: username,
+			Usages:   usages,
+		},
+	}
+}
+
+func TestVerifyWorkloadCSR(t *testing.T) {
+	testCases := map[string]struct {
+		csr         *cert.CertificateSigningRequest
+		expectedErr string
+	}{
+		"Valid CSR": {
+			csr: genWorkloadCSR(t, "csr", "system:serviceaccount:foo:bar", "spiffe://cluster.local/ns/foo/sa/bar"),
+		},
+		"Requester is not a service account": {
+			csr:         genWorkloadCSR(t, "csr", "alice", "spiffe://cluster.local/ns/foo/sa/bar"),
+			expectedErr: `the requester "alice" is not a service account`,
+		},
+		"Invalid service account": {
+			csr:         genWorkloadCSR(t, "csr", "system:serviceaccount:foo", "spiffe://cluster.local/ns/foo/sa/bar"),
+			expectedErr: `the requester "system:serviceaccount:foo" is not a valid service account`,
+		},
+		"Identity of another service account": {
+			csr: genWorkloadCSR(t, "csr", "system:serviceaccount:foo:bar", "spiffe://cluster.local/ns/foo/sa/baz"),
+			expectedErr: "the requested identities [spiffe://cluster.local/ns/foo/sa/baz] do not match the identity " +
+				`"spiffe://cluster.local/ns/foo/sa/bar" of the requester`,
+		},
+		"Additional DNS SAN": {
+			csr: genWorkloadCSR(t, "csr", "system:serviceaccount:foo:bar",
+				"spiffe://cluster.local/ns/foo/sa/bar,bar.foo.svc"),
+			expectedErr: "the certificate request has SANs other than the workload identity",
+		},
+		"Disallowed usage": {
+			csr: genWorkloadCSR(t, "csr", "system:serviceaccount:foo:bar", "spiffe://cluster.local/ns/foo/sa/bar",
+				cert.UsageDigitalSignature, cert.UsageCertSign),
+			expectedErr: `the usage "cert sign" is not allowed for a workload certificate`,
+		},
+		"Client auth usage": {
+			csr: genWorkloadCSR(t, "csr", "system:serviceaccount:foo:bar", "spiffe://cluster.local/ns/foo/sa/bar",
+				cert.UsageDigitalSignature, cert.UsageClientAuth),
+			expectedErr: `the usage "client auth" is not allowed for a workload certificate`,
+		},
+		"Subject organization": {
+			csr: genWorkloadCSRWithOptions(t, "csr", "system:serviceaccount:foo:bar",
+				util.CertOptions{Host: "spiffe://cluster.local/ns/foo/sa/bar", Org: "system:masters"}),
+			expectedErr: `the certificate request has the subject "O=system:masters", only the workload identity is allowed`,
+		},
+		"Subject common name": {
+			csr: genWorkloadCSRWithOptions(t, "csr", "system:serviceaccount:foo:bar",
+				util.CertOptions{Host: "spiffe://cluster.local/ns/foo/sa/bar", IsDualUse: true}),
+			expectedErr: `the certificate request has the subject "CN=spiffe://cluster.local/ns/foo/sa/bar,O=", only the workload identity is allowed`,
+		},
+	}
+
+	for id, tc := range testCases {
+		err := verifyWorkloadCSR(tc.csr)
+		if tc.expectedErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", id, err)
+			}
+		} else if err == nil || err.Error() != tc.expectedErr {
+			t.Errorf("%s: expected error %q, got %v", id, tc.expectedErr, err)
+		}
+	}
+}
+
+func TestCSRApprover(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		genWorkloadCSR(t, "approved", "system:serviceaccount:foo:bar", "spiffe://cluster.local/ns/foo/sa/bar"),
+		genWorkloadCSR(t, "denied", "system:serviceaccount:foo:bar", "spiffe://cluster.local/ns/foo/sa/baz"))
+	certClient := client.CertificatesV1beta1().CertificateSigningRequests()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	NewCSRApprover(certClient).Run(stopCh)
+
+	expected := map[string]cert.RequestConditionType{
+		"approved": cert.CertificateApproved,
+		"denied":   cert.CertificateDenied,
+	}
+	for name, conditionType := range expected {
+		var conditions []cert.CertificateSigningRequestCondition
+		for i := 0; i < 50 && len(conditions) == 0; i++ {
+			csr, err := certClient.Get(name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get CSR %s: %v", name, err)
+			}
+			conditions = csr.Status.Conditions
+			time.Sleep(100 * time.Millisecond)
+		}
+		if len(conditions) != 1 || conditions[0].Type != conditionType {
+			t.Errorf("CSR %s: expected a single %s condition, got %v", name, conditionType, conditions)
+			continue
+		}
+		if conditions[0].Reason != csrApproverReason {
+			t.Errorf("CSR %s: unexpected reason %q", name, conditions[0].Reason)
+		}
+		if conditionType == cert.CertificateDenied && !strings.Contains(conditions[0].Message, "do not match") {
+			t.Errorf("CSR %s: unexpected denial message %q", name, conditions[0].Message)
+		}
+	}
+}
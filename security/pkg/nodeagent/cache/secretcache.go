@@ -105,6 +105,9 @@ type Options struct {
 
 	// set this flag to true if skip validate format for certificate chain returned from CA.
 	SkipValidateCert bool
+
+	// KeyAlgorithm is the algorithm of the private keys generated for workload CSRs, RSA if unset.
+	KeyAlgorithm util.KeyAlgorithm
 }
 
 // SecretManager defines secrets management interface which is used by SDS.
@@ -594,8 +597,9 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 		csrHostName = connKey.ResourceName
 	}
 	options := util.CertOptions{
-		Host:       csrHostName,
-		RSAKeySize: keySize,
+		Host:         csrHostName,
+		RSAKeySize:   keySize,
+		KeyAlgorithm: sc.configOptions.KeyAlgorithm,
 	}
 
 	// Generate the cert/key, send CSR to CA.
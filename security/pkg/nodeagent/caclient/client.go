@@ -25,6 +25,7 @@ import (
 	caClientInterface "istio.io/istio/security/pkg/nodeagent/caclient/interface"
 	citadel "istio.io/istio/security/pkg/nodeagent/caclient/providers/citadel"
 	gca "istio.io/istio/security/pkg/nodeagent/caclient/providers/google"
+	k8scsr "istio.io/istio/security/pkg/nodeagent/caclient/providers/kubernetes"
 	vault "istio.io/istio/security/pkg/nodeagent/caclient/providers/vault"
 	"istio.io/pkg/env"
 	"istio.io/pkg/log"
@@ -34,11 +35,19 @@ const (
 	googleCAName  = "GoogleCA"
 	citadelName   = "Citadel"
 	vaultCAName   = "VaultCA"
+	k8sCSRName    = "KubernetesCSR"
 	retryInterval = time.Second * 2
 	maxRetries    = 100
 )
 
-var namespace = env.RegisterStringVar("NAMESPACE", "istio-system", "namespace that nodeagent/citadel run in").Get()
+var (
+	namespace = env.RegisterStringVar("NAMESPACE", "istio-system", "namespace that nodeagent/citadel run in").Get()
+
+	k8sCSRCACertFile = env.RegisterStringVar("KUBERNETES_CSR_CA_CERT_FILE",
+		"/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		"The cert of the cluster signer, which signs the Kubernetes CSRs of the KubernetesCSR CA provider. "+
+			"It must be the Istio root cert, or be signed by it").Get()
+)
 
 type configMap interface {
 	GetCATLSRootCert() (string, error)
@@ -63,9 +72,25 @@ func NewCAClient(endpoint, caProviderName string, tlsFlag bool, tlsRootCert []by
 			return nil, err
 		}
 		return citadel.NewCitadelClient(endpoint, tlsFlag, rootCert)
+	case k8sCSRName:
+		config, err := kube.BuildClientConfig("", "")
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s client config: %v", err)
+		}
+		cs, err := kube.CreateClientset("", "")
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s clientset: %v", err)
+		}
+		// The certificates signed by the cluster signer are verified against the Istio root cert.
+		controller := configmap.NewController(namespace, cs.CoreV1())
+		rootCert, err := getCATLSRootCertFromConfigMap(controller, retryInterval, maxRetries)
+		if err != nil {
+			return nil, err
+		}
+		return k8scsr.NewKubernetesCSRClient(config, k8sCSRCACertFile, rootCert)
 	default:
 		return nil, fmt.Errorf(
-			"CA provider %q isn't supported. Currently Istio supports %q", caProviderName, strings.Join([]string{googleCAName, citadelName, vaultCAName}, ","))
+			"CA provider %q isn't supported. Currently Istio supports %q", caProviderName, strings.Join([]string{googleCAName, citadelName, vaultCAName, k8sCSRName}, ","))
 	}
 }
 
@@ -0,0 +1,206 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+	"k8s.io/client-go/rest"
+
+	"istio.io/istio/security/pkg/k8s/controller"
+	caClientInterface "istio.io/istio/security/pkg/nodeagent/caclient/interface"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	csrNamePrefix = "istio-csr-"
+	// The maximum time to wait for a CSR to be approved and signed.
+	signTimeout = 30 * time.Second
+)
+
+var k8sCSRClientLog = log.RegisterScope("k8sCSRClientLog", "Kubernetes CSR client debugging", 0)
+
+type k8sCSRClient struct {
+	// certClient reads the CSRs with the credentials of the node agent.
+	certClient certclient.CertificateSigningRequestInterface
+	// requesterClient returns the client creating the CSRs with the credentials of a workload, so that the
+	// requester of a CSR is the service account of the workload.
+	requesterClient func(token string) (certclient.CertificateSigningRequestInterface, error)
+	caCertFile      string
+	rootCert        []byte
+}
+
+// NewKubernetesCSRClient creates a CA client submitting the CSRs as Kubernetes CertificateSigningRequests, which
+// are approved by Citadel and signed by the cluster signer. caCertFile is the cert of the cluster signer, and
+// rootCert the Istio root cert. The cluster signer must share the root of the mesh: its cert must be the Istio root
+// cert or be signed by it, otherwise the peers of the workloads reject their certificates.
+func NewKubernetesCSRClient(config *rest.Config, caCertFile string, rootCert []byte) (caClientInterface.Client, error) {
+	certClient, err := certclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the certificates client: %v", err)
+	}
+	return &k8sCSRClient{
+		certClient: certClient.CertificateSigningRequests(),
+		requesterClient: func(token string) (certclient.CertificateSigningRequestInterface, error) {
+			c, err := certclient.NewForConfig(workloadConfig(config, token))
+			if err != nil {
+				return nil, err
+			}
+			return c.CertificateSigningRequests(), nil
+		},
+		caCertFile: caCertFile,
+		rootCert:   rootCert,
+	}, nil
+}
+
+// workloadConfig returns a copy of the config authenticating with the token of a workload.
+func workloadConfig(config *rest.Config, token string) *rest.Config {
+	c := rest.AnonymousClientConfig(config)
+	c.BearerToken = token
+	return c
+}
+
+// CSRSign submits the CSR as a Kubernetes CSR on behalf of the workload of the token, and waits for it to be signed.
+// The TTL of the certificate is decided by the cluster signer. The certificate chain returned ends with the Istio root
+// cert, which the certificate is verified against.
+func (cl *k8sCSRClient) CSRSign(ctx context.Context, csrPEM []byte, token string,
+	certValidTTLInSec int64) ([]string /*PEM-encoded certificate chain*/, error) {
+	if token == "" {
+		return nil, errors.New("the token of the workload is required to submit a Kubernetes CSR")
+	}
+	requester, err := cl.requesterClient(token)
+	if err != nil {
+		k8sCSRClientLog.Errorf("Failed to create the certificates client of the workload: %v", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, signTimeout)
+	defer cancel()
+
+	csrName := csrNamePrefix + rand.String(16)
+	// Watch before creating the CSR to not miss its signing.
+	watcher, err := cl.certClient.Watch(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", csrName).String(),
+	})
+	if err != nil {
+		k8sCSRClientLog.Errorf("Failed to watch CSR %s: %v", csrName, err)
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	_, err = requester.Create(&cert.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   csrName,
+			Labels: map[string]string{controller.WorkloadCSRLabel: "true"},
+		},
+		Spec: cert.CertificateSigningRequestSpec{
+			Request: csrPEM,
+			Usages: []cert.KeyUsage{
+				cert.UsageDigitalSignature,
+				cert.UsageKeyEncipherment,
+				cert.UsageServerAuth,
+			},
+		},
+	})
+	if err != nil {
+		k8sCSRClientLog.Errorf("Failed to create CSR %s: %v", csrName, err)
+		return nil, err
+	}
+
+	certPEM, err := waitForCertificate(ctx, watcher, csrName)
+	if err != nil {
+		k8sCSRClientLog.Errorf("Failed to get the certificate of CSR %s: %v", csrName, err)
+		return nil, err
+	}
+
+	caCert, err := ioutil.ReadFile(cl.caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA cert: %v", err)
+	}
+	if err := verifyCertificate(certPEM, caCert, cl.rootCert); err != nil {
+		k8sCSRClientLog.Errorf("Failed to verify the certificate of CSR %s from the Istio root cert: %v", csrName, err)
+		return nil, fmt.Errorf("the cluster signer does not share the Istio root cert: %v", err)
+	}
+	// The approved and issued CSRs are garbage collected by Kubernetes.
+	certChain := []string{string(certPEM)}
+	if !bytes.Equal(bytes.TrimSpace(caCert), bytes.TrimSpace(cl.rootCert)) {
+		certChain = append(certChain, string(caCert))
+	}
+	return append(certChain, string(cl.rootCert)), nil
+}
+
+// waitForCertificate returns the certificate of the CSR once signed, or an error if the CSR is denied or deleted.
+func waitForCertificate(ctx context.Context, watcher watch.Interface, csrName string) ([]byte, error) {
+	for {
+		select {
+		case e, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("the watch of CSR %s is closed", csrName)
+			}
+			csr, ok := e.Object.(*cert.CertificateSigningRequest)
+			if !ok {
+				continue
+			}
+			if e.Type == watch.Deleted {
+				return nil, fmt.Errorf("CSR %s is deleted", csrName)
+			}
+			for _, c := range csr.Status.Conditions {
+				if c.Type == cert.CertificateDenied {
+					return nil, fmt.Errorf("CSR %s is denied: %s", csrName, c.Message)
+				}
+			}
+			if len(csr.Status.Certificate) > 0 {
+				return csr.Status.Certificate, nil
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("CSR %s is not signed: %v", csrName, ctx.Err())
+		}
+	}
+}
+
+// verifyCertificate verifies the certificate from the root cert, through the CA cert and the intermediate certs
+// following the certificate in certPEM, if any.
+func verifyCertificate(certPEM, caCert, rootCert []byte) error {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(rootCert) {
+		return errors.New("failed to parse the root cert")
+	}
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM(certPEM)
+	intermediates.AppendCertsFromPEM(caCert)
+	c, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return err
+	}
+	_, err = c.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
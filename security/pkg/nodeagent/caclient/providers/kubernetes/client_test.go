@@ -0,0 +1,242 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// fakeSigner approves or denies and signs the CSRs created through certClient, like Citadel and the cluster signer.
+func fakeSigner(t *testing.T, certClient certclient.CertificateSigningRequestInterface, caCert *x509.Certificate,
+	caKey crypto.PrivateKey, deny bool, stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+		csrs, err := certClient.List(metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for i := range csrs.Items {
+			csr := &csrs.Items[i]
+			if len(csr.Status.Conditions) > 0 {
+				continue
+			}
+			if csr.Labels[controller.WorkloadCSRLabel] != "true" {
+				t.Errorf("CSR %s is not labeled as a workload CSR", csr.Name)
+			}
+			if deny {
+				csr.Status.Conditions = []cert.CertificateSigningRequestCondition{
+					{Type: cert.CertificateDenied, Message: "identity mismatch"},
+				}
+			} else {
+				req, err := util.ParsePemEncodedCSR(csr.Spec.Request)
+				if err != nil {
+					t.Errorf("Failed to parse the CSR: %v", err)
+					continue
+				}
+				certBytes, err := util.GenCertFromCSR(req, caCert, req.PublicKey, caKey,
+					[]string{"spiffe://cluster.local/ns/foo/sa/bar"}, time.Hour, false)
+				if err != nil {
+					t.Errorf("Failed to sign the CSR: %v", err)
+					continue
+				}
+				csr.Status.Conditions = []cert.CertificateSigningRequestCondition{{Type: cert.CertificateApproved}}
+				csr.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+			}
+			if _, err := certClient.UpdateStatus(csr); err != nil {
+				t.Errorf("Failed to update CSR %s: %v", csr.Name, err)
+			}
+		}
+	}
+}
+
+func TestKubernetesCSRClient(t *testing.T) {
+	rootCertPEM, rootKeyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "Istio",
+		IsCA:         true,
+		IsSelfSigned: true,
+		TTL:          time.Hour,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the root cert: %v", err)
+	}
+	rootCert, err := util.ParsePemEncodedCertificate(rootCertPEM)
+	if err != nil {
+		t.Fatalf("Failed to parse the root cert: %v", err)
+	}
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPEM)
+	if err != nil {
+		t.Fatalf("Failed to parse the root key: %v", err)
+	}
+	// The cluster signer is signed by the Istio root.
+	caCertPEM, caKeyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:        "cluster signer",
+		IsCA:       true,
+		SignerCert: rootCert,
+		SignerPriv: rootKey,
+		TTL:        time.Hour,
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the CA cert: %v", err)
+	}
+	caCert, err := util.ParsePemEncodedCertificate(caCertPEM)
+	if err != nil {
+		t.Fatalf("Failed to parse the CA cert: %v", err)
+	}
+	caKey, err := util.ParsePemEncodedKey(caKeyPEM)
+	if err != nil {
+		t.Fatalf("Failed to parse the CA key: %v", err)
+	}
+	otherCACertPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "other signer",
+		IsCA:         true,
+		IsSelfSigned: true,
+		TTL:          time.Hour,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the other CA cert: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "k8s-csr-client")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caCertFile := filepath.Join(dir, "ca.crt")
+	rootCertFile := filepath.Join(dir, "root-cert.pem")
+	if err := ioutil.WriteFile(caCertFile, caCertPEM, 0644); err != nil {
+		t.Fatalf("Failed to write the CA cert: %v", err)
+	}
+	if err := ioutil.WriteFile(rootCertFile, rootCertPEM, 0644); err != nil {
+		t.Fatalf("Failed to write the root cert: %v", err)
+	}
+	csrPEM, _, err := util.GenCSR(util.CertOptions{
+		Host:       "spiffe://cluster.local/ns/foo/sa/bar",
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate the CSR: %v", err)
+	}
+
+	testCases := map[string]struct {
+		token         string
+		deny          bool
+		caCertFile    string
+		caCert        *x509.Certificate
+		caKey         crypto.PrivateKey
+		rootCert      []byte
+		expectedChain []string
+		expectedErr   string
+	}{
+		"Signed": {
+			token:         "workload-token",
+			caCertFile:    caCertFile,
+			caCert:        caCert,
+			caKey:         caKey,
+			rootCert:      rootCertPEM,
+			expectedChain: []string{string(caCertPEM), string(rootCertPEM)},
+		},
+		"Signed by the root": {
+			token:         "workload-token",
+			caCertFile:    rootCertFile,
+			caCert:        rootCert,
+			caKey:         rootKey,
+			rootCert:      rootCertPEM,
+			expectedChain: []string{string(rootCertPEM)},
+		},
+		"Denied": {
+			token:       "workload-token",
+			deny:        true,
+			caCertFile:  caCertFile,
+			caCert:      caCert,
+			caKey:       caKey,
+			rootCert:    rootCertPEM,
+			expectedErr: "is denied: identity mismatch",
+		},
+		"Missing token": {
+			caCertFile:  caCertFile,
+			caCert:      caCert,
+			caKey:       caKey,
+			rootCert:    rootCertPEM,
+			expectedErr: "the token of the workload is required to submit a Kubernetes CSR",
+		},
+		"Cluster signer not sharing the root": {
+			token:       "workload-token",
+			caCertFile:  caCertFile,
+			caCert:      caCert,
+			caKey:       caKey,
+			rootCert:    otherCACertPEM,
+			expectedErr: "certificate signed by unknown authority",
+		},
+	}
+
+	for id, tc := range testCases {
+		certClient := fake.NewSimpleClientset().CertificatesV1beta1().CertificateSigningRequests()
+		var requesterToken string
+		cl := &k8sCSRClient{
+			certClient: certClient,
+			requesterClient: func(token string) (certclient.CertificateSigningRequestInterface, error) {
+				requesterToken = token
+				return certClient, nil
+			},
+			caCertFile: tc.caCertFile,
+			rootCert:   tc.rootCert,
+		}
+		stopCh := make(chan struct{})
+		go fakeSigner(t, certClient, tc.caCert, tc.caKey, tc.deny, stopCh)
+
+		certChain, err := cl.CSRSign(context.Background(), csrPEM, tc.token, 3600)
+		close(stopCh)
+		if tc.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("%s: expected error containing %q, got %v", id, tc.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+			continue
+		}
+		if requesterToken != tc.token {
+			t.Errorf("%s: the CSR is created with token %q, expected %q", id, requesterToken, tc.token)
+		}
+		if len(certChain) != len(tc.expectedChain)+1 || !reflect.DeepEqual(certChain[1:], tc.expectedChain) {
+			t.Errorf("%s: unexpected cert chain %v", id, certChain)
+		}
+	}
+}
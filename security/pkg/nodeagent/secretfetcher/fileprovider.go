@@ -0,0 +1,188 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretfetcher
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/security/pkg/nodeagent/model"
+)
+
+// The delay between a file change and the reload of the secrets, to reload once for a burst of changes, e.g. the
+// atomic update of a mounted Kubernetes secret.
+const fileSecretReloadDelay = 100 * time.Millisecond
+
+// The files of a secret directory, named after the keys of a Kubernetes secret.
+var fileSecretKeys = []string{genericScrtCert, genericScrtKey, genericScrtCaCert, tlsScrtCert, tlsScrtKey}
+
+// FileSecretProvider is a SecretProvider of the secrets mounted as directories of PEM files in a root directory.
+// The directory of a secret is named after the secret, and has the files of the data of a Kubernetes secret, i.e.
+// "tls.crt" and "tls.key", or "cert", "key" and optionally "cacert", which is the layout of a Kubernetes secret
+// mounted as a volume. A directory whose name ends with IngressGatewaySdsCaSuffix only has a client CA cert, in
+// "cacert" or "tls.crt".
+type FileSecretProvider struct {
+	dir string
+
+	// secrets maps the resource names to the secrets read from dir.
+	secrets map[string]model.SecretItem
+	// mutex protects secrets.
+	mutex sync.RWMutex
+}
+
+// NewFileSecretProvider returns a FileSecretProvider of the secrets in dir.
+func NewFileSecretProvider(dir string) *FileSecretProvider {
+	p := &FileSecretProvider{
+		dir:     dir,
+		secrets: make(map[string]model.SecretItem),
+	}
+	p.reload()
+	return p
+}
+
+// GetSecret returns the secret of the name, and whether the secret exists.
+func (p *FileSecretProvider) GetSecret(name string) (model.SecretItem, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	secret, exist := p.secrets[name]
+	return secret, exist
+}
+
+// Run watches the secret directories until stopCh is closed, and calls notify with the names of the secrets
+// changed by the file changes.
+func (p *FileSecretProvider) Run(stopCh <-chan struct{}, notify func(name string)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		secretFetcherLog.Errorf("failed to watch the secrets in %s: %v", p.dir, err)
+		return
+	}
+	p.watchDirs(watcher)
+
+	go func() {
+		defer watcher.Close()
+		var reloadCh <-chan time.Time
+		for {
+			select {
+			case e := <-watcher.Events:
+				secretFetcherLog.Debugf("secret file event: %v", e)
+				if reloadCh == nil {
+					reloadCh = time.After(fileSecretReloadDelay)
+				}
+			case err := <-watcher.Errors:
+				secretFetcherLog.Warnf("error watching the secrets in %s: %v", p.dir, err)
+			case <-reloadCh:
+				reloadCh = nil
+				// Watch the directories of the new secrets.
+				p.watchDirs(watcher)
+				for _, name := range p.reload() {
+					notify(name)
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// watchDirs adds the root directory and the secret directories to the watcher.
+func (p *FileSecretProvider) watchDirs(watcher *fsnotify.Watcher) {
+	if err := watcher.Add(p.dir); err != nil {
+		secretFetcherLog.Warnf("failed to watch %s: %v", p.dir, err)
+	}
+	for _, name := range p.secretDirs() {
+		if err := watcher.Add(filepath.Join(p.dir, name)); err != nil {
+			secretFetcherLog.Warnf("failed to watch %s: %v", filepath.Join(p.dir, name), err)
+		}
+	}
+}
+
+// secretDirs returns the names of the secret directories in the root directory.
+func (p *FileSecretProvider) secretDirs() []string {
+	files, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		secretFetcherLog.Warnf("failed to read the secret directory %s: %v", p.dir, err)
+		return nil
+	}
+	names := []string{}
+	for _, f := range files {
+		// Skip the hidden files, e.g. the "..data" link of a mounted volume.
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		// Stat follows the symbolic links.
+		if info, err := os.Stat(filepath.Join(p.dir, f.Name())); err == nil && info.IsDir() {
+			names = append(names, f.Name())
+		}
+	}
+	return names
+}
+
+// reload reads the secrets from the files, and returns the names of the secrets added, updated or deleted.
+func (p *FileSecretProvider) reload() []string {
+	t := time.Now()
+	secrets := make(map[string]model.SecretItem)
+	for _, name := range p.secretDirs() {
+		scrt := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Data:       map[string][]byte{},
+		}
+		for _, key := range fileSecretKeys {
+			if data, err := ioutil.ReadFile(filepath.Join(p.dir, name, key)); err == nil {
+				scrt.Data[key] = data
+			}
+		}
+		serverItem, clientCAItem, _ := extractK8sSecretIntoSecretItem(scrt, t)
+		if serverItem != nil {
+			secrets[serverItem.ResourceName] = *serverItem
+		}
+		if clientCAItem != nil {
+			secrets[clientCAItem.ResourceName] = *clientCAItem
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	changed := []string{}
+	for name, secret := range secrets {
+		old, exist := p.secrets[name]
+		if exist && isSameSecret(old, secret) {
+			// Keep the version of an unchanged secret.
+			secrets[name] = old
+			continue
+		}
+		changed = append(changed, name)
+	}
+	for name := range p.secrets {
+		if _, exist := secrets[name]; !exist {
+			changed = append(changed, name)
+		}
+	}
+	p.secrets = secrets
+	return changed
+}
+
+func isSameSecret(a, b model.SecretItem) bool {
+	return bytes.Equal(a.CertificateChain, b.CertificateChain) && bytes.Equal(a.PrivateKey, b.PrivateKey) &&
+		bytes.Equal(a.RootCert, b.RootCert)
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretfetcher
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/nodeagent/model"
+)
+
+func writeSecretDir(t *testing.T, dir string, data map[string][]byte) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+	for key, value := range data {
+		if err := ioutil.WriteFile(filepath.Join(dir, key), value, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", filepath.Join(dir, key), err)
+		}
+	}
+}
+
+// waitForNotifications returns the sorted names notified until no notification is received for a while.
+func waitForNotifications(notifyCh chan string) []string {
+	names := []string{}
+	timeout := 5 * time.Second
+	for {
+		select {
+		case name := <-notifyCh:
+			names = append(names, name)
+			timeout = time.Second
+		case <-time.After(timeout):
+			sort.Strings(names)
+			return names
+		}
+	}
+}
+
+func checkFileSecret(t *testing.T, p *FileSecretProvider, name string, expected *model.SecretItem) {
+	secret, exist := p.GetSecret(name)
+	if expected == nil {
+		if exist {
+			t.Errorf("secret %s should not exist", name)
+		}
+		return
+	}
+	if !exist {
+		t.Errorf("secret %s should exist", name)
+		return
+	}
+	if secret.ResourceName != name || !bytes.Equal(secret.CertificateChain, expected.CertificateChain) ||
+		!bytes.Equal(secret.PrivateKey, expected.PrivateKey) || !bytes.Equal(secret.RootCert, expected.RootCert) {
+		t.Errorf("secret %s does not match, got %+v, expected %+v", name, secret, expected)
+	}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-secret-provider")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSecretDir(t, filepath.Join(dir, "foo"), map[string][]byte{
+		genericScrtCert:   k8sCertChainA,
+		genericScrtKey:    k8sKeyA,
+		genericScrtCaCert: k8sCaCertA,
+	})
+	writeSecretDir(t, filepath.Join(dir, "bar-cacert"), map[string][]byte{
+		tlsScrtCert: k8sTestCaCertB,
+	})
+	// Neither a secret directory, nor a valid secret.
+	writeSecretDir(t, filepath.Join(dir, "..data"), map[string][]byte{tlsScrtCert: k8sCertChainA, tlsScrtKey: k8sKeyA})
+	writeSecretDir(t, filepath.Join(dir, "invalid"), map[string][]byte{tlsScrtCert: []byte("invalid cert")})
+
+	p := NewFileSecretProvider(dir)
+	checkFileSecret(t, p, "foo", &model.SecretItem{CertificateChain: k8sCertChainA, PrivateKey: k8sKeyA})
+	checkFileSecret(t, p, "foo-cacert", &model.SecretItem{RootCert: k8sCaCertA})
+	checkFileSecret(t, p, "bar-cacert", &model.SecretItem{RootCert: k8sTestCaCertB})
+	checkFileSecret(t, p, "..data", nil)
+	checkFileSecret(t, p, "invalid", nil)
+	checkFileSecret(t, p, "non-existing", nil)
+
+	notifyCh := make(chan string, 10)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	p.Run(stopCh, func(name string) {
+		notifyCh <- name
+	})
+
+	// Update the key of foo, the client CA cert of foo is unchanged.
+	writeSecretDir(t, filepath.Join(dir, "foo"), map[string][]byte{genericScrtKey: k8sKeyB})
+	if names := waitForNotifications(notifyCh); len(names) != 1 || names[0] != "foo" {
+		t.Errorf("expected the notification of [foo], got %v", names)
+	}
+	checkFileSecret(t, p, "foo", &model.SecretItem{CertificateChain: k8sCertChainA, PrivateKey: k8sKeyB})
+
+	// Add a secret directory and delete another.
+	tmpDir, err := ioutil.TempDir("", "file-secret")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	writeSecretDir(t, filepath.Join(tmpDir, "qux"), map[string][]byte{
+		tlsScrtCert: k8sTestCertChainB,
+		tlsScrtKey:  k8sKeyB,
+	})
+	if err := os.Rename(filepath.Join(tmpDir, "qux"), filepath.Join(dir, "qux")); err != nil {
+		t.Fatalf("Failed to move the secret directory: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "bar-cacert")); err != nil {
+		t.Fatalf("Failed to delete the secret directory: %v", err)
+	}
+	if names := waitForNotifications(notifyCh); len(names) != 2 || names[0] != "bar-cacert" || names[1] != "qux" {
+		t.Errorf("expected the notifications of [bar-cacert qux], got %v", names)
+	}
+	checkFileSecret(t, p, "qux", &model.SecretItem{CertificateChain: k8sTestCertChainB, PrivateKey: k8sKeyB})
+	checkFileSecret(t, p, "bar-cacert", nil)
+}
+
+type fakeSecretProvider struct {
+	secrets map[string]model.SecretItem
+	notify  func(name string)
+}
+
+func (p *fakeSecretProvider) GetSecret(name string) (model.SecretItem, bool) {
+	secret, exist := p.secrets[name]
+	return secret, exist
+}
+
+func (p *fakeSecretProvider) Run(stopCh <-chan struct{}, notify func(name string)) {
+	p.notify = notify
+}
+
+func TestSecretFetcherWithSecretProvider(t *testing.T) {
+	updated := map[string]model.SecretItem{}
+	deleted := []string{}
+	sf := &SecretFetcher{
+		UseCaClient: false,
+		DeleteCache: func(secretName string) {
+			deleted = append(deleted, secretName)
+		},
+		UpdateCache: func(secretName string, ns model.SecretItem) {
+			updated[secretName] = ns
+		},
+		FallbackSecretName: "gateway-fallback",
+	}
+	provider := &fakeSecretProvider{
+		secrets: map[string]model.SecretItem{
+			"foo": {ResourceName: "foo", CertificateChain: k8sCertChainA, PrivateKey: k8sKeyA},
+		},
+	}
+	sf.AddSecretProvider("fake://", provider)
+	sf.runSecretProviders(make(chan struct{}))
+	// The fallback secret is not returned for the secrets of the providers.
+	sf.secrets.Store("gateway-fallback", model.SecretItem{ResourceName: "gateway-fallback"})
+
+	secret, ok := sf.FindIngressGatewaySecret("fake://foo")
+	if !ok || secret.ResourceName != "fake://foo" || !bytes.Equal(secret.PrivateKey, k8sKeyA) {
+		t.Errorf("unexpected secret fake://foo: %+v, %v", secret, ok)
+	}
+	if secret, ok := sf.FindIngressGatewaySecret("fake://bar"); ok {
+		t.Errorf("secret fake://bar should not exist, got %+v", secret)
+	}
+	if _, ok := sf.FindIngressGatewaySecret("bar"); !ok {
+		t.Error("the fallback secret should be returned for the Kubernetes secret bar")
+	}
+
+	provider.secrets["foo"] = model.SecretItem{ResourceName: "foo", CertificateChain: k8sCertChainA, PrivateKey: k8sKeyB}
+	provider.notify("foo")
+	if secret, ok := updated["fake://foo"]; !ok || secret.ResourceName != "fake://foo" ||
+		!bytes.Equal(secret.PrivateKey, k8sKeyB) {
+		t.Errorf("unexpected update of fake://foo: %+v, %v", secret, ok)
+	}
+
+	delete(provider.secrets, "foo")
+	provider.notify("foo")
+	if len(deleted) != 1 || deleted[0] != "fake://foo" {
+		t.Errorf("expected the deletion of [fake://foo], got %v", deleted)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretfetcher
+
+import (
+	"strings"
+
+	"istio.io/istio/security/pkg/nodeagent/model"
+)
+
+// SecretProvider provides the ingress gateway secrets whose credentialName starts with the prefix the provider is
+// added with, e.g. "file://". The names passed to the provider do not have the prefix, and a name with the
+// IngressGatewaySdsCaSuffix refers to a client CA cert.
+type SecretProvider interface {
+	// GetSecret returns the secret of the name, and whether the secret exists.
+	GetSecret(name string) (model.SecretItem, bool)
+
+	// Run starts the provider until stopCh is closed. The provider calls notify with the name of a secret when the
+	// secret is added, updated or deleted.
+	Run(stopCh <-chan struct{}, notify func(name string))
+}
+
+// AddSecretProvider adds the provider of the ingress gateway secrets whose names start with prefix. The secrets
+// without the prefix of any provider are Kubernetes secrets. It must be called before Run.
+func (sf *SecretFetcher) AddSecretProvider(prefix string, provider SecretProvider) {
+	if sf.providers == nil {
+		sf.providers = make(map[string]SecretProvider)
+	}
+	sf.providers[prefix] = provider
+}
+
+// findSecretProvider returns the provider of the secret name, with the name stripped of the provider prefix.
+func (sf *SecretFetcher) findSecretProvider(key string) (SecretProvider, string, bool) {
+	for prefix, provider := range sf.providers {
+		if strings.HasPrefix(key, prefix) {
+			return provider, strings.TrimPrefix(key, prefix), true
+		}
+	}
+	return nil, "", false
+}
+
+// runSecretProviders starts the secret providers until ch is closed.
+func (sf *SecretFetcher) runSecretProviders(ch <-chan struct{}) {
+	for prefix, provider := range sf.providers {
+		prefix, provider := prefix, provider
+		provider.Run(ch, func(name string) {
+			sf.providerSecretChanged(prefix, provider, name)
+		})
+	}
+}
+
+// providerSecretChanged pushes the change of a provider secret to the SecretCache.
+func (sf *SecretFetcher) providerSecretChanged(prefix string, provider SecretProvider, name string) {
+	key := prefix + name
+	secret, exist := provider.GetSecret(name)
+	if !exist {
+		secretFetcherLog.Infof("secret %s is deleted", key)
+		if sf.DeleteCache != nil {
+			sf.DeleteCache(key)
+		}
+		return
+	}
+	secretFetcherLog.Infof("secret %s is updated", key)
+	secret.ResourceName = key
+	if sf.UpdateCache != nil {
+		sf.UpdateCache(key, secret)
+	}
+}
@@ -55,6 +55,9 @@ const (
 	// IngressSecretNamespace the namespace of kubernetes secrets to watch.
 	ingressSecretNamespace = "INGRESS_GATEWAY_NAMESPACE"
 
+	// FileSecretProviderPrefix is the credentialName prefix of the ingress gateway secrets mounted as files.
+	FileSecretProviderPrefix = "file://"
+
 	// IngressGatewaySdsCaSuffix is the suffix of the sds resource name for root CA. All resource
 	// names for ingress gateway root certs end with "-cacert".
 	IngressGatewaySdsCaSuffix = "-cacert"
@@ -75,7 +78,11 @@ var (
 	secretControllerResyncPeriod = env.RegisterStringVar("SECRET_WATCHER_RESYNC_PERIOD", "", "").Get()
 	// ingressFallbackSecret specifies the name of fallback secret for ingress gateway.
 	ingressFallbackSecret = env.RegisterStringVar("INGRESS_GATEWAY_FALLBACK_SECRET", "gateway-fallback", "").Get()
-	secretFetcherLog      = log.RegisterScope("secretFetcherLog", "secret fetcher debugging", 0)
+	// ingressFileSecretDir specifies the directory of the ingress gateway secrets mounted as files. If it is set,
+	// the secrets with credentialName "file://<name>" are read from the directory <name> in it.
+	ingressFileSecretDir = env.RegisterStringVar("INGRESS_GATEWAY_FILE_SECRET_DIR", "",
+		"The directory of the ingress gateway secrets mounted as files, with the file:// credentialName prefix").Get()
+	secretFetcherLog = log.RegisterScope("secretFetcherLog", "secret fetcher debugging", 0)
 )
 
 // SecretFetcher fetches secret via watching k8s secrets or sending CSR to CA.
@@ -106,6 +113,9 @@ type SecretFetcher struct {
 
 	secretNamespace string
 	coreV1          corev1.CoreV1Interface
+
+	// providers maps the credentialName prefixes to the providers of the secrets that are not Kubernetes secrets.
+	providers map[string]SecretProvider
 }
 
 func fatalf(template string, args ...interface{}) {
@@ -131,6 +141,10 @@ func NewSecretFetcher(ingressGatewayAgent bool, endpoint, caProviderName string,
 		ret.FallbackSecretName = ingressFallbackSecret
 		secretFetcherLog.Debugf("SecretFetcher set fallback secret name %s", ret.FallbackSecretName)
 		ret.InitWithKubeClient(cs.CoreV1())
+		if ingressFileSecretDir != "" {
+			secretFetcherLog.Infof("SecretFetcher reads %s secrets from %s", FileSecretProviderPrefix, ingressFileSecretDir)
+			ret.AddSecretProvider(FileSecretProviderPrefix, NewFileSecretProvider(ingressFileSecretDir))
+		}
 	} else {
 		caClient, err := ca.NewCAClient(endpoint, caProviderName, tlsFlag, tlsRootCert,
 			vaultAddr, vaultRole, vaultAuthPath, vaultSignCsrPath)
@@ -148,6 +162,7 @@ func NewSecretFetcher(ingressGatewayAgent bool, endpoint, caProviderName string,
 // Run starts the SecretFetcher until a value is sent to ch.
 // Only used when watching kubernetes gateway secrets.
 func (sf *SecretFetcher) Run(ch chan struct{}) {
+	sf.runSecretProviders(ch)
 	go sf.scrtController.Run(ch)
 	cache.WaitForCacheSync(ch, sf.scrtController.HasSynced)
 }
@@ -444,9 +459,19 @@ func (sf *SecretFetcher) scrtUpdated(oldObj, newObj interface{}) {
 
 // FindIngressGatewaySecret returns the secret whose name matches the key, or empty secret if no
 // secret is present. The ok result indicates whether secret was found.
-// If there is a fallback secret named FallbackSecretName, return the fall back secret.
+// If the key starts with the prefix of a SecretProvider, the secret is returned by the provider.
+// Otherwise, if there is a fallback secret named FallbackSecretName, return the fall back secret.
 func (sf *SecretFetcher) FindIngressGatewaySecret(key string) (secret model.SecretItem, ok bool) {
 	secretFetcherLog.Debugf("SecretFetcher search for secret %s", key)
+	if provider, name, found := sf.findSecretProvider(key); found {
+		secret, ok = provider.GetSecret(name)
+		if !ok {
+			secretFetcherLog.Errorf("cannot find secret %s", key)
+			return model.SecretItem{}, false
+		}
+		secret.ResourceName = key
+		return secret, true
+	}
 	val, exist := sf.secrets.Load(key)
 	secretFetcherLog.Debugf("load secret %s from secret fetcher: %v", key, exist)
 	if !exist {
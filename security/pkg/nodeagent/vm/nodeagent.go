@@ -115,10 +115,11 @@ func (na *nodeAgentInternal) Start() error {
 
 func (na *nodeAgentInternal) createRequest() ([]byte, *pb.CsrRequest, error) {
 	csr, privKey, err := pkiutil.GenCSR(pkiutil.CertOptions{
-		Host:       na.identity,
-		Org:        na.config.CAClientConfig.Org,
-		RSAKeySize: na.config.CAClientConfig.RSAKeySize,
-		IsDualUse:  na.config.DualUse,
+		Host:         na.identity,
+		Org:          na.config.CAClientConfig.Org,
+		RSAKeySize:   na.config.CAClientConfig.RSAKeySize,
+		KeyAlgorithm: na.config.CAClientConfig.KeyAlgorithm,
+		IsDualUse:    na.config.DualUse,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -70,6 +70,8 @@ const (
 	selfSignedCA caTypes = iota
 	// pluggedCertCA means the Istio CA uses a operator-specified key/cert.
 	pluggedCertCA
+	// externalSignerCA means the Istio CA signs with an external Signer, e.g. Vault, and holds no signing key.
+	externalSignerCA
 )
 
 // IstioCAOptions holds the configurations for creating an Istio CA.
@@ -87,12 +89,18 @@ type IstioCAOptions struct {
 
 	// Config for creating self-signed root cert rotator.
 	RotatorConfig *SelfSignedCARootCertRotatorConfig
+
+	// RevocationList denies certificates to identities, and publishes the CRL of the CA. It is optional.
+	RevocationList *RevocationList
+
+	// Signer signs the certificates. If it is nil, the certificates are signed with the signing key of KeyCertBundle.
+	Signer Signer
 }
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
 func NewSelfSignedIstioCAOptions(ctx context.Context, readSigningCertOnly bool,
 	rootCertGracePeriodPercentile int, caCertTTL, rootCertCheckInverval, certTTL,
-	maxCertTTL time.Duration, org string, dualUse bool, namespace string,
+	maxCertTTL time.Duration, org string, dualUse bool, caKeyAlgorithm util.KeyAlgorithm, namespace string,
 	readCertRetryInterval time.Duration, client corev1.CoreV1Interface,
 	rootCertFile string, enableJitter bool) (caOpts *IstioCAOptions, err error) {
 	// For the first time the CA is up, if readSigningCertOnly is unset,
@@ -142,6 +150,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, readSigningCertOnly bool,
 			IsCA:         true,
 			IsSelfSigned: true,
 			RSAKeySize:   caKeySize,
+			KeyAlgorithm: caKeyAlgorithm,
 			IsDualUse:    dualUse,
 		}
 		pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
@@ -228,6 +237,31 @@ func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile
 	return caOpts, nil
 }
 
+// NewExternalSignerIstioCAOptions returns a new IstioCAOptions instance signing certificates with an external
+// signer, whose signing key is not available to Citadel. certChain is the chain of the CA certificates of the
+// signer, from the issuing CA certificate up to, but excluding, rootCert.
+func NewExternalSignerIstioCAOptions(signer Signer, certChain, rootCert []byte, certTTL, maxCertTTL time.Duration,
+	namespace string, client corev1.CoreV1Interface) (caOpts *IstioCAOptions, err error) {
+	caOpts = &IstioCAOptions{
+		CAType:     externalSignerCA,
+		CertTTL:    certTTL,
+		MaxCertTTL: maxCertTTL,
+		Signer:     signer,
+	}
+	if caOpts.KeyCertBundle, err = util.NewVerifiedCertChainBundleFromPem(certChain, rootCert); err != nil {
+		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
+	}
+
+	crt := caOpts.KeyCertBundle.GetCertChainPem()
+	if len(crt) == 0 {
+		crt = caOpts.KeyCertBundle.GetRootCertPem()
+	}
+	if err = updateCertInConfigmap(namespace, client, crt); err != nil {
+		pkiCaLog.Errorf("Failed to write Citadel cert to configmap (%v). Node agents will not be able to connect.", err)
+	}
+	return caOpts, nil
+}
+
 // IstioCA generates keys and certificates for Istio identities.
 type IstioCA struct {
 	certTTL    time.Duration
@@ -235,20 +269,32 @@ type IstioCA struct {
 
 	keyCertBundle util.KeyCertBundle
 
+	// signer signs the certificates, with the signing key of keyCertBundle unless the CA uses an external signer.
+	signer Signer
+
 	livenessProbe *probe.Probe
 
 	// rootCertRotator periodically rotates self-signed root cert for CA. It is nil
 	// if CA is not self-signed CA.
 	rootCertRotator *SelfSignedCARootCertRotator
+
+	// revocationList denies certificates to identities, and publishes the CRL of the CA. It is nil if
+	// revocation is not enabled.
+	revocationList *RevocationList
 }
 
 // NewIstioCA returns a new IstioCA instance.
 func NewIstioCA(opts *IstioCAOptions) (*IstioCA, error) {
 	ca := &IstioCA{
-		certTTL:       opts.CertTTL,
-		maxCertTTL:    opts.MaxCertTTL,
-		keyCertBundle: opts.KeyCertBundle,
-		livenessProbe: probe.NewProbe(),
+		certTTL:        opts.CertTTL,
+		maxCertTTL:     opts.MaxCertTTL,
+		keyCertBundle:  opts.KeyCertBundle,
+		livenessProbe:  probe.NewProbe(),
+		revocationList: opts.RevocationList,
+		signer:         opts.Signer,
+	}
+	if ca.signer == nil {
+		ca.signer = &keyCertBundleSigner{keyCertBundle: opts.KeyCertBundle}
 	}
 
 	if opts.CAType == selfSignedCA && opts.RotatorConfig.CheckInterval > time.Duration(0) {
@@ -262,20 +308,46 @@ func (ca *IstioCA) Run(stopChan chan struct{}) {
 		// Start root cert rotator in a separate goroutine.
 		go ca.rootCertRotator.Run(stopChan)
 	}
+	if ca.revocationList != nil {
+		// Load the revocation list and publish the CRL in a separate goroutine.
+		go ca.revocationList.Run(ca.keyCertBundle, stopChan)
+	}
 }
 
 // Sign takes a PEM-encoded CSR, subject IDs and lifetime, and returns a signed certificate. If forCA is true,
 // the signed certificate is a CA certificate, otherwise, it is a workload certificate.
 // TODO(myidpt): Add error code to identify the Sign error types.
 func (ca *IstioCA) Sign(csrPEM []byte, subjectIDs []string, requestedLifetime time.Duration, forCA bool) ([]byte, error) {
-	signingCert, signingKey, _, _ := ca.keyCertBundle.GetAll()
-	if signingCert == nil {
-		return nil, caerror.NewError(caerror.CANotReady, fmt.Errorf("Istio CA is not ready")) // nolint
+	cert, _, err := ca.sign(csrPEM, subjectIDs, requestedLifetime, forCA)
+	return cert, err
+}
+
+// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
+func (ca *IstioCA) SignWithCertChain(csrPEM []byte, subjectIDs []string, ttl time.Duration, forCA bool) ([]byte, error) {
+	cert, certChain, err := ca.sign(csrPEM, subjectIDs, ttl, forCA)
+	if err != nil {
+		return nil, err
+	}
+	if len(certChain) > 0 {
+		cert = append(cert, certChain...)
+	}
+	return cert, nil
+}
+
+// sign validates the request and signs it with the signer of the CA. It returns the certificate and the chain of
+// the CA certificates returned by the signer.
+func (ca *IstioCA) sign(csrPEM []byte, subjectIDs []string, requestedLifetime time.Duration, forCA bool) (
+	[]byte, []byte, error) {
+	if ca.revocationList != nil {
+		if id, denied := ca.revocationList.IsDenied(subjectIDs); denied {
+			return nil, nil, caerror.NewError(caerror.IdentityDenied,
+				fmt.Errorf("identity %s is denied by the revocation list", id))
+		}
 	}
 
 	csr, err := util.ParsePemEncodedCSR(csrPEM)
 	if err != nil {
-		return nil, caerror.NewError(caerror.CSRError, err)
+		return nil, nil, caerror.NewError(caerror.CSRError, err)
 	}
 
 	lifetime := requestedLifetime
@@ -285,35 +357,18 @@ func (ca *IstioCA) Sign(csrPEM []byte, subjectIDs []string, requestedLifetime ti
 	}
 	// If the requested TTL is greater than maxCertTTL, return an error
 	if requestedLifetime.Seconds() > ca.maxCertTTL.Seconds() {
-		return nil, caerror.NewError(caerror.TTLError, fmt.Errorf(
+		return nil, nil, caerror.NewError(caerror.TTLError, fmt.Errorf(
 			"requested TTL %s is greater than the max allowed TTL %s", requestedLifetime, ca.maxCertTTL))
 	}
 
-	certBytes, err := util.GenCertFromCSR(csr, signingCert, csr.PublicKey, *signingKey, subjectIDs, lifetime, forCA)
-	if err != nil {
-		return nil, caerror.NewError(caerror.CertGenError, err)
-	}
-
-	block := &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: certBytes,
-	}
-	cert := pem.EncodeToMemory(block)
-
-	return cert, nil
-}
-
-// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
-func (ca *IstioCA) SignWithCertChain(csrPEM []byte, subjectIDs []string, ttl time.Duration, forCA bool) ([]byte, error) {
-	cert, err := ca.Sign(csrPEM, subjectIDs, ttl, forCA)
+	cert, certChain, err := ca.signer.Sign(csr, subjectIDs, lifetime, forCA)
 	if err != nil {
-		return nil, err
-	}
-	chainPem := ca.GetCAKeyCertBundle().GetCertChainPem()
-	if len(chainPem) > 0 {
-		cert = append(cert, chainPem...)
+		if e, ok := err.(*caerror.Error); ok {
+			return nil, nil, e
+		}
+		return nil, nil, caerror.NewError(caerror.CertGenError, err)
 	}
-	return cert, nil
+	return cert, certChain, nil
 }
 
 // GetCAKeyCertBundle returns the KeyCertBundle for the CA.
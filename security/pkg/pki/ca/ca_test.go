@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"fmt"
 	"io/ioutil"
 	"reflect"
 	"testing"
@@ -101,7 +102,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), readSigningCertOnly,
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
-		maxCertTTL, org, false, caNamespace, -1, client.CoreV1(),
+		maxCertTTL, org, false, util.RSAKey, caNamespace, -1, client.CoreV1(),
 		rootCertFile, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
@@ -168,6 +169,45 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	}
 }
 
+func TestCreateSelfSignedIstioCAWithECDSAKey(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), false,
+		0, time.Hour, time.Hour, 30*time.Minute, time.Hour, "test.ca.Org", false,
+		util.ECDSAP256Key, "default", -1, client.CoreV1(), "", false)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while createing self-signed CA: %v", err)
+	}
+
+	_, signingKey, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAll()
+	if alg, _, err := util.GetKeyAlgorithm(*signingKey); err != nil || alg != util.ECDSAP256Key {
+		t.Errorf("Unexpected CA key algorithm (expecting %v, actual %v): %v", util.ECDSAP256Key, alg, err)
+	}
+
+	// The ECDSA CA must still be able to sign CSRs for RSA keys.
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	csrPEM, keyPEM, err := util.GenCSR(util.CertOptions{Host: subjectID, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 30*time.Minute, false)
+	if err != nil {
+		t.Fatalf("Failed to sign CSR: %v", err)
+	}
+	fields := &util.VerifyFields{
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		IsCA:        false,
+		Host:        subjectID,
+	}
+	if err = util.VerifyCertificate(keyPEM, certPEM, rootCertBytes, fields); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	rootCertPem := cert1Pem
 	// Use the same signing cert and root cert for self-signed CA.
@@ -193,7 +233,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), readSigningCertOnly,
 		0, caCertTTL, rootCertCheckInverval, certTTL, maxCertTTL,
-		org, false, caNamespace, -1, client.CoreV1(),
+		org, false, util.RSAKey, caNamespace, -1, client.CoreV1(),
 		rootCertFile, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
@@ -265,7 +305,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, readSigningCertOnly, 0,
 		caCertTTL, certTTL, rootCertCheckInverval, maxCertTTL, org, false,
-		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false)
+		util.RSAKey, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false)
 	if err == nil {
 		t.Errorf("Expected error, but succeeded.")
 	} else if err.Error() != expectedErr {
@@ -284,7 +324,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel1()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, readSigningCertOnly, 0,
 		caCertTTL, certTTL, rootCertCheckInverval, maxCertTTL, org, false,
-		caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false)
+		util.RSAKey, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, false)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -593,6 +633,155 @@ func TestSignWithCertChain(t *testing.T) {
 	}
 }
 
+// fakeSigner signs with a keyCertBundleSigner and returns certChain, unless err is set. It records the requests.
+type fakeSigner struct {
+	signer     *keyCertBundleSigner
+	certChain  []byte
+	err        error
+	subjectIDs []string
+	lifetime   time.Duration
+}
+
+func (s *fakeSigner) Sign(csr *x509.CertificateRequest, subjectIDs []string, lifetime time.Duration,
+	forCA bool) ([]byte, []byte, error) {
+	s.subjectIDs = subjectIDs
+	s.lifetime = lifetime
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	cert, _, err := s.signer.Sign(csr, subjectIDs, lifetime, forCA)
+	return cert, s.certChain, err
+}
+
+func TestExternalSignerCA(t *testing.T) {
+	rootCertFile := "../testdata/multilevelpki/root-cert.pem"
+	signingCertFile := "../testdata/multilevelpki/int2-cert.pem"
+	signingKeyFile := "../testdata/multilevelpki/int2-key.pem"
+	intCertFile := "../testdata/multilevelpki/int-cert.pem"
+	caNamespace := "default"
+
+	rootCert, err := ioutil.ReadFile(rootCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingCert, err := ioutil.ReadFile(signingCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intCert, err := ioutil.ReadFile(intCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certChain := append(signingCert, intCert...)
+	bundle, err := util.NewVerifiedKeyCertBundleFromFile(signingCertFile, signingKeyFile, intCertFile, rootCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrPEM, privPEM, err := util.GenCSR(util.CertOptions{Host: "spiffe://different.com/test", RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subjectIDs := []string{"spiffe://example.com/ns/foo/sa/bar"}
+
+	testCases := map[string]struct {
+		signErr          error
+		ttl              time.Duration
+		expectedErr      string
+		expectedErrType  string
+		expectedLifetime time.Duration
+	}{
+		"Success": {
+			ttl:              time.Hour,
+			expectedLifetime: time.Hour,
+		},
+		"Default TTL": {
+			expectedLifetime: 30 * time.Minute,
+		},
+		"TTL error": {
+			ttl:             2 * time.Hour,
+			expectedErr:     "requested TTL 2h0m0s is greater than the max allowed TTL 1h0m0s",
+			expectedErrType: "TTL_ERROR",
+		},
+		"Signer error": {
+			signErr:          fmt.Errorf("permission denied"),
+			ttl:              time.Hour,
+			expectedErr:      "permission denied",
+			expectedErrType:  "CERT_GEN_ERROR",
+			expectedLifetime: time.Hour,
+		},
+		"Signer CA error": {
+			signErr:          caerror.NewError(caerror.CANotReady, fmt.Errorf("vault is sealed")),
+			ttl:              time.Hour,
+			expectedErr:      "vault is sealed",
+			expectedErrType:  "CA_NOT_READY",
+			expectedLifetime: time.Hour,
+		},
+	}
+	for id, tc := range testCases {
+		client := fake.NewSimpleClientset()
+		signer := &fakeSigner{signer: &keyCertBundleSigner{keyCertBundle: bundle}, certChain: certChain, err: tc.signErr}
+		caopts, err := NewExternalSignerIstioCAOptions(signer, certChain, rootCert,
+			30*time.Minute, time.Hour, caNamespace, client.CoreV1())
+		if err != nil {
+			t.Fatalf("%s: failed to create an external signer CA Options: %v", id, err)
+		}
+		ca, err := NewIstioCA(caopts)
+		if err != nil {
+			t.Fatalf("%s: failed to create an external signer CA: %v", id, err)
+		}
+
+		cert, key, chain, root := ca.GetCAKeyCertBundle().GetAllPem()
+		if len(cert) != 0 || len(key) != 0 {
+			t.Errorf("%s: the CA should not hold a signing key/cert", id)
+		}
+		if !bytes.Equal(chain, certChain) || !bytes.Equal(root, rootCert) {
+			t.Errorf("%s: unexpected cert chain or root cert in the KeyCertBundle", id)
+		}
+		// The configmap holds the cert chain, as for a plugged cert CA.
+		strCertFromConfigMap, err := configmap.NewController(caNamespace, client.CoreV1()).GetCATLSRootCert()
+		if err != nil {
+			t.Errorf("%s: cannot get the CA cert from configmap (%v)", id, err)
+		} else if certFromConfigMap, _ := base64.StdEncoding.DecodeString(strCertFromConfigMap); !bytes.Equal(
+			certFromConfigMap, certChain) {
+			t.Errorf("%s: the cert in configmap is not the cert chain of the signer", id)
+		}
+
+		certPEM, signErr := ca.SignWithCertChain(csrPEM, subjectIDs, tc.ttl, false)
+		if signer.lifetime != tc.expectedLifetime {
+			t.Errorf("%s: unexpected lifetime passed to the signer: %v VS (expected) %v", id, signer.lifetime,
+				tc.expectedLifetime)
+		}
+		if tc.expectedErr != "" {
+			if signErr == nil {
+				t.Errorf("%s: expected error %q but succeeded", id, tc.expectedErr)
+				continue
+			}
+			if signErr.(*caerror.Error).Error() != tc.expectedErr {
+				t.Errorf("%s: unexpected error %q VS (expected) %q", id, signErr.Error(), tc.expectedErr)
+			}
+			if signErr.(*caerror.Error).ErrorType() != tc.expectedErrType {
+				t.Errorf("%s: unexpected error type %s VS (expected) %s", id,
+					signErr.(*caerror.Error).ErrorType(), tc.expectedErrType)
+			}
+			continue
+		}
+		if signErr != nil {
+			t.Errorf("%s: unexpected error: %v", id, signErr)
+			continue
+		}
+		if !reflect.DeepEqual(signer.subjectIDs, subjectIDs) {
+			t.Errorf("%s: unexpected subject IDs passed to the signer: %v", id, signer.subjectIDs)
+		}
+		keyPair, err := tls.X509KeyPair(certPEM, privPEM)
+		if err != nil {
+			t.Errorf("%s: %v", id, err)
+		} else if len(keyPair.Certificate) != 3 {
+			t.Errorf("%s: unexpected number of certificates returned: %d (expected 3)", id, len(keyPair.Certificate))
+		}
+	}
+}
+
 func createCA(maxTTL time.Duration) (*IstioCA, error) {
 	// Generate root CA key and cert.
 	rootCAOpts := util.CertOptions{
@@ -0,0 +1,422 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// RevocationConfigMapName is the ConfigMap holding the revocation list of the CA, and the CRL it publishes.
+	RevocationConfigMapName = "istio-ca-revocation-list"
+	// RevocationListID is the key of the revocation entries in the ConfigMap, in JSON.
+	RevocationListID = "revocations.json"
+	// CRLID is the key of the PEM encoded CRL in the ConfigMap.
+	CRLID = "ca-crl.pem"
+)
+
+// RevocationEntry revokes a certificate by its serial number, or denies certificates to an identity.
+type RevocationEntry struct {
+	// SerialNumber is the serial number of the revoked certificate, in hexadecimal.
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// Identity is the identity denied certificates, e.g. spiffe://cluster.local/ns/default/sa/foo.
+	Identity string `json:"identity,omitempty"`
+	// Reason is a free form description of the revocation.
+	Reason string `json:"reason,omitempty"`
+	// RevokedAt is the time the entry was added.
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// ParseSerialNumber parses a serial number in hexadecimal, optionally prefixed with 0x or with its bytes
+// separated by colons, as printed by openssl.
+func ParseSerialNumber(serial string) (*big.Int, error) {
+	s := strings.ToLower(strings.Replace(strings.TrimSpace(serial), ":", "", -1))
+	s = strings.TrimPrefix(s, "0x")
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid serial number %q", serial)
+	}
+	return n, nil
+}
+
+// normalize validates an entry and returns it with its serial number in canonical form.
+func (e RevocationEntry) normalize() (RevocationEntry, error) {
+	if (e.SerialNumber == "") == (e.Identity == "") {
+		return e, fmt.Errorf("an entry must have either a serial number or an identity")
+	}
+	if e.SerialNumber != "" {
+		n, err := ParseSerialNumber(e.SerialNumber)
+		if err != nil {
+			return e, err
+		}
+		e.SerialNumber = n.Text(16)
+	}
+	return e, nil
+}
+
+// ReadRevocationEntries reads the entries of the revocation list of the CA from its ConfigMap. No entry is returned
+// if the ConfigMap does not exist.
+func ReadRevocationEntries(namespace string, client corev1.CoreV1Interface) ([]RevocationEntry, error) {
+	cm, err := client.ConfigMaps(namespace).Get(RevocationConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the revocation list: %v", err)
+	}
+	return parseRevocationEntries(cm.Data[RevocationListID])
+}
+
+// IsCRLPublished returns whether the CA publishes a CRL in the ConfigMap of its revocation list, i.e. whether
+// certificates can be revoked. The CA does not publish a CRL if it uses an external signer, or does not sign with
+// its root certificate.
+func IsCRLPublished(namespace string, client corev1.CoreV1Interface) (bool, error) {
+	cm, err := client.ConfigMaps(namespace).Get(RevocationConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read the revocation list: %v", err)
+	}
+	return cm.Data[CRLID] != "", nil
+}
+
+// AddRevocationEntries adds entries to the revocation list of the CA in its ConfigMap, creating it if needed.
+// Entries already in the list are ignored.
+func AddRevocationEntries(namespace string, client corev1.CoreV1Interface, entries ...RevocationEntry) error {
+	for i := range entries {
+		e, err := entries[i].normalize()
+		if err != nil {
+			return err
+		}
+		if e.RevokedAt.IsZero() {
+			e.RevokedAt = time.Now().UTC()
+		}
+		entries[i] = e
+	}
+
+	return updateRevocationConfigMap(namespace, client, func(cm *v1.ConfigMap) error {
+		existing, err := parseRevocationEntries(cm.Data[RevocationListID])
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			found := false
+			for _, x := range existing {
+				if x.SerialNumber == e.SerialNumber && x.Identity == e.Identity {
+					found = true
+					break
+				}
+			}
+			if !found {
+				existing = append(existing, e)
+			}
+		}
+		content, err := json.MarshalIndent(existing, "", "  ")
+		if err != nil {
+			return err
+		}
+		cm.Data[RevocationListID] = string(content)
+		return nil
+	})
+}
+
+func parseRevocationEntries(content string) ([]RevocationEntry, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	var entries []RevocationEntry
+	if err := json.Unmarshal([]byte(content), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse the revocation list: %v", err)
+	}
+	for i := range entries {
+		e, err := entries[i].normalize()
+		if err != nil {
+			return nil, fmt.Errorf("invalid revocation entry %d: %v", i, err)
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// updateRevocationConfigMap applies a change to the ConfigMap of the revocation list, creating it if needed.
+// The change is applied again if the ConfigMap was updated concurrently.
+func updateRevocationConfigMap(namespace string, client corev1.CoreV1Interface, update func(*v1.ConfigMap) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := client.ConfigMaps(namespace).Get(RevocationConfigMapName, metav1.GetOptions{})
+		exists := true
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to read the revocation list: %v", err)
+			}
+			cm = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      RevocationConfigMapName,
+					Namespace: namespace,
+				},
+			}
+			exists = false
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		if err := update(cm); err != nil {
+			return err
+		}
+		if exists {
+			_, err = client.ConfigMaps(namespace).Update(cm)
+		} else {
+			_, err = client.ConfigMaps(namespace).Create(cm)
+		}
+		return err
+	})
+}
+
+// RevocationList is the revocation list of the CA. It is loaded from the ConfigMap of the CA, and publishes the
+// CRL of the revoked serial numbers in it.
+type RevocationList struct {
+	namespace     string
+	client        corev1.CoreV1Interface
+	checkInterval time.Duration
+	// crlTTL is the validity of a CRL. The CRL is published again at half of its validity, so that relying parties
+	// never see an expired CRL as long as the CA is available.
+	crlTTL time.Duration
+
+	mu         sync.RWMutex
+	serials    map[string]RevocationEntry
+	identities map[string]RevocationEntry
+	// content is the content of the revocation list last loaded.
+	content string
+	// crlSigner is the certificate that signed the CRL last published, and crlPublished the time it was published.
+	crlSigner    []byte
+	crlPublished time.Time
+}
+
+// NewRevocationList returns a new RevocationList, reading the ConfigMap of the CA in the given namespace at the
+// given interval, and publishing CRLs valid for crlTTL. Envoy rejects the certificates checked against an expired
+// CRL, so all the Istio mutual TLS connections fail if the CA is unavailable for longer than half of crlTTL.
+func NewRevocationList(namespace string, client corev1.CoreV1Interface, checkInterval, crlTTL time.Duration) *RevocationList {
+	return &RevocationList{
+		namespace:     namespace,
+		client:        client,
+		checkInterval: checkInterval,
+		crlTTL:        crlTTL,
+		serials:       map[string]RevocationEntry{},
+		identities:    map[string]RevocationEntry{},
+	}
+}
+
+// Load reads the revocation list from the ConfigMap. It returns whether the list changed.
+func (rl *RevocationList) Load() (bool, error) {
+	var content string
+	cm, err := rl.client.ConfigMaps(rl.namespace).Get(RevocationConfigMapName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to read the revocation list: %v", err)
+	}
+	if err == nil {
+		content = cm.Data[RevocationListID]
+	}
+
+	rl.mu.RLock()
+	unchanged := content == rl.content
+	rl.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	entries, err := parseRevocationEntries(content)
+	if err != nil {
+		return false, err
+	}
+	serials := map[string]RevocationEntry{}
+	identities := map[string]RevocationEntry{}
+	for _, e := range entries {
+		if e.SerialNumber != "" {
+			serials[e.SerialNumber] = e
+		} else {
+			identities[e.Identity] = e
+		}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.serials = serials
+	rl.identities = identities
+	rl.content = content
+	pkiCaLog.Infof("Loaded the revocation list: %d revoked serial number(s) and %d denied identities",
+		len(serials), len(identities))
+	return true, nil
+}
+
+// IsRevoked returns whether the certificate with the given serial number is revoked.
+func (rl *RevocationList) IsRevoked(serial *big.Int) bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	_, found := rl.serials[serial.Text(16)]
+	return found
+}
+
+// IsDenied returns the first of the given identities that is denied certificates, if any.
+func (rl *RevocationList) IsDenied(identities []string) (string, bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	for _, id := range identities {
+		if _, found := rl.identities[id]; found {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// CreateCRL returns a PEM encoded CRL of the revoked serial numbers, signed by the signing key of the CA.
+func (rl *RevocationList) CreateCRL(keyCertBundle util.KeyCertBundle, now time.Time) ([]byte, error) {
+	signingCert, signingKey, _, _ := keyCertBundle.GetAll()
+	if signingCert == nil || signingKey == nil {
+		return nil, fmt.Errorf("the CA is not ready to sign a CRL")
+	}
+
+	rl.mu.RLock()
+	revoked := make([]pkix.RevokedCertificate, 0, len(rl.serials))
+	for serial, e := range rl.serials {
+		n, _ := new(big.Int).SetString(serial, 16)
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: n, RevocationTime: e.RevokedAt})
+	}
+	rl.mu.RUnlock()
+	// A stable order makes the CRL only change with its content and time
+	sort.Slice(revoked, func(i, j int) bool { return revoked[i].SerialNumber.Cmp(revoked[j].SerialNumber) < 0 })
+
+	crl, err := signingCert.CreateCRL(rand.Reader, *signingKey, revoked, now, now.Add(rl.crlTTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CRL: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), nil
+}
+
+// publishCRL creates a CRL and writes it to the ConfigMap of the revocation list.
+func (rl *RevocationList) publishCRL(keyCertBundle util.KeyCertBundle) error {
+	now := time.Now()
+	crl, err := rl.CreateCRL(keyCertBundle, now)
+	if err != nil {
+		return err
+	}
+	err = updateRevocationConfigMap(rl.namespace, rl.client, func(cm *v1.ConfigMap) error {
+		cm.Data[CRLID] = string(crl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish the CRL: %v", err)
+	}
+
+	signer, _, _, _ := keyCertBundle.GetAllPem()
+	rl.mu.Lock()
+	rl.crlSigner = signer
+	rl.crlPublished = now
+	rl.mu.Unlock()
+	return nil
+}
+
+// unpublishCRL removes the CRL from the ConfigMap of the revocation list, if any.
+func (rl *RevocationList) unpublishCRL() error {
+	rl.mu.RLock()
+	published := rl.crlSigner != nil
+	rl.mu.RUnlock()
+	if !published {
+		cm, err := rl.client.ConfigMaps(rl.namespace).Get(RevocationConfigMapName, metav1.GetOptions{})
+		if errors.IsNotFound(err) || (err == nil && cm.Data[CRLID] == "") {
+			return nil
+		}
+	}
+
+	pkiCaLog.Warnf("The CA does not sign with its own root certificate, no CRL is published in configmap %s",
+		RevocationConfigMapName)
+	err := updateRevocationConfigMap(rl.namespace, rl.client, func(cm *v1.ConfigMap) error {
+		delete(cm.Data, CRLID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove the CRL: %v", err)
+	}
+	rl.mu.Lock()
+	rl.crlSigner = nil
+	rl.mu.Unlock()
+	return nil
+}
+
+// shouldPublishCRL returns whether the CRL published should be replaced, because it is about to expire or was
+// signed by another key.
+func (rl *RevocationList) shouldPublishCRL(keyCertBundle util.KeyCertBundle) bool {
+	signer, _, _, _ := keyCertBundle.GetAllPem()
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return !bytes.Equal(signer, rl.crlSigner) || time.Since(rl.crlPublished) > rl.crlTTL/2
+}
+
+// check loads the revocation list and publishes the CRL if needed.
+func (rl *RevocationList) check(keyCertBundle util.KeyCertBundle) {
+	changed, err := rl.Load()
+	if err != nil {
+		pkiCaLog.Errorf("Failed to load the revocation list: %v", err)
+		return
+	}
+	// Without a signing key, e.g. with an external signer, the CA cannot sign a CRL. Envoy requires a CRL for
+	// every CA of the chain of a certificate once a CRL is configured, so the CRL is only published if the CA
+	// signs with the root certificate. Otherwise, certificates cannot be revoked, only identities denied.
+	if signer, _, _, root := keyCertBundle.GetAllPem(); len(signer) == 0 || !bytes.Equal(signer, root) {
+		if err := rl.unpublishCRL(); err != nil {
+			pkiCaLog.Errorf("%v", err)
+		}
+		return
+	}
+	if changed || rl.shouldPublishCRL(keyCertBundle) {
+		if err := rl.publishCRL(keyCertBundle); err != nil {
+			pkiCaLog.Errorf("%v", err)
+			return
+		}
+		pkiCaLog.Infof("Published the CRL in configmap %s in namespace %s", RevocationConfigMapName, rl.namespace)
+	}
+}
+
+// Run loads the revocation list and publishes the CRL periodically, until the stop channel is closed.
+func (rl *RevocationList) Run(keyCertBundle util.KeyCertBundle, stopCh <-chan struct{}) {
+	rl.check(keyCertBundle)
+	ticker := time.NewTicker(rl.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rl.check(keyCertBundle)
+		case <-stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestParseSerialNumber(t *testing.T) {
+	testCases := map[string]struct {
+		serial   string
+		expected int64
+		err      bool
+	}{
+		"hex":           {serial: "1a2B", expected: 0x1a2b},
+		"0x prefix":     {serial: "0x1a2b", expected: 0x1a2b},
+		"openssl":       {serial: "1A:2B", expected: 0x1a2b},
+		"spaces":        {serial: " 1a2b\n", expected: 0x1a2b},
+		"invalid":       {serial: "xyz", err: true},
+		"negative":      {serial: "-1", err: true},
+		"empty":         {serial: "", err: true},
+		"leading zeros": {serial: "00:01", expected: 1},
+	}
+	for id, tc := range testCases {
+		n, err := ParseSerialNumber(tc.serial)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error parsing %q", id, tc.serial)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+			continue
+		}
+		if n.Int64() != tc.expected {
+			t.Errorf("%s: expected %x, got %x", id, tc.expected, n)
+		}
+	}
+}
+
+func TestAddAndReadRevocationEntries(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	entries, err := ReadRevocationEntries("istio-system", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Unexpected error reading a missing revocation list: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entry, got %v", entries)
+	}
+
+	if err := AddRevocationEntries("istio-system", client.CoreV1(), RevocationEntry{}); err == nil {
+		t.Errorf("Expected an error adding an entry without serial number or identity")
+	}
+	if err := AddRevocationEntries("istio-system", client.CoreV1(),
+		RevocationEntry{SerialNumber: "1", Identity: "spiffe://cluster.local/ns/foo/sa/bar"}); err == nil {
+		t.Errorf("Expected an error adding an entry with both a serial number and an identity")
+	}
+
+	if err := AddRevocationEntries("istio-system", client.CoreV1(),
+		RevocationEntry{SerialNumber: "0A:0B", Reason: "leaked"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := AddRevocationEntries("istio-system", client.CoreV1(),
+		RevocationEntry{SerialNumber: "a0b"},
+		RevocationEntry{Identity: "spiffe://cluster.local/ns/foo/sa/bar"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err = ReadRevocationEntries("istio-system", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", entries)
+	}
+	if entries[0].SerialNumber != "a0b" || entries[0].Reason != "leaked" || entries[0].RevokedAt.IsZero() {
+		t.Errorf("Unexpected entry %+v", entries[0])
+	}
+	if entries[1].Identity != "spiffe://cluster.local/ns/foo/sa/bar" {
+		t.Errorf("Unexpected entry %+v", entries[1])
+	}
+}
+
+func TestRevocationList(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rl := NewRevocationList("istio-system", client.CoreV1(), time.Minute, 24*time.Hour)
+
+	changed, err := rl.Load()
+	if err != nil || changed {
+		t.Errorf("Expected an unchanged empty list, got changed %v and error %v", changed, err)
+	}
+
+	if err := AddRevocationEntries("istio-system", client.CoreV1(),
+		RevocationEntry{SerialNumber: "10"},
+		RevocationEntry{Identity: "spiffe://cluster.local/ns/foo/sa/bar"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if changed, err = rl.Load(); err != nil || !changed {
+		t.Errorf("Expected a changed list, got changed %v and error %v", changed, err)
+	}
+	if changed, err = rl.Load(); err != nil || changed {
+		t.Errorf("Expected an unchanged list, got changed %v and error %v", changed, err)
+	}
+
+	if !rl.IsRevoked(big.NewInt(16)) {
+		t.Errorf("Expected serial number 0x10 to be revoked")
+	}
+	if rl.IsRevoked(big.NewInt(10)) {
+		t.Errorf("Expected serial number 0xa not to be revoked")
+	}
+	if id, denied := rl.IsDenied([]string{"spiffe://cluster.local/ns/foo/sa/baz",
+		"spiffe://cluster.local/ns/foo/sa/bar"}); !denied || id != "spiffe://cluster.local/ns/foo/sa/bar" {
+		t.Errorf("Expected identity spiffe://cluster.local/ns/foo/sa/bar to be denied, got %q", id)
+	}
+	if _, denied := rl.IsDenied([]string{"spiffe://cluster.local/ns/foo/sa/baz"}); denied {
+		t.Errorf("Expected identity spiffe://cluster.local/ns/foo/sa/baz not to be denied")
+	}
+}
+
+func TestRevocationListPublishesCRL(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), false,
+		0, time.Hour, time.Hour, 30*time.Minute, time.Hour, "test.ca.Org", false,
+		util.ECDSAP256Key, "istio-system", -1, client.CoreV1(), "", false)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AddRevocationEntries("istio-system", client.CoreV1(), RevocationEntry{SerialNumber: "10"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rl := NewRevocationList("istio-system", client.CoreV1(), time.Minute, 24*time.Hour)
+	rl.check(ca.GetCAKeyCertBundle())
+	if rl.shouldPublishCRL(ca.GetCAKeyCertBundle()) {
+		t.Errorf("Expected the CRL not to be published again")
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("istio-system").Get(RevocationConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cm.Data[RevocationListID] == "" {
+		t.Errorf("Expected the revocation list to be preserved")
+	}
+	block, _ := pem.Decode([]byte(cm.Data[CRLID]))
+	if block == nil || block.Type != "X509 CRL" {
+		t.Fatalf("Expected a PEM encoded CRL, got %q", cm.Data[CRLID])
+	}
+	crl, err := x509.ParseCRL(block.Bytes)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing the CRL: %v", err)
+	}
+	signingCert, _, _, _ := ca.GetCAKeyCertBundle().GetAll()
+	if err := signingCert.CheckCRLSignature(crl); err != nil {
+		t.Errorf("Expected the CRL to be signed by the CA: %v", err)
+	}
+	revoked := crl.TBSCertList.RevokedCertificates
+	if len(revoked) != 1 || revoked[0].SerialNumber.Int64() != 16 {
+		t.Errorf("Expected serial number 0x10 to be revoked, got %v", revoked)
+	}
+	if next := crl.TBSCertList.NextUpdate; next.Sub(crl.TBSCertList.ThisUpdate) != 24*time.Hour {
+		t.Errorf("Unexpected CRL validity until %v", next)
+	}
+}
+
+func TestRevocationListDoesNotPublishCRLForIntermediateCA(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewPluggedCertIstioCAOptions("../testdata/multilevelpki/int-cert-chain.pem",
+		"../testdata/multilevelpki/int-cert.pem", "../testdata/multilevelpki/int-key.pem",
+		"../testdata/multilevelpki/root-cert.pem", time.Hour, time.Hour, "istio-system", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create a plugged-cert CA Options: %v", err)
+	}
+	if err := AddRevocationEntries("istio-system", client.CoreV1(), RevocationEntry{SerialNumber: "10"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A CRL published before the intermediate CA was plugged in
+	if err := updateRevocationConfigMap("istio-system", client.CoreV1(), func(cm *v1.ConfigMap) error {
+		cm.Data[CRLID] = "crl"
+		return nil
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rl := NewRevocationList("istio-system", client.CoreV1(), time.Minute, 24*time.Hour)
+	rl.check(caopts.KeyCertBundle)
+
+	cm, err := client.CoreV1().ConfigMaps("istio-system").Get(RevocationConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if crl, found := cm.Data[CRLID]; found {
+		t.Errorf("Expected no CRL for an intermediate CA, got %q", crl)
+	}
+	if cm.Data[RevocationListID] == "" {
+		t.Errorf("Expected the revocation list to be preserved")
+	}
+	if !rl.IsRevoked(big.NewInt(16)) {
+		t.Errorf("Expected serial number 0x10 to be revoked")
+	}
+}
+
+func TestSignDeniedIdentity(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ca, err := createCA(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca.revocationList = NewRevocationList("istio-system", client.CoreV1(), time.Minute, 24*time.Hour)
+	if err := AddRevocationEntries("istio-system", client.CoreV1(),
+		RevocationEntry{Identity: "spiffe://example.com/ns/foo/sa/bar"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ca.revocationList.Load(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	csrPEM, _, err := util.GenCSR(util.CertOptions{RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, signErr := ca.Sign(csrPEM, []string{"spiffe://example.com/ns/foo/sa/bar"}, time.Hour, false)
+	if cert != nil {
+		t.Errorf("Expected no certificate for a denied identity")
+	}
+	if signErr == nil || signErr.(*caerror.Error).ErrorType() != "IDENTITY_DENIED" {
+		t.Errorf("Expected an IDENTITY_DENIED error, got %v", signErr)
+	}
+
+	if _, signErr = ca.Sign(csrPEM, []string{"spiffe://example.com/ns/foo/sa/baz"}, time.Hour, false); signErr != nil {
+		t.Errorf("Unexpected error signing a certificate for an identity that is not denied: %v", signErr)
+	}
+}
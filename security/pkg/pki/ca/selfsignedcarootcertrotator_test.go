@@ -314,7 +314,7 @@ func getDefaultSelfSignedIstioCAOptions(client corev1.CoreV1Interface) *IstioCAO
 	caopts, _ := NewSelfSignedIstioCAOptions(context.Background(),
 		readSigningCertOnly, cmd.DefaultRootCertGracePeriodPercentile, caCertTTL,
 		rootCertCheckInverval, defaultCertTTL, maxCertTTL, org, false,
-		caNamespace, -1, client, rootCertFile, false)
+		util.RSAKey, caNamespace, -1, client, rootCertFile, false)
 	return caopts
 }
 
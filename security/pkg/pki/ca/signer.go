@@ -0,0 +1,61 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	caerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// Signer issues the certificates of an IstioCA. The IstioCA authenticates, authorizes and validates the
+// requests, the Signer only signs them. An external Signer, e.g. Vault, keeps the signing key out of Citadel.
+type Signer interface {
+	// Sign signs the CSR for the subject IDs, and returns the PEM encoded certificate and the PEM encoded chain of
+	// the CA certificates between the certificate and the root certificate, which is excluded. If forCA is true,
+	// the certificate is a CA certificate, otherwise, it is a workload certificate.
+	// Returning a *caerror.Error sets the type of the error, any other error is reported as a caerror.CertGenError.
+	Sign(csr *x509.CertificateRequest, subjectIDs []string, lifetime time.Duration, forCA bool) (
+		cert, certChain []byte, err error)
+}
+
+// keyCertBundleSigner signs certificates with the signing key of a KeyCertBundle. This is the Signer of the
+// self-signed and plugged cert CAs.
+type keyCertBundleSigner struct {
+	keyCertBundle util.KeyCertBundle
+}
+
+func (s *keyCertBundleSigner) Sign(csr *x509.CertificateRequest, subjectIDs []string, lifetime time.Duration,
+	forCA bool) ([]byte, []byte, error) {
+	signingCert, signingKey, certChain, _ := s.keyCertBundle.GetAll()
+	if signingCert == nil {
+		return nil, nil, caerror.NewError(caerror.CANotReady, fmt.Errorf("Istio CA is not ready")) // nolint
+	}
+
+	certBytes, err := util.GenCertFromCSR(csr, signingCert, csr.PublicKey, *signingKey, subjectIDs, lifetime, forCA)
+	if err != nil {
+		return nil, nil, caerror.NewError(caerror.CertGenError, err)
+	}
+
+	block := &pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: certBytes,
+	}
+	return pem.EncodeToMemory(block), certChain, nil
+}
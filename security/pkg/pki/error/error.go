@@ -28,6 +28,8 @@ const (
 	TTLError
 	// CertGenError means an error happened during the certificate generation.
 	CertGenError
+	// IdentityDenied means the identity of the certificate is denied by the revocation list of the CA.
+	IdentityDenied
 )
 
 // Error encapsulates the short and long errors.
@@ -52,6 +54,8 @@ func (e Error) ErrorType() string {
 		return "TTL_ERROR"
 	case CertGenError:
 		return "CERT_GEN_ERROR"
+	case IdentityDenied:
+		return "IDENTITY_DENIED"
 	}
 	return "UNKNOWN"
 }
@@ -67,6 +71,8 @@ func (e Error) HTTPErrorCode() codes.Code {
 		return codes.InvalidArgument
 	case TTLError:
 		return codes.InvalidArgument
+	case IdentityDenied:
+		return codes.PermissionDenied
 	}
 	return codes.Internal
 }
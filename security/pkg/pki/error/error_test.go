@@ -52,6 +52,12 @@ func TestError(t *testing.T) {
 			message: "CERT_GEN_ERROR",
 			code:    codes.Internal,
 		},
+		"IDENTITY_DENIED": {
+			eType:   IdentityDenied,
+			err:     fmt.Errorf("test error6"),
+			message: "IDENTITY_DENIED",
+			code:    codes.PermissionDenied,
+		},
 		"UNKNOWN": {
 			eType:   -1,
 			err:     fmt.Errorf("test error5"),
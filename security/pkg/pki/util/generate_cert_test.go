@@ -16,6 +16,9 @@ package util
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -291,6 +294,49 @@ func TestGenCertKeyFromOptions(t *testing.T) {
 				CommonName:  "a", // only first host used for CN
 			},
 		},
+		{
+			name: "Generate ECDSA P-256 private key",
+			certOptions: CertOptions{
+				Host:         "spiffe://domain/ns/bar/sa/foo",
+				NotBefore:    notBefore,
+				TTL:          ttl,
+				SignerCert:   caCert,
+				SignerPriv:   caPriv,
+				IsClient:     true,
+				IsServer:     true,
+				KeyAlgorithm: ECDSAP256Key,
+			},
+			verifyFields: &VerifyFields{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+				IsCA:        false,
+				KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+				NotBefore:   notBefore,
+				TTL:         ttl,
+				Org:         "MyOrg",
+			},
+		},
+		{
+			name: "Generate ECDSA P-384 private key with PKCS8",
+			certOptions: CertOptions{
+				Host:         "spiffe://domain/ns/bar/sa/foo",
+				NotBefore:    notBefore,
+				TTL:          ttl,
+				SignerCert:   caCert,
+				SignerPriv:   caPriv,
+				IsClient:     true,
+				IsServer:     true,
+				KeyAlgorithm: ECDSAP384Key,
+				PKCS8Key:     true,
+			},
+			verifyFields: &VerifyFields{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+				IsCA:        false,
+				KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+				NotBefore:   notBefore,
+				TTL:         ttl,
+				Org:         "MyOrg",
+			},
+		},
 		{
 			name: "Generate PKCS8 private key",
 			certOptions: CertOptions{
@@ -348,11 +394,16 @@ func TestGenCertFromCSR(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to generate signee key pair %v", err)
 	}
+	ecSigneeKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Errorf("failed to generate ECDSA signee key pair %v", err)
+	}
 
 	cases := []struct {
 		name        string
 		subjectIDs  []string
 		csrTemplate *x509.CertificateRequest
+		signeeKey   crypto.Signer
 	}{
 		{
 			name:       "Single subject ID",
@@ -382,10 +433,24 @@ func TestGenCertFromCSR(t *testing.T) {
 				Version:            3,
 			},
 		},
+		{
+			name:       "ECDSA CSR signed by RSA CA",
+			subjectIDs: []string{"spiffe://test.com/abc/def"},
+			csrTemplate: &x509.CertificateRequest{
+				SignatureAlgorithm: x509.ECDSAWithSHA256,
+				DNSNames:           []string{"name_in_csr"},
+				Version:            3,
+			},
+			signeeKey: ecSigneeKey,
+		},
 	}
 
 	for _, c := range cases {
-		derBytes, err := x509.CreateCertificateRequest(rand.Reader, c.csrTemplate, signeeKey)
+		var key crypto.Signer = signeeKey
+		if c.signeeKey != nil {
+			key = c.signeeKey
+		}
+		derBytes, err := x509.CreateCertificateRequest(rand.Reader, c.csrTemplate, key)
 		if err != nil {
 			t.Error("failed to create certificate request")
 		}
@@ -393,7 +458,7 @@ func TestGenCertFromCSR(t *testing.T) {
 		if err != nil {
 			t.Errorf("failed to parse certificate request %v", err)
 		}
-		derBytes, err = GenCertFromCSR(csr, signingCert, &signeeKey.PublicKey, *signingKey, c.subjectIDs, time.Hour, false)
+		derBytes, err = GenCertFromCSR(csr, signingCert, key.Public(), *signingKey, c.subjectIDs, time.Hour, false)
 		if err != nil {
 			t.Errorf("failed to GenCertFromCSR, error %v", err)
 		}
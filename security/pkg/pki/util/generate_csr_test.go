@@ -54,6 +54,38 @@ func TestGenCSR(t *testing.T) {
 	}
 }
 
+func TestGenCSRWithECDSAKey(t *testing.T) {
+	csrOptions := CertOptions{
+		Host:         "spiffe://cluster.local/ns/bar/sa/foo",
+		Org:          "MyOrg",
+		KeyAlgorithm: ECDSAP256Key,
+	}
+
+	csrPem, keyPem, err := GenCSR(csrOptions)
+	if err != nil {
+		t.Fatalf("failed to gen CSR: %v", err)
+	}
+
+	csr, err := ParsePemEncodedCSR(csrPem)
+	if err != nil {
+		t.Fatalf("failed to parse csr: %v", err)
+	}
+	if err = csr.CheckSignature(); err != nil {
+		t.Errorf("csr signature is invalid: %v", err)
+	}
+	if csr.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("unexpected public key algorithm: want %v but got %v", x509.ECDSA, csr.PublicKeyAlgorithm)
+	}
+
+	priv, err := ParsePemEncodedKey(keyPem)
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	if alg, _, err := GetKeyAlgorithm(priv); err != nil || alg != ECDSAP256Key {
+		t.Errorf("unexpected key algorithm: want %v but got %v (%v)", ECDSAP256Key, alg, err)
+	}
+}
+
 func TestGenCSRWithInvalidOption(t *testing.T) {
 	// Options with invalid Key size.
 	csrOptions := CertOptions{
@@ -67,6 +99,19 @@ func TestGenCSRWithInvalidOption(t *testing.T) {
 	if err == nil || csr != nil || priv != nil {
 		t.Errorf("Should have failed")
 	}
+
+	// Options with an unsupported key algorithm.
+	csrOptions = CertOptions{
+		Host:         "test_ca.com",
+		Org:          "MyOrg",
+		KeyAlgorithm: "DSA",
+	}
+
+	csr, priv, err = GenCSR(csrOptions)
+
+	if err == nil || csr != nil || priv != nil {
+		t.Errorf("Should have failed")
+	}
 }
 
 func TestGenCSRTemplateForDualUse(t *testing.T) {
@@ -0,0 +1,86 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"strings"
+)
+
+// KeyAlgorithm is the algorithm used to generate a private key.
+type KeyAlgorithm string
+
+const (
+	// RSAKey generates an RSA key of CertOptions.RSAKeySize bits. It is the default.
+	RSAKey KeyAlgorithm = "RSA"
+	// ECDSAP256Key generates an ECDSA key on the NIST P-256 curve.
+	ECDSAP256Key KeyAlgorithm = "ECDSA-P256"
+	// ECDSAP384Key generates an ECDSA key on the NIST P-384 curve.
+	ECDSAP384Key KeyAlgorithm = "ECDSA-P384"
+)
+
+// SupportedKeyAlgorithms lists the key algorithms that can be used to generate keys.
+var SupportedKeyAlgorithms = []KeyAlgorithm{RSAKey, ECDSAP256Key, ECDSAP384Key}
+
+// ParseKeyAlgorithm returns the KeyAlgorithm with the given (case insensitive) name. An empty name
+// selects RSA.
+func ParseKeyAlgorithm(name string) (KeyAlgorithm, error) {
+	if name == "" {
+		return RSAKey, nil
+	}
+	for _, alg := range SupportedKeyAlgorithms {
+		if strings.EqualFold(name, string(alg)) {
+			return alg, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported key algorithm %q, must be one of %v", name, SupportedKeyAlgorithms)
+}
+
+// generateKey generates a private key using the algorithm in the options.
+func generateKey(options CertOptions) (crypto.PrivateKey, error) {
+	switch options.KeyAlgorithm {
+	case "", RSAKey:
+		return rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	case ECDSAP256Key:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ECDSAP384Key:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", options.KeyAlgorithm)
+	}
+}
+
+// GetKeyAlgorithm returns the algorithm of an existing private key, along with its size if it is an RSA key.
+func GetKeyAlgorithm(privKey crypto.PrivateKey) (KeyAlgorithm, int, error) {
+	switch k := privKey.(type) {
+	case *rsa.PrivateKey:
+		return RSAKey, k.N.BitLen(), nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return ECDSAP256Key, 0, nil
+		case elliptic.P384():
+			return ECDSAP384Key, 0, nil
+		}
+		return "", 0, fmt.Errorf("unsupported ECDSA curve: %s", k.Curve.Params().Name)
+	default:
+		return "", 0, fmt.Errorf("unsupported key type: %T", privKey)
+	}
+}
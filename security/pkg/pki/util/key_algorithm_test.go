@@ -0,0 +1,93 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestParseKeyAlgorithm(t *testing.T) {
+	testCases := map[string]struct {
+		name        string
+		expected    KeyAlgorithm
+		expectedErr bool
+	}{
+		"Empty defaults to RSA": {
+			name:     "",
+			expected: RSAKey,
+		},
+		"RSA": {
+			name:     "RSA",
+			expected: RSAKey,
+		},
+		"ECDSA P-256": {
+			name:     "ECDSA-P256",
+			expected: ECDSAP256Key,
+		},
+		"ECDSA P-384 case insensitive": {
+			name:     "ecdsa-p384",
+			expected: ECDSAP384Key,
+		},
+		"Unsupported": {
+			name:        "DSA",
+			expectedErr: true,
+		},
+	}
+
+	for id, tc := range testCases {
+		alg, err := ParseKeyAlgorithm(tc.name)
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected error but got none", id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", id, err)
+		} else if alg != tc.expected {
+			t.Errorf("%s: unexpected key algorithm: want %v but got %v", id, tc.expected, alg)
+		}
+	}
+}
+
+func TestGetKeyAlgorithm(t *testing.T) {
+	for _, alg := range SupportedKeyAlgorithms {
+		priv, err := generateKey(CertOptions{KeyAlgorithm: alg, RSAKeySize: 1024})
+		if err != nil {
+			t.Fatalf("%s: failed to generate key: %v", alg, err)
+		}
+		got, size, err := GetKeyAlgorithm(priv)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", alg, err)
+		}
+		if got != alg {
+			t.Errorf("unexpected key algorithm: want %v but got %v", alg, got)
+		}
+		if alg == RSAKey && size != 1024 {
+			t.Errorf("unexpected RSA key size: want 1024 but got %d", size)
+		}
+	}
+
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if _, _, err := GetKeyAlgorithm(p224); err == nil {
+		t.Error("expected an error for an unsupported curve")
+	}
+}
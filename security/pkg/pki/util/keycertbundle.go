@@ -117,6 +117,42 @@ func NewKeyCertBundleWithRootCertFromFile(rootCertFile string) (*KeyCertBundleIm
 	}, nil
 }
 
+// NewVerifiedCertChainBundleFromPem returns a new KeyCertBundle with the cert chain and the root cert, but without
+// the signing key/cert, for a CA whose signing key is held by an external signer. It returns an error if the first
+// cert of the chain cannot be verified from the root cert through the rest of the chain.
+func NewVerifiedCertChainBundleFromPem(certChainBytes, rootCertBytes []byte) (*KeyCertBundleImpl, error) {
+	if len(certChainBytes) > 0 {
+		rcp := x509.NewCertPool()
+		if !rcp.AppendCertsFromPEM(rootCertBytes) {
+			return nil, fmt.Errorf("failed to parse root cert PEM")
+		}
+		icp := x509.NewCertPool()
+		icp.AppendCertsFromPEM(certChainBytes)
+		cert, err := ParsePemEncodedCertificate(certChainBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cert chain PEM: %v", err)
+		}
+		opts := x509.VerifyOptions{
+			Intermediates: icp,
+			Roots:         rcp,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		if _, err := cert.Verify(opts); err != nil {
+			return nil, fmt.Errorf("cannot verify the cert chain with the provided root cert with error: %v", err)
+		}
+	} else if _, err := ParsePemEncodedCertificate(rootCertBytes); err != nil {
+		return nil, fmt.Errorf("failed to parse root cert PEM: %v", err)
+	}
+	return &KeyCertBundleImpl{
+		certBytes:      []byte{},
+		cert:           nil,
+		privKeyBytes:   []byte{},
+		privKey:        nil,
+		certChainBytes: copyBytes(certChainBytes),
+		rootCertBytes:  copyBytes(rootCertBytes),
+	}, nil
+}
+
 // GetAllPem returns all key/cert PEMs in KeyCertBundle together. Getting all values together avoids inconsistency.
 func (b *KeyCertBundleImpl) GetAllPem() (certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) {
 	b.mutex.RLock()
@@ -186,17 +222,18 @@ func (b *KeyCertBundleImpl) CertOptions() (*CertOptions, error) {
 	if len(ids) != 1 {
 		return nil, fmt.Errorf("expect single id from the cert, found %v", ids)
 	}
-	size, err := GetRSAKeySize(*b.privKey)
+	alg, size, err := GetKeyAlgorithm(*b.privKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get RSA key size: %v", err)
+		return nil, fmt.Errorf("failed to get key algorithm: %v", err)
 	}
 	return &CertOptions{
-		Host:       ids[0],
-		Org:        b.cert.Issuer.Organization[0],
-		IsCA:       b.cert.IsCA,
-		TTL:        b.cert.NotAfter.Sub(b.cert.NotBefore),
-		RSAKeySize: size,
-		IsDualUse:  ids[0] == b.cert.Subject.CommonName,
+		Host:         ids[0],
+		Org:          b.cert.Issuer.Organization[0],
+		IsCA:         b.cert.IsCA,
+		TTL:          b.cert.NotAfter.Sub(b.cert.NotBefore),
+		RSAKeySize:   size,
+		KeyAlgorithm: alg,
+		IsDualUse:    ids[0] == b.cert.Subject.CommonName,
 	}, nil
 }
 
@@ -15,6 +15,7 @@
 package util
 
 import (
+	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
@@ -303,3 +304,72 @@ func TestNewVerifiedKeyCertBundleFromFile(t *testing.T) {
 		}
 	}
 }
+
+func TestNewVerifiedCertChainBundleFromPem(t *testing.T) {
+	testCases := map[string]struct {
+		certChainFiles []string
+		rootCertFile   string
+		expectedErr    string
+	}{
+		"Success - 3 level CA": {
+			certChainFiles: []string{int2CertFile, intCertFile},
+			rootCertFile:   rootCertFile,
+		},
+		"Success - root CA without cert chain": {
+			rootCertFile: rootCertFile,
+		},
+		"Failure - incomplete cert chain": {
+			certChainFiles: []string{int2CertFile},
+			rootCertFile:   rootCertFile,
+			expectedErr: "cannot verify the cert chain with the provided root cert with error: " +
+				"x509: certificate signed by unknown authority",
+		},
+		"Failure - invalid cert chain": {
+			certChainFiles: []string{badCertFile},
+			rootCertFile:   rootCertFile,
+			expectedErr:    "failed to parse cert chain PEM: invalid PEM encoded certificate",
+		},
+		"Failure - invalid root cert": {
+			rootCertFile: badCertFile,
+			expectedErr:  "failed to parse root cert PEM: invalid PEM encoded certificate",
+		},
+	}
+	for id, tc := range testCases {
+		var certChain []byte
+		for _, f := range tc.certChainFiles {
+			b, err := ioutil.ReadFile(f)
+			if err != nil {
+				t.Fatalf("%s: failed to read %s: %v", id, f, err)
+			}
+			certChain = append(certChain, b...)
+		}
+		rootCert, err := ioutil.ReadFile(tc.rootCertFile)
+		if err != nil {
+			t.Fatalf("%s: failed to read %s: %v", id, tc.rootCertFile, err)
+		}
+
+		bundle, err := NewVerifiedCertChainBundleFromPem(certChain, rootCert)
+		if err != nil {
+			if tc.expectedErr == "" {
+				t.Errorf("%s: Unexpected error: %v", id, err)
+			} else if strings.Compare(err.Error(), tc.expectedErr) != 0 {
+				t.Errorf("%s: Unexpected error: %v VS (expected) %s", id, err, tc.expectedErr)
+			}
+			continue
+		} else if tc.expectedErr != "" {
+			t.Errorf("%s: Expected error %s but succeeded", id, tc.expectedErr)
+			continue
+		}
+
+		cert, key, chain, root := bundle.GetAll()
+		if cert != nil || key != nil {
+			t.Errorf("%s: the bundle should not have a signing key/cert", id)
+		}
+		if string(chain) != string(certChain) {
+			t.Errorf("%s: unexpected cert chain %q", id, chain)
+		}
+		if string(root) != string(rootCert) {
+			t.Errorf("%s: unexpected root cert %q", id, root)
+		}
+	}
+}
@@ -31,8 +31,9 @@ function show_difference() {
 function refresh_reference() {
     local NAME=$1
     local ACTUAL=$2
+    local GOLDEN_FILE=$3
 
-    echo "${ACTUAL}" > "${SCRIPT_DIR}/testdata/${NAME}_golden.txt"
+    echo "${ACTUAL}" > "${GOLDEN_FILE}"
     echo "golden file for test ${NAME} updated"
 }
 
@@ -54,6 +55,7 @@ function compareWithGolden() {
   local PARAMS="${3:-}"
   local ACTUAL_OUTPUT
   local FILE_UNDER_TEST
+  local GOLDEN_FILE="${SCRIPT_DIR}/testdata/${TEST_NAME}_golden.txt"
 
   case "${TEST_MODE}" in
    "script")
@@ -61,6 +63,8 @@ function compareWithGolden() {
    ;;
    "golang")
     FILE_UNDER_TEST="${ISTIO_OUT}/istio-iptables --dry-run"
+    # The dry run also prints the resulting iptables-restore input
+    GOLDEN_FILE="${SCRIPT_DIR}/testdata/golang/${TEST_NAME}_golden.txt"
    ;;
    "script_clean")
     FILE_UNDER_TEST="${SCRIPT_DIR}/../../tools/packaging/common/istio-clean-iptables.sh"
@@ -78,9 +82,9 @@ function compareWithGolden() {
   ACTUAL_OUTPUT="$(${FILE_UNDER_TEST} ${PARAMS} 2>/dev/null)"
 
   if [[ "x${REFRESH_GOLDEN:-false}x" = "xtruex" ]] ; then
-    refresh_reference "${TEST_NAME}" "${ACTUAL_OUTPUT}"
+    refresh_reference "${TEST_NAME}" "${ACTUAL_OUTPUT}" "${GOLDEN_FILE}"
   else
-    EXPECTED_OUTPUT=$(cat "${SCRIPT_DIR}/testdata/${TEST_NAME}_golden.txt")
+    EXPECTED_OUTPUT=$(cat "${GOLDEN_FILE}")
     if assert_equals "${TEST_NAME}" "${ACTUAL_OUTPUT}" "${EXPECTED_OUTPUT}"; then
       echo -e "ok\tistio.io/$0/${TEST_NAME} (${TEST_MODE})\t0.000s"
     else
@@ -98,10 +102,7 @@ TEST_MODES=( "$@" )
 SCRIPT_NAME=$0
 SCRIPT_DIR=$(dirname "$SCRIPT_NAME")
 if [[ ${#TEST_MODES[@]} -eq 0 ]] ; then
-    TEST_MODES+=("script")
-    if [[ "x${REFRESH_GOLDEN:-false}x" != "xtruex" ]] ; then
-        TEST_MODES+=("golang")
-    fi
+    TEST_MODES+=("script" "golang")
 fi
 export PATH="${SCRIPT_DIR}/stubs:${PATH}"
 
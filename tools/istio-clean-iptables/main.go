@@ -18,6 +18,7 @@ import (
 	"flag"
 	"os"
 
+	"istio.io/istio/tools/istio-iptables/pkg/builder"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
 
 	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
@@ -46,9 +47,19 @@ func removeOldChains(ext dep.Dependencies, cmd dep.Cmd) {
 	ext.RunQuietlyAndIgnore(cmd, "-t", constants.NAT, "-X", constants.ISTIOINREDIRECT)
 }
 
+func removeNftablesTables(ext dep.Dependencies) {
+	// The rules of each iptables table are held by a dedicated nftables table
+	for _, family := range []string{constants.NFTIPV4, constants.NFTIPV6} {
+		for _, table := range []string{constants.NAT, constants.MANGLE, constants.FILTER} {
+			ext.RunQuietlyAndIgnore(dep.NFT, "delete", "table", family, builder.NftablesTableName(table))
+		}
+	}
+}
+
 func run(args []string, flagSet *flag.FlagSet) {
-	var dryRun bool
+	var dryRun, nftables bool
 	flagSet.BoolVar(&dryRun, "dryRun", false, "Do not call any external dependencies like ipcmd")
+	flagSet.BoolVar(&nftables, "nftables", false, "Remove the rules programmed with nftables rather than with iptables")
 	err := flagSet.Parse(args)
 	if err != nil {
 		return
@@ -61,6 +72,12 @@ func run(args []string, flagSet *flag.FlagSet) {
 		ext = &dep.RealDependencies{}
 	}
 
+	if nftables {
+		defer ext.RunOrFail(dep.NFT, "list", "ruleset")
+		removeNftablesTables(ext)
+		return
+	}
+
 	defer func() {
 		for _, cmd := range []dep.Cmd{dep.IPTABLESSAVE, dep.IP6TABLESSAVE} {
 			ext.RunOrFail(cmd)
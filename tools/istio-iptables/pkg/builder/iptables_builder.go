@@ -43,3 +43,15 @@ type IptablesBuilder interface {
 	IptablesProducer
 	IptablesConsumer
 }
+
+// NftablesConsumer is an interface for constructing an nft ruleset from the rules
+type NftablesConsumer interface {
+	// BuildNftables creates the nft ruleset, to be applied atomically with nft -f
+	BuildNftables() (string, error)
+}
+
+// NftablesBuilder is a higher level interface based on builder pattern, rendering the rules with nftables.
+type NftablesBuilder interface {
+	IptablesProducer
+	NftablesConsumer
+}
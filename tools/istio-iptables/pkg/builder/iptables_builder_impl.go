@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
 	"istio.io/istio/tools/istio-iptables/pkg/dependencies"
 )
 
@@ -105,12 +106,60 @@ func (rb *IptablesBuilderImpl) BuildV6() []string {
 	return rules
 }
 
+// isBuiltinChain returns true if chain is one of the chains iptables tables are created with
+func isBuiltinChain(chain string) bool {
+	switch chain {
+	case constants.PREROUTING, constants.INPUT, constants.FORWARD, constants.OUTPUT, constants.POSTROUTING:
+		return true
+	}
+	return false
+}
+
+// buildRestore creates the iptables-restore input of rules, grouped by table. The user defined chains are
+// declared at the beginning of their table. The input is meant to be applied with --noflush, so that the
+// rules are added to the built-in chains rather than replacing them.
+func buildRestore(rules []*Rule) string {
+	var tables []string
+	chains := map[string][]string{}
+	tableRules := map[string][]string{}
+	for _, r := range rules {
+		if _, f := tableRules[r.table]; !f {
+			tables = append(tables, r.table)
+		}
+		tableRules[r.table] = append(tableRules[r.table], strings.Join(r.params, " "))
+		if isBuiltinChain(r.chain) {
+			continue
+		}
+		declared := false
+		for _, c := range chains[r.table] {
+			if c == r.chain {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			chains[r.table] = append(chains[r.table], r.chain)
+		}
+	}
+
+	var b strings.Builder
+	for _, table := range tables {
+		fmt.Fprintf(&b, "*%s\n", table)
+		for _, chain := range chains[table] {
+			fmt.Fprintf(&b, ":%s - [0:0]\n", chain)
+		}
+		for _, rule := range tableRules[table] {
+			fmt.Fprintln(&b, rule)
+		}
+		fmt.Fprintln(&b, "COMMIT")
+	}
+	return b.String()
+}
+
 func (rb *IptablesBuilderImpl) BuildV4Restore() string {
-	//TODO (abhide): implement this
-	return ""
+	return buildRestore(rb.rules.rulesv4)
 }
 
 func (rb *IptablesBuilderImpl) BuildV6Restore() string {
-	//TODO (abhide): implement this
-	return ""
+	return buildRestore(rb.rules.rulesv6)
 }
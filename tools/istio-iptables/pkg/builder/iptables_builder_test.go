@@ -19,7 +19,6 @@ import (
 	"testing"
 )
 
-//TODO(abhide): Add more testcases once BuildV6Restore() are implemented
 func TestBuildV6Restore(t *testing.T) {
 	iptables := NewIptablesBuilder()
 	expected := ""
@@ -29,7 +28,6 @@ func TestBuildV6Restore(t *testing.T) {
 	}
 }
 
-//TODO(abhide): Add more testcases once BuildV4Restore() are implemented
 func TestBuildV4Restore(t *testing.T) {
 	iptables := NewIptablesBuilder()
 	expected := ""
@@ -39,6 +37,41 @@ func TestBuildV4Restore(t *testing.T) {
 	}
 }
 
+func TestBuildV4RestoreRules(t *testing.T) {
+	iptables := NewIptablesBuilder()
+	iptables.AppendRuleV4("ISTIO_REDIRECT", "nat", "-p", "tcp", "-j", "REDIRECT", "--to-port", "15001")
+	iptables.AppendRuleV4("OUTPUT", "nat", "-p", "tcp", "-j", "ISTIO_OUTPUT")
+	iptables.AppendRuleV4("ISTIO_OUTPUT", "nat", "-j", "ISTIO_REDIRECT")
+	iptables.InsertRuleV4("PREROUTING", "nat", 1, "-i", "eth1", "-j", "RETURN")
+	iptables.AppendRuleV4("ISTIO_DIVERT", "mangle", "-j", "ACCEPT")
+	iptables.AppendRuleV6("INPUT", "filter", "-j", "REJECT")
+	actual := iptables.BuildV4Restore()
+	expected := `*nat
+:ISTIO_REDIRECT - [0:0]
+:ISTIO_OUTPUT - [0:0]
+-A ISTIO_REDIRECT -p tcp -j REDIRECT --to-port 15001
+-A OUTPUT -p tcp -j ISTIO_OUTPUT
+-A ISTIO_OUTPUT -j ISTIO_REDIRECT
+-I PREROUTING 1 -i eth1 -j RETURN
+COMMIT
+*mangle
+:ISTIO_DIVERT - [0:0]
+-A ISTIO_DIVERT -j ACCEPT
+COMMIT
+`
+	if expected != actual {
+		t.Errorf("Output didn't match: Got: %s, Expected: %s", actual, expected)
+	}
+	actual = iptables.BuildV6Restore()
+	expected = `*filter
+-A INPUT -j REJECT
+COMMIT
+`
+	if expected != actual {
+		t.Errorf("Output didn't match: Got: %s, Expected: %s", actual, expected)
+	}
+}
+
 func TestBuildV4InsertSingleRule(t *testing.T) {
 	iptables := NewIptablesBuilder()
 	iptables.InsertRuleV4("chain", "table", 2, "-f", "foo", "-b", "bar")
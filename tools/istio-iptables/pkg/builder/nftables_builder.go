@@ -0,0 +1,262 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+)
+
+// nftChain represents a chain of an nftables table and its rules, in nft syntax
+type nftChain struct {
+	name  string
+	rules []string
+}
+
+// nftTable represents the nftables table holding the rules of an iptables table for a family
+type nftTable struct {
+	family string
+	// table is the name of the iptables table
+	table  string
+	chains []*nftChain
+}
+
+// NftablesBuilderImpl is an implementation for IptablesProducer, rendering the rules as a single nft ruleset.
+// Rules are given as iptables parameters and translated to nft syntax. The rules of each iptables table are
+// held by a dedicated nftables table, so that they can be replaced or removed as a whole.
+type NftablesBuilderImpl struct {
+	tables []*nftTable
+	// err is the first error met translating the rules
+	err error
+}
+
+// NewNftablesBuilder creates a new NftablesBuilderImpl
+func NewNftablesBuilder() *NftablesBuilderImpl {
+	return &NftablesBuilderImpl{}
+}
+
+// NftablesTableName returns the name of the nftables table holding the rules of an iptables table
+func NftablesTableName(table string) string {
+	return constants.NFTTABLEPREFIX + table
+}
+
+func (nb *NftablesBuilderImpl) InsertRuleV4(chain string, table string, position int, params ...string) IptablesProducer {
+	nb.insertRule(constants.NFTIPV4, chain, table, position, params)
+	return nb
+}
+
+func (nb *NftablesBuilderImpl) InsertRuleV6(chain string, table string, position int, params ...string) IptablesProducer {
+	nb.insertRule(constants.NFTIPV6, chain, table, position, params)
+	return nb
+}
+
+func (nb *NftablesBuilderImpl) AppendRuleV4(chain string, table string, params ...string) IptablesProducer {
+	nb.insertRule(constants.NFTIPV4, chain, table, -1, params)
+	return nb
+}
+
+func (nb *NftablesBuilderImpl) AppendRuleV6(chain string, table string, params ...string) IptablesProducer {
+	nb.insertRule(constants.NFTIPV6, chain, table, -1, params)
+	return nb
+}
+
+// insertRule inserts a rule at a position of the chain, counted from 1 as with iptables. The rule is appended
+// if the position is -1.
+func (nb *NftablesBuilderImpl) insertRule(family string, chain string, table string, position int, params []string) {
+	t := nb.table(family, table)
+	rule, jump, err := translateRule(family, params)
+	if err != nil {
+		if nb.err == nil {
+			nb.err = fmt.Errorf("unable to translate rule %q of chain %s: %v", strings.Join(params, " "), chain, err)
+		}
+		return
+	}
+	c := t.chain(chain)
+	if jump != "" {
+		t.chain(jump)
+	}
+	if position < 1 || position > len(c.rules) {
+		c.rules = append(c.rules, rule)
+		return
+	}
+	c.rules = append(c.rules, "")
+	copy(c.rules[position:], c.rules[position-1:])
+	c.rules[position-1] = rule
+}
+
+func (nb *NftablesBuilderImpl) table(family string, table string) *nftTable {
+	for _, t := range nb.tables {
+		if t.family == family && t.table == table {
+			return t
+		}
+	}
+	t := &nftTable{family: family, table: table}
+	nb.tables = append(nb.tables, t)
+	return t
+}
+
+func (t *nftTable) chain(name string) *nftChain {
+	for _, c := range t.chains {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &nftChain{name: name}
+	t.chains = append(t.chains, c)
+	return c
+}
+
+// baseChain returns the nft type, hook and priority of a built-in iptables chain, or an empty string for a
+// user defined chain. Priorities are the ones of the iptables tables, so that the rules are evaluated in the
+// same order with respect to other iptables or nftables rules.
+func baseChain(table string, chain string) string {
+	var typ, priority string
+	switch table {
+	case constants.NAT:
+		typ, priority = "nat", "-100"
+		if chain == constants.POSTROUTING || chain == constants.INPUT {
+			priority = "100"
+		}
+	case constants.MANGLE:
+		typ, priority = "filter", "-150"
+	case constants.FILTER:
+		typ, priority = "filter", "0"
+	default:
+		return ""
+	}
+	if !isBuiltinChain(chain) {
+		return ""
+	}
+	return fmt.Sprintf("type %s hook %s priority %s; policy accept;", typ, strings.ToLower(chain), priority)
+}
+
+// BuildNftables creates the nft ruleset, to be applied atomically with nft -f. Each table is deleted
+// and created again, so that applying the ruleset again replaces the rules rather than adding them twice.
+func (nb *NftablesBuilderImpl) BuildNftables() (string, error) {
+	if nb.err != nil {
+		return "", nb.err
+	}
+	var b strings.Builder
+	for _, t := range nb.tables {
+		name := fmt.Sprintf("%s %s", t.family, NftablesTableName(t.table))
+		// Adding the table first makes the deletion succeed if it does not exist yet
+		fmt.Fprintf(&b, "add table %s\n", name)
+		fmt.Fprintf(&b, "delete table %s\n", name)
+		fmt.Fprintf(&b, "add table %s\n", name)
+		// Chains are created before the rules, which may jump to any of them
+		for _, c := range t.chains {
+			if base := baseChain(t.table, c.name); base != "" {
+				fmt.Fprintf(&b, "add chain %s %s { %s }\n", name, c.name, base)
+			} else {
+				fmt.Fprintf(&b, "add chain %s %s\n", name, c.name)
+			}
+		}
+		for _, c := range t.chains {
+			for _, r := range c.rules {
+				fmt.Fprintf(&b, "add rule %s %s %s\n", name, c.name, r)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// translateRule translates the iptables parameters of a rule to nft syntax. It also returns the chain the
+// rule jumps to, if any.
+func translateRule(family string, params []string) (string, string, error) {
+	var exprs []string
+	var target, jump, mark, port string
+	negate := false
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+		if param == "!" {
+			negate = true
+			continue
+		}
+		if i+1 >= len(params) {
+			return "", "", fmt.Errorf("missing value for %s", param)
+		}
+		i++
+		value := params[i]
+		op := ""
+		if negate {
+			op = "!= "
+			negate = false
+		}
+		switch param {
+		case "-p":
+			// The protocol is matched by the port, if any
+			if i+1 < len(params) && params[i+1] == "--dport" {
+				if i+2 >= len(params) {
+					return "", "", fmt.Errorf("missing value for --dport")
+				}
+				exprs = append(exprs, fmt.Sprintf("%s dport %s%s", value, op, params[i+2]))
+				i += 2
+				continue
+			}
+			exprs = append(exprs, fmt.Sprintf("meta l4proto %s%s", op, value))
+		case "-s":
+			exprs = append(exprs, fmt.Sprintf("%s saddr %s%s", family, op, value))
+		case "-d":
+			exprs = append(exprs, fmt.Sprintf("%s daddr %s%s", family, op, value))
+		case "-i":
+			exprs = append(exprs, fmt.Sprintf("iifname %s%q", op, value))
+		case "-o":
+			exprs = append(exprs, fmt.Sprintf("oifname %s%q", op, value))
+		case "-m":
+			switch value {
+			case "owner", "state":
+				// Matched by the options of the module
+			case "socket":
+				exprs = append(exprs, "socket transparent 1")
+			default:
+				return "", "", fmt.Errorf("unsupported match %s", value)
+			}
+		case "--uid-owner":
+			exprs = append(exprs, fmt.Sprintf("meta skuid %s%s", op, value))
+		case "--gid-owner":
+			exprs = append(exprs, fmt.Sprintf("meta skgid %s%s", op, value))
+		case "--state":
+			exprs = append(exprs, fmt.Sprintf("ct state %s%s", op, strings.ToLower(value)))
+		case "-j":
+			target = value
+		case "--set-mark", "--tproxy-mark":
+			// Only the full mask is used by istio
+			mark = strings.Split(value, "/")[0]
+		case "--to-port", "--on-port":
+			port = value
+		default:
+			return "", "", fmt.Errorf("unsupported parameter %s", param)
+		}
+	}
+
+	switch target {
+	case constants.RETURN, constants.ACCEPT, constants.REJECT:
+		exprs = append(exprs, strings.ToLower(target))
+	case constants.MARK:
+		exprs = append(exprs, "meta mark set "+mark)
+	case constants.REDIRECT:
+		exprs = append(exprs, "redirect to :"+port)
+	case constants.TPROXY:
+		exprs = append(exprs, fmt.Sprintf("meta mark set %s tproxy to :%s accept", mark, port))
+	case "":
+		return "", "", fmt.Errorf("missing target")
+	default:
+		exprs = append(exprs, "jump "+target)
+		jump = target
+	}
+	return strings.Join(exprs, " "), jump, nil
+}
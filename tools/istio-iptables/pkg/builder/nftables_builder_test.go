@@ -0,0 +1,148 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildNftablesEmpty(t *testing.T) {
+	nftables := NewNftablesBuilder()
+	actual, err := nftables.BuildNftables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != "" {
+		t.Errorf("Expected an empty ruleset; but instead got Actual: %q", actual)
+	}
+}
+
+func TestBuildNftablesRedirect(t *testing.T) {
+	nftables := NewNftablesBuilder()
+	nftables.AppendRuleV4("ISTIO_REDIRECT", "nat", "-p", "tcp", "-j", "REDIRECT", "--to-port", "15001")
+	nftables.AppendRuleV4("PREROUTING", "nat", "-p", "tcp", "-j", "ISTIO_INBOUND")
+	nftables.AppendRuleV4("ISTIO_INBOUND", "nat", "-p", "tcp", "--dport", "22", "-j", "RETURN")
+	nftables.AppendRuleV4("OUTPUT", "nat", "-p", "tcp", "-j", "ISTIO_OUTPUT")
+	nftables.AppendRuleV4("ISTIO_OUTPUT", "nat", "-o", "lo", "!", "-d", "127.0.0.1/32", "-j", "ISTIO_REDIRECT")
+	nftables.AppendRuleV4("ISTIO_OUTPUT", "nat", "-m", "owner", "--uid-owner", "1337", "-j", "RETURN")
+	nftables.AppendRuleV4("ISTIO_OUTPUT", "nat", "-m", "owner", "--gid-owner", "1337", "-j", "RETURN")
+	nftables.AppendRuleV4("ISTIO_OUTPUT", "nat", "-d", "10.0.0.0/8", "-j", "RETURN")
+	nftables.InsertRuleV4("PREROUTING", "nat", 1, "-i", "eth1", "-j", "RETURN")
+	nftables.AppendRuleV6("INPUT", "filter", "-m", "state", "--state", "ESTABLISHED", "-j", "ACCEPT")
+	nftables.AppendRuleV6("INPUT", "filter", "-j", "REJECT")
+
+	actual, err := nftables.BuildNftables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"add table ip istio_nat",
+		"delete table ip istio_nat",
+		"add table ip istio_nat",
+		"add chain ip istio_nat ISTIO_REDIRECT",
+		"add chain ip istio_nat PREROUTING { type nat hook prerouting priority -100; policy accept; }",
+		"add chain ip istio_nat ISTIO_INBOUND",
+		"add chain ip istio_nat OUTPUT { type nat hook output priority -100; policy accept; }",
+		"add chain ip istio_nat ISTIO_OUTPUT",
+		"add rule ip istio_nat ISTIO_REDIRECT meta l4proto tcp redirect to :15001",
+		"add rule ip istio_nat PREROUTING iifname \"eth1\" return",
+		"add rule ip istio_nat PREROUTING meta l4proto tcp jump ISTIO_INBOUND",
+		"add rule ip istio_nat ISTIO_INBOUND tcp dport 22 return",
+		"add rule ip istio_nat OUTPUT meta l4proto tcp jump ISTIO_OUTPUT",
+		"add rule ip istio_nat ISTIO_OUTPUT oifname \"lo\" ip daddr != 127.0.0.1/32 jump ISTIO_REDIRECT",
+		"add rule ip istio_nat ISTIO_OUTPUT meta skuid 1337 return",
+		"add rule ip istio_nat ISTIO_OUTPUT meta skgid 1337 return",
+		"add rule ip istio_nat ISTIO_OUTPUT ip daddr 10.0.0.0/8 return",
+		"add table ip6 istio_filter",
+		"delete table ip6 istio_filter",
+		"add table ip6 istio_filter",
+		"add chain ip6 istio_filter INPUT { type filter hook input priority 0; policy accept; }",
+		"add rule ip6 istio_filter INPUT ct state established accept",
+		"add rule ip6 istio_filter INPUT reject",
+	}
+	if actual != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("Actual and expected output mismatch; but instead got Actual:\n%s\nExpected:\n%s", actual, strings.Join(expected, "\n"))
+	}
+}
+
+func TestBuildNftablesTProxy(t *testing.T) {
+	nftables := NewNftablesBuilder()
+	nftables.AppendRuleV4("ISTIO_DIVERT", "mangle", "-j", "MARK", "--set-mark", "1337")
+	nftables.AppendRuleV4("ISTIO_DIVERT", "mangle", "-j", "ACCEPT")
+	nftables.AppendRuleV4("ISTIO_TPROXY", "mangle", "!", "-d", "127.0.0.1/32", "-p", "tcp", "-j", "TPROXY",
+		"--tproxy-mark", "1337/0xffffffff", "--on-port", "15001")
+	nftables.AppendRuleV4("ISTIO_INBOUND", "mangle", "-p", "tcp", "--dport", "80", "-m", "socket", "-j", "ISTIO_DIVERT")
+	nftables.AppendRuleV4("ISTIO_INBOUND", "mangle", "-p", "tcp", "--dport", "80", "-j", "ISTIO_TPROXY")
+
+	actual, err := nftables.BuildNftables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"add table ip istio_mangle",
+		"delete table ip istio_mangle",
+		"add table ip istio_mangle",
+		"add chain ip istio_mangle ISTIO_DIVERT",
+		"add chain ip istio_mangle ISTIO_TPROXY",
+		"add chain ip istio_mangle ISTIO_INBOUND",
+		"add rule ip istio_mangle ISTIO_DIVERT meta mark set 1337",
+		"add rule ip istio_mangle ISTIO_DIVERT accept",
+		"add rule ip istio_mangle ISTIO_TPROXY ip daddr != 127.0.0.1/32 meta l4proto tcp meta mark set 1337 tproxy to :15001 accept",
+		"add rule ip istio_mangle ISTIO_INBOUND tcp dport 80 socket transparent 1 jump ISTIO_DIVERT",
+		"add rule ip istio_mangle ISTIO_INBOUND tcp dport 80 jump ISTIO_TPROXY",
+	}
+	if actual != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("Actual and expected output mismatch; but instead got Actual:\n%s\nExpected:\n%s", actual, strings.Join(expected, "\n"))
+	}
+}
+
+func TestBuildNftablesInsertPosition(t *testing.T) {
+	nftables := NewNftablesBuilder()
+	nftables.AppendRuleV6("chain", "nat", "-d", "::1/128", "-j", "RETURN")
+	nftables.InsertRuleV6("chain", "nat", 1, "-s", "::6/128", "-j", "RETURN")
+	nftables.InsertRuleV6("chain", "nat", 2, "-i", "eth0", "-j", "RETURN")
+	nftables.InsertRuleV6("chain", "nat", 10, "-o", "lo", "-j", "RETURN")
+
+	actual, err := nftables.BuildNftables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"add rule ip6 istio_nat chain ip6 saddr ::6/128 return",
+		"add rule ip6 istio_nat chain iifname \"eth0\" return",
+		"add rule ip6 istio_nat chain ip6 daddr ::1/128 return",
+		"add rule ip6 istio_nat chain oifname \"lo\" return",
+	}
+	if !strings.HasSuffix(actual, strings.Join(expected, "\n")+"\n") {
+		t.Errorf("Actual and expected output mismatch; but instead got Actual:\n%s\nExpected:\n%s", actual, strings.Join(expected, "\n"))
+	}
+}
+
+func TestBuildNftablesUnsupported(t *testing.T) {
+	cases := [][]string{
+		{"-m", "conntrack", "-j", "RETURN"},
+		{"--foo", "bar", "-j", "RETURN"},
+		{"-p", "tcp"},
+		{"-d"},
+	}
+	for _, params := range cases {
+		nftables := NewNftablesBuilder()
+		nftables.AppendRuleV4("chain", "nat", params...)
+		if _, err := nftables.BuildNftables(); err == nil {
+			t.Errorf("Expected an error translating %v", params)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/tools/istio-iptables/pkg/builder"
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
+)
+
+// recordingDependencies records the rules of the iptables and ip6tables commands into a builder. Other
+// commands are run by the wrapped Dependencies.
+type recordingDependencies struct {
+	dep.Dependencies
+	rules builder.IptablesProducer
+	// passthrough is set if the iptables and ip6tables commands are also run after being recorded
+	passthrough bool
+}
+
+func newRecordingDependencies(ext dep.Dependencies, rules builder.IptablesProducer, passthrough bool) *recordingDependencies {
+	return &recordingDependencies{
+		Dependencies: ext,
+		rules:        rules,
+		passthrough:  passthrough,
+	}
+}
+
+// record adds the rule of an iptables or ip6tables command to the builder. It returns true if the command
+// must not be run.
+func (n *recordingDependencies) record(cmd dep.Cmd, args ...string) bool {
+	if cmd != dep.IPTABLES && cmd != dep.IP6TABLES {
+		return false
+	}
+	table := constants.FILTER
+	if len(args) >= 2 && args[0] == "-t" {
+		table = args[1]
+		args = args[2:]
+	}
+	if len(args) < 2 {
+		panic(fmt.Errorf("unable to record %s command: %s", cmd, strings.Join(args, " ")))
+	}
+	chain := args[1]
+	switch args[0] {
+	case "-N", "-F":
+		// Chains are created along with their rules, and flushing a chain has no effect on the rules
+		// recorded.
+	case "-A":
+		if cmd == dep.IPTABLES {
+			n.rules.AppendRuleV4(chain, table, args[2:]...)
+		} else {
+			n.rules.AppendRuleV6(chain, table, args[2:]...)
+		}
+	case "-I":
+		if len(args) < 3 {
+			panic(fmt.Errorf("missing position in %s command: %s", cmd, strings.Join(args, " ")))
+		}
+		position, err := strconv.Atoi(args[2])
+		if err != nil {
+			panic(err)
+		}
+		if cmd == dep.IPTABLES {
+			n.rules.InsertRuleV4(chain, table, position, args[3:]...)
+		} else {
+			n.rules.InsertRuleV6(chain, table, position, args[3:]...)
+		}
+	default:
+		panic(fmt.Errorf("unable to record %s command: %s", cmd, strings.Join(args, " ")))
+	}
+	return !n.passthrough
+}
+
+// RunOrFail records iptables rules, and runs a command and panics, if it fails
+func (n *recordingDependencies) RunOrFail(cmd dep.Cmd, args ...string) {
+	if !n.record(cmd, args...) {
+		n.Dependencies.RunOrFail(cmd, args...)
+	}
+}
+
+// Run records iptables rules, and runs a command
+func (n *recordingDependencies) Run(cmd dep.Cmd, args ...string) error {
+	if n.record(cmd, args...) {
+		return nil
+	}
+	return n.Dependencies.Run(cmd, args...)
+}
+
+// RunQuietlyAndIgnore records iptables rules, and runs a command quietly and ignores errors
+func (n *recordingDependencies) RunQuietlyAndIgnore(cmd dep.Cmd, args ...string) {
+	if !n.record(cmd, args...) {
+		n.Dependencies.RunQuietlyAndIgnore(cmd, args...)
+	}
+}
+
+// applyNftables applies the rules recorded into an nftables builder atomically, and panics if it fails
+func (n *recordingDependencies) applyNftables(nftables builder.NftablesConsumer) {
+	ruleset, err := nftables.BuildNftables()
+	if err != nil {
+		panic(err)
+	}
+	n.Dependencies.RunWithInputOrFail(ruleset, dep.NFT, "-f", "-")
+}
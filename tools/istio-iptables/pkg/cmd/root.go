@@ -32,6 +32,12 @@ var rootCmd = &cobra.Command{
 	Long: "Script responsible for setting up port forwarding for Istio sidecar.",
 	Run: func(cmd *cobra.Command, args []string) {
 		config := constructConfig()
+		if config.Verify {
+			if err := verify(config); err != nil {
+				handleError(err)
+			}
+			return
+		}
 		run(config)
 	},
 }
@@ -52,6 +58,8 @@ func constructConfig() *config.Config {
 		OutboundIPRangesExclude: viper.GetString(constants.ServiceExcludeCidr),
 		KubevirtInterfaces:      viper.GetString(constants.KubeVirtInterfaces),
 		DryRun:                  viper.GetBool(constants.DryRun),
+		Nftables:                viper.GetBool(constants.Nftables),
+		Verify:                  viper.GetBool(constants.Verify),
 		EnableInboundIPv6s:      nil,
 	}
 }
@@ -162,11 +170,26 @@ func init() {
 	}
 	viper.SetDefault(constants.InboundTProxyRouteTable, "133")
 
-	rootCmd.Flags().BoolP(constants.DryRun, "n", true, "Do not call any external dependencies like iptables")
+	rootCmd.Flags().BoolP(constants.DryRun, "n", true,
+		"Do not call any external dependencies like iptables, print the commands and the resulting iptables-restore input instead")
 	if err := viper.BindPFlag(constants.DryRun, rootCmd.Flags().Lookup(constants.DryRun)); err != nil {
 		handleError(err)
 	}
 	viper.SetDefault(constants.DryRun, false)
+
+	rootCmd.Flags().Bool(constants.Nftables, false,
+		"Program the redirection rules with nftables, as a single atomic ruleset, rather than with iptables (default $NFTABLES)")
+	if err := viper.BindPFlag(constants.Nftables, rootCmd.Flags().Lookup(constants.Nftables)); err != nil {
+		handleError(err)
+	}
+	viper.SetDefault(constants.Nftables, false)
+
+	rootCmd.Flags().Bool(constants.Verify, false,
+		"Do not change any rule, report the differences between the live rules and the ones of the configuration instead")
+	if err := viper.BindPFlag(constants.Verify, rootCmd.Flags().Lookup(constants.Verify)); err != nil {
+		handleError(err)
+	}
+	viper.SetDefault(constants.Verify, false)
 }
 
 func Execute() {
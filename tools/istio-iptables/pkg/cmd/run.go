@@ -24,6 +24,7 @@ import (
 
 	"istio.io/pkg/env"
 
+	"istio.io/istio/tools/istio-iptables/pkg/builder"
 	"istio.io/istio/tools/istio-iptables/pkg/config"
 	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
 )
@@ -300,11 +301,50 @@ func run(config *config.Config) {
 		ext = &dep.RealDependencies{}
 	}
 
+	iptables := builder.NewIptablesBuilder()
+	nftables := builder.NewNftablesBuilder()
+	var rules *recordingDependencies
+	if config.Nftables {
+		// The iptables rules are recorded and applied as a single nft ruleset once complete.
+		rules = newRecordingDependencies(ext, nftables, false)
+		ext = rules
+	} else if config.DryRun {
+		// The commands are printed as they would be run, followed by the resulting ruleset.
+		rules = newRecordingDependencies(ext, iptables, true)
+		ext = rules
+	}
+
 	defer func() {
-		ext.RunOrFail(dep.IPTABLESSAVE)
-		ext.RunOrFail(dep.IP6TABLESSAVE)
+		switch {
+		case config.Nftables:
+			ext.RunOrFail(dep.NFT, "list", "ruleset")
+		case config.DryRun:
+			printRestore(dep.IPTABLESRESTORE, iptables.BuildV4Restore())
+			printRestore(dep.IP6TABLESRESTORE, iptables.BuildV6Restore())
+		default:
+			ext.RunOrFail(dep.IPTABLESSAVE)
+			ext.RunOrFail(dep.IP6TABLESSAVE)
+		}
 	}()
 
+	configure(ext, config)
+
+	if config.Nftables {
+		rules.applyNftables(nftables)
+	}
+}
+
+// printRestore prints the iptables-restore input of a family, if any, along with the command applying it.
+func printRestore(cmd dep.Cmd, restore string) {
+	if restore == "" {
+		return
+	}
+	fmt.Printf("# %s --noflush\n", cmd)
+	fmt.Print(restore)
+}
+
+// configure programs the rules of the given configuration.
+func configure(ext dep.Dependencies, config *config.Config) {
 	// TODO: more flexibility - maybe a whitelist of users to be captured for output instead of a blacklist.
 	if config.ProxyUID == "" {
 		usr, err := ext.LookupUser()
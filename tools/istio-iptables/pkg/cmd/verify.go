@@ -0,0 +1,355 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/tools/istio-iptables/pkg/builder"
+	"istio.io/istio/tools/istio-iptables/pkg/config"
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
+)
+
+// noopDependencies runs no command, so that the rules of a configuration are computed without side effects.
+// The local IP, the user and the output of commands are the real ones.
+type noopDependencies struct {
+	dep.RealDependencies
+}
+
+// RunOrFail does nothing
+func (n *noopDependencies) RunOrFail(cmd dep.Cmd, args ...string) {}
+
+// Run does nothing
+func (n *noopDependencies) Run(cmd dep.Cmd, args ...string) error {
+	return nil
+}
+
+// RunQuietlyAndIgnore does nothing
+func (n *noopDependencies) RunQuietlyAndIgnore(cmd dep.Cmd, args ...string) {}
+
+// RunWithInputOrFail does nothing
+func (n *noopDependencies) RunWithInputOrFail(input string, cmd dep.Cmd, args ...string) {}
+
+// tableRules holds the rules of each chain, in order, keyed by table and chain.
+type tableRules map[string]map[string][]string
+
+// verify compares the live rules with the ones the configuration produces, and prints the differences. It
+// returns an error if there is any.
+func verify(config *config.Config) error {
+	if config.Nftables {
+		return fmt.Errorf("verifying the rules is not supported with nftables")
+	}
+
+	ext := &noopDependencies{}
+	iptables := builder.NewIptablesBuilder()
+	configure(newRecordingDependencies(ext, iptables, false), config)
+
+	var diffs []string
+	for _, family := range []struct {
+		save    dep.Cmd
+		restore string
+	}{
+		{dep.IPTABLESSAVE, iptables.BuildV4Restore()},
+		{dep.IP6TABLESSAVE, iptables.BuildV6Restore()},
+	} {
+		expected, err := parseRestore(family.restore)
+		if err != nil {
+			return err
+		}
+		output, err := ext.RunAndGetOutput(family.save)
+		if err != nil {
+			if family.restore == "" {
+				// The family may not be supported by the kernel, no rule is expected anyway
+				continue
+			}
+			return fmt.Errorf("unable to read the live rules with %s: %v", family.save, err)
+		}
+		live, err := parseRestore(output)
+		if err != nil {
+			return fmt.Errorf("unable to parse the output of %s: %v", family.save, err)
+		}
+		for _, d := range drift(expected, live) {
+			diffs = append(diffs, fmt.Sprintf("%s: %s", family.save, d))
+		}
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("The live rules match the configuration")
+		return nil
+	}
+	fmt.Println("Drift from the rules of the configuration:")
+	for _, d := range diffs {
+		fmt.Println("  " + d)
+	}
+	return fmt.Errorf("found %d difference(s) between the live rules and the configuration", len(diffs))
+}
+
+// parseRestore parses iptables-save output, or iptables-restore input, into the rules of each chain.
+func parseRestore(restore string) (tableRules, error) {
+	rules := tableRules{}
+	var table string
+	for _, line := range strings.Split(restore, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line == "COMMIT" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "*"):
+			table = line[1:]
+			if rules[table] == nil {
+				rules[table] = map[string][]string{}
+			}
+		case table == "":
+			return nil, fmt.Errorf("line %q is not in a table", line)
+		case strings.HasPrefix(line, ":"):
+			chain := strings.Fields(line[1:])[0]
+			if _, f := rules[table][chain]; !f {
+				rules[table][chain] = []string{}
+			}
+		default:
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid line %q", line)
+			}
+			chain := fields[1]
+			switch fields[0] {
+			case "-A":
+				rules[table][chain] = append(rules[table][chain], strings.Join(fields[2:], " "))
+			case "-I":
+				if len(fields) < 3 {
+					return nil, fmt.Errorf("missing position in line %q", line)
+				}
+				position, err := strconv.Atoi(fields[2])
+				if err != nil {
+					return nil, fmt.Errorf("invalid position in line %q: %v", line, err)
+				}
+				chainRules := rules[table][chain]
+				if position < 1 || position > len(chainRules) {
+					position = len(chainRules) + 1
+				}
+				chainRules = append(chainRules, "")
+				copy(chainRules[position:], chainRules[position-1:])
+				chainRules[position-1] = strings.Join(fields[3:], " ")
+				rules[table][chain] = chainRules
+			default:
+				return nil, fmt.Errorf("unsupported line %q", line)
+			}
+		}
+	}
+	return rules, nil
+}
+
+func isIstioChain(chain string) bool {
+	return strings.HasPrefix(chain, "ISTIO_")
+}
+
+// drift returns the differences between the expected and live rules of a family. The istio chains must hold
+// exactly the expected rules, in order, while the other chains must hold at least the expected rules and no
+// other jump to an istio chain.
+func drift(expected tableRules, live tableRules) []string {
+	var diffs []string
+	for _, table := range sortedTables(expected, live) {
+		for _, chain := range sortedChains(expected[table], live[table]) {
+			want, wanted := expected[table][chain]
+			got, found := live[table][chain]
+			switch {
+			case !wanted && isIstioChain(chain):
+				diffs = append(diffs, fmt.Sprintf("unexpected chain -t %s %s", table, chain))
+				continue
+			case !found && isIstioChain(chain):
+				diffs = append(diffs, fmt.Sprintf("missing chain -t %s %s", table, chain))
+				continue
+			}
+
+			missing, unexpected := diffRules(want, got)
+			for _, r := range missing {
+				diffs = append(diffs, fmt.Sprintf("missing rule -t %s -A %s %s", table, chain, r))
+			}
+			for _, r := range unexpected {
+				if isIstioChain(chain) || isIstioChain(ruleTarget(r)) {
+					diffs = append(diffs, fmt.Sprintf("unexpected rule -t %s -A %s %s", table, chain, r))
+				}
+			}
+			if isIstioChain(chain) && len(missing) == 0 && len(unexpected) == 0 && !sameOrder(want, got) {
+				diffs = append(diffs, fmt.Sprintf("rules of chain -t %s %s are not in the expected order", table, chain))
+			}
+		}
+	}
+	return diffs
+}
+
+// diffRules returns the wanted rules that were not found, and the rules found that were not wanted.
+func diffRules(want []string, got []string) ([]string, []string) {
+	remaining := map[string]int{}
+	for _, r := range got {
+		remaining[normalizeRule(r)]++
+	}
+	var missing []string
+	for _, r := range want {
+		key := normalizeRule(r)
+		if remaining[key] == 0 {
+			missing = append(missing, r)
+			continue
+		}
+		remaining[key]--
+	}
+	var unexpected []string
+	for _, r := range got {
+		key := normalizeRule(r)
+		if remaining[key] > 0 {
+			unexpected = append(unexpected, r)
+			remaining[key]--
+		}
+	}
+	return missing, unexpected
+}
+
+func sameOrder(want []string, got []string) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if normalizeRule(want[i]) != normalizeRule(got[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleTarget returns the target of a rule, or an empty string if it has none.
+func ruleTarget(rule string) string {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "-j" || fields[i] == "--jump" {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// normalizeRule returns a canonical form of the parameters of a rule, so that the rules generated can be
+// compared with the ones printed by iptables-save, which uses its own order and formats.
+func normalizeRule(rule string) string {
+	fields := strings.Fields(rule)
+	var options []string
+	for i := 0; i < len(fields); {
+		var option []string
+		if fields[i] == "!" {
+			option = append(option, "!")
+			i++
+			if i == len(fields) {
+				break
+			}
+		}
+		name := fields[i]
+		i++
+		var values []string
+		for i < len(fields) && fields[i] != "!" && !strings.HasPrefix(fields[i], "-") {
+			values = append(values, fields[i])
+			i++
+		}
+
+		switch name {
+		case "-m":
+			// Protocol matches are implied by -p
+			if len(values) == 1 && (values[0] == constants.TCP || values[0] == "udp") {
+				continue
+			}
+		case "--to-ports":
+			name = "--to-port"
+		case "-s", "-d":
+			if len(values) == 1 {
+				values[0] = normalizeCIDR(values[0])
+			}
+		case "--set-xmark", "--set-mark", "--tproxy-mark":
+			if len(values) == 1 {
+				if name == "--set-xmark" && strings.HasSuffix(strings.ToLower(values[0]), "/0xffffffff") {
+					name = "--set-mark"
+				}
+				values[0] = normalizeMark(values[0])
+			}
+		case "--on-ip":
+			// The address of the destination is the default
+			if len(values) == 1 && net.ParseIP(values[0]).IsUnspecified() {
+				continue
+			}
+		}
+		option = append(option, name)
+		options = append(options, strings.Join(append(option, values...), " "))
+	}
+	sort.Strings(options)
+	return strings.Join(options, " ")
+}
+
+// normalizeCIDR returns an address with its prefix length, as printed by iptables-save.
+func normalizeCIDR(address string) string {
+	if !strings.Contains(address, "/") {
+		if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+			address += "/128"
+		} else {
+			address += "/32"
+		}
+	}
+	if _, ipNet, err := net.ParseCIDR(address); err == nil {
+		return ipNet.String()
+	}
+	return address
+}
+
+// normalizeMark returns a mark and its optional mask in decimal, without the mask if all the bits are set.
+func normalizeMark(mark string) string {
+	parts := strings.Split(mark, "/")
+	for i, p := range parts {
+		if v, err := strconv.ParseUint(p, 0, 32); err == nil {
+			parts[i] = strconv.FormatUint(v, 10)
+		}
+	}
+	if len(parts) == 2 && parts[1] == "4294967295" {
+		parts = parts[:1]
+	}
+	return strings.Join(parts, "/")
+}
+
+func sortedTables(rules ...tableRules) []string {
+	set := map[string]struct{}{}
+	for _, r := range rules {
+		for table := range r {
+			set[table] = struct{}{}
+		}
+	}
+	return sortedSet(set)
+}
+
+func sortedChains(chains ...map[string][]string) []string {
+	set := map[string]struct{}{}
+	for _, c := range chains {
+		for chain := range c {
+			set[chain] = struct{}{}
+		}
+	}
+	return sortedSet(set)
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeRule(t *testing.T) {
+	cases := []struct {
+		generated string
+		saved     string
+	}{
+		{
+			"-p tcp --dport 22 -j RETURN",
+			"-p tcp -m tcp --dport 22 -j RETURN",
+		},
+		{
+			"-p tcp -j REDIRECT --to-port 15001",
+			"-p tcp -j REDIRECT --to-ports 15001",
+		},
+		{
+			"-o lo ! -d 127.0.0.1/32 -j ISTIO_IN_REDIRECT",
+			"! -d 127.0.0.1/32 -o lo -j ISTIO_IN_REDIRECT",
+		},
+		{
+			"-i lo -d ::1 -j ACCEPT",
+			"-d ::1/128 -i lo -j ACCEPT",
+		},
+		{
+			"-j MARK --set-mark 1337",
+			"-j MARK --set-xmark 0x539/0xffffffff",
+		},
+		{
+			"! -d 127.0.0.1/32 -p tcp -j TPROXY --tproxy-mark 1337/0xffffffff --on-port 15001",
+			"! -d 127.0.0.1/32 -p tcp -j TPROXY --on-port 15001 --on-ip 0.0.0.0 --tproxy-mark 0x539/0xffffffff",
+		},
+	}
+	for _, c := range cases {
+		if normalizeRule(c.generated) != normalizeRule(c.saved) {
+			t.Errorf("Expected %q and %q to match; but got %q and %q",
+				c.generated, c.saved, normalizeRule(c.generated), normalizeRule(c.saved))
+		}
+	}
+
+	if normalizeRule("-d 127.0.0.1/32 -j RETURN") == normalizeRule("! -d 127.0.0.1/32 -j RETURN") {
+		t.Errorf("Expected negated rules not to match")
+	}
+}
+
+func TestParseRestore(t *testing.T) {
+	restore := `# iptables-restore --noflush
+*nat
+:PREROUTING ACCEPT [0:0]
+:ISTIO_INBOUND - [0:0]
+-A PREROUTING -j DOCKER
+-A PREROUTING -p tcp -j ISTIO_INBOUND
+-I PREROUTING 1 -i eth1 -j RETURN
+-A ISTIO_INBOUND -p tcp --dport 22 -j RETURN
+COMMIT
+*filter
+:ISTIO_EMPTY - [0:0]
+COMMIT
+`
+	actual, err := parseRestore(restore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := tableRules{
+		"nat": {
+			"PREROUTING":    {"-i eth1 -j RETURN", "-j DOCKER", "-p tcp -j ISTIO_INBOUND"},
+			"ISTIO_INBOUND": {"-p tcp --dport 22 -j RETURN"},
+		},
+		"filter": {
+			"ISTIO_EMPTY": {},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Actual and expected output mismatch; but instead got Actual: %#v ; Expected: %#v", actual, expected)
+	}
+
+	if _, err := parseRestore("-A PREROUTING -j RETURN"); err == nil {
+		t.Errorf("Expected an error parsing a rule outside of a table")
+	}
+}
+
+func TestDrift(t *testing.T) {
+	expected := tableRules{
+		"nat": {
+			"OUTPUT":         {"-p tcp -j ISTIO_OUTPUT"},
+			"ISTIO_OUTPUT":   {"-d 127.0.0.1/32 -j RETURN", "-j ISTIO_REDIRECT"},
+			"ISTIO_REDIRECT": {"-p tcp -j REDIRECT --to-port 15001"},
+		},
+	}
+	cases := []struct {
+		name     string
+		live     tableRules
+		expected []string
+	}{
+		{
+			name: "no drift",
+			live: tableRules{
+				"nat": {
+					"OUTPUT":         {"-j DOCKER", "-p tcp -j ISTIO_OUTPUT"},
+					"POSTROUTING":    {"-j MASQUERADE"},
+					"ISTIO_OUTPUT":   {"-d 127.0.0.1/32 -j RETURN", "-j ISTIO_REDIRECT"},
+					"ISTIO_REDIRECT": {"-p tcp -j REDIRECT --to-ports 15001"},
+				},
+			},
+		},
+		{
+			name: "missing rules and chains",
+			live: tableRules{
+				"nat": {
+					"OUTPUT":       {},
+					"ISTIO_OUTPUT": {"-d 127.0.0.1/32 -j RETURN"},
+				},
+			},
+			expected: []string{
+				"missing rule -t nat -A ISTIO_OUTPUT -j ISTIO_REDIRECT",
+				"missing chain -t nat ISTIO_REDIRECT",
+				"missing rule -t nat -A OUTPUT -p tcp -j ISTIO_OUTPUT",
+			},
+		},
+		{
+			name: "unexpected rules and chains",
+			live: tableRules{
+				"nat": {
+					"OUTPUT":         {"-p tcp -j ISTIO_OUTPUT", "-p tcp -j ISTIO_OUTPUT"},
+					"PREROUTING":     {"-p tcp -j ISTIO_INBOUND"},
+					"ISTIO_INBOUND":  {},
+					"ISTIO_OUTPUT":   {"-d 127.0.0.1/32 -j RETURN", "-j ISTIO_REDIRECT", "-j RETURN"},
+					"ISTIO_REDIRECT": {"-p tcp -j REDIRECT --to-ports 15001"},
+				},
+			},
+			expected: []string{
+				"unexpected chain -t nat ISTIO_INBOUND",
+				"unexpected rule -t nat -A ISTIO_OUTPUT -j RETURN",
+				"unexpected rule -t nat -A OUTPUT -p tcp -j ISTIO_OUTPUT",
+				"unexpected rule -t nat -A PREROUTING -p tcp -j ISTIO_INBOUND",
+			},
+		},
+		{
+			name: "order",
+			live: tableRules{
+				"nat": {
+					"OUTPUT":         {"-p tcp -j ISTIO_OUTPUT"},
+					"ISTIO_OUTPUT":   {"-j ISTIO_REDIRECT", "-d 127.0.0.1/32 -j RETURN"},
+					"ISTIO_REDIRECT": {"-p tcp -j REDIRECT --to-ports 15001"},
+				},
+			},
+			expected: []string{
+				"rules of chain -t nat ISTIO_OUTPUT are not in the expected order",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := drift(expected, c.live)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("Actual and expected output mismatch; but instead got Actual: %#v ; Expected: %#v", actual, c.expected)
+			}
+		})
+	}
+}
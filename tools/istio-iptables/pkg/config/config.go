@@ -25,6 +25,8 @@ import (
 // Command line options
 type Config struct {
 	DryRun                  bool   `json:"DRY_RUN"`
+	Nftables                bool   `json:"NFTABLES"`
+	Verify                  bool   `json:"VERIFY"`
 	ProxyPort               string `json:"PROXY_PORT"`
 	InboundCapturePort      string `json:"INBOUND_CAPTURE_PORT"`
 	ProxyUID                string `json:"PROXY_UID"`
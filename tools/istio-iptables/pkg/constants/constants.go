@@ -18,21 +18,32 @@ package constants
 const (
 	MANGLE = "mangle"
 	NAT    = "nat"
+	FILTER = "filter"
 )
 
 // Constants used for generating iptables commands
 const (
 	TCP = "tcp"
 
-	TPROXY     = "TPROXY"
-	PREROUTING = "PREROUTING"
-	RETURN     = "RETURN"
-	ACCEPT     = "ACCEPT"
-	REJECT     = "REJECT"
-	INPUT      = "INPUT"
-	OUTPUT     = "OUTPUT"
-	REDIRECT   = "REDIRECT"
-	MARK       = "MARK"
+	TPROXY      = "TPROXY"
+	PREROUTING  = "PREROUTING"
+	POSTROUTING = "POSTROUTING"
+	FORWARD     = "FORWARD"
+	RETURN      = "RETURN"
+	ACCEPT      = "ACCEPT"
+	REJECT      = "REJECT"
+	INPUT       = "INPUT"
+	OUTPUT      = "OUTPUT"
+	REDIRECT    = "REDIRECT"
+	MARK        = "MARK"
+)
+
+// nftables families, and the prefix of the tables holding the rules of each iptables table
+const (
+	NFTIPV4 = "ip"
+	NFTIPV6 = "ip6"
+
+	NFTTABLEPREFIX = "istio_"
 )
 
 // iptables chains
@@ -61,5 +72,7 @@ const (
 	ProxyGID                  = "proxy-gid"
 	KubeVirtInterfaces        = "kube-virt-interfaces"
 	DryRun                    = "dry-run"
+	Nftables                  = "nftables"
+	Verify                    = "verify"
 	Clean                     = "clean"
 )
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	"istio.io/pkg/env"
+	"istio.io/pkg/log"
 )
 
 // RealDependencies implementation of interface Dependencies, which is used in production
@@ -52,8 +53,16 @@ func (r *RealDependencies) LookupUser() (*user.User, error) {
 }
 
 func (r *RealDependencies) execute(cmd Cmd, redirectStdout bool, args ...string) error {
+	return r.executeWithInput("", cmd, redirectStdout, args...)
+}
+
+func (r *RealDependencies) executeWithInput(input string, cmd Cmd, redirectStdout bool, args ...string) error {
 	fmt.Printf("%s %s\n", cmd, strings.Join(args, " "))
 	externalCommand := exec.Command(string(cmd), args...)
+	if input != "" {
+		log.Debugf("%s input:\n%s", cmd, input)
+		externalCommand.Stdin = strings.NewReader(input)
+	}
 	externalCommand.Stdout = os.Stdout
 	//TODO Check naming and redirection logic
 	if !redirectStdout {
@@ -80,3 +89,18 @@ func (r *RealDependencies) Run(cmd Cmd, args ...string) error {
 func (r *RealDependencies) RunQuietlyAndIgnore(cmd Cmd, args ...string) {
 	_ = r.execute(cmd, true, args...)
 }
+
+// RunWithInputOrFail runs a command reading the given input from stdin and panics, if it fails
+func (r *RealDependencies) RunWithInputOrFail(input string, cmd Cmd, args ...string) {
+	err := r.executeWithInput(input, cmd, false, args...)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RunAndGetOutput runs a command quietly and returns its output
+func (r *RealDependencies) RunAndGetOutput(cmd Cmd, args ...string) (string, error) {
+	output, err := exec.Command(string(cmd), args...).Output()
+	return string(output), err
+}
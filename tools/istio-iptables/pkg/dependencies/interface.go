@@ -22,11 +22,14 @@ import (
 type Cmd string
 
 const (
-	IPTABLES      = "iptables"
-	IPTABLESSAVE  = "iptables-save"
-	IP6TABLES     = "ip6tables"
-	IP6TABLESSAVE = "ip6tables-save"
-	IP            = "ip"
+	IPTABLES         = "iptables"
+	IPTABLESSAVE     = "iptables-save"
+	IPTABLESRESTORE  = "iptables-restore"
+	IP6TABLES        = "ip6tables"
+	IP6TABLESSAVE    = "ip6tables-save"
+	IP6TABLESRESTORE = "ip6tables-restore"
+	IP               = "ip"
+	NFT              = "nft"
 )
 
 // Dependencies is used as abstraction for the commands used from the operating system
@@ -41,4 +44,8 @@ type Dependencies interface {
 	Run(cmd Cmd, args ...string) error
 	// RunQuietlyAndIgnore runs a command quietly and ignores errors
 	RunQuietlyAndIgnore(cmd Cmd, args ...string)
+	// RunWithInputOrFail runs a command reading the given input from stdin and panics, if it fails
+	RunWithInputOrFail(input string, cmd Cmd, args ...string)
+	// RunAndGetOutput runs a command quietly and returns its output
+	RunAndGetOutput(cmd Cmd, args ...string) (string, error)
 }
@@ -53,3 +53,15 @@ func (s *StdoutStubDependencies) Run(cmd Cmd, args ...string) error {
 func (s *StdoutStubDependencies) RunQuietlyAndIgnore(cmd Cmd, args ...string) {
 	fmt.Println(fmt.Sprintf("%s %s", cmd, strings.Join(args, " ")))
 }
+
+// RunWithInputOrFail runs a command reading the given input from stdin and panics, if it fails
+func (s *StdoutStubDependencies) RunWithInputOrFail(input string, cmd Cmd, args ...string) {
+	fmt.Println(fmt.Sprintf("%s %s", cmd, strings.Join(args, " ")))
+	fmt.Println(input)
+}
+
+// RunAndGetOutput runs a command quietly and returns its output
+func (s *StdoutStubDependencies) RunAndGetOutput(cmd Cmd, args ...string) (string, error) {
+	fmt.Println(fmt.Sprintf("%s %s", cmd, strings.Join(args, " ")))
+	return "", nil
+}